@@ -0,0 +1,41 @@
+package command
+
+import (
+	"fmt"
+)
+
+// Append adds Value to the end of the string stored at Key, creating the key if it doesn't exist
+type Append struct {
+	Key   string
+	Value []byte
+}
+
+func (a Append) String() string {
+	return fmt.Sprintf("APPEND: %q to %q", a.Value, a.Key)
+}
+
+func (a Append) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(AppendCmd), a.Key, a.Value})
+}
+
+func (Append) CommandType() CommandType {
+	return AppendCmd
+}
+
+func (Append) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toAppend(data []any) (Append, error) {
+	if len(data) != 2 {
+		return Append{}, wrongNumberOfArgs(AppendCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Append{}, fmt.Errorf("expected the inputs to the APPEND command to be strings: %w", err)
+	}
+
+	return Append{Key: args[0], Value: []byte(args[1])}, nil
+}
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"fmt"
+)
+
+// BLMove is the blocking version of LMove. If the list stored at Source doesn't exist, the client is blocked until
+// it's pushed to or the timeout passes.
+// `BLMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT> timeout`
+type BLMove struct {
+	Source      string
+	Destination string
+	From        ListEnd
+	To          ListEnd
+
+	// How long to block for in milliseconds. A timeout of 0 blocks forever
+	TimeoutMs int64
+}
+
+func (l BLMove) String() string {
+	return fmt.Sprintf("BLMOVE: %q %s -> %q %s timeout %dms", l.Source, l.From, l.Destination, l.To, l.TimeoutMs)
+}
+
+func (l BLMove) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{
+		string(BLMoveCmd), l.Source, l.Destination, string(l.From), string(l.To), formatTimeout(l.TimeoutMs),
+	})
+}
+
+func (BLMove) CommandType() CommandType {
+	return BLMoveCmd
+}
+
+func (BLMove) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toBLMove(data []any) (BLMove, error) {
+	if len(data) != 5 {
+		return BLMove{}, wrongNumberOfArgs(BLMoveCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return BLMove{}, fmt.Errorf("expected the inputs to the BLMOVE command to be strings: %w", err)
+	}
+
+	from, err := toListEnd(args[2])
+	if err != nil {
+		return BLMove{}, err
+	}
+	to, err := toListEnd(args[3])
+	if err != nil {
+		return BLMove{}, err
+	}
+	timeoutMs, err := parseTimeout(args[4])
+	if err != nil {
+		return BLMove{}, err
+	}
+
+	return BLMove{Source: args[0], Destination: args[1], From: from, To: to, TimeoutMs: timeoutMs}, nil
+}
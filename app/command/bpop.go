@@ -0,0 +1,97 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The longest timeout that a blocking command can have, in milliseconds, so that it still fits in a time.Duration
+const maxTimeoutMs = math.MaxInt64 / int64(time.Millisecond)
+
+// BPop removes and returns an element from one end of the first of the lists stored at Keys that isn't empty. If
+// none of them hold a list, the client is blocked until one of them is pushed to or the timeout passes. It covers
+// BLPOP and BRPOP, which pop from the start and the end of the list. Cmd is the one that was sent.
+// `BLPOP key [key ...] timeout`
+type BPop struct {
+	Cmd  CommandType
+	Keys []string
+
+	// How long to block for in milliseconds. A timeout of 0 blocks forever
+	TimeoutMs int64
+}
+
+func (p BPop) String() string {
+	return fmt.Sprintf("%s: %q timeout %dms", strings.ToUpper(string(p.Cmd)), p.Keys, p.TimeoutMs)
+}
+
+func (p BPop) EncodedCommand() (string, error) {
+	cmdList := []any{string(p.Cmd)}
+	for _, key := range p.Keys {
+		cmdList = append(cmdList, key)
+	}
+	cmdList = append(cmdList, formatTimeout(p.TimeoutMs))
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (p BPop) CommandType() CommandType {
+	return p.Cmd
+}
+
+func (BPop) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// End is the end of the list that the element is popped from
+func (p BPop) End() ListEnd {
+	if p.Cmd == BLPopCmd {
+		return ListLeft
+	}
+	return ListRight
+}
+
+func toBPop(cmdType CommandType, data []any) (BPop, error) {
+	if len(data) < 2 {
+		return BPop{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return BPop{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	timeoutMs, err := parseTimeout(args[len(args)-1])
+	if err != nil {
+		return BPop{}, err
+	}
+
+	return BPop{Cmd: cmdType, Keys: args[:len(args)-1], TimeoutMs: timeoutMs}, nil
+}
+
+// parseTimeout parses the timeout of a blocking command, which is a number of seconds that can have a fractional
+// part, and returns it in milliseconds. Like in redis, it's rounded up to the next millisecond
+func parseTimeout(arg string) (int64, error) {
+	seconds, ok := ParseFloat(arg)
+	if !ok {
+		return 0, ErrorReply("ERR timeout is not a float or out of range")
+	}
+
+	timeoutMs := math.Ceil(seconds * 1000)
+	if timeoutMs > float64(maxTimeoutMs) {
+		return 0, ErrorReply("ERR timeout is out of range")
+	}
+	if timeoutMs < 0 {
+		return 0, ErrorReply("ERR timeout is negative")
+	}
+
+	return int64(timeoutMs), nil
+}
+
+// formatTimeout formats a timeout in milliseconds as the number of seconds that blocking commands are sent with
+func formatTimeout(timeoutMs int64) string {
+	return strconv.FormatFloat(float64(timeoutMs)/1000, 'f', -1, 64)
+}
@@ -13,6 +13,23 @@ type Command interface {
 	EncodedCommand() (string, error)
 
 	CommandType() CommandType
+
+	// Flags describes how the command behaves, such as whether it can modify the store
+	Flags() CommandFlags
+}
+
+// CommandFlags is a set of metadata flags describing a command
+type CommandFlags uint32
+
+const (
+	// WriteFlag is set for commands that may modify the store. A master propagates the write commands
+	// that change its store to its replicas
+	WriteFlag CommandFlags = 1 << iota
+)
+
+// Has is true if every one of the provided flags is set
+func (f CommandFlags) Has(flags CommandFlags) bool {
+	return f&flags == flags
 }
 
 type CommandType string
@@ -24,8 +41,112 @@ const (
 	WaitCmd     CommandType = "wait"
 	SetCmd      CommandType = "set"
 	GetCmd      CommandType = "get"
+	DelCmd      CommandType = "del"
+	UnlinkCmd   CommandType = "unlink"
+	ExistsCmd   CommandType = "exists"
+	TypeCmd     CommandType = "type"
+	RenameCmd   CommandType = "rename"
+	RenameNXCmd CommandType = "renamenx"
+	CopyCmd     CommandType = "copy"
+	ExpireCmd   CommandType = "expire"
+	PExpireCmd  CommandType = "pexpire"
+	ExpireAtCmd CommandType = "expireat"
+	TTLCmd      CommandType = "ttl"
+	PTTLCmd     CommandType = "pttl"
+	PersistCmd  CommandType = "persist"
+	IncrCmd     CommandType = "incr"
+	DecrCmd     CommandType = "decr"
+	IncrByCmd   CommandType = "incrby"
+	DecrByCmd   CommandType = "decrby"
+	AppendCmd   CommandType = "append"
+	StrLenCmd   CommandType = "strlen"
+	GetRangeCmd CommandType = "getrange"
+	SetRangeCmd CommandType = "setrange"
+	GetDelCmd   CommandType = "getdel"
+	GetExCmd    CommandType = "getex"
+	GetSetCmd   CommandType = "getset"
+	SetNXCmd    CommandType = "setnx"
+	SetExCmd    CommandType = "setex"
+	PSetExCmd   CommandType = "psetex"
+	MGetCmd     CommandType = "mget"
+	MSetCmd     CommandType = "mset"
+	MSetNXCmd   CommandType = "msetnx"
+	LPushCmd    CommandType = "lpush"
+	RPushCmd    CommandType = "rpush"
+	LPushXCmd   CommandType = "lpushx"
+	RPushXCmd   CommandType = "rpushx"
+	LPopCmd     CommandType = "lpop"
+	RPopCmd     CommandType = "rpop"
+	LRangeCmd   CommandType = "lrange"
+	LLenCmd     CommandType = "llen"
+	LIndexCmd   CommandType = "lindex"
+	LSetCmd     CommandType = "lset"
+	LInsertCmd  CommandType = "linsert"
+	LRemCmd     CommandType = "lrem"
+	LTrimCmd    CommandType = "ltrim"
+	LPosCmd     CommandType = "lpos"
+	LMoveCmd    CommandType = "lmove"
+	BLPopCmd    CommandType = "blpop"
+	BRPopCmd    CommandType = "brpop"
+	BLMoveCmd   CommandType = "blmove"
+	LMPopCmd    CommandType = "lmpop"
+	BLMPopCmd   CommandType = "blmpop"
+	HSetCmd     CommandType = "hset"
+	HSetNXCmd   CommandType = "hsetnx"
+	HGetCmd     CommandType = "hget"
+	HMGetCmd    CommandType = "hmget"
+	HDelCmd     CommandType = "hdel"
+	HGetAllCmd  CommandType = "hgetall"
+	HKeysCmd    CommandType = "hkeys"
+	HValsCmd    CommandType = "hvals"
+	HLenCmd     CommandType = "hlen"
+	HExistsCmd  CommandType = "hexists"
+	HIncrByCmd  CommandType = "hincrby"
+	HScanCmd    CommandType = "hscan"
+	HExpireCmd  CommandType = "hexpire"
+	HPExpireCmd CommandType = "hpexpire"
+	HTTLCmd     CommandType = "httl"
+	HPTTLCmd    CommandType = "hpttl"
+	HPersistCmd CommandType = "hpersist"
+	SAddCmd     CommandType = "sadd"
+	SRemCmd     CommandType = "srem"
+	SMembersCmd CommandType = "smembers"
+	SCardCmd    CommandType = "scard"
+	SPopCmd     CommandType = "spop"
+	SMoveCmd    CommandType = "smove"
+	SInterCmd   CommandType = "sinter"
+	SUnionCmd   CommandType = "sunion"
+	SDiffCmd    CommandType = "sdiff"
+	SScanCmd    CommandType = "sscan"
 	ReplConfCmd CommandType = "replconf"
 	PSyncCmd    CommandType = "psync"
+	SaveCmd     CommandType = "save"
+	BgSaveCmd   CommandType = "bgsave"
+	LastSaveCmd CommandType = "lastsave"
+	HelloCmd    CommandType = "hello"
+
+	PExpireAtCmd   CommandType = "pexpireat"
+	ExpireTimeCmd  CommandType = "expiretime"
+	PExpireTimeCmd CommandType = "pexpiretime"
+	IncrByFloatCmd CommandType = "incrbyfloat"
+
+	HIncrByFloatCmd CommandType = "hincrbyfloat"
+	HRandFieldCmd   CommandType = "hrandfield"
+	HExpireAtCmd    CommandType = "hexpireat"
+	HPExpireAtCmd   CommandType = "hpexpireat"
+	HExpireTimeCmd  CommandType = "hexpiretime"
+	HPExpireTimeCmd CommandType = "hpexpiretime"
+
+	SIsMemberCmd   CommandType = "sismember"
+	SMIsMemberCmd  CommandType = "smismember"
+	SRandMemberCmd CommandType = "srandmember"
+	SInterStoreCmd CommandType = "sinterstore"
+	SUnionStoreCmd CommandType = "sunionstore"
+	SDiffStoreCmd  CommandType = "sdiffstore"
+	SInterCardCmd  CommandType = "sintercard"
+
+	ReplicaOfCmd CommandType = "replicaof"
+	SlaveOfCmd   CommandType = "slaveof"
 )
 
 func ToCommand(data []any) (Command, error) {
@@ -55,12 +176,154 @@ func ToCommand(data []any) (Command, error) {
 		return toGet(cmdData)
 	case SetCmd:
 		return toSet(cmdData)
+	case DelCmd:
+		return toDel(cmdData)
+	case UnlinkCmd:
+		return toUnlink(cmdData)
+	case ExistsCmd:
+		return toExists(cmdData)
+	case TypeCmd:
+		return toType(cmdData)
+	case RenameCmd:
+		return toRename(cmdData)
+	case RenameNXCmd:
+		return toRenameNX(cmdData)
+	case CopyCmd:
+		return toCopy(cmdData)
+	case ExpireCmd, PExpireCmd, ExpireAtCmd, PExpireAtCmd:
+		return toExpire(CommandType(cmdType), cmdData)
+	case TTLCmd, PTTLCmd, ExpireTimeCmd, PExpireTimeCmd:
+		return toTTL(CommandType(cmdType), cmdData)
+	case PersistCmd:
+		return toPersist(cmdData)
+	case IncrCmd, DecrCmd, IncrByCmd, DecrByCmd:
+		return toIncrBy(CommandType(cmdType), cmdData)
+	case IncrByFloatCmd:
+		return toIncrByFloat(cmdData)
+	case AppendCmd:
+		return toAppend(cmdData)
+	case StrLenCmd:
+		return toStrLen(cmdData)
+	case GetRangeCmd:
+		return toGetRange(cmdData)
+	case SetRangeCmd:
+		return toSetRange(cmdData)
+	case GetDelCmd:
+		return toGetDel(cmdData)
+	case GetExCmd:
+		return toGetEx(cmdData)
+	case GetSetCmd:
+		return toGetSet(cmdData)
+	case SetNXCmd:
+		return toSetNX(cmdData)
+	case SetExCmd, PSetExCmd:
+		return toSetEx(CommandType(cmdType), cmdData)
+	case MGetCmd:
+		return toMGet(cmdData)
+	case MSetCmd:
+		return toMSet(cmdData)
+	case MSetNXCmd:
+		return toMSetNX(cmdData)
+	case LPushCmd, RPushCmd, LPushXCmd, RPushXCmd:
+		return toPush(CommandType(cmdType), cmdData)
+	case LPopCmd, RPopCmd:
+		return toPop(CommandType(cmdType), cmdData)
+	case LRangeCmd:
+		return toLRange(cmdData)
+	case LLenCmd:
+		return toLLen(cmdData)
+	case LIndexCmd:
+		return toLIndex(cmdData)
+	case LSetCmd:
+		return toLSet(cmdData)
+	case LInsertCmd:
+		return toLInsert(cmdData)
+	case LRemCmd:
+		return toLRem(cmdData)
+	case LTrimCmd:
+		return toLTrim(cmdData)
+	case LPosCmd:
+		return toLPos(cmdData)
+	case LMoveCmd:
+		return toLMove(cmdData)
+	case BLPopCmd, BRPopCmd:
+		return toBPop(CommandType(cmdType), cmdData)
+	case BLMoveCmd:
+		return toBLMove(cmdData)
+	case LMPopCmd, BLMPopCmd:
+		return toLMPop(CommandType(cmdType), cmdData)
+	case HSetCmd:
+		return toHSet(cmdData)
+	case HSetNXCmd:
+		return toHSetNX(cmdData)
+	case HGetCmd:
+		return toHGet(cmdData)
+	case HMGetCmd:
+		return toHMGet(cmdData)
+	case HDelCmd:
+		return toHDel(cmdData)
+	case HGetAllCmd, HKeysCmd, HValsCmd:
+		return toHGetAll(CommandType(cmdType), cmdData)
+	case HLenCmd:
+		return toHLen(cmdData)
+	case HExistsCmd:
+		return toHExists(cmdData)
+	case HIncrByCmd:
+		return toHIncrBy(cmdData)
+	case HIncrByFloatCmd:
+		return toHIncrByFloat(cmdData)
+	case HRandFieldCmd:
+		return toHRandField(cmdData)
+	case HScanCmd:
+		return toHScan(cmdData)
+	case HExpireCmd, HPExpireCmd, HExpireAtCmd, HPExpireAtCmd:
+		return toHExpire(CommandType(cmdType), cmdData)
+	case HTTLCmd, HPTTLCmd, HExpireTimeCmd, HPExpireTimeCmd:
+		return toHTTL(CommandType(cmdType), cmdData)
+	case HPersistCmd:
+		return toHPersist(cmdData)
+	case SAddCmd:
+		return toSAdd(cmdData)
+	case SRemCmd:
+		return toSRem(cmdData)
+	case SMembersCmd:
+		return toSMembers(cmdData)
+	case SIsMemberCmd:
+		return toSIsMember(cmdData)
+	case SMIsMemberCmd:
+		return toSMIsMember(cmdData)
+	case SCardCmd:
+		return toSCard(cmdData)
+	case SPopCmd:
+		return toSPop(cmdData)
+	case SRandMemberCmd:
+		return toSRandMember(cmdData)
+	case SMoveCmd:
+		return toSMove(cmdData)
+	case SInterCmd, SUnionCmd, SDiffCmd:
+		return toSCombine(CommandType(cmdType), cmdData)
+	case SInterStoreCmd, SUnionStoreCmd, SDiffStoreCmd:
+		return toSCombineStore(CommandType(cmdType), cmdData)
+	case SInterCardCmd:
+		return toSInterCard(cmdData)
+	case SScanCmd:
+		return toSScan(cmdData)
 	case ReplConfCmd:
 		return toReplConf(cmdData)
 	case PSyncCmd:
 		return toPSync(cmdData)
+	case SaveCmd:
+		return toSave(cmdData)
+	case BgSaveCmd:
+		return toBgSave(cmdData)
+	case LastSaveCmd:
+		return toLastSave(cmdData)
+	case HelloCmd:
+		return toHello(cmdData)
+	case ReplicaOfCmd, SlaveOfCmd:
+		return toReplicaOf(cmdData)
 	default:
 	}
 
-	return nil, fmt.Errorf("unknown command %q", cmdType)
+	return nil, unknownCommand(cmdStr, cmdData)
 }
@@ -2,7 +2,10 @@ package command
 
 import (
 	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -37,6 +40,38 @@ func TestEncodeCommand(t *testing.T) {
 			cmd:               Wait{NumReplicas: 1, WaitForMs: 2},
 			expectedCmdString: "*3\r\n$4\r\nwait\r\n$1\r\n1\r\n$1\r\n2\r\n",
 		},
+		{
+			cmd:               Unlink{Keys: []string{"a", "b"}},
+			expectedCmdString: "*3\r\n$6\r\nunlink\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               Exists{Keys: []string{"a"}},
+			expectedCmdString: "*2\r\n$6\r\nexists\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               Type{Key: "a"},
+			expectedCmdString: "*2\r\n$4\r\ntype\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               Rename{Key: "a", NewKey: "b"},
+			expectedCmdString: "*3\r\n$6\r\nrename\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               RenameNX{Key: "a", NewKey: "b"},
+			expectedCmdString: "*3\r\n$8\r\nrenamenx\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               Copy{Source: "a", Destination: "b", Replace: true},
+			expectedCmdString: "*4\r\n$4\r\ncopy\r\n$1\r\na\r\n$1\r\nb\r\n$7\r\nREPLACE\r\n",
+		},
+		{
+			cmd:               Hello{},
+			expectedCmdString: "*1\r\n$5\r\nhello\r\n",
+		},
+		{
+			cmd:               Hello{ProtocolVersion: 3, ClientName: "cli"},
+			expectedCmdString: "*4\r\n$5\r\nhello\r\n$1\r\n3\r\n$7\r\nSETNAME\r\n$3\r\ncli\r\n",
+		},
 		{
 			cmd:               Get{},
 			expectedCmdString: "*2\r\n$3\r\nget\r\n$0\r\n\r\n",
@@ -50,12 +85,16 @@ func TestEncodeCommand(t *testing.T) {
 			expectedCmdString: "*3\r\n$8\r\nreplconf\r\n$3\r\nkey\r\n$3\r\nval\r\n",
 		},
 		{
-			cmd:               Set{KeyPayload: "key", ValuePayload: "val"},
+			cmd:               Set{KeyPayload: "key", ValuePayload: []byte("val")},
 			expectedCmdString: "*3\r\n$3\r\nset\r\n$3\r\nkey\r\n$3\r\nval\r\n",
 		},
 		{
-			cmd:               Set{KeyPayload: "key", ValuePayload: "val", ExpiryTimeMs: 100},
-			expectedCmdString: "*5\r\n$3\r\nset\r\n$3\r\nkey\r\n$3\r\nval\r\n$2\r\npx\r\n$3\r\n100\r\n",
+			cmd:               Set{KeyPayload: "key", ValuePayload: []byte("val"), ExpiryOption: ExpiryPX, ExpiryTime: 100},
+			expectedCmdString: "*5\r\n$3\r\nset\r\n$3\r\nkey\r\n$3\r\nval\r\n$2\r\nPX\r\n$3\r\n100\r\n",
+		},
+		{
+			cmd:               Set{KeyPayload: "key", ValuePayload: []byte("val"), ExpiryOption: KeepTTL, Condition: SetIfExists, Get: true},
+			expectedCmdString: "*6\r\n$3\r\nset\r\n$3\r\nkey\r\n$3\r\nval\r\n$2\r\nXX\r\n$3\r\nGET\r\n$7\r\nKEEPTTL\r\n",
 		},
 		{
 			cmd:               PSync{},
@@ -65,6 +104,134 @@ func TestEncodeCommand(t *testing.T) {
 			cmd:               PSync{ReplicationID: "2", MasterOffset: "1"},
 			expectedCmdString: "*3\r\n$5\r\npsync\r\n$1\r\n2\r\n$1\r\n1\r\n",
 		},
+		{
+			cmd:               Del{Keys: []string{"a", "b"}},
+			expectedCmdString: "*3\r\n$3\r\ndel\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               Expire{Cmd: PExpireCmd, Key: "a", Time: 100, Options: ExpireLT | ExpireXX},
+			expectedCmdString: "*5\r\n$7\r\npexpire\r\n$1\r\na\r\n$3\r\n100\r\n$2\r\nXX\r\n$2\r\nLT\r\n",
+		},
+		{
+			cmd:               TTL{Cmd: TTLCmd, Key: "a"},
+			expectedCmdString: "*2\r\n$3\r\nttl\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               IncrBy{Cmd: IncrCmd, Key: "a", Increment: 1},
+			expectedCmdString: "*2\r\n$4\r\nincr\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               IncrBy{Cmd: DecrByCmd, Key: "a", Increment: -5},
+			expectedCmdString: "*3\r\n$6\r\ndecrby\r\n$1\r\na\r\n$1\r\n5\r\n",
+		},
+		{
+			cmd:               IncrByFloat{Key: "a", Increment: 0.1},
+			expectedCmdString: "*3\r\n$11\r\nincrbyfloat\r\n$1\r\na\r\n$3\r\n0.1\r\n",
+		},
+		{
+			cmd:               SetRange{Key: "a", Offset: 3, Value: []byte("b")},
+			expectedCmdString: "*4\r\n$8\r\nsetrange\r\n$1\r\na\r\n$1\r\n3\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               GetEx{Key: "a", ExpiryOption: ExpiryPX, ExpiryTime: 100},
+			expectedCmdString: "*4\r\n$5\r\ngetex\r\n$1\r\na\r\n$2\r\nPX\r\n$3\r\n100\r\n",
+		},
+		{
+			cmd:               MSetNX{Pairs: []KeyValue{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}}},
+			expectedCmdString: "*5\r\n$6\r\nmsetnx\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n",
+		},
+		{
+			cmd:               Push{Cmd: RPushCmd, Key: "a", Values: [][]byte{[]byte("1"), []byte("2")}},
+			expectedCmdString: "*4\r\n$5\r\nrpush\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\n2\r\n",
+		},
+		{
+			cmd:               Pop{Cmd: LPopCmd, Key: "a", Count: 1},
+			expectedCmdString: "*2\r\n$4\r\nlpop\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               Pop{Cmd: RPopCmd, Key: "a", Count: 3, WithCount: true},
+			expectedCmdString: "*3\r\n$4\r\nrpop\r\n$1\r\na\r\n$1\r\n3\r\n",
+		},
+		{
+			cmd:               LRange{Key: "a", Start: 0, End: -1},
+			expectedCmdString: "*4\r\n$6\r\nlrange\r\n$1\r\na\r\n$1\r\n0\r\n$2\r\n-1\r\n",
+		},
+		{
+			cmd:               LInsert{Key: "a", Pivot: []byte("b"), Value: []byte("c")},
+			expectedCmdString: "*5\r\n$7\r\nlinsert\r\n$1\r\na\r\n$5\r\nAFTER\r\n$1\r\nb\r\n$1\r\nc\r\n",
+		},
+		{
+			cmd:               LPos{Key: "a", Value: []byte("b"), Rank: -1, Count: 0, WithCount: true},
+			expectedCmdString: "*7\r\n$4\r\nlpos\r\n$1\r\na\r\n$1\r\nb\r\n$4\r\nRANK\r\n$2\r\n-1\r\n$5\r\nCOUNT\r\n$1\r\n0\r\n",
+		},
+		{
+			cmd:               LMove{Source: "a", Destination: "b", From: ListLeft, To: ListRight},
+			expectedCmdString: "*5\r\n$5\r\nlmove\r\n$1\r\na\r\n$1\r\nb\r\n$4\r\nLEFT\r\n$5\r\nRIGHT\r\n",
+		},
+		{
+			cmd:               BPop{Cmd: BRPopCmd, Keys: []string{"a", "b"}, TimeoutMs: 1500},
+			expectedCmdString: "*4\r\n$5\r\nbrpop\r\n$1\r\na\r\n$1\r\nb\r\n$3\r\n1.5\r\n",
+		},
+		{
+			cmd:               LMPop{Cmd: BLMPopCmd, Keys: []string{"a"}, End: ListLeft, Count: 2},
+			expectedCmdString: "*7\r\n$6\r\nblmpop\r\n$1\r\n0\r\n$1\r\n1\r\n$1\r\na\r\n$4\r\nLEFT\r\n$5\r\nCOUNT\r\n$1\r\n2\r\n",
+		},
+		{
+			cmd:               HSet{Key: "h", Pairs: []FieldValue{{Field: "a", Value: []byte("1")}, {Field: "b", Value: []byte("2")}}},
+			expectedCmdString: "*6\r\n$4\r\nhset\r\n$1\r\nh\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n",
+		},
+		{
+			cmd:               HIncrByFloat{Key: "h", Field: "a", Increment: 0.5},
+			expectedCmdString: "*4\r\n$12\r\nhincrbyfloat\r\n$1\r\nh\r\n$1\r\na\r\n$3\r\n0.5\r\n",
+		},
+		{
+			cmd:               HScan{Key: "h", Cursor: 12, Match: "a*", Count: 10, NoValues: true},
+			expectedCmdString: "*6\r\n$5\r\nhscan\r\n$1\r\nh\r\n$2\r\n12\r\n$5\r\nMATCH\r\n$2\r\na*\r\n$8\r\nNOVALUES\r\n",
+		},
+		{
+			cmd:               HExpire{Cmd: HPExpireAtCmd, Key: "h", Time: 1700000000000, Options: ExpireGT, Fields: []string{"a", "b"}},
+			expectedCmdString: "*8\r\n$10\r\nhpexpireat\r\n$1\r\nh\r\n$13\r\n1700000000000\r\n$2\r\nGT\r\n$6\r\nFIELDS\r\n$1\r\n2\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               HPersist{Key: "h", Fields: []string{"a"}},
+			expectedCmdString: "*5\r\n$8\r\nhpersist\r\n$1\r\nh\r\n$6\r\nFIELDS\r\n$1\r\n1\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               SRem{Key: "s", Members: []string{"a", "b"}},
+			expectedCmdString: "*4\r\n$4\r\nsrem\r\n$1\r\ns\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               SPop{Key: "s", Count: 2, WithCount: true},
+			expectedCmdString: "*3\r\n$4\r\nspop\r\n$1\r\ns\r\n$1\r\n2\r\n",
+		},
+		{
+			cmd:               SCombineStore{Cmd: SUnionStoreCmd, Destination: "d", Keys: []string{"a", "b"}},
+			expectedCmdString: "*4\r\n$11\r\nsunionstore\r\n$1\r\nd\r\n$1\r\na\r\n$1\r\nb\r\n",
+		},
+		{
+			cmd:               SInterCard{Keys: []string{"a", "b"}, Limit: 5},
+			expectedCmdString: "*6\r\n$10\r\nsintercard\r\n$1\r\n2\r\n$1\r\na\r\n$1\r\nb\r\n$5\r\nLIMIT\r\n$1\r\n5\r\n",
+		},
+		{
+			cmd:               Persist{Key: "a"},
+			expectedCmdString: "*2\r\n$7\r\npersist\r\n$1\r\na\r\n",
+		},
+		{
+			cmd:               Save{},
+			expectedCmdString: "*1\r\n$4\r\nsave\r\n",
+		},
+		{
+			cmd:               BgSave{},
+			expectedCmdString: "*1\r\n$6\r\nbgsave\r\n",
+		},
+		{
+			cmd:               LastSave{},
+			expectedCmdString: "*1\r\n$8\r\nlastsave\r\n",
+		},
+		{
+			cmd:               ReplicaOf{Host: "localhost", Port: "6379"},
+			expectedCmdString: "*3\r\n$9\r\nreplicaof\r\n$9\r\nlocalhost\r\n$4\r\n6379\r\n",
+		},
 	} {
 		t.Run(fmt.Sprintf("should be able to encode command %q", tc.expectedCmdString), func(t *testing.T) {
 			res, err := tc.cmd.EncodedCommand()
@@ -73,3 +240,385 @@ func TestEncodeCommand(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandFlags(t *testing.T) {
+	for _, cmd := range []Command{Set{}, Del{}, Unlink{}, Rename{}, RenameNX{}, Copy{}, Expire{}, Persist{}, IncrBy{}, IncrByFloat{}, SetNX{}, Append{}, SetRange{}, GetDel{}, GetEx{}, MSet{}, MSetNX{}, Push{}, Pop{}, LSet{}, LInsert{}, LRem{}, LTrim{}, LMove{}, BPop{}, BLMove{}, LMPop{}, HSet{}, HSetNX{}, HDel{}, HIncrBy{}, HIncrByFloat{}, HExpire{}, HPersist{}, SAdd{}, SRem{}, SPop{}, SMove{}, SCombineStore{}} {
+		assert.True(t, cmd.Flags().Has(WriteFlag), "expected %T to be a write command", cmd)
+	}
+
+	for _, cmd := range []Command{Ping{}, Get{}, Info{}, Wait{}, ReplConf{}, PSync{}, Save{}, ReplicaOf{}, Exists{}, Type{}, TTL{}, StrLen{}, GetRange{}, MGet{}, LRange{}, LLen{}, LIndex{}, LPos{}, HGet{}, HMGet{}, HGetAll{}, HLen{}, HExists{}, HRandField{}, HScan{}, HTTL{}, SMembers{}, SIsMember{}, SMIsMember{}, SCard{}, SRandMember{}, SCombine{}, SInterCard{}, SScan{}} {
+		assert.False(t, cmd.Flags().Has(WriteFlag), "expected %T not to be a write command", cmd)
+	}
+}
+
+func TestExpiresAt(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+
+	for _, tc := range []struct {
+		cmd       Expire
+		expiresAt time.Time
+	}{
+		{cmd: Expire{Cmd: ExpireCmd, Time: 10}, expiresAt: now.Add(10 * time.Second)},
+		{cmd: Expire{Cmd: PExpireCmd, Time: -10}, expiresAt: now.Add(-10 * time.Millisecond)},
+		{cmd: Expire{Cmd: ExpireAtCmd, Time: 1_800_000_000}, expiresAt: time.UnixMilli(1_800_000_000_000)},
+		{cmd: Expire{Cmd: PExpireAtCmd, Time: 1_800_000_000_001}, expiresAt: time.UnixMilli(1_800_000_000_001)},
+	} {
+		expiresAt, err := tc.cmd.ExpiresAt(now)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.expiresAt.UnixMilli(), expiresAt.UnixMilli(), "unexpected expiry for %v", tc.cmd)
+	}
+
+	for _, cmd := range []Expire{
+		{Cmd: ExpireCmd, Time: math.MaxInt64 / 100},
+		{Cmd: ExpireAtCmd, Time: math.MinInt64},
+		{Cmd: PExpireCmd, Time: math.MaxInt64 - 1},
+	} {
+		_, err := cmd.ExpiresAt(now)
+		assert.Equal(t, ErrorReply(fmt.Sprintf("ERR invalid expire time in '%s' command", cmd.Cmd)), err)
+	}
+
+	t.Run("hash field expiries should be limited to 2^48 milliseconds after the unix epoch", func(t *testing.T) {
+		expiresAt, err := HExpire{Cmd: HExpireCmd, Time: 10}.ExpiresAt(now)
+		assert.NoError(t, err)
+		assert.Equal(t, now.Add(10*time.Second).UnixMilli(), expiresAt.UnixMilli())
+
+		expiresAt, err = HExpire{Cmd: HPExpireAtCmd, Time: 1 << 48}.ExpiresAt(now)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1<<48), expiresAt.UnixMilli())
+
+		for _, cmd := range []HExpire{
+			{Cmd: HPExpireAtCmd, Time: 1<<48 + 1},
+			{Cmd: HExpireCmd, Time: -1},
+			{Cmd: HExpireAtCmd, Time: math.MaxInt64},
+		} {
+			_, err := cmd.ExpiresAt(now)
+			assert.Equal(t, ErrorReply("ERR invalid expire time, must be >= 0 and <= 2^48"), err)
+		}
+	})
+}
+
+func TestToCommandErrors(t *testing.T) {
+	for _, tc := range []struct {
+		data          []any
+		expectedError ErrorReply
+	}{
+		{
+			data:          []any{"FOO", "a", "b"},
+			expectedError: "ERR unknown command 'FOO', with args beginning with: 'a' 'b' ",
+		},
+		{
+			data:          []any{"SET", "a"},
+			expectedError: "ERR wrong number of arguments for 'set' command",
+		},
+		{
+			data:          []any{"SET", "a", "b", "px"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"SET", "a", "b", "NX", "XX"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"SET", "a", "b", "EX", "1", "PXAT", "1"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"SET", "a", "b", "KEEPTTL", "EX", "1"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"SET", "a", "b", "SOMETIMES"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"SET", "a", "b", "EX", "0"},
+			expectedError: "ERR invalid expire time in 'set' command",
+		},
+		{
+			data:          []any{"SET", "a", "b", "px", "soon"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"WAIT", "1"},
+			expectedError: "ERR wrong number of arguments for 'wait' command",
+		},
+		{
+			data:          []any{"WAIT", "one", "100"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"WAIT", "1", "-1"},
+			expectedError: "ERR timeout is negative",
+		},
+		{
+			data:          []any{"RENAME", "a"},
+			expectedError: "ERR wrong number of arguments for 'rename' command",
+		},
+		{
+			data:          []any{"COPY", "a", "b", "DB", "1"},
+			expectedError: "ERR DB index is out of range",
+		},
+		{
+			data:          []any{"COPY", "a", "b", "EVERYWHERE"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"EXPIRE", "a", "soon"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"EXPIRE", "a", "10", "NX", "GT"},
+			expectedError: "ERR NX and XX, GT or LT options at the same time are not compatible",
+		},
+		{
+			data:          []any{"PEXPIRE", "a", "10", "GT", "LT"},
+			expectedError: "ERR GT and LT options at the same time are not compatible",
+		},
+		{
+			data:          []any{"EXPIREAT", "a", "10", "SOMETIME"},
+			expectedError: "ERR Unsupported option SOMETIME",
+		},
+		{
+			data:          []any{"SETEX", "a", "0", "b"},
+			expectedError: "ERR invalid expire time in 'setex' command",
+		},
+		{
+			data:          []any{"GETRANGE", "a", "0", "end"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"SETRANGE", "a", "-1", "b"},
+			expectedError: "ERR offset is out of range",
+		},
+		{
+			data:          []any{"GETEX", "a", "EX", "10", "PERSIST"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"GETEX", "a", "PX"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"GETEX", "a", "KEEPTTL"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"MSET", "a", "1", "b"},
+			expectedError: "ERR wrong number of arguments for 'mset' command",
+		},
+		{
+			data:          []any{"MGET"},
+			expectedError: "ERR wrong number of arguments for 'mget' command",
+		},
+		{
+			data:          []any{"INCR", "a", "1"},
+			expectedError: "ERR wrong number of arguments for 'incr' command",
+		},
+		{
+			data:          []any{"INCRBY", "a", "1.5"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"DECRBY", "a", "-9223372036854775808"},
+			expectedError: "ERR decrement would overflow",
+		},
+		{
+			data:          []any{"INCRBYFLOAT", "a", "nan"},
+			expectedError: ErrNotFloat,
+		},
+		{
+			data:          []any{"INCRBYFLOAT", "a", "0x10"},
+			expectedError: ErrNotFloat,
+		},
+		{
+			data:          []any{"TTL", "a", "b"},
+			expectedError: "ERR wrong number of arguments for 'ttl' command",
+		},
+		{
+			data:          []any{"LPUSH", "a"},
+			expectedError: "ERR wrong number of arguments for 'lpush' command",
+		},
+		{
+			data:          []any{"LPOP", "a", "-1"},
+			expectedError: ErrNotPositive,
+		},
+		{
+			data:          []any{"RPOP", "a", "1", "2"},
+			expectedError: "ERR wrong number of arguments for 'rpop' command",
+		},
+		{
+			data:          []any{"LRANGE", "a", "0", "b"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"HSET", "h", "a", "1", "b"},
+			expectedError: "ERR wrong number of arguments for 'hset' command",
+		},
+		{
+			data:          []any{"HMGET", "h"},
+			expectedError: "ERR wrong number of arguments for 'hmget' command",
+		},
+		{
+			data:          []any{"HINCRBY", "h", "a", "1.5"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"HINCRBYFLOAT", "h", "a", "abc"},
+			expectedError: ErrNotFloat,
+		},
+		{
+			data:          []any{"HRANDFIELD", "h", "1", "VALUES"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"HRANDFIELD", "h", "-9223372036854775808"},
+			expectedError: "ERR value is out of range",
+		},
+		{
+			data:          []any{"HRANDFIELD", "h", "-5000000000000000000", "WITHVALUES"},
+			expectedError: "ERR value is out of range",
+		},
+		{
+			data:          []any{"HRANDFIELD", "h", "-9223372036854775807"},
+			expectedError: "ERR value is out of range",
+		},
+		{
+			data:          []any{"HSCAN", "h", "-1"},
+			expectedError: "ERR invalid cursor",
+		},
+		{
+			data:          []any{"HSCAN", "h", "0", "COUNT", "0"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"HSCAN", "h", "0", "MATCH"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"HSCAN", "h", "0", "COUNT", "many"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"HEXPIRE", "h", "10", "a", "1", "b"},
+			expectedError: "ERR Mandatory argument FIELDS is missing or not at the right position",
+		},
+		{
+			data:          []any{"HEXPIRE", "h", "10", "NX", "XX", "FIELDS", "1", "a"},
+			expectedError: "ERR Mandatory argument FIELDS is missing or not at the right position",
+		},
+		{
+			data:          []any{"HPEXPIRE", "h", "soon", "FIELDS", "1", "a"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"HTTL", "h", "FIELDS", "0", "a"},
+			expectedError: "ERR Parameter `numFields` should be greater than 0",
+		},
+		{
+			data:          []any{"HPERSIST", "h", "FIELDS", "2", "a"},
+			expectedError: "ERR The `numfields` parameter must match the number of arguments",
+		},
+		{
+			data:          []any{"SADD", "s"},
+			expectedError: "ERR wrong number of arguments for 'sadd' command",
+		},
+		{
+			data:          []any{"SPOP", "s", "-1"},
+			expectedError: "ERR value is out of range, must be positive",
+		},
+		{
+			data:          []any{"SRANDMEMBER", "s", "many"},
+			expectedError: ErrNotInteger,
+		},
+		{
+			data:          []any{"SRANDMEMBER", "s", "-9223372036854775807"},
+			expectedError: "ERR value is out of range",
+		},
+		{
+			data:          []any{"SINTERCARD", "0", "a"},
+			expectedError: "ERR numkeys should be greater than 0",
+		},
+		{
+			data:          []any{"SINTERCARD", "3", "a", "b"},
+			expectedError: "ERR Number of keys can't be greater than number of args",
+		},
+		{
+			data:          []any{"SINTERCARD", "1", "a", "LIMIT", "-1"},
+			expectedError: "ERR LIMIT can't be negative",
+		},
+		{
+			data:          []any{"SINTERCARD", "1", "a", "b"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"LINSERT", "a", "IN", "b", "c"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"LPOS", "a", "b", "RANK", "0"},
+			expectedError: "ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list",
+		},
+		{
+			data:          []any{"LPOS", "a", "b", "COUNT", "-1"},
+			expectedError: "ERR COUNT can't be negative",
+		},
+		{
+			data:          []any{"LPOS", "a", "b", "MAXLEN"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"LMOVE", "a", "b", "LEFT", "UP"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"BLPOP", "a"},
+			expectedError: "ERR wrong number of arguments for 'blpop' command",
+		},
+		{
+			data:          []any{"BLPOP", "a", "soon"},
+			expectedError: "ERR timeout is not a float or out of range",
+		},
+		{
+			data:          []any{"BRPOP", "a", "-1"},
+			expectedError: "ERR timeout is negative",
+		},
+		{
+			data:          []any{"BLMOVE", "a", "b", "LEFT", "RIGHT", "1e300"},
+			expectedError: "ERR timeout is out of range",
+		},
+		{
+			data:          []any{"LMPOP", "0", "a", "LEFT"},
+			expectedError: "ERR numkeys should be greater than 0",
+		},
+		{
+			data:          []any{"LMPOP", "2", "a", "LEFT"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"LMPOP", "1", "a", "LEFT", "COUNT", "0"},
+			expectedError: "ERR count should be greater than 0",
+		},
+		{
+			data:          []any{"LMPOP", "1", "a", "LEFT", "COUNT", "1", "COUNT", "2"},
+			expectedError: ErrSyntax,
+		},
+		{
+			data:          []any{"BLMPOP", "1", "a", "LEFT"},
+			expectedError: "ERR wrong number of arguments for 'blmpop' command",
+		},
+		{
+			data:          []any{"HELLO", "three"},
+			expectedError: "ERR Protocol version is not an integer or out of range",
+		},
+	} {
+		t.Run(fmt.Sprintf("command %v should fail with %q", tc.data, tc.expectedError), func(t *testing.T) {
+			_, err := ToCommand(tc.data)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+
+	t.Run("unknown command errors should only include the start of long arguments", func(t *testing.T) {
+		_, err := ToCommand([]any{"FOO", strings.Repeat("a", 200), "b"})
+		assert.Equal(t, ErrorReply(fmt.Sprintf("ERR unknown command 'FOO', with args beginning with: '%s' ", strings.Repeat("a", 128))), err)
+	})
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Copy copies the value at Source to Destination. `COPY source destination [DB destination-db] [REPLACE]` only
+// overwrites an existing destination if REPLACE is set. Only database 0 is supported
+type Copy struct {
+	Source      string
+	Destination string
+	Replace     bool
+}
+
+func (c Copy) String() string {
+	return fmt.Sprintf("COPY: %q -> %q, replace %t", c.Source, c.Destination, c.Replace)
+}
+
+func (c Copy) EncodedCommand() (string, error) {
+	cmdList := []any{string(CopyCmd), c.Source, c.Destination}
+	if c.Replace {
+		cmdList = append(cmdList, "REPLACE")
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (Copy) CommandType() CommandType {
+	return CopyCmd
+}
+
+func (Copy) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toCopy(data []any) (Copy, error) {
+	if len(data) < 2 {
+		return Copy{}, wrongNumberOfArgs(CopyCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Copy{}, fmt.Errorf("expected the inputs to the COPY command to be strings: %w", err)
+	}
+	copyCmd := Copy{Source: args[0], Destination: args[1]}
+
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToLower(args[i]); {
+		case option == "replace":
+			copyCmd.Replace = true
+		case option == "db" && i+1 < len(args):
+			db, err := strconv.Atoi(args[i+1])
+			if err != nil {
+				return Copy{}, ErrNotInteger
+			}
+			if db != 0 {
+				return Copy{}, ErrorReply("ERR DB index is out of range")
+			}
+			i++
+		default:
+			return Copy{}, ErrSyntax
+		}
+	}
+
+	return copyCmd, nil
+}
@@ -0,0 +1,89 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Del struct {
+	Keys []string
+}
+
+func (del Del) String() string {
+	return fmt.Sprintf("DEL: %q", strings.Join(del.Keys, " "))
+}
+
+func (del Del) EncodedCommand() (string, error) {
+	cmdList := []any{string(DelCmd)}
+	for _, key := range del.Keys {
+		cmdList = append(cmdList, key)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (Del) CommandType() CommandType {
+	return DelCmd
+}
+
+func (Del) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toDel(data []any) (Del, error) {
+	if len(data) == 0 {
+		return Del{}, wrongNumberOfArgs(DelCmd)
+	}
+
+	keys := make([]string, 0, len(data))
+	for _, rawKey := range data {
+		key, ok := rawKey.(string)
+		if !ok {
+			return Del{}, fmt.Errorf("expected the keys of the DEL command to be strings but got %[1]v of type %[1]T", rawKey)
+		}
+		keys = append(keys, key)
+	}
+
+	return Del{Keys: keys}, nil
+}
+
+// Unlink deletes keys like DEL, but the work of freeing large values is done in the background
+type Unlink struct {
+	Keys []string
+}
+
+func (unlink Unlink) String() string {
+	return fmt.Sprintf("UNLINK: %q", strings.Join(unlink.Keys, " "))
+}
+
+func (unlink Unlink) EncodedCommand() (string, error) {
+	cmdList := []any{string(UnlinkCmd)}
+	for _, key := range unlink.Keys {
+		cmdList = append(cmdList, key)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (Unlink) CommandType() CommandType {
+	return UnlinkCmd
+}
+
+func (Unlink) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toUnlink(data []any) (Unlink, error) {
+	if len(data) == 0 {
+		return Unlink{}, wrongNumberOfArgs(UnlinkCmd)
+	}
+
+	keys, err := toStrings(data)
+	if err != nil {
+		return Unlink{}, fmt.Errorf("expected the keys of the UNLINK command to be strings: %w", err)
+	}
+
+	return Unlink{Keys: keys}, nil
+}
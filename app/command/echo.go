@@ -21,9 +21,13 @@ func (Echo) CommandType() CommandType {
 	return EchoCmd
 }
 
+func (Echo) Flags() CommandFlags {
+	return 0
+}
+
 func toEcho(data []any) (Echo, error) {
 	if len(data) != 1 {
-		return Echo{}, fmt.Errorf("expected only one data element for ECHO command, but found %d: %v", len(data), data)
+		return Echo{}, wrongNumberOfArgs(EchoCmd)
 	}
 	res, ok := data[0].(string)
 	if !ok {
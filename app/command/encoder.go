@@ -2,26 +2,31 @@ package command
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"strconv"
 	"strings"
 )
 
 type Encoder struct {
 	UseBulkStrings bool
+
+	// The RESP version to encode data for. Under RESP2, the types that only exist in RESP3 are encoded as the closest
+	// RESP2 type. The zero value encodes for RESP2
+	Protocol int
 }
 
 // TODO: As this grows in complexity, it may be worth thinking about restructing these encoder/decoder bits
 func (e Encoder) Encode(data any) (string, error) {
-
-	// TODO: Handle arrays that are not explicityl []any
-	// t := reflect.TypeOf(data)
-	// if t.Kind() == reflect.Array {
-	// }
-
 	switch typedData := data.(type) {
-	// case []byte: // TODO: Not sure if this needs to be handled serperately (as a bulk string)
-	// 	return encodeBulkString(typedData)
 	case []any:
 		return e.EncodeArray(typedData)
+	case SetReply:
+		return e.encodeAggregate('~', typedData)
+	case PushReply:
+		return e.encodeAggregate('>', typedData)
+	case MapReply:
+		return e.EncodeMap(typedData)
 	default:
 		return e.EncodePrimitive(typedData)
 	}
@@ -37,10 +42,41 @@ func (e Encoder) MustEncode(data any) string {
 }
 
 func (e Encoder) EncodeArray(arrayData []any) (string, error) {
+	return e.encodeAggregate('*', arrayData)
+}
+
+// EncodeMap encodes a map under RESP3, or a flat array of alternating keys and values under RESP2
+func (e Encoder) EncodeMap(mapData MapReply) (string, error) {
+	builder := strings.Builder{}
+
+	if e.isRESP3() {
+		builder.WriteString(fmt.Sprintf("%%%d%s", len(mapData), Delimeter))
+	} else {
+		builder.WriteString(fmt.Sprintf("*%d%s", len(mapData)*2, Delimeter))
+	}
+	for _, entry := range mapData {
+		for _, data := range []any{entry.Key, entry.Value} {
+			res, err := e.Encode(data)
+			if err != nil {
+				return "", fmt.Errorf("failed to encode map entry: %w", err)
+			}
+			builder.WriteString(res)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// encodeAggregate encodes an array, set or push message. Sets and push messages are encoded as arrays under RESP2
+func (e Encoder) encodeAggregate(typePrefix byte, elements []any) (string, error) {
+	if !e.isRESP3() {
+		typePrefix = '*'
+	}
+
 	builder := strings.Builder{}
 
-	builder.WriteString(fmt.Sprintf("*%d%s", len(arrayData), Delimeter))
-	for _, data := range arrayData {
+	builder.WriteString(fmt.Sprintf("%c%d%s", typePrefix, len(elements), Delimeter))
+	for _, data := range elements {
 		res, err := e.Encode(data)
 		if err != nil {
 			return "", fmt.Errorf("failed to encode list element: %w", err)
@@ -56,7 +92,12 @@ func (e Encoder) EncodePrimitive(data any) (string, error) {
 	var err error
 	switch typedData := data.(type) {
 	case int:
+		result, err = encodeInt(int64(typedData))
+	case int64:
 		result, err = encodeInt(typedData)
+	case []byte:
+		// Byte slices hold binary data, so unlike strings they are always encoded as bulk strings
+		result, err = encodeBulkString(string(typedData))
 	case string:
 		if e.UseBulkStrings {
 			result, err = encodeBulkString(typedData)
@@ -64,7 +105,19 @@ func (e Encoder) EncodePrimitive(data any) (string, error) {
 			result, err = encodeString(typedData)
 		}
 	case bool:
-		result, err = encodeBool(typedData)
+		result, err = e.encodeBool(typedData)
+	case float64:
+		result, err = e.encodeDouble(typedData)
+	case *big.Int:
+		result, err = e.encodeBigNumber(typedData)
+	case Null:
+		result, err = e.encodeNull("$-1")
+	case NullArray:
+		result, err = e.encodeNull("*-1")
+	case VerbatimString:
+		result, err = e.encodeVerbatimString(typedData)
+	case ErrorReply:
+		result, err = e.encodeError(typedData)
 	default:
 		return "", fmt.Errorf("tried to encode primitive data of an unknown type %[1]T: %[1]v", data)
 	}
@@ -75,7 +128,11 @@ func (e Encoder) EncodePrimitive(data any) (string, error) {
 	return fmt.Sprint(result, Delimeter), nil
 }
 
-func encodeInt(data int) (string, error) {
+func (e Encoder) isRESP3() bool {
+	return e.Protocol >= RESP3
+}
+
+func encodeInt(data int64) (string, error) {
 	return fmt.Sprintf(":%d", data), nil
 }
 
@@ -87,39 +144,83 @@ func encodeString(data string) (string, error) {
 	return fmt.Sprintf("+%s", data), nil
 }
 
-func encodeBool(data bool) (string, error) {
+// encodeBool encodes a boolean under RESP3, or the integers 1 and 0 under RESP2
+func (e Encoder) encodeBool(data bool) (string, error) {
+	if !e.isRESP3() {
+		if data {
+			return encodeInt(1)
+		}
+		return encodeInt(0)
+	}
+
 	if data {
 		return "#t", nil
 	}
 	return "#f", nil
 }
 
-//  case '+':
-// 		return nil, errors.New("TODO: Implement parser.parseSimpleString()")
-// 	case '-':
-// 		return nil, errors.New("TODO: Implement parser.parseSimpleError()")
-// 	case ':':
-// 		return parser.parseInt()
-// 	case '$':
-// 		return parser.parseBulkString()
-// 	case '*':
-// 		return parser.parseArray()
-// 	case '_':
-// 		return nil, errors.New("TODO: Implement parser.parseNull()")
-// 	case '#':
-// 		return nil, errors.New("TODO: Implement parser.parseBool()")
-// 	case ',':
-// 		return nil, errors.New("TODO: Implement parser.parseDouble()")
-// 	case '(':
-// 		return nil, errors.New("TODO: Implement parser.parseBigNumber()")
-// 	case '!':
-// 		return nil, errors.New("TODO: Implement parser.parseBulkError()")
-// 	case '=':
-// 		return nil, errors.New("TODO: Implement parser.parseVerbatimString()")
-// 	case '%':
-// 		return nil, errors.New("TODO: Implement parser.parseMap()")
-// 	case '~':
-// 		return nil, errors.New("TODO: Implement parser.parseSet()")
-// 	case '>':
-// 		return nil, errors.New("TODO: Implement parser.parsePush()")
-// 	}
+// encodeDouble encodes a double under RESP3, or a bulk string under RESP2
+func (e Encoder) encodeDouble(data float64) (string, error) {
+	if !e.isRESP3() {
+		return encodeBulkString(FormatDouble(data))
+	}
+	return fmt.Sprintf(",%s", FormatDouble(data)), nil
+}
+
+// encodeBigNumber encodes a big number under RESP3, or a bulk string under RESP2
+func (e Encoder) encodeBigNumber(data *big.Int) (string, error) {
+	if data == nil {
+		return "", fmt.Errorf("tried to encode a nil big number")
+	}
+
+	if !e.isRESP3() {
+		return encodeBulkString(data.String())
+	}
+	return fmt.Sprintf("(%s", data.String()), nil
+}
+
+// encodeNull encodes the RESP3 null, or the provided RESP2 null
+func (e Encoder) encodeNull(resp2Null string) (string, error) {
+	if !e.isRESP3() {
+		return resp2Null, nil
+	}
+	return "_", nil
+}
+
+// encodeVerbatimString encodes a verbatim string under RESP3, or a bulk string of just the text under RESP2
+func (e Encoder) encodeVerbatimString(data VerbatimString) (string, error) {
+	if !e.isRESP3() {
+		return encodeBulkString(data.Text)
+	}
+
+	if len(data.Format) != 3 {
+		return "", fmt.Errorf("expected verbatim string format to be three characters but it was %q", data.Format)
+	}
+	return fmt.Sprintf("=%d\r\n%s:%s", len(data.Text)+4, data.Format, data.Text), nil
+}
+
+// encodeError encodes a simple error. Simple errors can't contain line breaks, so an error with line breaks is
+// encoded as a bulk error under RESP3, and has them replaced with spaces under RESP2
+func (e Encoder) encodeError(data ErrorReply) (string, error) {
+	if !strings.ContainsAny(string(data), "\r\n") {
+		return fmt.Sprintf("-%s", data), nil
+	}
+
+	if e.isRESP3() {
+		return fmt.Sprintf("!%d\r\n%s", len(data), data), nil
+	}
+	return fmt.Sprintf("-%s", strings.NewReplacer("\r", " ", "\n", " ").Replace(string(data))), nil
+}
+
+// FormatDouble formats a float the way that redis does in replies
+func FormatDouble(data float64) string {
+	switch {
+	case math.IsInf(data, 1):
+		return "inf"
+	case math.IsInf(data, -1):
+		return "-inf"
+	case math.IsNaN(data):
+		return "nan"
+	}
+	return strconv.FormatFloat(data, 'g', -1, 64)
+}
@@ -2,6 +2,8 @@ package command
 
 import (
 	"fmt"
+	"math"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -10,6 +12,7 @@ import (
 func TestEncoder(t *testing.T) {
 	for _, tc := range []struct {
 		input          any
+		protocol       int
 		expectedOutput string
 	}{
 		{
@@ -20,21 +23,141 @@ func TestEncoder(t *testing.T) {
 			input:          100,
 			expectedOutput: ":100\r\n",
 		},
+		{
+			input:          []byte("a\r\nb"),
+			expectedOutput: "$4\r\na\r\nb\r\n",
+		},
+		{
+			input:          int64(-100),
+			expectedOutput: ":-100\r\n",
+		},
 		{
 			input:          []any{1, 2, "3"},
 			expectedOutput: "*3\r\n:1\r\n:2\r\n+3\r\n",
 		},
 		{
 			input:          true,
+			protocol:       RESP3,
 			expectedOutput: "#t\r\n",
 		},
 		{
 			input:          false,
+			protocol:       RESP3,
 			expectedOutput: "#f\r\n",
 		},
+		{
+			input:          true,
+			protocol:       RESP2,
+			expectedOutput: ":1\r\n",
+		},
+		{
+			input:          false,
+			protocol:       RESP2,
+			expectedOutput: ":0\r\n",
+		},
+		{
+			input:          Null{},
+			protocol:       RESP3,
+			expectedOutput: "_\r\n",
+		},
+		{
+			input:          Null{},
+			protocol:       RESP2,
+			expectedOutput: "$-1\r\n",
+		},
+		{
+			input:          NullArray{},
+			protocol:       RESP3,
+			expectedOutput: "_\r\n",
+		},
+		{
+			input:          NullArray{},
+			protocol:       RESP2,
+			expectedOutput: "*-1\r\n",
+		},
+		{
+			input:          1.5,
+			protocol:       RESP3,
+			expectedOutput: ",1.5\r\n",
+		},
+		{
+			input:          math.Inf(-1),
+			protocol:       RESP3,
+			expectedOutput: ",-inf\r\n",
+		},
+		{
+			input:          1.5,
+			protocol:       RESP2,
+			expectedOutput: "$3\r\n1.5\r\n",
+		},
+		{
+			input:          big.NewInt(12345),
+			protocol:       RESP3,
+			expectedOutput: "(12345\r\n",
+		},
+		{
+			input:          big.NewInt(12345),
+			protocol:       RESP2,
+			expectedOutput: "$5\r\n12345\r\n",
+		},
+		{
+			input:          ErrorReply("ERR bad"),
+			protocol:       RESP3,
+			expectedOutput: "-ERR bad\r\n",
+		},
+		{
+			input:          ErrorReply("ERR bad\nthing"),
+			protocol:       RESP3,
+			expectedOutput: "!13\r\nERR bad\nthing\r\n",
+		},
+		{
+			input:          ErrorReply("ERR bad\nthing"),
+			protocol:       RESP2,
+			expectedOutput: "-ERR bad thing\r\n",
+		},
+		{
+			input:          VerbatimString{Format: "txt", Text: "some text"},
+			protocol:       RESP3,
+			expectedOutput: "=13\r\ntxt:some text\r\n",
+		},
+		{
+			input:          VerbatimString{Format: "txt", Text: "some text"},
+			protocol:       RESP2,
+			expectedOutput: "$9\r\nsome text\r\n",
+		},
+		{
+			input:          MapReply{{Key: "a", Value: 1}, {Key: "b", Value: []any{2}}},
+			protocol:       RESP3,
+			expectedOutput: "%2\r\n+a\r\n:1\r\n+b\r\n*1\r\n:2\r\n",
+		},
+		{
+			input:          MapReply{{Key: "a", Value: 1}, {Key: "b", Value: []any{2}}},
+			protocol:       RESP2,
+			expectedOutput: "*4\r\n+a\r\n:1\r\n+b\r\n*1\r\n:2\r\n",
+		},
+		{
+			input:          SetReply{1, 2},
+			protocol:       RESP3,
+			expectedOutput: "~2\r\n:1\r\n:2\r\n",
+		},
+		{
+			input:          SetReply{1, 2},
+			protocol:       RESP2,
+			expectedOutput: "*2\r\n:1\r\n:2\r\n",
+		},
+		{
+			input:          PushReply{"message", Null{}},
+			protocol:       RESP3,
+			expectedOutput: ">2\r\n+message\r\n_\r\n",
+		},
+		{
+			input:          PushReply{"message", Null{}},
+			protocol:       RESP2,
+			expectedOutput: "*2\r\n+message\r\n$-1\r\n",
+		},
 	} {
-		t.Run(fmt.Sprintf("encoding input %v", tc.input), func(t *testing.T) {
-			e := Encoder{}
+		t.Run(fmt.Sprintf("encoding input %v with protocol %d", tc.input, tc.protocol), func(t *testing.T) {
+			e := Encoder{Protocol: tc.protocol}
 			res, err := e.Encode(tc.input)
 			assert.NoError(t, err)
 			assert.Equal(t, tc.expectedOutput, res)
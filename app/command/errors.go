@@ -0,0 +1,59 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ErrorReply is an error that is sent to or received from the other end of a connection as a RESP error. Like in
+// redis, the message starts with an error code such as ERR or WRONGTYPE that clients can use to classify it
+type ErrorReply string
+
+func (e ErrorReply) Error() string {
+	return string(e)
+}
+
+// The errors that are shared between commands, worded the same way that redis words them
+var (
+	ErrSyntax      = ErrorReply("ERR syntax error")
+	ErrNotInteger  = ErrorReply("ERR value is not an integer or out of range")
+	ErrWrongType   = ErrorReply("WRONGTYPE Operation against a key holding the wrong kind of value")
+	ErrNoSuchKey   = ErrorReply("ERR no such key")
+	ErrNotFloat    = ErrorReply("ERR value is not a valid float")
+	ErrOverflow    = ErrorReply("ERR increment or decrement would overflow")
+	ErrNotPositive = ErrorReply("ERR value is out of range, must be positive")
+)
+
+// The most characters of a command's name and arguments that are included in an unknown command error
+const maxUnknownCommandArgsLength = 128
+
+// wrongNumberOfArgs is the error for a command that was sent with too many or too few arguments
+func wrongNumberOfArgs(cmdType CommandType) ErrorReply {
+	return ErrorReply(fmt.Sprintf("ERR wrong number of arguments for '%s' command", cmdType))
+}
+
+// unknownCommand is the error for a command that doesn't exist. Like in redis, it includes the start of the
+// command's arguments to help with debugging
+func unknownCommand(name string, args []any) ErrorReply {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("ERR unknown command '%s', with args beginning with: ", truncate(name, maxUnknownCommandArgsLength)))
+
+	remaining := maxUnknownCommandArgsLength
+	for _, arg := range args {
+		if remaining <= 0 {
+			break
+		}
+		argStr := truncate(fmt.Sprint(arg), remaining)
+		builder.WriteString(fmt.Sprintf("'%s' ", argStr))
+		remaining -= len(argStr)
+	}
+
+	return ErrorReply(builder.String())
+}
+
+func truncate(s string, maxLength int) string {
+	if len(s) > maxLength {
+		return s[:maxLength]
+	}
+	return s
+}
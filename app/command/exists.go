@@ -0,0 +1,46 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Exists counts how many of the provided keys exist. A key that is provided more than once is counted more than once
+type Exists struct {
+	Keys []string
+}
+
+func (exists Exists) String() string {
+	return fmt.Sprintf("EXISTS: %q", strings.Join(exists.Keys, " "))
+}
+
+func (exists Exists) EncodedCommand() (string, error) {
+	cmdList := []any{string(ExistsCmd)}
+	for _, key := range exists.Keys {
+		cmdList = append(cmdList, key)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (Exists) CommandType() CommandType {
+	return ExistsCmd
+}
+
+func (Exists) Flags() CommandFlags {
+	return 0
+}
+
+func toExists(data []any) (Exists, error) {
+	if len(data) == 0 {
+		return Exists{}, wrongNumberOfArgs(ExistsCmd)
+	}
+
+	keys, err := toStrings(data)
+	if err != nil {
+		return Exists{}, fmt.Errorf("expected the keys of the EXISTS command to be strings: %w", err)
+	}
+
+	return Exists{Keys: keys}, nil
+}
@@ -0,0 +1,243 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExpireOptions is the set of NX, XX, GT and LT options that control when an expire command replaces a key's expiry
+type ExpireOptions uint8
+
+const (
+	// ExpireNX only sets the expiry if the key has none
+	ExpireNX ExpireOptions = 1 << iota
+	// ExpireXX only sets the expiry if the key already has one
+	ExpireXX
+	// ExpireGT only sets the expiry if it's later than the current one. A key without an expiry never expires, so
+	// GT never applies to it
+	ExpireGT
+	// ExpireLT only sets the expiry if it's earlier than the current one, or if the key has no expiry
+	ExpireLT
+)
+
+// Has is true if every one of the provided options is set
+func (o ExpireOptions) Has(options ExpireOptions) bool {
+	return o&options == options
+}
+
+// names returns the names of the options that are set, in the order that they're encoded in
+func (o ExpireOptions) names() []string {
+	var names []string
+	for _, option := range []struct {
+		option ExpireOptions
+		name   string
+	}{{ExpireNX, "NX"}, {ExpireXX, "XX"}, {ExpireGT, "GT"}, {ExpireLT, "LT"}} {
+		if o.Has(option.option) {
+			names = append(names, option.name)
+		}
+	}
+	return names
+}
+
+// Expire sets when Key expires. It covers EXPIRE and PEXPIRE, which take a timeout from now in seconds or
+// milliseconds, and EXPIREAT and PEXPIREAT, which take a unix time in seconds or milliseconds. Cmd is the one
+// that was sent
+type Expire struct {
+	Cmd     CommandType
+	Key     string
+	Time    int64
+	Options ExpireOptions
+}
+
+func (e Expire) String() string {
+	return fmt.Sprintf("%s: %q at %d, options %v", strings.ToUpper(string(e.Cmd)), e.Key, e.Time, e.Options.names())
+}
+
+func (e Expire) EncodedCommand() (string, error) {
+	cmdList := []any{string(e.Cmd), e.Key, strconv.FormatInt(e.Time, 10)}
+	for _, name := range e.Options.names() {
+		cmdList = append(cmdList, name)
+	}
+
+	encoder := Encoder{UseBulkStrings: true}
+	return encoder.EncodeArray(cmdList)
+}
+
+func (e Expire) CommandType() CommandType {
+	return e.Cmd
+}
+
+func (Expire) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// ExpiresAt works out the absolute time that the key should expire at. Relative times are added to now. An error
+// is returned if the time can't be represented as a unix time in milliseconds
+func (e Expire) ExpiresAt(now time.Time) (time.Time, error) {
+	inSeconds := e.Cmd == ExpireCmd || e.Cmd == ExpireAtCmd
+	relative := e.Cmd == ExpireCmd || e.Cmd == PExpireCmd
+
+	expiresAt, ok := toExpiryTime(now, e.Time, inSeconds, relative)
+	if !ok {
+		return time.Time{}, invalidExpireTime(e.Cmd)
+	}
+	return expiresAt, nil
+}
+
+// ExpiryOption is the option of SET or GETEX that decides when the value expires
+type ExpiryOption string
+
+const (
+	// NoExpiry means that no expiry option was given. SET then removes any expiry that the key had, while GETEX
+	// leaves it alone
+	NoExpiry ExpiryOption = ""
+	// ExpiryEX expires the value after a number of seconds
+	ExpiryEX ExpiryOption = "EX"
+	// ExpiryPX expires the value after a number of milliseconds
+	ExpiryPX ExpiryOption = "PX"
+	// ExpiryEXAT expires the value at a unix time in seconds
+	ExpiryEXAT ExpiryOption = "EXAT"
+	// ExpiryPXAT expires the value at a unix time in milliseconds
+	ExpiryPXAT ExpiryOption = "PXAT"
+	// KeepTTL makes SET keep the expiry that the key already had
+	KeepTTL ExpiryOption = "KEEPTTL"
+	// ExpiryPersist makes GETEX remove the key's expiry
+	ExpiryPersist ExpiryOption = "PERSIST"
+)
+
+// HasTime is true for the options that are followed by the time that the value expires at
+func (o ExpiryOption) HasTime() bool {
+	switch o {
+	case ExpiryEX, ExpiryPX, ExpiryEXAT, ExpiryPXAT:
+		return true
+	}
+	return false
+}
+
+// expiresAt converts the time that followed the option to an absolute time
+func (o ExpiryOption) expiresAt(now time.Time, expiryTime int64, cmdType CommandType) (time.Time, error) {
+	inSeconds := o == ExpiryEX || o == ExpiryEXAT
+	relative := o == ExpiryEX || o == ExpiryPX
+
+	expiresAt, ok := toExpiryTime(now, expiryTime, inSeconds, relative)
+	if !ok {
+		return time.Time{}, invalidExpireTime(cmdType)
+	}
+	return expiresAt, nil
+}
+
+// parseExpiryTime parses the time that follows an expiry option, which has to be positive
+func parseExpiryTime(cmdType CommandType, arg string) (int64, error) {
+	expiryTime, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	if expiryTime <= 0 {
+		return 0, invalidExpireTime(cmdType)
+	}
+	return expiryTime, nil
+}
+
+func invalidExpireTime(cmdType CommandType) ErrorReply {
+	return ErrorReply(fmt.Sprintf("ERR invalid expire time in '%s' command", cmdType))
+}
+
+// toExpiryTime converts an expiry in seconds or milliseconds, that is either relative to now or a unix time, to an
+// absolute time. It returns false if the time can't be represented as a unix time in milliseconds
+func toExpiryTime(now time.Time, expiryTime int64, inSeconds bool, relative bool) (time.Time, bool) {
+	ms := expiryTime
+	if inSeconds {
+		if ms > math.MaxInt64/1000 || ms < math.MinInt64/1000 {
+			return time.Time{}, false
+		}
+		ms *= 1000
+	}
+
+	if relative {
+		nowMs := now.UnixMilli()
+		if (ms > 0 && nowMs > math.MaxInt64-ms) || (ms < 0 && nowMs < math.MinInt64-ms) {
+			return time.Time{}, false
+		}
+		ms += nowMs
+	}
+
+	return time.UnixMilli(ms), true
+}
+
+func toExpire(cmdType CommandType, data []any) (Expire, error) {
+	if len(data) < 2 {
+		return Expire{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Expire{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	expiryTime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return Expire{}, ErrNotInteger
+	}
+	expire := Expire{Cmd: cmdType, Key: args[0], Time: expiryTime}
+
+	for _, arg := range args[2:] {
+		switch strings.ToLower(arg) {
+		case "nx":
+			expire.Options |= ExpireNX
+		case "xx":
+			expire.Options |= ExpireXX
+		case "gt":
+			expire.Options |= ExpireGT
+		case "lt":
+			expire.Options |= ExpireLT
+		default:
+			return Expire{}, ErrorReply(fmt.Sprintf("ERR Unsupported option %s", arg))
+		}
+	}
+
+	if expire.Options.Has(ExpireNX) && expire.Options&(ExpireXX|ExpireGT|ExpireLT) != 0 {
+		return Expire{}, ErrorReply("ERR NX and XX, GT or LT options at the same time are not compatible")
+	}
+	if expire.Options.Has(ExpireGT | ExpireLT) {
+		return Expire{}, ErrorReply("ERR GT and LT options at the same time are not compatible")
+	}
+
+	return expire, nil
+}
+
+// Persist removes the expiry from Key so that it's kept until it's deleted
+type Persist struct {
+	Key string
+}
+
+func (p Persist) String() string {
+	return fmt.Sprintf("PERSIST: %q", p.Key)
+}
+
+func (p Persist) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(PersistCmd), p.Key})
+}
+
+func (Persist) CommandType() CommandType {
+	return PersistCmd
+}
+
+func (Persist) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toPersist(data []any) (Persist, error) {
+	if len(data) != 1 {
+		return Persist{}, wrongNumberOfArgs(PersistCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return Persist{}, fmt.Errorf("expected the key of the PERSIST command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+	return Persist{Key: key}, nil
+}
@@ -21,9 +21,13 @@ func (Get) CommandType() CommandType {
 	return GetCmd
 }
 
+func (Get) Flags() CommandFlags {
+	return 0
+}
+
 func toGet(data []any) (Get, error) {
 	if len(data) != 1 {
-		return Get{}, fmt.Errorf("expected 1 key entry to follow GET command but got %d entries: %v", len(data), data)
+		return Get{}, wrongNumberOfArgs(GetCmd)
 	}
 
 	key, ok := data[0].(string)
@@ -0,0 +1,119 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetDel returns the string stored at a key and deletes the key
+type GetDel struct {
+	Key string
+}
+
+func (g GetDel) String() string {
+	return fmt.Sprintf("GETDEL: %q", g.Key)
+}
+
+func (g GetDel) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(GetDelCmd), g.Key})
+}
+
+func (GetDel) CommandType() CommandType {
+	return GetDelCmd
+}
+
+func (GetDel) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toGetDel(data []any) (GetDel, error) {
+	if len(data) != 1 {
+		return GetDel{}, wrongNumberOfArgs(GetDelCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return GetDel{}, fmt.Errorf("expected the key of the GETDEL command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+
+	return GetDel{Key: key}, nil
+}
+
+// GetEx returns the string stored at Key and can change its expiry at the same time.
+// `GETEX key [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | PERSIST]`
+type GetEx struct {
+	Key string
+
+	// How the key's expiry should change. Without an option, the expiry is left alone
+	ExpiryOption ExpiryOption
+	ExpiryTime   int64
+}
+
+func (g GetEx) String() string {
+	return fmt.Sprintf("GETEX: %q with expiration %s %d", g.Key, g.ExpiryOption, g.ExpiryTime)
+}
+
+func (g GetEx) EncodedCommand() (string, error) {
+	cmdList := []any{string(GetExCmd), g.Key}
+	switch {
+	case g.ExpiryOption.HasTime():
+		cmdList = append(cmdList, string(g.ExpiryOption), strconv.FormatInt(g.ExpiryTime, 10))
+	case g.ExpiryOption == ExpiryPersist:
+		cmdList = append(cmdList, string(ExpiryPersist))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (GetEx) CommandType() CommandType {
+	return GetExCmd
+}
+
+func (GetEx) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// ExpiresAt works out the absolute time that the key should expire at. It should only be used if the expiry
+// option has a time
+func (g GetEx) ExpiresAt(now time.Time) (time.Time, error) {
+	return g.ExpiryOption.expiresAt(now, g.ExpiryTime, GetExCmd)
+}
+
+func toGetEx(data []any) (GetEx, error) {
+	if len(data) == 0 {
+		return GetEx{}, wrongNumberOfArgs(GetExCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return GetEx{}, fmt.Errorf("expected the inputs to the GETEX command to be strings: %w", err)
+	}
+	getEx := GetEx{Key: args[0]}
+
+	for i := 1; i < len(args); i++ {
+		option := ExpiryOption(strings.ToUpper(args[i]))
+		if getEx.ExpiryOption != NoExpiry && getEx.ExpiryOption != option {
+			return GetEx{}, ErrSyntax
+		}
+
+		switch {
+		case option == ExpiryPersist:
+			getEx.ExpiryOption = ExpiryPersist
+		case option.HasTime() && i+1 < len(args):
+			expiryTime, err := parseExpiryTime(GetExCmd, args[i+1])
+			if err != nil {
+				return GetEx{}, err
+			}
+			getEx.ExpiryOption, getEx.ExpiryTime = option, expiryTime
+			i++
+		default:
+			return GetEx{}, ErrSyntax
+		}
+	}
+
+	return getEx, nil
+}
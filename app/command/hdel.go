@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HDel removes fields from the hash stored at Key. The hash is deleted once it has no fields left.
+// `HDEL key field [field ...]`
+type HDel struct {
+	Key    string
+	Fields []string
+}
+
+func (hdel HDel) String() string {
+	return fmt.Sprintf("HDEL: %q %q", hdel.Key, strings.Join(hdel.Fields, " "))
+}
+
+func (hdel HDel) EncodedCommand() (string, error) {
+	cmdList := []any{string(HDelCmd), hdel.Key}
+	for _, field := range hdel.Fields {
+		cmdList = append(cmdList, field)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (HDel) CommandType() CommandType {
+	return HDelCmd
+}
+
+func (HDel) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toHDel(data []any) (HDel, error) {
+	if len(data) < 2 {
+		return HDel{}, wrongNumberOfArgs(HDelCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HDel{}, fmt.Errorf("expected the inputs to the HDEL command to be strings: %w", err)
+	}
+
+	return HDel{Key: args[0], Fields: args[1:]}, nil
+}
@@ -0,0 +1,83 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Hello switches the RESP version used for replies to a client and returns information about the server.
+// `HELLO [protover [AUTH username password] [SETNAME clientname]]` can also authenticate the client and name it
+type Hello struct {
+	// The requested RESP version, or 0 if the client didn't ask to change it
+	ProtocolVersion int
+
+	Username string
+	Password string
+
+	ClientName string
+}
+
+func (h Hello) String() string {
+	return fmt.Sprintf("HELLO: protocol version %d, username %q, client name %q", h.ProtocolVersion, h.Username, h.ClientName)
+}
+
+func (h Hello) EncodedCommand() (string, error) {
+	args := []any{string(HelloCmd)}
+	if h.ProtocolVersion != 0 {
+		args = append(args, strconv.Itoa(h.ProtocolVersion))
+		if h.HasAuth() {
+			args = append(args, "AUTH", h.Username, h.Password)
+		}
+		if h.ClientName != "" {
+			args = append(args, "SETNAME", h.ClientName)
+		}
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(args)
+}
+
+func (Hello) CommandType() CommandType {
+	return HelloCmd
+}
+
+func (Hello) Flags() CommandFlags {
+	return 0
+}
+
+// HasAuth is true if the client sent credentials with the HELLO command
+func (h Hello) HasAuth() bool {
+	return h.Username != ""
+}
+
+func toHello(data []any) (Hello, error) {
+	args, err := toStrings(data)
+	if err != nil {
+		return Hello{}, fmt.Errorf("expected the inputs to the HELLO command to be strings: %w", err)
+	}
+	if len(args) == 0 {
+		return Hello{}, nil
+	}
+
+	protocolVersion, err := strconv.Atoi(args[0])
+	if err != nil || protocolVersion <= 0 {
+		return Hello{}, ErrorReply("ERR Protocol version is not an integer or out of range")
+	}
+	hello := Hello{ProtocolVersion: protocolVersion}
+
+	for i := 1; i < len(args); i++ {
+		switch option := strings.ToLower(args[i]); {
+		case option == "auth" && i+2 < len(args):
+			hello.Username, hello.Password = args[i+1], args[i+2]
+			i += 2
+		case option == "setname" && i+1 < len(args):
+			hello.ClientName = args[i+1]
+			i++
+		default:
+			return Hello{}, ErrorReply(fmt.Sprintf("ERR Syntax error in HELLO option '%s'", args[i]))
+		}
+	}
+
+	return hello, nil
+}
@@ -0,0 +1,165 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The latest unix time in milliseconds that a hash field can expire at, which is the same limit that redis has
+const maxFieldExpiryMs = 1 << 48
+
+// HExpire sets when Fields of the hash stored at Key expire. It covers HEXPIRE and HPEXPIRE, which take a timeout
+// from now in seconds or milliseconds, and HEXPIREAT and HPEXPIREAT, which take a unix time in seconds or
+// milliseconds. Cmd is the one that was sent.
+// `HEXPIRE key seconds [NX | XX | GT | LT] FIELDS numfields field [field ...]`
+type HExpire struct {
+	Cmd     CommandType
+	Key     string
+	Time    int64
+	Options ExpireOptions
+	Fields  []string
+}
+
+func (h HExpire) String() string {
+	return fmt.Sprintf("%s: %q %q at %d, options %v", strings.ToUpper(string(h.Cmd)), h.Key, h.Fields, h.Time, h.Options.names())
+}
+
+func (h HExpire) EncodedCommand() (string, error) {
+	cmdList := []any{string(h.Cmd), h.Key, strconv.FormatInt(h.Time, 10)}
+	for _, name := range h.Options.names() {
+		cmdList = append(cmdList, name)
+	}
+	cmdList = append(cmdList, encodeFieldsArgument(h.Fields)...)
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (h HExpire) CommandType() CommandType {
+	return h.Cmd
+}
+
+func (HExpire) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// ExpiresAt works out the absolute time that the fields should expire at. Relative times are added to now. Like
+// in redis, the time can't be negative or later than 2^48 milliseconds after the unix epoch
+func (h HExpire) ExpiresAt(now time.Time) (time.Time, error) {
+	inSeconds := h.Cmd == HExpireCmd || h.Cmd == HExpireAtCmd
+	relative := h.Cmd == HExpireCmd || h.Cmd == HPExpireCmd
+
+	expiresAt, ok := toExpiryTime(now, h.Time, inSeconds, relative)
+	if h.Time < 0 || !ok || expiresAt.UnixMilli() > maxFieldExpiryMs {
+		return time.Time{}, ErrorReply("ERR invalid expire time, must be >= 0 and <= 2^48")
+	}
+	return expiresAt, nil
+}
+
+func toHExpire(cmdType CommandType, data []any) (HExpire, error) {
+	if len(data) < 5 {
+		return HExpire{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HExpire{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	expiryTime, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return HExpire{}, ErrNotInteger
+	}
+	hexpire := HExpire{Cmd: cmdType, Key: args[0], Time: expiryTime}
+
+	// Unlike EXPIRE, only a single condition can be given
+	fieldsStart := 2
+	switch strings.ToUpper(args[2]) {
+	case "NX":
+		hexpire.Options = ExpireNX
+	case "XX":
+		hexpire.Options = ExpireXX
+	case "GT":
+		hexpire.Options = ExpireGT
+	case "LT":
+		hexpire.Options = ExpireLT
+	}
+	if hexpire.Options != 0 {
+		fieldsStart++
+	}
+
+	hexpire.Fields, err = parseFieldsArgument(args[fieldsStart:])
+	if err != nil {
+		return HExpire{}, err
+	}
+	return hexpire, nil
+}
+
+// HPersist removes the expiry from Fields of the hash stored at Key so that they're kept until they're deleted.
+// `HPERSIST key FIELDS numfields field [field ...]`
+type HPersist struct {
+	Key    string
+	Fields []string
+}
+
+func (h HPersist) String() string {
+	return fmt.Sprintf("HPERSIST: %q %q", h.Key, h.Fields)
+}
+
+func (h HPersist) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(append([]any{string(HPersistCmd), h.Key}, encodeFieldsArgument(h.Fields)...))
+}
+
+func (HPersist) CommandType() CommandType {
+	return HPersistCmd
+}
+
+func (HPersist) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toHPersist(data []any) (HPersist, error) {
+	if len(data) < 4 {
+		return HPersist{}, wrongNumberOfArgs(HPersistCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HPersist{}, fmt.Errorf("expected the inputs to the HPERSIST command to be strings: %w", err)
+	}
+
+	fields, err := parseFieldsArgument(args[1:])
+	if err != nil {
+		return HPersist{}, err
+	}
+	return HPersist{Key: args[0], Fields: fields}, nil
+}
+
+// parseFieldsArgument parses the `FIELDS numfields field [field ...]` argument that the hash field expiry commands
+// end with
+func parseFieldsArgument(args []string) ([]string, error) {
+	if len(args) < 2 || strings.ToUpper(args[0]) != "FIELDS" {
+		return nil, ErrorReply("ERR Mandatory argument FIELDS is missing or not at the right position")
+	}
+
+	numFields, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil || numFields <= 0 {
+		return nil, ErrorReply("ERR Parameter `numFields` should be greater than 0")
+	}
+	if numFields != int64(len(args)-2) {
+		return nil, ErrorReply("ERR The `numfields` parameter must match the number of arguments")
+	}
+
+	return args[2:], nil
+}
+
+func encodeFieldsArgument(fields []string) []any {
+	encoded := []any{"FIELDS", strconv.Itoa(len(fields))}
+	for _, field := range fields {
+		encoded = append(encoded, field)
+	}
+	return encoded
+}
@@ -0,0 +1,123 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HGet returns the value stored at a field of the hash stored at Key.
+// `HGET key field`
+type HGet struct {
+	Key   string
+	Field string
+}
+
+func (hget HGet) String() string {
+	return fmt.Sprintf("HGET: %q %q", hget.Key, hget.Field)
+}
+
+func (hget HGet) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(HGetCmd), hget.Key, hget.Field})
+}
+
+func (HGet) CommandType() CommandType {
+	return HGetCmd
+}
+
+func (HGet) Flags() CommandFlags {
+	return 0
+}
+
+func toHGet(data []any) (HGet, error) {
+	if len(data) != 2 {
+		return HGet{}, wrongNumberOfArgs(HGetCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HGet{}, fmt.Errorf("expected the inputs to the HGET command to be strings: %w", err)
+	}
+
+	return HGet{Key: args[0], Field: args[1]}, nil
+}
+
+// HMGet returns the values stored at each of the fields of the hash stored at Key, with a null for any field that
+// doesn't exist.
+// `HMGET key field [field ...]`
+type HMGet struct {
+	Key    string
+	Fields []string
+}
+
+func (hmget HMGet) String() string {
+	return fmt.Sprintf("HMGET: %q %q", hmget.Key, strings.Join(hmget.Fields, " "))
+}
+
+func (hmget HMGet) EncodedCommand() (string, error) {
+	cmdList := []any{string(HMGetCmd), hmget.Key}
+	for _, field := range hmget.Fields {
+		cmdList = append(cmdList, field)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (HMGet) CommandType() CommandType {
+	return HMGetCmd
+}
+
+func (HMGet) Flags() CommandFlags {
+	return 0
+}
+
+func toHMGet(data []any) (HMGet, error) {
+	if len(data) < 2 {
+		return HMGet{}, wrongNumberOfArgs(HMGetCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HMGet{}, fmt.Errorf("expected the inputs to the HMGET command to be strings: %w", err)
+	}
+
+	return HMGet{Key: args[0], Fields: args[1:]}, nil
+}
+
+// HExists returns whether a field exists in the hash stored at Key.
+// `HEXISTS key field`
+type HExists struct {
+	Key   string
+	Field string
+}
+
+func (hexists HExists) String() string {
+	return fmt.Sprintf("HEXISTS: %q %q", hexists.Key, hexists.Field)
+}
+
+func (hexists HExists) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(HExistsCmd), hexists.Key, hexists.Field})
+}
+
+func (HExists) CommandType() CommandType {
+	return HExistsCmd
+}
+
+func (HExists) Flags() CommandFlags {
+	return 0
+}
+
+func toHExists(data []any) (HExists, error) {
+	if len(data) != 2 {
+		return HExists{}, wrongNumberOfArgs(HExistsCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HExists{}, fmt.Errorf("expected the inputs to the HEXISTS command to be strings: %w", err)
+	}
+
+	return HExists{Key: args[0], Field: args[1]}, nil
+}
@@ -0,0 +1,43 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HGetAll returns every field and value of the hash stored at Key. It covers HKEYS and HVALS, which only return the
+// fields or the values. Cmd is the one that was sent.
+// `HGETALL key`
+type HGetAll struct {
+	Cmd CommandType
+	Key string
+}
+
+func (hgetall HGetAll) String() string {
+	return fmt.Sprintf("%s: %q", strings.ToUpper(string(hgetall.Cmd)), hgetall.Key)
+}
+
+func (hgetall HGetAll) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(hgetall.Cmd), hgetall.Key})
+}
+
+func (hgetall HGetAll) CommandType() CommandType {
+	return hgetall.Cmd
+}
+
+func (HGetAll) Flags() CommandFlags {
+	return 0
+}
+
+func toHGetAll(cmdType CommandType, data []any) (HGetAll, error) {
+	if len(data) != 1 {
+		return HGetAll{}, wrongNumberOfArgs(cmdType)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return HGetAll{}, fmt.Errorf("expected the key of the %s command to be a string but it was %[2]v of type %[2]T", cmdType, data[0])
+	}
+	return HGetAll{Cmd: cmdType, Key: key}, nil
+}
@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// HIncrBy adds Increment to the integer stored at a field of the hash stored at Key.
+// `HINCRBY key field increment`
+type HIncrBy struct {
+	Key       string
+	Field     string
+	Increment int64
+}
+
+func (incr HIncrBy) String() string {
+	return fmt.Sprintf("HINCRBY: %q %q by %d", incr.Key, incr.Field, incr.Increment)
+}
+
+func (incr HIncrBy) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(HIncrByCmd), incr.Key, incr.Field, strconv.FormatInt(incr.Increment, 10)})
+}
+
+func (HIncrBy) CommandType() CommandType {
+	return HIncrByCmd
+}
+
+func (HIncrBy) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toHIncrBy(data []any) (HIncrBy, error) {
+	if len(data) != 3 {
+		return HIncrBy{}, wrongNumberOfArgs(HIncrByCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HIncrBy{}, fmt.Errorf("expected the inputs to the HINCRBY command to be strings: %w", err)
+	}
+
+	increment, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return HIncrBy{}, ErrNotInteger
+	}
+
+	return HIncrBy{Key: args[0], Field: args[1], Increment: increment}, nil
+}
+
+// HIncrByFloat adds Increment to the number stored at a field of the hash stored at Key. Like INCRBYFLOAT, it's
+// propagated to replicas as an HSET of the result.
+// `HINCRBYFLOAT key field increment`
+type HIncrByFloat struct {
+	Key       string
+	Field     string
+	Increment float64
+}
+
+func (incr HIncrByFloat) String() string {
+	return fmt.Sprintf("HINCRBYFLOAT: %q %q by %v", incr.Key, incr.Field, incr.Increment)
+}
+
+func (incr HIncrByFloat) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{
+		string(HIncrByFloatCmd), incr.Key, incr.Field, strconv.FormatFloat(incr.Increment, 'f', -1, 64),
+	})
+}
+
+func (HIncrByFloat) CommandType() CommandType {
+	return HIncrByFloatCmd
+}
+
+func (HIncrByFloat) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toHIncrByFloat(data []any) (HIncrByFloat, error) {
+	if len(data) != 3 {
+		return HIncrByFloat{}, wrongNumberOfArgs(HIncrByFloatCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HIncrByFloat{}, fmt.Errorf("expected the inputs to the HINCRBYFLOAT command to be strings: %w", err)
+	}
+
+	increment, ok := ParseFloat(args[2])
+	if !ok {
+		return HIncrByFloat{}, ErrNotFloat
+	}
+
+	return HIncrByFloat{Key: args[0], Field: args[1], Increment: increment}, nil
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+)
+
+// HLen returns the number of fields in the hash stored at Key
+type HLen struct {
+	Key string
+}
+
+func (h HLen) String() string {
+	return fmt.Sprintf("HLEN: %q", h.Key)
+}
+
+func (h HLen) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(HLenCmd), h.Key})
+}
+
+func (HLen) CommandType() CommandType {
+	return HLenCmd
+}
+
+func (HLen) Flags() CommandFlags {
+	return 0
+}
+
+func toHLen(data []any) (HLen, error) {
+	if len(data) != 1 {
+		return HLen{}, wrongNumberOfArgs(HLenCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return HLen{}, fmt.Errorf("expected the key of the HLEN command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+	return HLen{Key: key}, nil
+}
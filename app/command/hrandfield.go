@@ -0,0 +1,87 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The most elements that a negative count can ask for. Redis only requires that a count has a positive
+// counterpart, but a reply that repeats elements that many times would never finish being built, and would exhaust
+// the server's memory trying
+const maxRandomRepeats = 1 << 20
+
+// HRandField returns random fields of the hash stored at Key. Without a count, a single field is returned rather
+// than an array. A positive count returns up to that many distinct fields, while a negative count returns exactly
+// that many fields, which can repeat.
+// `HRANDFIELD key [count [WITHVALUES]]`
+type HRandField struct {
+	Key       string
+	Count     int64
+	WithCount bool
+
+	// Return the value of each field along with it
+	WithValues bool
+}
+
+func (h HRandField) String() string {
+	if !h.WithCount {
+		return fmt.Sprintf("HRANDFIELD: %q", h.Key)
+	}
+	return fmt.Sprintf("HRANDFIELD: %q count %d with values %t", h.Key, h.Count, h.WithValues)
+}
+
+func (h HRandField) EncodedCommand() (string, error) {
+	cmdList := []any{string(HRandFieldCmd), h.Key}
+	if h.WithCount {
+		cmdList = append(cmdList, strconv.FormatInt(h.Count, 10))
+	}
+	if h.WithValues {
+		cmdList = append(cmdList, "WITHVALUES")
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (HRandField) CommandType() CommandType {
+	return HRandFieldCmd
+}
+
+func (HRandField) Flags() CommandFlags {
+	return 0
+}
+
+func toHRandField(data []any) (HRandField, error) {
+	if len(data) < 1 || len(data) > 3 {
+		return HRandField{}, wrongNumberOfArgs(HRandFieldCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HRandField{}, fmt.Errorf("expected the inputs to the HRANDFIELD command to be strings: %w", err)
+	}
+	hrandfield := HRandField{Key: args[0], Count: 1}
+	if len(args) == 1 {
+		return hrandfield, nil
+	}
+
+	hrandfield.Count, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return HRandField{}, ErrNotInteger
+	}
+	hrandfield.WithCount = true
+
+	if len(args) == 3 {
+		if strings.ToUpper(args[2]) != "WITHVALUES" {
+			return HRandField{}, ErrSyntax
+		}
+		hrandfield.WithValues = true
+	}
+
+	if hrandfield.Count < -maxRandomRepeats {
+		return HRandField{}, ErrorReply("ERR value is out of range")
+	}
+
+	return hrandfield, nil
+}
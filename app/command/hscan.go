@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The number of elements that a SCAN-like command looks at when it isn't given a count
+const defaultScanCount = 10
+
+// HScan iterates over the fields of the hash stored at Key. Each call returns some of the fields along with the
+// cursor to continue from, and the iteration is complete once the returned cursor is 0.
+// `HSCAN key cursor [MATCH pattern] [COUNT count] [NOVALUES]`
+type HScan struct {
+	Key    string
+	Cursor uint64
+
+	// Only return the fields that match this glob-style pattern. "*" matches every field
+	Match string
+
+	// A hint for how many fields to look at
+	Count int64
+
+	// Only return the fields, without their values
+	NoValues bool
+}
+
+func (h HScan) String() string {
+	return fmt.Sprintf("HSCAN: %q cursor %d match %q count %d no values %t", h.Key, h.Cursor, h.Match, h.Count, h.NoValues)
+}
+
+func (h HScan) EncodedCommand() (string, error) {
+	cmdList := []any{string(HScanCmd), h.Key, strconv.FormatUint(h.Cursor, 10)}
+	cmdList = append(cmdList, encodeScanOptions(h.Match, h.Count)...)
+	if h.NoValues {
+		cmdList = append(cmdList, "NOVALUES")
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (HScan) CommandType() CommandType {
+	return HScanCmd
+}
+
+func (HScan) Flags() CommandFlags {
+	return 0
+}
+
+func toHScan(data []any) (HScan, error) {
+	if len(data) < 2 {
+		return HScan{}, wrongNumberOfArgs(HScanCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HScan{}, fmt.Errorf("expected the inputs to the HSCAN command to be strings: %w", err)
+	}
+
+	cursor, err := parseScanCursor(args[1])
+	if err != nil {
+		return HScan{}, err
+	}
+	hscan := HScan{Key: args[0], Cursor: cursor, Match: "*", Count: defaultScanCount}
+
+	for i := 2; i < len(args); i++ {
+		if strings.ToUpper(args[i]) == "NOVALUES" {
+			hscan.NoValues = true
+			continue
+		}
+		i, err = parseScanOption(args, i, &hscan.Match, &hscan.Count)
+		if err != nil {
+			return HScan{}, err
+		}
+	}
+
+	return hscan, nil
+}
+
+func parseScanCursor(rawCursor string) (uint64, error) {
+	cursor, err := strconv.ParseUint(rawCursor, 10, 64)
+	if err != nil {
+		return 0, ErrorReply("ERR invalid cursor")
+	}
+	return cursor, nil
+}
+
+// parseScanOption parses the MATCH or COUNT option of a SCAN-like command that starts at args[i], and returns the
+// index of the option's last argument
+func parseScanOption(args []string, i int, match *string, count *int64) (int, error) {
+	option := strings.ToUpper(args[i])
+	if (option != "MATCH" && option != "COUNT") || i+1 == len(args) {
+		return 0, ErrSyntax
+	}
+
+	if option == "MATCH" {
+		*match = args[i+1]
+		return i + 1, nil
+	}
+
+	value, err := strconv.ParseInt(args[i+1], 10, 64)
+	if err != nil {
+		return 0, ErrNotInteger
+	}
+	if value < 1 {
+		return 0, ErrSyntax
+	}
+	*count = value
+	return i + 1, nil
+}
+
+// encodeScanOptions returns the MATCH and COUNT options of a SCAN-like command, leaving out the ones that have
+// their default values
+func encodeScanOptions(match string, count int64) []any {
+	var options []any
+	if match != "*" {
+		options = append(options, "MATCH", match)
+	}
+	if count != defaultScanCount {
+		options = append(options, "COUNT", strconv.FormatInt(count, 10))
+	}
+	return options
+}
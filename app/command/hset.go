@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+)
+
+// FieldValue is a value stored in a hash, along with its field
+type FieldValue struct {
+	Field string
+	Value []byte
+}
+
+// HSet stores each of the values at its field of the hash stored at Key, and creates the hash if it doesn't exist.
+// When a field is provided more than once, the last value wins.
+// `HSET key field value [field value ...]`
+type HSet struct {
+	Key   string
+	Pairs []FieldValue
+}
+
+func (hset HSet) String() string {
+	return fmt.Sprintf("HSET: %q %s", hset.Key, formatFieldValues(hset.Pairs))
+}
+
+func (hset HSet) EncodedCommand() (string, error) {
+	cmdList := []any{string(HSetCmd), hset.Key}
+	for _, pair := range hset.Pairs {
+		cmdList = append(cmdList, pair.Field, pair.Value)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (HSet) CommandType() CommandType {
+	return HSetCmd
+}
+
+func (HSet) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toHSet(data []any) (HSet, error) {
+	if len(data) < 3 || len(data)%2 != 1 {
+		return HSet{}, wrongNumberOfArgs(HSetCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HSet{}, fmt.Errorf("expected the inputs to the HSET command to be strings: %w", err)
+	}
+
+	pairs := make([]FieldValue, 0, len(args)/2)
+	for i := 1; i < len(args); i += 2 {
+		pairs = append(pairs, FieldValue{Field: args[i], Value: []byte(args[i+1])})
+	}
+	return HSet{Key: args[0], Pairs: pairs}, nil
+}
+
+// HSetNX stores a value at a field of the hash stored at Key if the field doesn't exist.
+// `HSETNX key field value`
+type HSetNX struct {
+	Key   string
+	Field string
+	Value []byte
+}
+
+func (hset HSetNX) String() string {
+	return fmt.Sprintf("HSETNX: %q (%q -> %q)", hset.Key, hset.Field, hset.Value)
+}
+
+func (hset HSetNX) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(HSetNXCmd), hset.Key, hset.Field, hset.Value})
+}
+
+func (HSetNX) CommandType() CommandType {
+	return HSetNXCmd
+}
+
+func (HSetNX) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toHSetNX(data []any) (HSetNX, error) {
+	if len(data) != 3 {
+		return HSetNX{}, wrongNumberOfArgs(HSetNXCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HSetNX{}, fmt.Errorf("expected the inputs to the HSETNX command to be strings: %w", err)
+	}
+
+	return HSetNX{Key: args[0], Field: args[1], Value: []byte(args[2])}, nil
+}
+
+func formatFieldValues(pairs []FieldValue) string {
+	formatted := ""
+	for i, pair := range pairs {
+		if i > 0 {
+			formatted += ", "
+		}
+		formatted += fmt.Sprintf("(%q -> %q)", pair.Field, pair.Value)
+	}
+	return formatted
+}
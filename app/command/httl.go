@@ -0,0 +1,50 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HTTL returns when Fields of the hash stored at Key expire. It covers HTTL and HPTTL, which return the time left
+// in seconds or milliseconds, and HEXPIRETIME and HPEXPIRETIME, which return the unix time in seconds or
+// milliseconds. Cmd is the one that was sent.
+// `HTTL key FIELDS numfields field [field ...]`
+type HTTL struct {
+	Cmd    CommandType
+	Key    string
+	Fields []string
+}
+
+func (h HTTL) String() string {
+	return fmt.Sprintf("%s: %q %q", strings.ToUpper(string(h.Cmd)), h.Key, h.Fields)
+}
+
+func (h HTTL) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(append([]any{string(h.Cmd), h.Key}, encodeFieldsArgument(h.Fields)...))
+}
+
+func (h HTTL) CommandType() CommandType {
+	return h.Cmd
+}
+
+func (HTTL) Flags() CommandFlags {
+	return 0
+}
+
+func toHTTL(cmdType CommandType, data []any) (HTTL, error) {
+	if len(data) < 4 {
+		return HTTL{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return HTTL{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	fields, err := parseFieldsArgument(args[1:])
+	if err != nil {
+		return HTTL{}, err
+	}
+	return HTTL{Cmd: cmdType, Key: args[0], Fields: fields}, nil
+}
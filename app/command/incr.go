@@ -0,0 +1,132 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// IncrBy adds Increment to the integer stored at Key. It covers INCR and DECR, which add 1 and -1, and INCRBY and
+// DECRBY, which take the amount to add or subtract. Cmd is the one that was sent
+type IncrBy struct {
+	Cmd       CommandType
+	Key       string
+	Increment int64
+}
+
+func (incr IncrBy) String() string {
+	return fmt.Sprintf("%s: %q by %d", strings.ToUpper(string(incr.Cmd)), incr.Key, incr.Increment)
+}
+
+func (incr IncrBy) EncodedCommand() (string, error) {
+	cmdList := []any{string(incr.Cmd), incr.Key}
+	switch incr.Cmd {
+	case IncrByCmd:
+		cmdList = append(cmdList, strconv.FormatInt(incr.Increment, 10))
+	case DecrByCmd:
+		cmdList = append(cmdList, strconv.FormatInt(-incr.Increment, 10))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (incr IncrBy) CommandType() CommandType {
+	return incr.Cmd
+}
+
+func (IncrBy) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toIncrBy(cmdType CommandType, data []any) (IncrBy, error) {
+	numArgs := 1
+	if cmdType == IncrByCmd || cmdType == DecrByCmd {
+		numArgs = 2
+	}
+	if len(data) != numArgs {
+		return IncrBy{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return IncrBy{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+	incr := IncrBy{Cmd: cmdType, Key: args[0], Increment: 1}
+
+	switch cmdType {
+	case DecrCmd:
+		incr.Increment = -1
+	case IncrByCmd, DecrByCmd:
+		incr.Increment, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return IncrBy{}, ErrNotInteger
+		}
+		if cmdType == DecrByCmd {
+			// The smallest int64 has no positive counterpart
+			if incr.Increment == math.MinInt64 {
+				return IncrBy{}, ErrorReply("ERR decrement would overflow")
+			}
+			incr.Increment = -incr.Increment
+		}
+	}
+
+	return incr, nil
+}
+
+// IncrByFloat adds Increment to the number stored at Key. Since the result depends on floating point rounding,
+// it's propagated to replicas as a SET of the result
+type IncrByFloat struct {
+	Key       string
+	Increment float64
+}
+
+func (incr IncrByFloat) String() string {
+	return fmt.Sprintf("INCRBYFLOAT: %q by %v", incr.Key, incr.Increment)
+}
+
+func (incr IncrByFloat) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(IncrByFloatCmd), incr.Key, strconv.FormatFloat(incr.Increment, 'f', -1, 64)})
+}
+
+func (IncrByFloat) CommandType() CommandType {
+	return IncrByFloatCmd
+}
+
+func (IncrByFloat) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toIncrByFloat(data []any) (IncrByFloat, error) {
+	if len(data) != 2 {
+		return IncrByFloat{}, wrongNumberOfArgs(IncrByFloatCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return IncrByFloat{}, fmt.Errorf("expected the inputs to the INCRBYFLOAT command to be strings: %w", err)
+	}
+
+	increment, ok := ParseFloat(args[1])
+	if !ok {
+		return IncrByFloat{}, ErrNotFloat
+	}
+
+	return IncrByFloat{Key: args[0], Increment: increment}, nil
+}
+
+// ParseFloat parses a float the way that redis does. Unlike strconv.ParseFloat, NaN, hex floats and underscores
+// aren't allowed
+func ParseFloat(s string) (float64, bool) {
+	if strings.ContainsAny(s, "_xXpP") {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(value) {
+		return 0, false
+	}
+	return value, true
+}
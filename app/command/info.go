@@ -21,18 +21,22 @@ func (Info) CommandType() CommandType {
 	return InfoCmd
 }
 
+func (Info) Flags() CommandFlags {
+	return 0
+}
+
 func toInfo(data []any) (Info, error) {
 	if len(data) != 1 {
-		return Info{}, fmt.Errorf("expected only one data element for INFO command, but found %d: %v", len(data), data)
+		return Info{}, wrongNumberOfArgs(InfoCmd)
 	}
 	res, ok := data[0].(string)
 	if !ok {
 		return Info{}, fmt.Errorf("expected the input to the echo command to be a string but it was %[1]v of type %[1]v", data[0])
 	}
 
-	// At this point, 'replication' is the only valid value
-	if res != "replication" {
-		return Info{}, fmt.Errorf("expected the input for the INFO command to be 'replication' but it was %q", res)
+	// At this point, 'replication' and 'persistence' are the only valid values
+	if res != "replication" && res != "persistence" {
+		return Info{}, ErrorReply(fmt.Sprintf("ERR unsupported INFO section '%s', expected 'replication' or 'persistence'", res))
 	}
 
 	return Info{Payload: res}, nil
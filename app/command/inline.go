@@ -0,0 +1,119 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// splitInlineArgs splits an inline command into its arguments the same way that redis does. Arguments are separated
+// by whitespace and can be quoted. Double quoted arguments support the escape sequences \n, \r, \t, \b, \a, \xHH
+// and a backslash followed by any other character, while single quoted arguments only support \'
+func splitInlineArgs(line []byte) ([]any, error) {
+	args := []any{}
+
+	i := 0
+	for {
+		for i < len(line) && isInlineSpace(line[i]) {
+			i++
+		}
+		if i == len(line) {
+			return args, nil
+		}
+
+		var arg []byte
+		inDoubleQuotes, inSingleQuotes := false, false
+		for done := false; !done; {
+			switch {
+			case inDoubleQuotes:
+				if i == len(line) {
+					return nil, errUnbalancedQuotes
+				}
+
+				switch {
+				case line[i] == '\\' && i+3 < len(line) && line[i+1] == 'x' && isHexDigit(line[i+2]) && isHexDigit(line[i+3]):
+					value, _ := strconv.ParseUint(string(line[i+2:i+4]), 16, 8)
+					arg = append(arg, byte(value))
+					i += 3
+				case line[i] == '\\' && i+1 < len(line):
+					i++
+					arg = append(arg, unescapeInlineChar(line[i]))
+				case line[i] == '"':
+					// The closing quote must be followed by a space or by nothing
+					if i+1 < len(line) && !isInlineSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg = append(arg, line[i])
+				}
+			case inSingleQuotes:
+				if i == len(line) {
+					return nil, errUnbalancedQuotes
+				}
+
+				switch {
+				case line[i] == '\\' && i+1 < len(line) && line[i+1] == '\'':
+					i++
+					arg = append(arg, '\'')
+				case line[i] == '\'':
+					if i+1 < len(line) && !isInlineSpace(line[i+1]) {
+						return nil, errUnbalancedQuotes
+					}
+					done = true
+				default:
+					arg = append(arg, line[i])
+				}
+			default:
+				if i == len(line) || isInlineSpace(line[i]) {
+					done = true
+					continue
+				}
+
+				switch line[i] {
+				case '"':
+					inDoubleQuotes = true
+				case '\'':
+					inSingleQuotes = true
+				default:
+					arg = append(arg, line[i])
+				}
+			}
+
+			if i < len(line) {
+				i++
+			}
+		}
+
+		args = append(args, string(arg))
+	}
+}
+
+var errUnbalancedQuotes = fmt.Errorf("%w: unbalanced quotes in request", ErrProtocol)
+
+func isInlineSpace(char byte) bool {
+	switch char {
+	case ' ', '\n', '\r', '\t', '\v', '\f':
+		return true
+	}
+	return false
+}
+
+func isHexDigit(char byte) bool {
+	return ('0' <= char && char <= '9') || ('a' <= char && char <= 'f') || ('A' <= char && char <= 'F')
+}
+
+func unescapeInlineChar(char byte) byte {
+	switch char {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	case 'b':
+		return '\b'
+	case 'a':
+		return '\a'
+	}
+	return char
+}
@@ -0,0 +1,91 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LIndex returns the element at Index in the list stored at Key. A negative index counts back from the end of the
+// list
+type LIndex struct {
+	Key   string
+	Index int64
+}
+
+func (l LIndex) String() string {
+	return fmt.Sprintf("LINDEX: %q at %d", l.Key, l.Index)
+}
+
+func (l LIndex) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LIndexCmd), l.Key, strconv.FormatInt(l.Index, 10)})
+}
+
+func (LIndex) CommandType() CommandType {
+	return LIndexCmd
+}
+
+func (LIndex) Flags() CommandFlags {
+	return 0
+}
+
+func toLIndex(data []any) (LIndex, error) {
+	if len(data) != 2 {
+		return LIndex{}, wrongNumberOfArgs(LIndexCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LIndex{}, fmt.Errorf("expected the inputs to the LINDEX command to be strings: %w", err)
+	}
+
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return LIndex{}, ErrNotInteger
+	}
+
+	return LIndex{Key: args[0], Index: index}, nil
+}
+
+// LSet replaces the element at Index in the list stored at Key with Value. A negative index counts back from the
+// end of the list
+type LSet struct {
+	Key   string
+	Index int64
+	Value []byte
+}
+
+func (l LSet) String() string {
+	return fmt.Sprintf("LSET: %q at %d to %q", l.Key, l.Index, l.Value)
+}
+
+func (l LSet) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LSetCmd), l.Key, strconv.FormatInt(l.Index, 10), l.Value})
+}
+
+func (LSet) CommandType() CommandType {
+	return LSetCmd
+}
+
+func (LSet) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toLSet(data []any) (LSet, error) {
+	if len(data) != 3 {
+		return LSet{}, wrongNumberOfArgs(LSetCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LSet{}, fmt.Errorf("expected the inputs to the LSET command to be strings: %w", err)
+	}
+
+	index, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return LSet{}, ErrNotInteger
+	}
+
+	return LSet{Key: args[0], Index: index, Value: []byte(args[2])}, nil
+}
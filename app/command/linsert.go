@@ -0,0 +1,61 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LInsert adds Value to the list stored at Key, right before or after the first element that is equal to Pivot.
+// `LINSERT key <BEFORE | AFTER> pivot element`
+type LInsert struct {
+	Key    string
+	Before bool
+	Pivot  []byte
+	Value  []byte
+}
+
+func (l LInsert) position() string {
+	if l.Before {
+		return "BEFORE"
+	}
+	return "AFTER"
+}
+
+func (l LInsert) String() string {
+	return fmt.Sprintf("LINSERT: %q %q %s %q", l.Key, l.Value, strings.ToLower(l.position()), l.Pivot)
+}
+
+func (l LInsert) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LInsertCmd), l.Key, l.position(), l.Pivot, l.Value})
+}
+
+func (LInsert) CommandType() CommandType {
+	return LInsertCmd
+}
+
+func (LInsert) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toLInsert(data []any) (LInsert, error) {
+	if len(data) != 4 {
+		return LInsert{}, wrongNumberOfArgs(LInsertCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LInsert{}, fmt.Errorf("expected the inputs to the LINSERT command to be strings: %w", err)
+	}
+
+	var before bool
+	switch strings.ToUpper(args[1]) {
+	case "BEFORE":
+		before = true
+	case "AFTER":
+	default:
+		return LInsert{}, ErrSyntax
+	}
+
+	return LInsert{Key: args[0], Before: before, Pivot: []byte(args[2]), Value: []byte(args[3])}, nil
+}
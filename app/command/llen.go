@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+)
+
+// LLen returns the length of the list stored at Key
+type LLen struct {
+	Key string
+}
+
+func (l LLen) String() string {
+	return fmt.Sprintf("LLEN: %q", l.Key)
+}
+
+func (l LLen) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LLenCmd), l.Key})
+}
+
+func (LLen) CommandType() CommandType {
+	return LLenCmd
+}
+
+func (LLen) Flags() CommandFlags {
+	return 0
+}
+
+func toLLen(data []any) (LLen, error) {
+	if len(data) != 1 {
+		return LLen{}, wrongNumberOfArgs(LLenCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return LLen{}, fmt.Errorf("expected the key of the LLEN command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+	return LLen{Key: key}, nil
+}
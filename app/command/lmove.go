@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ListEnd is one of the two ends of a list
+type ListEnd string
+
+const (
+	// ListLeft is the start of a list, where LPUSH pushes to
+	ListLeft ListEnd = "LEFT"
+	// ListRight is the end of a list, where RPUSH pushes to
+	ListRight ListEnd = "RIGHT"
+)
+
+// toListEnd parses a LEFT or RIGHT argument
+func toListEnd(arg string) (ListEnd, error) {
+	switch end := ListEnd(strings.ToUpper(arg)); end {
+	case ListLeft, ListRight:
+		return end, nil
+	}
+	return "", ErrSyntax
+}
+
+// LMove pops an element from the From end of the list stored at Source and pushes it to the To end of the list
+// stored at Destination. Source and Destination can be the same list, which rotates it.
+// `LMOVE source destination <LEFT | RIGHT> <LEFT | RIGHT>`
+type LMove struct {
+	Source      string
+	Destination string
+	From        ListEnd
+	To          ListEnd
+}
+
+func (l LMove) String() string {
+	return fmt.Sprintf("LMOVE: %q %s -> %q %s", l.Source, l.From, l.Destination, l.To)
+}
+
+func (l LMove) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LMoveCmd), l.Source, l.Destination, string(l.From), string(l.To)})
+}
+
+func (LMove) CommandType() CommandType {
+	return LMoveCmd
+}
+
+func (LMove) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toLMove(data []any) (LMove, error) {
+	if len(data) != 4 {
+		return LMove{}, wrongNumberOfArgs(LMoveCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LMove{}, fmt.Errorf("expected the inputs to the LMOVE command to be strings: %w", err)
+	}
+
+	from, err := toListEnd(args[2])
+	if err != nil {
+		return LMove{}, err
+	}
+	to, err := toListEnd(args[3])
+	if err != nil {
+		return LMove{}, err
+	}
+
+	return LMove{Source: args[0], Destination: args[1], From: from, To: to}, nil
+}
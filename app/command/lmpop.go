@@ -0,0 +1,110 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LMPop pops up to Count elements from one end of the first of the lists stored at Keys that isn't empty. It covers
+// LMPOP, which replies with a null if none of the keys hold a list, and BLMPOP, which blocks the client until one of
+// them is pushed to or the timeout passes. Cmd is the one that was sent.
+// `LMPOP numkeys key [key ...] <LEFT | RIGHT> [COUNT count]`
+// `BLMPOP timeout numkeys key [key ...] <LEFT | RIGHT> [COUNT count]`
+type LMPop struct {
+	Cmd   CommandType
+	Keys  []string
+	End   ListEnd
+	Count int64
+
+	// How long BLMPOP blocks for in milliseconds. A timeout of 0 blocks forever
+	TimeoutMs int64
+}
+
+func (p LMPop) String() string {
+	if p.Cmd == BLMPopCmd {
+		return fmt.Sprintf("BLMPOP: %q %s count %d timeout %dms", p.Keys, p.End, p.Count, p.TimeoutMs)
+	}
+	return fmt.Sprintf("LMPOP: %q %s count %d", p.Keys, p.End, p.Count)
+}
+
+func (p LMPop) EncodedCommand() (string, error) {
+	cmdList := []any{string(p.Cmd)}
+	if p.Cmd == BLMPopCmd {
+		cmdList = append(cmdList, formatTimeout(p.TimeoutMs))
+	}
+	cmdList = append(cmdList, strconv.Itoa(len(p.Keys)))
+	for _, key := range p.Keys {
+		cmdList = append(cmdList, key)
+	}
+	cmdList = append(cmdList, string(p.End), "COUNT", strconv.FormatInt(p.Count, 10))
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (p LMPop) CommandType() CommandType {
+	return p.Cmd
+}
+
+func (LMPop) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toLMPop(cmdType CommandType, data []any) (LMPop, error) {
+	minArgs := 3
+	if cmdType == BLMPopCmd {
+		minArgs = 4
+	}
+	if len(data) < minArgs {
+		return LMPop{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LMPop{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	// BLMPOP starts with its timeout, but like in redis, it's parsed after the rest of the command
+	rawTimeout := ""
+	if cmdType == BLMPopCmd {
+		rawTimeout, args = args[0], args[1:]
+	}
+
+	numKeys, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || numKeys <= 0 {
+		return LMPop{}, ErrorReply("ERR numkeys should be greater than 0")
+	}
+	if numKeys >= int64(len(args)-1) {
+		return LMPop{}, ErrSyntax
+	}
+
+	lmpop := LMPop{Cmd: cmdType, Keys: args[1 : numKeys+1], Count: 1}
+	lmpop.End, err = toListEnd(args[numKeys+1])
+	if err != nil {
+		return LMPop{}, err
+	}
+
+	withCount := false
+	for i := numKeys + 2; i < int64(len(args)); i++ {
+		if withCount || strings.ToUpper(args[i]) != "COUNT" || i+1 == int64(len(args)) {
+			return LMPop{}, ErrSyntax
+		}
+
+		lmpop.Count, err = strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil || lmpop.Count <= 0 {
+			return LMPop{}, ErrorReply("ERR count should be greater than 0")
+		}
+		withCount = true
+		i++
+	}
+
+	if cmdType == BLMPopCmd {
+		lmpop.TimeoutMs, err = parseTimeout(rawTimeout)
+		if err != nil {
+			return LMPop{}, err
+		}
+	}
+
+	return lmpop, nil
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// LPos returns the index of elements that are equal to Value in the list stored at Key.
+// `LPOS key element [RANK rank] [COUNT num-matches] [MAXLEN len]`
+type LPos struct {
+	Key   string
+	Value []byte
+
+	// Rank is which match to start from. A negative rank searches from the end of the list, so -1 is the last
+	// match. It's 1 if it isn't provided
+	Rank int64
+
+	// Count is how many matches to return, or 0 for all of them. Without a count, only the first match is returned
+	// and the reply is its index rather than an array
+	Count     int64
+	WithCount bool
+
+	// MaxLen is how many elements to compare at most, or 0 to compare every element
+	MaxLen int64
+}
+
+func (l LPos) String() string {
+	return fmt.Sprintf("LPOS: %q of %q, rank %d, count %d, maxlen %d", l.Key, l.Value, l.Rank, l.Count, l.MaxLen)
+}
+
+func (l LPos) EncodedCommand() (string, error) {
+	cmdList := []any{string(LPosCmd), l.Key, l.Value}
+	if l.Rank != 1 {
+		cmdList = append(cmdList, "RANK", strconv.FormatInt(l.Rank, 10))
+	}
+	if l.WithCount {
+		cmdList = append(cmdList, "COUNT", strconv.FormatInt(l.Count, 10))
+	}
+	if l.MaxLen != 0 {
+		cmdList = append(cmdList, "MAXLEN", strconv.FormatInt(l.MaxLen, 10))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (LPos) CommandType() CommandType {
+	return LPosCmd
+}
+
+func (LPos) Flags() CommandFlags {
+	return 0
+}
+
+func toLPos(data []any) (LPos, error) {
+	if len(data) < 2 {
+		return LPos{}, wrongNumberOfArgs(LPosCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LPos{}, fmt.Errorf("expected the inputs to the LPOS command to be strings: %w", err)
+	}
+	lpos := LPos{Key: args[0], Value: []byte(args[1]), Rank: 1}
+
+	for i := 2; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return LPos{}, ErrSyntax
+		}
+
+		option := strings.ToUpper(args[i])
+		if option != "RANK" && option != "COUNT" && option != "MAXLEN" {
+			return LPos{}, ErrSyntax
+		}
+
+		optionValue, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			return LPos{}, ErrNotInteger
+		}
+
+		switch option {
+		case "RANK":
+			switch optionValue {
+			case 0:
+				return LPos{}, ErrorReply("ERR RANK can't be zero: use 1 to start from the first match, 2 from the second ... or use negative to start from the end of the list")
+			case math.MinInt64:
+				// The rank is negated to search from the end, which the smallest int64 can't be
+				return LPos{}, ErrorReply("ERR value is out of range")
+			}
+			lpos.Rank = optionValue
+		case "COUNT":
+			if optionValue < 0 {
+				return LPos{}, ErrorReply("ERR COUNT can't be negative")
+			}
+			lpos.Count, lpos.WithCount = optionValue, true
+		case "MAXLEN":
+			if optionValue < 0 {
+				return LPos{}, ErrorReply("ERR MAXLEN can't be negative")
+			}
+			lpos.MaxLen = optionValue
+		}
+	}
+
+	return lpos, nil
+}
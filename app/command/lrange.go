@@ -0,0 +1,95 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LRange returns the elements of the list stored at Key from Start to End, inclusive. Negative offsets count back
+// from the end of the list
+type LRange struct {
+	Key   string
+	Start int64
+	End   int64
+}
+
+func (l LRange) String() string {
+	return fmt.Sprintf("LRANGE: %q from %d to %d", l.Key, l.Start, l.End)
+}
+
+func (l LRange) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LRangeCmd), l.Key, strconv.FormatInt(l.Start, 10), strconv.FormatInt(l.End, 10)})
+}
+
+func (LRange) CommandType() CommandType {
+	return LRangeCmd
+}
+
+func (LRange) Flags() CommandFlags {
+	return 0
+}
+
+func toLRange(data []any) (LRange, error) {
+	key, start, end, err := toListRange(LRangeCmd, data)
+	if err != nil {
+		return LRange{}, err
+	}
+	return LRange{Key: key, Start: start, End: end}, nil
+}
+
+// LTrim removes every element of the list stored at Key that isn't between Start and End, inclusive. Negative
+// offsets count back from the end of the list
+type LTrim struct {
+	Key   string
+	Start int64
+	End   int64
+}
+
+func (l LTrim) String() string {
+	return fmt.Sprintf("LTRIM: %q from %d to %d", l.Key, l.Start, l.End)
+}
+
+func (l LTrim) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LTrimCmd), l.Key, strconv.FormatInt(l.Start, 10), strconv.FormatInt(l.End, 10)})
+}
+
+func (LTrim) CommandType() CommandType {
+	return LTrimCmd
+}
+
+func (LTrim) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toLTrim(data []any) (LTrim, error) {
+	key, start, end, err := toListRange(LTrimCmd, data)
+	if err != nil {
+		return LTrim{}, err
+	}
+	return LTrim{Key: key, Start: start, End: end}, nil
+}
+
+// toListRange parses the `key start stop` arguments of LRANGE and LTRIM
+func toListRange(cmdType CommandType, data []any) (string, int64, int64, error) {
+	if len(data) != 3 {
+		return "", 0, 0, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return "", 0, 0, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return "", 0, 0, ErrNotInteger
+	}
+	end, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return "", 0, 0, ErrNotInteger
+	}
+
+	return args[0], start, end, nil
+}
@@ -0,0 +1,50 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// LRem removes elements that are equal to Value from the list stored at Key. A positive Count removes at most that
+// many starting from the start of the list, a negative Count removes at most that many starting from the end, and
+// a Count of 0 removes all of them
+type LRem struct {
+	Key   string
+	Count int64
+	Value []byte
+}
+
+func (l LRem) String() string {
+	return fmt.Sprintf("LREM: %q count %d of %q", l.Key, l.Count, l.Value)
+}
+
+func (l LRem) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LRemCmd), l.Key, strconv.FormatInt(l.Count, 10), l.Value})
+}
+
+func (LRem) CommandType() CommandType {
+	return LRemCmd
+}
+
+func (LRem) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toLRem(data []any) (LRem, error) {
+	if len(data) != 3 {
+		return LRem{}, wrongNumberOfArgs(LRemCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return LRem{}, fmt.Errorf("expected the inputs to the LREM command to be strings: %w", err)
+	}
+
+	count, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return LRem{}, ErrNotInteger
+	}
+
+	return LRem{Key: args[0], Count: count, Value: []byte(args[2])}, nil
+}
@@ -0,0 +1,46 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MGet returns the strings stored at each of the keys, with a null for any key that doesn't hold a string
+type MGet struct {
+	Keys []string
+}
+
+func (mget MGet) String() string {
+	return fmt.Sprintf("MGET: %q", strings.Join(mget.Keys, " "))
+}
+
+func (mget MGet) EncodedCommand() (string, error) {
+	cmdList := []any{string(MGetCmd)}
+	for _, key := range mget.Keys {
+		cmdList = append(cmdList, key)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (MGet) CommandType() CommandType {
+	return MGetCmd
+}
+
+func (MGet) Flags() CommandFlags {
+	return 0
+}
+
+func toMGet(data []any) (MGet, error) {
+	if len(data) == 0 {
+		return MGet{}, wrongNumberOfArgs(MGetCmd)
+	}
+
+	keys, err := toStrings(data)
+	if err != nil {
+		return MGet{}, fmt.Errorf("expected the keys of the MGET command to be strings: %w", err)
+	}
+
+	return MGet{Keys: keys}, nil
+}
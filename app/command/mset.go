@@ -0,0 +1,108 @@
+package command
+
+import (
+	"fmt"
+)
+
+// KeyValue is a string value along with the key that it should be stored at
+type KeyValue struct {
+	Key   string
+	Value []byte
+}
+
+// MSet stores each of the values at its key, as if each of them was a SET without options. When a key is provided
+// more than once, the last value wins
+type MSet struct {
+	Pairs []KeyValue
+}
+
+func (mset MSet) String() string {
+	return fmt.Sprintf("MSET: %s", formatPairs(mset.Pairs))
+}
+
+func (mset MSet) EncodedCommand() (string, error) {
+	return encodePairs(MSetCmd, mset.Pairs)
+}
+
+func (MSet) CommandType() CommandType {
+	return MSetCmd
+}
+
+func (MSet) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toMSet(data []any) (MSet, error) {
+	pairs, err := toKeyValues(MSetCmd, data)
+	if err != nil {
+		return MSet{}, err
+	}
+	return MSet{Pairs: pairs}, nil
+}
+
+// MSetNX stores each of the values at its key like MSET, but only if none of the keys exist
+type MSetNX struct {
+	Pairs []KeyValue
+}
+
+func (mset MSetNX) String() string {
+	return fmt.Sprintf("MSETNX: %s", formatPairs(mset.Pairs))
+}
+
+func (mset MSetNX) EncodedCommand() (string, error) {
+	return encodePairs(MSetNXCmd, mset.Pairs)
+}
+
+func (MSetNX) CommandType() CommandType {
+	return MSetNXCmd
+}
+
+func (MSetNX) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toMSetNX(data []any) (MSetNX, error) {
+	pairs, err := toKeyValues(MSetNXCmd, data)
+	if err != nil {
+		return MSetNX{}, err
+	}
+	return MSetNX{Pairs: pairs}, nil
+}
+
+func toKeyValues(cmdType CommandType, data []any) ([]KeyValue, error) {
+	if len(data) == 0 || len(data)%2 != 0 {
+		return nil, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return nil, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	pairs := make([]KeyValue, 0, len(args)/2)
+	for i := 0; i < len(args); i += 2 {
+		pairs = append(pairs, KeyValue{Key: args[i], Value: []byte(args[i+1])})
+	}
+	return pairs, nil
+}
+
+func encodePairs(cmdType CommandType, pairs []KeyValue) (string, error) {
+	cmdList := []any{string(cmdType)}
+	for _, pair := range pairs {
+		cmdList = append(cmdList, pair.Key, pair.Value)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func formatPairs(pairs []KeyValue) string {
+	formatted := ""
+	for i, pair := range pairs {
+		if i > 0 {
+			formatted += ", "
+		}
+		formatted += fmt.Sprintf("(%q -> %q)", pair.Key, pair.Value)
+	}
+	return formatted
+}
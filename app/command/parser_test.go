@@ -2,173 +2,321 @@ package command
 
 import (
 	"fmt"
-	"math"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
-func TestParseSimpleString(t *testing.T) {
+func TestParse(t *testing.T) {
 	for _, tc := range []struct {
-		input          string
-		expectedOutput string
+		rawCmdString string
+		expectedCmd  Command
 	}{
 		{
-			input:          "+",
-			expectedOutput: "",
+			// NOTE: This test also checks for the case insensitivity of command parsing
+			rawCmdString: "*1\r\n$4\r\nPiNg\r\n",
+			expectedCmd:  Ping{},
 		},
 		{
-			input:          "+0",
-			expectedOutput: "0",
+			rawCmdString: "*2\r\n$4\r\nECHO\r\n$4\r\ntest\r\n",
+			expectedCmd:  Echo{Payload: "test"},
 		},
 		{
-			input:          "+abc",
-			expectedOutput: "abc",
+			rawCmdString: "*3\r\n$3\r\nSET\r\n$6\r\nbanana\r\n$6\r\nyellow\r\n",
+			expectedCmd:  Set{KeyPayload: "banana", ValuePayload: []byte("yellow")},
 		},
 		{
-			input:          "++++++",
-			expectedOutput: "+++++",
+			// NOTE: This also checks that px is case insensitive
+			rawCmdString: "*5\r\n$3\r\nSET\r\n$6\r\nbanana\r\n$6\r\nyellow\r\n$2\r\npX\r\n$3\r\n100\r\n",
+			expectedCmd:  Set{KeyPayload: "banana", ValuePayload: []byte("yellow"), ExpiryOption: ExpiryPX, ExpiryTime: 100},
 		},
-	} {
-		t.Run(fmt.Sprintf("input %q should parse to string %q", tc.input, tc.expectedOutput), func(t *testing.T) {
-			parser := CommandParser{tokens: []string{tc.input}}
-			res, err := parser.parseSimpleString()
-			assert.NoError(t, err)
-			assert.Equal(t, res, tc.expectedOutput)
-		})
-	}
-}
-
-func TestParseInt(t *testing.T) {
-	for _, tc := range []struct {
-		input          string
-		expectedOutput int64
-	}{
 		{
-			input:          ":0",
-			expectedOutput: 0,
+			rawCmdString: "*7\r\n$3\r\nSET\r\n$4\r\nlock\r\n$2\r\nme\r\n$2\r\nnx\r\n$2\r\nEX\r\n$2\r\n30\r\n$3\r\nget\r\n",
+			expectedCmd:  Set{KeyPayload: "lock", ValuePayload: []byte("me"), ExpiryOption: ExpiryEX, ExpiryTime: 30, Condition: SetIfMissing, Get: true},
 		},
 		{
-			input:          ":1",
-			expectedOutput: 1,
+			rawCmdString: "*2\r\n$3\r\nGET\r\n$6\r\nbanana\r\n",
+			expectedCmd:  Get{Payload: "banana"},
 		},
 		{
-			input:          ":-1",
-			expectedOutput: -1,
+			rawCmdString: "*3\r\n$3\r\nDEL\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  Del{Keys: []string{"a", "b"}},
 		},
 		{
-			input:          fmt.Sprintf(":%d", math.MaxInt),
-			expectedOutput: math.MaxInt64,
+			rawCmdString: "*1\r\n$5\r\nHELLO\r\n",
+			expectedCmd:  Hello{},
 		},
 		{
-			input:          fmt.Sprintf(":%d", math.MinInt),
-			expectedOutput: math.MinInt64,
+			rawCmdString: "*7\r\n$5\r\nHELLO\r\n$1\r\n3\r\n$4\r\nauth\r\n$7\r\ndefault\r\n$4\r\npass\r\n$7\r\nSETNAME\r\n$3\r\ncli\r\n",
+			expectedCmd:  Hello{ProtocolVersion: 3, Username: "default", Password: "pass", ClientName: "cli"},
 		},
-	} {
-		t.Run(fmt.Sprintf("input %q should parse to int %d", tc.input, tc.expectedOutput), func(t *testing.T) {
-			parser := CommandParser{tokens: []string{tc.input}}
-			res, err := parser.parseInt()
-			assert.NoError(t, err)
-			assert.Equal(t, res, tc.expectedOutput)
-		})
-	}
-}
-
-func TestParseBulkString(t *testing.T) {
-	for _, tc := range []struct {
-		input          []string
-		expectedOutput string
-	}{
 		{
-			input:          []string{"$0", ""},
-			expectedOutput: "",
+			rawCmdString: "*2\r\n$4\r\nINFO\r\n$11\r\nreplication\r\n",
+			expectedCmd:  Info{Payload: "replication"},
 		},
 		{
-			input:          []string{"$1", "a"},
-			expectedOutput: "a",
+			rawCmdString: "*2\r\n$4\r\nINFO\r\n$11\r\npersistence\r\n",
+			expectedCmd:  Info{Payload: "persistence"},
 		},
 		{
-			input:          []string{"$3", "xyz"},
-			expectedOutput: "xyz",
+			rawCmdString: "*1\r\n$4\r\nSAVE\r\n",
+			expectedCmd:  Save{},
 		},
 		{
-			// This is an edge case where the input string contains the delimeter \r\n
-			input:          []string{"$6", "abc", "d"},
-			expectedOutput: "abc\r\nd",
+			rawCmdString: "*3\r\n$6\r\nUNLINK\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  Unlink{Keys: []string{"a", "b"}},
 		},
-	} {
-		t.Run(fmt.Sprintf("input %q should parse to string %q", tc.input, tc.expectedOutput), func(t *testing.T) {
-			parser := CommandParser{tokens: tc.input}
-			res, err := parser.parseBulkString()
-			assert.NoError(t, err)
-			assert.Equal(t, res, tc.expectedOutput)
-		})
-	}
-}
-
-func TestParseArray(t *testing.T) {
-	for _, tc := range []struct {
-		input          []string
-		expectedOutput []any
-	}{
 		{
-			// Empty Array: "*0\r\n"
-			input:          []string{"*0"},
-			expectedOutput: []any{},
+			rawCmdString: "*3\r\n$6\r\nEXISTS\r\n$1\r\na\r\n$1\r\na\r\n",
+			expectedCmd:  Exists{Keys: []string{"a", "a"}},
 		},
 		{
-			input:          []string{"*1", ":1"},
-			expectedOutput: []any{int64(1)},
+			rawCmdString: "*2\r\n$4\r\nTYPE\r\n$1\r\na\r\n",
+			expectedCmd:  Type{Key: "a"},
 		},
 		{
-			input:          []string{"*2", "$4", "ECHO", "$4", "test"},
-			expectedOutput: []any{"ECHO", "test"},
+			rawCmdString: "*3\r\n$6\r\nRENAME\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  Rename{Key: "a", NewKey: "b"},
 		},
-		// Nested Array
 		{
-			input:          []string{"*2", "$1", "1", "*2", "$1", "2", "$1", "3"},
-			expectedOutput: []any{"1", []any{"2", "3"}},
+			rawCmdString: "*3\r\n$8\r\nRENAMENX\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  RenameNX{Key: "a", NewKey: "b"},
 		},
-	} {
-		t.Run(fmt.Sprintf("input %q should parse to array %q", tc.input, tc.expectedOutput), func(t *testing.T) {
-			parser := CommandParser{tokens: tc.input}
-			res, err := parser.parseArray()
-			assert.NoError(t, err)
-			assert.Equal(t, res, tc.expectedOutput)
-		})
-	}
-}
-
-func TestParse(t *testing.T) {
-	for _, tc := range []struct {
-		rawCmdString string
-		expectedCmd  Command
-	}{
 		{
-			// NOTE: This test also checks for the case insensitivity of command parsing
-			rawCmdString: "*1\r\n$4\r\nPiNg\r\n",
-			expectedCmd:  Ping{},
+			rawCmdString: "*3\r\n$4\r\nCOPY\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  Copy{Source: "a", Destination: "b"},
 		},
 		{
-			rawCmdString: "*2\r\n$4\r\nECHO\r\n$4\r\ntest\r\n",
-			expectedCmd:  Echo{Payload: "test"},
+			rawCmdString: "*6\r\n$4\r\nCOPY\r\n$1\r\na\r\n$1\r\nb\r\n$2\r\ndb\r\n$1\r\n0\r\n$7\r\nreplace\r\n",
+			expectedCmd:  Copy{Source: "a", Destination: "b", Replace: true},
 		},
 		{
-			rawCmdString: "*3\r\n$3\r\nSET\r\n$6\r\nbanana\r\n$6\r\nyellow\r\n",
-			expectedCmd:  Set{KeyPayload: "banana", ValuePayload: "yellow"},
+			rawCmdString: "*5\r\n$6\r\nEXPIRE\r\n$1\r\na\r\n$2\r\n10\r\n$2\r\nxx\r\n$2\r\nGT\r\n",
+			expectedCmd:  Expire{Cmd: ExpireCmd, Key: "a", Time: 10, Options: ExpireXX | ExpireGT},
 		},
 		{
-			// NOTE: This also checks that px is case insensitive
-			rawCmdString: "*5\r\n$3\r\nSET\r\n$6\r\nbanana\r\n$6\r\nyellow\r\n$2\r\npX\r\n$3\r\n100\r\n",
-			expectedCmd:  Set{KeyPayload: "banana", ValuePayload: "yellow", ExpiryTimeMs: int64(100)},
+			rawCmdString: "*3\r\n$9\r\nPEXPIREAT\r\n$1\r\na\r\n$13\r\n1700000000000\r\n",
+			expectedCmd:  Expire{Cmd: PExpireAtCmd, Key: "a", Time: 1700000000000},
 		},
 		{
-			rawCmdString: "*2\r\n$3\r\nGET\r\n$6\r\nbanana\r\n",
-			expectedCmd:  Get{Payload: "banana"},
+			rawCmdString: "*2\r\n$4\r\nPTTL\r\n$1\r\na\r\n",
+			expectedCmd:  TTL{Cmd: PTTLCmd, Key: "a"},
 		},
 		{
-			rawCmdString: "*2\r\n$4\r\nINFO\r\n$11\r\nreplication\r\n",
-			expectedCmd:  Info{Payload: "replication"},
+			rawCmdString: "*2\r\n$10\r\nEXPIRETIME\r\n$1\r\na\r\n",
+			expectedCmd:  TTL{Cmd: ExpireTimeCmd, Key: "a"},
+		},
+		{
+			rawCmdString: "*2\r\n$4\r\nDECR\r\n$1\r\na\r\n",
+			expectedCmd:  IncrBy{Cmd: DecrCmd, Key: "a", Increment: -1},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nDECRBY\r\n$1\r\na\r\n$2\r\n10\r\n",
+			expectedCmd:  IncrBy{Cmd: DecrByCmd, Key: "a", Increment: -10},
+		},
+		{
+			rawCmdString: "*3\r\n$11\r\nINCRBYFLOAT\r\n$1\r\na\r\n$5\r\n5.0e3\r\n",
+			expectedCmd:  IncrByFloat{Key: "a", Increment: 5000},
+		},
+		{
+			rawCmdString: "*4\r\n$5\r\nSETEX\r\n$1\r\na\r\n$2\r\n10\r\n$1\r\nb\r\n",
+			expectedCmd:  Set{KeyPayload: "a", ValuePayload: []byte("b"), ExpiryOption: ExpiryEX, ExpiryTime: 10},
+		},
+		{
+			rawCmdString: "*4\r\n$6\r\nPSETEX\r\n$1\r\na\r\n$2\r\n10\r\n$1\r\nb\r\n",
+			expectedCmd:  Set{KeyPayload: "a", ValuePayload: []byte("b"), ExpiryOption: ExpiryPX, ExpiryTime: 10},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nGETSET\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  Set{KeyPayload: "a", ValuePayload: []byte("b"), Get: true},
+		},
+		{
+			rawCmdString: "*3\r\n$5\r\nSETNX\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  SetNX{Key: "a", Value: []byte("b")},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nAPPEND\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  Append{Key: "a", Value: []byte("b")},
+		},
+		{
+			rawCmdString: "*2\r\n$6\r\nSTRLEN\r\n$1\r\na\r\n",
+			expectedCmd:  StrLen{Key: "a"},
+		},
+		{
+			rawCmdString: "*4\r\n$8\r\nGETRANGE\r\n$1\r\na\r\n$1\r\n0\r\n$2\r\n-1\r\n",
+			expectedCmd:  GetRange{Key: "a", Start: 0, End: -1},
+		},
+		{
+			rawCmdString: "*4\r\n$8\r\nSETRANGE\r\n$1\r\na\r\n$1\r\n5\r\n$1\r\nb\r\n",
+			expectedCmd:  SetRange{Key: "a", Offset: 5, Value: []byte("b")},
+		},
+		{
+			rawCmdString: "*2\r\n$6\r\nGETDEL\r\n$1\r\na\r\n",
+			expectedCmd:  GetDel{Key: "a"},
+		},
+		{
+			rawCmdString: "*4\r\n$5\r\nGETEX\r\n$1\r\na\r\n$4\r\nexat\r\n$10\r\n1700000000\r\n",
+			expectedCmd:  GetEx{Key: "a", ExpiryOption: ExpiryEXAT, ExpiryTime: 1700000000},
+		},
+		{
+			rawCmdString: "*3\r\n$5\r\nGETEX\r\n$1\r\na\r\n$7\r\npersist\r\n",
+			expectedCmd:  GetEx{Key: "a", ExpiryOption: ExpiryPersist},
+		},
+		{
+			rawCmdString: "*3\r\n$4\r\nMGET\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  MGet{Keys: []string{"a", "b"}},
+		},
+		{
+			rawCmdString: "*5\r\n$4\r\nMSET\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n",
+			expectedCmd:  MSet{Pairs: []KeyValue{{Key: "a", Value: []byte("1")}, {Key: "b", Value: []byte("2")}}},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nMSETNX\r\n$1\r\na\r\n$1\r\n1\r\n",
+			expectedCmd:  MSetNX{Pairs: []KeyValue{{Key: "a", Value: []byte("1")}}},
+		},
+		{
+			rawCmdString: "*4\r\n$5\r\nLPUSH\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\n2\r\n",
+			expectedCmd:  Push{Cmd: LPushCmd, Key: "a", Values: [][]byte{[]byte("1"), []byte("2")}},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nRPUSHX\r\n$1\r\na\r\n$1\r\n1\r\n",
+			expectedCmd:  Push{Cmd: RPushXCmd, Key: "a", Values: [][]byte{[]byte("1")}},
+		},
+		{
+			rawCmdString: "*2\r\n$4\r\nLPOP\r\n$1\r\na\r\n",
+			expectedCmd:  Pop{Cmd: LPopCmd, Key: "a", Count: 1},
+		},
+		{
+			rawCmdString: "*3\r\n$4\r\nRPOP\r\n$1\r\na\r\n$1\r\n2\r\n",
+			expectedCmd:  Pop{Cmd: RPopCmd, Key: "a", Count: 2, WithCount: true},
+		},
+		{
+			rawCmdString: "*4\r\n$6\r\nLRANGE\r\n$1\r\na\r\n$1\r\n1\r\n$2\r\n-2\r\n",
+			expectedCmd:  LRange{Key: "a", Start: 1, End: -2},
+		},
+		{
+			rawCmdString: "*2\r\n$4\r\nLLEN\r\n$1\r\na\r\n",
+			expectedCmd:  LLen{Key: "a"},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nLINDEX\r\n$1\r\na\r\n$2\r\n-1\r\n",
+			expectedCmd:  LIndex{Key: "a", Index: -1},
+		},
+		{
+			rawCmdString: "*4\r\n$4\r\nLSET\r\n$1\r\na\r\n$1\r\n0\r\n$1\r\nb\r\n",
+			expectedCmd:  LSet{Key: "a", Index: 0, Value: []byte("b")},
+		},
+		{
+			rawCmdString: "*5\r\n$7\r\nLINSERT\r\n$1\r\na\r\n$6\r\nbefore\r\n$1\r\nb\r\n$1\r\nc\r\n",
+			expectedCmd:  LInsert{Key: "a", Before: true, Pivot: []byte("b"), Value: []byte("c")},
+		},
+		{
+			rawCmdString: "*4\r\n$4\r\nLREM\r\n$1\r\na\r\n$2\r\n-2\r\n$1\r\nb\r\n",
+			expectedCmd:  LRem{Key: "a", Count: -2, Value: []byte("b")},
+		},
+		{
+			rawCmdString: "*4\r\n$5\r\nLTRIM\r\n$1\r\na\r\n$1\r\n0\r\n$1\r\n9\r\n",
+			expectedCmd:  LTrim{Key: "a", Start: 0, End: 9},
+		},
+		{
+			rawCmdString: "*7\r\n$4\r\nLPOS\r\n$1\r\na\r\n$1\r\nb\r\n$6\r\nmaxlen\r\n$2\r\n10\r\n$4\r\nrank\r\n$1\r\n2\r\n",
+			expectedCmd:  LPos{Key: "a", Value: []byte("b"), Rank: 2, MaxLen: 10},
+		},
+		{
+			rawCmdString: "*5\r\n$5\r\nLMOVE\r\n$1\r\na\r\n$1\r\nb\r\n$5\r\nright\r\n$4\r\nleft\r\n",
+			expectedCmd:  LMove{Source: "a", Destination: "b", From: ListRight, To: ListLeft},
+		},
+		{
+			rawCmdString: "*4\r\n$5\r\nBLPOP\r\n$1\r\na\r\n$1\r\nb\r\n$5\r\n0.001\r\n",
+			expectedCmd:  BPop{Cmd: BLPopCmd, Keys: []string{"a", "b"}, TimeoutMs: 1},
+		},
+		{
+			rawCmdString: "*6\r\n$6\r\nBLMOVE\r\n$1\r\na\r\n$1\r\nb\r\n$4\r\nleft\r\n$5\r\nright\r\n$1\r\n0\r\n",
+			expectedCmd:  BLMove{Source: "a", Destination: "b", From: ListLeft, To: ListRight},
+		},
+		{
+			rawCmdString: "*5\r\n$5\r\nLMPOP\r\n$1\r\n2\r\n$1\r\na\r\n$1\r\nb\r\n$5\r\nright\r\n",
+			expectedCmd:  LMPop{Cmd: LMPopCmd, Keys: []string{"a", "b"}, End: ListRight, Count: 1},
+		},
+		{
+			rawCmdString: "*7\r\n$6\r\nBLMPOP\r\n$3\r\n0.5\r\n$1\r\n1\r\n$1\r\na\r\n$4\r\nLEFT\r\n$5\r\ncount\r\n$1\r\n3\r\n",
+			expectedCmd:  LMPop{Cmd: BLMPopCmd, Keys: []string{"a"}, End: ListLeft, Count: 3, TimeoutMs: 500},
+		},
+		{
+			rawCmdString: "*6\r\n$4\r\nHSET\r\n$1\r\nh\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n",
+			expectedCmd:  HSet{Key: "h", Pairs: []FieldValue{{Field: "a", Value: []byte("1")}, {Field: "b", Value: []byte("2")}}},
+		},
+		{
+			rawCmdString: "*4\r\n$5\r\nHMGET\r\n$1\r\nh\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  HMGet{Key: "h", Fields: []string{"a", "b"}},
+		},
+		{
+			rawCmdString: "*2\r\n$5\r\nHVALS\r\n$1\r\nh\r\n",
+			expectedCmd:  HGetAll{Cmd: HValsCmd, Key: "h"},
+		},
+		{
+			rawCmdString: "*4\r\n$7\r\nHINCRBY\r\n$1\r\nh\r\n$1\r\na\r\n$2\r\n-5\r\n",
+			expectedCmd:  HIncrBy{Key: "h", Field: "a", Increment: -5},
+		},
+		{
+			rawCmdString: "*4\r\n$10\r\nHRANDFIELD\r\n$1\r\nh\r\n$2\r\n-3\r\n$10\r\nwithvalues\r\n",
+			expectedCmd:  HRandField{Key: "h", Count: -3, WithCount: true, WithValues: true},
+		},
+		{
+			rawCmdString: "*8\r\n$5\r\nHSCAN\r\n$1\r\nh\r\n$2\r\n42\r\n$8\r\nnovalues\r\n$5\r\nMATCH\r\n$8\r\nnovalues\r\n$5\r\ncount\r\n$3\r\n100\r\n",
+			expectedCmd:  HScan{Key: "h", Cursor: 42, Match: "novalues", Count: 100, NoValues: true},
+		},
+		{
+			rawCmdString: "*7\r\n$7\r\nHEXPIRE\r\n$1\r\nh\r\n$2\r\n10\r\n$2\r\nlt\r\n$6\r\nfields\r\n$1\r\n1\r\n$1\r\na\r\n",
+			expectedCmd:  HExpire{Cmd: HExpireCmd, Key: "h", Time: 10, Options: ExpireLT, Fields: []string{"a"}},
+		},
+		{
+			rawCmdString: "*6\r\n$5\r\nHPTTL\r\n$1\r\nh\r\n$6\r\nFIELDS\r\n$1\r\n2\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  HTTL{Cmd: HPTTLCmd, Key: "h", Fields: []string{"a", "b"}},
+		},
+		{
+			rawCmdString: "*5\r\n$8\r\nHPERSIST\r\n$1\r\nh\r\n$6\r\nFIELDS\r\n$1\r\n1\r\n$1\r\na\r\n",
+			expectedCmd:  HPersist{Key: "h", Fields: []string{"a"}},
+		},
+		{
+			rawCmdString: "*4\r\n$4\r\nSADD\r\n$1\r\ns\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  SAdd{Key: "s", Members: []string{"a", "b"}},
+		},
+		{
+			rawCmdString: "*3\r\n$11\r\nSRANDMEMBER\r\n$1\r\ns\r\n$2\r\n-3\r\n",
+			expectedCmd:  SRandMember{Key: "s", Count: -3, WithCount: true},
+		},
+		{
+			rawCmdString: "*3\r\n$6\r\nSINTER\r\n$1\r\na\r\n$1\r\nb\r\n",
+			expectedCmd:  SCombine{Cmd: SInterCmd, Keys: []string{"a", "b"}},
+		},
+		{
+			rawCmdString: "*6\r\n$10\r\nSINTERCARD\r\n$1\r\n2\r\n$1\r\na\r\n$1\r\nb\r\n$5\r\nlimit\r\n$1\r\n3\r\n",
+			expectedCmd:  SInterCard{Keys: []string{"a", "b"}, Limit: 3},
+		},
+		{
+			rawCmdString: "*5\r\n$5\r\nSSCAN\r\n$1\r\ns\r\n$1\r\n0\r\n$5\r\nMATCH\r\n$2\r\na*\r\n",
+			expectedCmd:  SScan{Key: "s", Match: "a*", Count: 10},
+		},
+		{
+			rawCmdString: "*2\r\n$7\r\nPERSIST\r\n$1\r\na\r\n",
+			expectedCmd:  Persist{Key: "a"},
+		},
+		{
+			rawCmdString: "*1\r\n$6\r\nBGSAVE\r\n",
+			expectedCmd:  BgSave{},
+		},
+		{
+			rawCmdString: "*1\r\n$8\r\nLASTSAVE\r\n",
+			expectedCmd:  LastSave{},
+		},
+		{
+			rawCmdString: "*3\r\n$9\r\nREPLICAOF\r\n$9\r\nlocalhost\r\n$4\r\n6379\r\n",
+			expectedCmd:  ReplicaOf{Host: "localhost", Port: "6379"},
+		},
+		{
+			rawCmdString: "*3\r\n$7\r\nSLAVEOF\r\n$2\r\nNO\r\n$3\r\nONE\r\n",
+			expectedCmd:  ReplicaOf{Host: "NO", Port: "ONE"},
 		},
 	} {
 		t.Run(fmt.Sprintf("input %q should parse to populated %T command", tc.rawCmdString, tc.expectedCmd), func(t *testing.T) {
@@ -1,9 +1,5 @@
 package command
 
-import (
-	"fmt"
-)
-
 type Ping struct{}
 
 func (Ping) String() string {
@@ -19,9 +15,13 @@ func (Ping) CommandType() CommandType {
 	return PingCmd
 }
 
+func (Ping) Flags() CommandFlags {
+	return 0
+}
+
 func toPing(data []any) (Ping, error) {
 	if len(data) != 0 {
-		return Ping{}, fmt.Errorf("expected data for PING to be empty, but it was: %v", data)
+		return Ping{}, wrongNumberOfArgs(PingCmd)
 	}
 	return Ping{}, nil
 }
@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Pop removes and returns elements from one end of the list stored at Key. It covers LPOP and RPOP, which pop from
+// the start and the end of the list. Cmd is the one that was sent.
+// `LPOP key [count]`
+type Pop struct {
+	Cmd CommandType
+	Key string
+
+	// Without a count, a single element is popped and the reply is that element rather than an array
+	Count     int64
+	WithCount bool
+}
+
+func (p Pop) String() string {
+	if !p.WithCount {
+		return fmt.Sprintf("%s: %q", strings.ToUpper(string(p.Cmd)), p.Key)
+	}
+	return fmt.Sprintf("%s: %q count %d", strings.ToUpper(string(p.Cmd)), p.Key, p.Count)
+}
+
+func (p Pop) EncodedCommand() (string, error) {
+	cmdList := []any{string(p.Cmd), p.Key}
+	if p.WithCount {
+		cmdList = append(cmdList, strconv.FormatInt(p.Count, 10))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (p Pop) CommandType() CommandType {
+	return p.Cmd
+}
+
+func (Pop) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// End is the end of the list that elements are popped from
+func (p Pop) End() ListEnd {
+	if p.Cmd == LPopCmd {
+		return ListLeft
+	}
+	return ListRight
+}
+
+func toPop(cmdType CommandType, data []any) (Pop, error) {
+	if len(data) != 1 && len(data) != 2 {
+		return Pop{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Pop{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+	pop := Pop{Cmd: cmdType, Key: args[0], Count: 1}
+
+	if len(args) == 2 {
+		pop.Count, err = strconv.ParseInt(args[1], 10, 64)
+		if err != nil || pop.Count < 0 {
+			return Pop{}, ErrNotPositive
+		}
+		pop.WithCount = true
+	}
+
+	return pop, nil
+}
@@ -0,0 +1,72 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Push adds Values to one end of the list stored at Key, one at a time, and creates the list if it doesn't exist. It
+// covers LPUSH and RPUSH, which push to the start and the end of the list, and LPUSHX and RPUSHX, which only push if
+// the list already exists. Cmd is the one that was sent
+type Push struct {
+	Cmd    CommandType
+	Key    string
+	Values [][]byte
+}
+
+func (p Push) String() string {
+	values := make([]string, 0, len(p.Values))
+	for _, value := range p.Values {
+		values = append(values, fmt.Sprintf("%q", value))
+	}
+	return fmt.Sprintf("%s: %q <- %s", strings.ToUpper(string(p.Cmd)), p.Key, strings.Join(values, " "))
+}
+
+func (p Push) EncodedCommand() (string, error) {
+	cmdList := []any{string(p.Cmd), p.Key}
+	for _, value := range p.Values {
+		cmdList = append(cmdList, value)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (p Push) CommandType() CommandType {
+	return p.Cmd
+}
+
+func (Push) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// End is the end of the list that the values are pushed to
+func (p Push) End() ListEnd {
+	if p.Cmd == LPushCmd || p.Cmd == LPushXCmd {
+		return ListLeft
+	}
+	return ListRight
+}
+
+// OnlyIfExists is true for LPUSHX and RPUSHX, which don't create a missing list
+func (p Push) OnlyIfExists() bool {
+	return p.Cmd == LPushXCmd || p.Cmd == RPushXCmd
+}
+
+func toPush(cmdType CommandType, data []any) (Push, error) {
+	if len(data) < 2 {
+		return Push{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Push{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	values := make([][]byte, 0, len(args)-1)
+	for _, arg := range args[1:] {
+		values = append(values, []byte(arg))
+	}
+
+	return Push{Cmd: cmdType, Key: args[0], Values: values}, nil
+}
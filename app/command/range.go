@@ -0,0 +1,99 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GetRange returns the part of the string stored at Key between the Start and End offsets, inclusive. Negative
+// offsets count back from the end of the string
+type GetRange struct {
+	Key   string
+	Start int64
+	End   int64
+}
+
+func (g GetRange) String() string {
+	return fmt.Sprintf("GETRANGE: %q from %d to %d", g.Key, g.Start, g.End)
+}
+
+func (g GetRange) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(GetRangeCmd), g.Key, strconv.FormatInt(g.Start, 10), strconv.FormatInt(g.End, 10)})
+}
+
+func (GetRange) CommandType() CommandType {
+	return GetRangeCmd
+}
+
+func (GetRange) Flags() CommandFlags {
+	return 0
+}
+
+func toGetRange(data []any) (GetRange, error) {
+	if len(data) != 3 {
+		return GetRange{}, wrongNumberOfArgs(GetRangeCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return GetRange{}, fmt.Errorf("expected the inputs to the GETRANGE command to be strings: %w", err)
+	}
+
+	start, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return GetRange{}, ErrNotInteger
+	}
+	end, err := strconv.ParseInt(args[2], 10, 64)
+	if err != nil {
+		return GetRange{}, ErrNotInteger
+	}
+
+	return GetRange{Key: args[0], Start: start, End: end}, nil
+}
+
+// SetRange overwrites the string stored at Key with Value, starting at Offset. If the string is shorter than
+// Offset, it's padded with zero bytes first
+type SetRange struct {
+	Key    string
+	Offset int64
+	Value  []byte
+}
+
+func (s SetRange) String() string {
+	return fmt.Sprintf("SETRANGE: %q at %d to %q", s.Key, s.Offset, s.Value)
+}
+
+func (s SetRange) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SetRangeCmd), s.Key, strconv.FormatInt(s.Offset, 10), s.Value})
+}
+
+func (SetRange) CommandType() CommandType {
+	return SetRangeCmd
+}
+
+func (SetRange) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toSetRange(data []any) (SetRange, error) {
+	if len(data) != 3 {
+		return SetRange{}, wrongNumberOfArgs(SetRangeCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SetRange{}, fmt.Errorf("expected the inputs to the SETRANGE command to be strings: %w", err)
+	}
+
+	offset, err := strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return SetRange{}, ErrNotInteger
+	}
+	if offset < 0 {
+		return SetRange{}, ErrorReply("ERR offset is out of range")
+	}
+
+	return SetRange{Key: args[0], Offset: offset, Value: []byte(args[2])}, nil
+}
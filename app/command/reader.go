@@ -0,0 +1,394 @@
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+)
+
+const (
+	// The largest bulk string that will be read. This matches the default proto-max-bulk-len of redis
+	MaxBulkLength = 512 * 1024 * 1024
+
+	// The most elements that an array can have. This matches the limit redis puts on the number of arguments to a command
+	MaxArrayLength = 1024 * 1024
+
+	// The longest line that will be read for anything other than bulk data, such as a simple string or the length of
+	// a bulk string or array
+	MaxLineLength = 64 * 1024
+)
+
+// ErrProtocol is wrapped by every error caused by malformed RESP data. Once one of these errors is returned, the
+// stream can't be read any further since there's no way to tell where the next value starts
+var ErrProtocol = errors.New("Protocol error")
+
+// Reader decodes RESP values from a stream. Bulk data is read using its length prefix, so values may contain any
+// bytes, and pipelined values are decoded one at a time out of the same buffer
+type Reader struct {
+	reader *bufio.Reader
+
+	// The number of bytes read for the value that is currently being read
+	bytesRead int
+
+	// If set, every byte read for the current value is also appended to raw
+	capturing bool
+	raw       []byte
+}
+
+// NewReader returns a Reader for r. If r is already a *bufio.Reader it is used directly, so the caller may keep
+// reading from it in between values
+func NewReader(r io.Reader) *Reader {
+	bufReader, ok := r.(*bufio.Reader)
+	if !ok {
+		bufReader = bufio.NewReader(r)
+	}
+
+	return &Reader{reader: bufReader}
+}
+
+// ReadValue reads the next value off of the stream and returns it along with the number of bytes that it took up.
+// Simple and bulk strings are returned as strings, integers as int64s, simple and bulk errors as ErrorReplys and
+// arrays as []any. Nulls, including the RESP2 null bulk string and null array, are returned as nil. The rest of the
+// RESP3 types are returned as bools, float64s, *big.Ints, VerbatimStrings, MapReplys, SetReplys and PushReplys.
+// Attributes are read and dropped, so the value that they describe is returned instead
+func (r *Reader) ReadValue() (any, int, error) {
+	r.bytesRead = 0
+	value, err := r.readValue()
+	return value, r.bytesRead, err
+}
+
+// ReadCommand reads the next command off of the stream and returns its arguments along with the number of bytes that
+// it took up. A command is either an array of bulk strings or, like in redis, an inline command if it doesn't start
+// with '*'. Inline commands are a single line of arguments separated by spaces, which makes it possible to send
+// commands by hand with tools like telnet and netcat
+func (r *Reader) ReadCommand() ([]any, int, error) {
+	r.bytesRead = 0
+	args, err := r.readCommand()
+	return args, r.bytesRead, err
+}
+
+// ReadRaw reads the next value off of the stream and returns it exactly as it was sent. A line that doesn't start
+// with a RESP type is read as an inline command
+func (r *Reader) ReadRaw() (string, error) {
+	r.capturing = true
+	r.raw = r.raw[:0]
+	defer func() { r.capturing = false }()
+
+	next, err := r.reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+
+	if isRESPType(next[0]) {
+		_, _, err = r.ReadValue()
+	} else {
+		_, _, err = r.ReadCommand()
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(r.raw), nil
+}
+
+func (r *Reader) readCommand() ([]any, error) {
+	next, err := r.reader.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	if next[0] != '*' {
+		return r.readInlineCommand()
+	}
+
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+
+	numArgs, err := parseLength(line[1:], MaxArrayLength, "multibulk")
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]any, 0, min(numArgs, 1024))
+	for range numArgs {
+		line, err := r.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, fmt.Errorf("%w: expected '$', got '%s'", ErrProtocol, firstByte(line))
+		}
+
+		arg, err := r.readBulkData(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if arg == nil {
+			return nil, fmt.Errorf("%w: invalid bulk length", ErrProtocol)
+		}
+		args = append(args, *arg)
+	}
+
+	return args, nil
+}
+
+// readInlineCommand reads a command that was sent as a single line. Unlike the rest of RESP, the line may end
+// with just \n
+func (r *Reader) readInlineCommand() ([]any, error) {
+	line, err := r.readRawLine()
+	if err != nil {
+		return nil, err
+	}
+
+	line = bytes.TrimSuffix(line, []byte("\n"))
+	line = bytes.TrimSuffix(line, []byte("\r"))
+	return splitInlineArgs(line)
+}
+
+func (r *Reader) readValue() (any, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, fmt.Errorf("%w: found an empty line while reading a value", ErrProtocol)
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return ErrorReply(line[1:]), nil
+	case ':':
+		value, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid integer %q", ErrProtocol, line[1:])
+		}
+		return value, nil
+	case '$':
+		value, err := r.readBulkData(line[1:])
+		if err != nil || value == nil {
+			return nil, err
+		}
+		return *value, nil
+	case '*':
+		return r.readArray(line[1:])
+	case '_':
+		if len(line) != 1 {
+			return nil, fmt.Errorf("%w: invalid null %q", ErrProtocol, line)
+		}
+		return nil, nil
+	case '#':
+		return parseBool(line[1:])
+	case ',':
+		value, err := strconv.ParseFloat(string(line[1:]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid double %q", ErrProtocol, line[1:])
+		}
+		return value, nil
+	case '(':
+		value, ok := new(big.Int).SetString(string(line[1:]), 10)
+		if !ok {
+			return nil, fmt.Errorf("%w: invalid big number %q", ErrProtocol, line[1:])
+		}
+		return value, nil
+	case '!':
+		value, err := r.readBulkData(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		if value == nil {
+			return nil, fmt.Errorf("%w: invalid bulk error length", ErrProtocol)
+		}
+		return ErrorReply(*value), nil
+	case '=':
+		return r.readVerbatimString(line[1:])
+	case '%':
+		return r.readMap(line[1:])
+	case '~':
+		values, err := r.readElements(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		return SetReply(values), nil
+	case '>':
+		values, err := r.readElements(line[1:])
+		if err != nil {
+			return nil, err
+		}
+		return PushReply(values), nil
+	case '|':
+		// Attributes only add information about the value that follows them, which is all that's needed here
+		if _, err := r.readMap(line[1:]); err != nil {
+			return nil, err
+		}
+		return r.readValue()
+	}
+
+	return nil, fmt.Errorf("%w: unexpected type %q", ErrProtocol, line[0])
+}
+
+func (r *Reader) readArray(lengthBytes []byte) (any, error) {
+	if string(lengthBytes) == "-1" {
+		return nil, nil
+	}
+	return r.readElements(lengthBytes)
+}
+
+// readElements reads the elements of an array, set or push message once its length has been read
+func (r *Reader) readElements(lengthBytes []byte) ([]any, error) {
+	length, err := parseLength(lengthBytes, MaxArrayLength, "multibulk")
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]any, 0, min(length, 1024))
+	for range length {
+		value, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+
+	return values, nil
+}
+
+func (r *Reader) readMap(lengthBytes []byte) (MapReply, error) {
+	length, err := parseLength(lengthBytes, MaxArrayLength, "map")
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(MapReply, 0, min(length, 1024))
+	for range length {
+		key, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		value, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, MapEntry{Key: key, Value: value})
+	}
+
+	return entries, nil
+}
+
+// readVerbatimString reads a verbatim string once its length has been read. The data starts with a three
+// letter format followed by a colon
+func (r *Reader) readVerbatimString(lengthBytes []byte) (VerbatimString, error) {
+	data, err := r.readBulkData(lengthBytes)
+	if err != nil {
+		return VerbatimString{}, err
+	}
+	if data == nil || len(*data) < 4 || (*data)[3] != ':' {
+		return VerbatimString{}, fmt.Errorf("%w: invalid verbatim string", ErrProtocol)
+	}
+
+	return VerbatimString{Format: (*data)[:3], Text: (*data)[4:]}, nil
+}
+
+// readBulkData reads the data of a bulk string once its length has been read. A nil string is returned for the
+// null bulk string
+func (r *Reader) readBulkData(lengthBytes []byte) (*string, error) {
+	if string(lengthBytes) == "-1" {
+		return nil, nil
+	}
+
+	length, err := parseLength(lengthBytes, MaxBulkLength, "bulk")
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, length+len(Delimeter))
+	if _, err := io.ReadFull(r.reader, data); err != nil {
+		return nil, err
+	}
+	r.consumed(data)
+
+	if !bytes.HasSuffix(data, []byte(Delimeter)) {
+		return nil, fmt.Errorf("%w: bulk string of length %d was not terminated by CRLF", ErrProtocol, length)
+	}
+
+	value := string(data[:length])
+	return &value, nil
+}
+
+func parseBool(data []byte) (bool, error) {
+	switch string(data) {
+	case "t":
+		return true, nil
+	case "f":
+		return false, nil
+	}
+	return false, fmt.Errorf("%w: invalid boolean %q", ErrProtocol, data)
+}
+
+func parseLength(lengthBytes []byte, maxLength int, lengthType string) (int, error) {
+	length, err := strconv.Atoi(string(lengthBytes))
+	if err != nil || length < 0 || length > maxLength {
+		return 0, fmt.Errorf("%w: invalid %s length", ErrProtocol, lengthType)
+	}
+	return length, nil
+}
+
+// readLine reads a CRLF terminated line and returns it without the CRLF
+func (r *Reader) readLine() ([]byte, error) {
+	line, err := r.readRawLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasSuffix(line, []byte(Delimeter)) {
+		return nil, fmt.Errorf("%w: line %q was not terminated by CRLF", ErrProtocol, line)
+	}
+	return line[:len(line)-len(Delimeter)], nil
+}
+
+// readRawLine reads up to and including the next \n
+func (r *Reader) readRawLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.reader.ReadSlice('\n')
+		if len(line)+len(chunk) > MaxLineLength {
+			return nil, fmt.Errorf("%w: too big line", ErrProtocol)
+		}
+		line = append(line, chunk...)
+
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		break
+	}
+	r.consumed(line)
+
+	return line, nil
+}
+
+func (r *Reader) consumed(data []byte) {
+	r.bytesRead += len(data)
+	if r.capturing {
+		r.raw = append(r.raw, data...)
+	}
+}
+
+// isRESPType is true if b is the first byte of one of the RESP types
+func isRESPType(b byte) bool {
+	return bytes.IndexByte([]byte("+-:$*_#,(!=%~>|"), b) != -1
+}
+
+func firstByte(line []byte) string {
+	if len(line) == 0 {
+		return ""
+	}
+	return string(line[:1])
+}
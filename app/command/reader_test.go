@@ -0,0 +1,386 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func readValueFromString(input string) (any, int, error) {
+	return NewReader(strings.NewReader(input)).ReadValue()
+}
+
+func TestReadSimpleString(t *testing.T) {
+	for _, tc := range []struct {
+		input          string
+		expectedOutput string
+	}{
+		{
+			input:          "+\r\n",
+			expectedOutput: "",
+		},
+		{
+			input:          "+0\r\n",
+			expectedOutput: "0",
+		},
+		{
+			input:          "+abc\r\n",
+			expectedOutput: "abc",
+		},
+		{
+			input:          "++++++\r\n",
+			expectedOutput: "+++++",
+		},
+	} {
+		t.Run(fmt.Sprintf("input %q should read as string %q", tc.input, tc.expectedOutput), func(t *testing.T) {
+			res, size, err := readValueFromString(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.Equal(t, len(tc.input), size)
+		})
+	}
+}
+
+func TestReadInt(t *testing.T) {
+	for _, tc := range []struct {
+		input          string
+		expectedOutput int64
+	}{
+		{
+			input:          ":0\r\n",
+			expectedOutput: 0,
+		},
+		{
+			input:          ":1\r\n",
+			expectedOutput: 1,
+		},
+		{
+			input:          ":-1\r\n",
+			expectedOutput: -1,
+		},
+		{
+			input:          fmt.Sprintf(":%d\r\n", math.MaxInt),
+			expectedOutput: math.MaxInt64,
+		},
+		{
+			input:          fmt.Sprintf(":%d\r\n", math.MinInt),
+			expectedOutput: math.MinInt64,
+		},
+	} {
+		t.Run(fmt.Sprintf("input %q should read as int %d", tc.input, tc.expectedOutput), func(t *testing.T) {
+			res, _, err := readValueFromString(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, res)
+		})
+	}
+}
+
+func TestReadBulkString(t *testing.T) {
+	for _, tc := range []struct {
+		input          string
+		expectedOutput any
+	}{
+		{
+			input:          "$0\r\n\r\n",
+			expectedOutput: "",
+		},
+		{
+			input:          "$1\r\na\r\n",
+			expectedOutput: "a",
+		},
+		{
+			input:          "$3\r\nxyz\r\n",
+			expectedOutput: "xyz",
+		},
+		{
+			// Bulk strings are binary safe, so they may contain the delimeter \r\n
+			input:          "$6\r\nabc\r\nd\r\n",
+			expectedOutput: "abc\r\nd",
+		},
+		{
+			input:          "$3\r\n\x00\n\xff\r\n",
+			expectedOutput: "\x00\n\xff",
+		},
+		{
+			input:          "$-1\r\n",
+			expectedOutput: nil,
+		},
+	} {
+		t.Run(fmt.Sprintf("input %q should read as string %q", tc.input, tc.expectedOutput), func(t *testing.T) {
+			res, size, err := readValueFromString(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.Equal(t, len(tc.input), size)
+		})
+	}
+}
+
+func TestReadArray(t *testing.T) {
+	for _, tc := range []struct {
+		input          string
+		expectedOutput any
+	}{
+		{
+			input:          "*0\r\n",
+			expectedOutput: []any{},
+		},
+		{
+			input:          "*1\r\n:1\r\n",
+			expectedOutput: []any{int64(1)},
+		},
+		{
+			input:          "*2\r\n$4\r\nECHO\r\n$4\r\ntest\r\n",
+			expectedOutput: []any{"ECHO", "test"},
+		},
+		// Nested Array
+		{
+			input:          "*2\r\n$1\r\n1\r\n*2\r\n$1\r\n2\r\n$1\r\n3\r\n",
+			expectedOutput: []any{"1", []any{"2", "3"}},
+		},
+		{
+			input:          "*2\r\n-ERR bad\r\n$-1\r\n",
+			expectedOutput: []any{ErrorReply("ERR bad"), nil},
+		},
+		{
+			input:          "*-1\r\n",
+			expectedOutput: nil,
+		},
+	} {
+		t.Run(fmt.Sprintf("input %q should read as array %q", tc.input, tc.expectedOutput), func(t *testing.T) {
+			res, size, err := readValueFromString(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.Equal(t, len(tc.input), size)
+		})
+	}
+}
+
+func TestReadRESP3Types(t *testing.T) {
+	for _, tc := range []struct {
+		input          string
+		expectedOutput any
+	}{
+		{
+			input:          "_\r\n",
+			expectedOutput: nil,
+		},
+		{
+			input:          "#t\r\n",
+			expectedOutput: true,
+		},
+		{
+			input:          "#f\r\n",
+			expectedOutput: false,
+		},
+		{
+			input:          ",1.25\r\n",
+			expectedOutput: 1.25,
+		},
+		{
+			input:          ",-inf\r\n",
+			expectedOutput: math.Inf(-1),
+		},
+		{
+			input:          ",10\r\n",
+			expectedOutput: float64(10),
+		},
+		{
+			input:          "(3492890328409238509324850943850943825024385\r\n",
+			expectedOutput: mustParseBigInt(t, "3492890328409238509324850943850943825024385"),
+		},
+		{
+			input:          "!22\r\nSYNTAX invalid\r\nsyntax\r\n",
+			expectedOutput: ErrorReply("SYNTAX invalid\r\nsyntax"),
+		},
+		{
+			input:          "=15\r\ntxt:Some string\r\n",
+			expectedOutput: VerbatimString{Format: "txt", Text: "Some string"},
+		},
+		{
+			input:          "%2\r\n+first\r\n:1\r\n+second\r\n:2\r\n",
+			expectedOutput: MapReply{{Key: "first", Value: int64(1)}, {Key: "second", Value: int64(2)}},
+		},
+		{
+			input:          "~2\r\n+a\r\n#t\r\n",
+			expectedOutput: SetReply{"a", true},
+		},
+		{
+			input:          ">2\r\n+message\r\n$5\r\nhello\r\n",
+			expectedOutput: PushReply{"message", "hello"},
+		},
+		// Attributes are dropped, leaving the value that they describe
+		{
+			input:          "|1\r\n+ttl\r\n:3600\r\n*1\r\n:1\r\n",
+			expectedOutput: []any{int64(1)},
+		},
+	} {
+		t.Run(fmt.Sprintf("input %q should read as %v", tc.input, tc.expectedOutput), func(t *testing.T) {
+			res, size, err := readValueFromString(tc.input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, res)
+			assert.Equal(t, len(tc.input), size)
+		})
+	}
+}
+
+func mustParseBigInt(t *testing.T, s string) *big.Int {
+	value, ok := new(big.Int).SetString(s, 10)
+	require.True(t, ok)
+	return value
+}
+
+func TestReadPipelinedCommands(t *testing.T) {
+	reader := NewReader(strings.NewReader("*1\r\n$4\r\nPING\r\n*2\r\n$3\r\nGET\r\n$3\r\na\nb\r\n"))
+
+	args, size, err := reader.ReadCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []any{"PING"}, args)
+	assert.Equal(t, 14, size)
+
+	args, size, err = reader.ReadCommand()
+	require.NoError(t, err)
+	assert.Equal(t, []any{"GET", "a\nb"}, args)
+	assert.Equal(t, 22, size)
+
+	_, _, err = reader.ReadCommand()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestReadInlineCommand(t *testing.T) {
+	for _, tc := range []struct {
+		input          string
+		expectedOutput []any
+	}{
+		{
+			input:          "PING\r\n",
+			expectedOutput: []any{"PING"},
+		},
+		{
+			input:          "SET foo bar\n",
+			expectedOutput: []any{"SET", "foo", "bar"},
+		},
+		{
+			input:          "  SET\tfoo   bar  \r\n",
+			expectedOutput: []any{"SET", "foo", "bar"},
+		},
+		{
+			input:          "\r\n",
+			expectedOutput: []any{},
+		},
+		{
+			input:          "+PING\r\n",
+			expectedOutput: []any{"+PING"},
+		},
+		{
+			input:          "SET \"a\\tb\\x41\\\"\" 'c\\'d' \"\"\r\n",
+			expectedOutput: []any{"SET", "a\tbA\"", "c'd", ""},
+		},
+		{
+			input:          "SET foo\"bar baz\" x\r\n",
+			expectedOutput: []any{"SET", "foobar baz", "x"},
+		},
+	} {
+		t.Run(fmt.Sprintf("inline command %q should read as %q", tc.input, tc.expectedOutput), func(t *testing.T) {
+			args, size, err := NewReader(strings.NewReader(tc.input)).ReadCommand()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedOutput, args)
+			assert.Equal(t, len(tc.input), size)
+		})
+	}
+
+	t.Run("inline and RESP commands can be pipelined together", func(t *testing.T) {
+		reader := NewReader(strings.NewReader("PING\n*2\r\n$3\r\nGET\r\n$1\r\na\r\nGET b\r\n"))
+		for _, expectedArgs := range [][]any{{"PING"}, {"GET", "a"}, {"GET", "b"}} {
+			args, _, err := reader.ReadCommand()
+			require.NoError(t, err)
+			assert.Equal(t, expectedArgs, args)
+		}
+	})
+}
+
+func TestReadRaw(t *testing.T) {
+	reader := NewReader(strings.NewReader("+FULLRESYNC abc 0\r\n*2\r\n$3\r\nGET\r\n$1\r\na\r\n"))
+
+	raw, err := reader.ReadRaw()
+	require.NoError(t, err)
+	assert.Equal(t, "+FULLRESYNC abc 0\r\n", raw)
+
+	raw, err = reader.ReadRaw()
+	require.NoError(t, err)
+	assert.Equal(t, "*2\r\n$3\r\nGET\r\n$1\r\na\r\n", raw)
+
+	// Lines that don't start with a RESP type are inline commands
+	raw, err = NewReader(strings.NewReader("SET foo bar\n")).ReadRaw()
+	require.NoError(t, err)
+	assert.Equal(t, "SET foo bar\n", raw)
+}
+
+func TestReadInvalidData(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "a bulk string that is longer than its length",
+			input: "$1\r\nab\r\n",
+		},
+		{
+			name:  "a negative bulk length",
+			input: "$-2\r\n",
+		},
+		{
+			name:  "a bulk length over the limit",
+			input: fmt.Sprintf("$%d\r\n", MaxBulkLength+1),
+		},
+		{
+			name:  "an array length over the limit",
+			input: fmt.Sprintf("*%d\r\n", MaxArrayLength+1),
+		},
+		{
+			name:  "a line over the limit",
+			input: "+" + strings.Repeat("a", MaxLineLength) + "\r\n",
+		},
+		{
+			name:  "a line that is not terminated by CRLF",
+			input: "+OK\n",
+		},
+		{
+			name:  "an unknown type",
+			input: "?1\r\n",
+		},
+		{
+			name:  "an invalid boolean",
+			input: "#x\r\n",
+		},
+		{
+			name:  "an invalid double",
+			input: ",1.2.3\r\n",
+		},
+		{
+			name:  "an invalid big number",
+			input: "(12a\r\n",
+		},
+		{
+			name:  "a verbatim string without a format",
+			input: "=2\r\nab\r\n",
+		},
+	} {
+		t.Run(fmt.Sprintf("should fail to read %s", tc.name), func(t *testing.T) {
+			_, _, err := readValueFromString(tc.input)
+			assert.ErrorIs(t, err, ErrProtocol)
+		})
+	}
+
+	t.Run("should only read arrays of bulk strings as commands", func(t *testing.T) {
+		for _, input := range []string{"*1\r\n+PING\r\n", "*1\r\n:1\r\n", "*1\r\n$-1\r\n", "SET a \"b\r\n", "SET 'it''s'\r\n"} {
+			_, _, err := NewReader(strings.NewReader(input)).ReadCommand()
+			assert.ErrorIs(t, err, ErrProtocol)
+		}
+	})
+}
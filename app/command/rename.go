@@ -0,0 +1,79 @@
+package command
+
+import (
+	"fmt"
+)
+
+// Rename moves the value at Key to NewKey, replacing anything that was already at NewKey
+type Rename struct {
+	Key    string
+	NewKey string
+}
+
+func (rename Rename) String() string {
+	return fmt.Sprintf("RENAME: %q -> %q", rename.Key, rename.NewKey)
+}
+
+func (rename Rename) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(RenameCmd), rename.Key, rename.NewKey})
+}
+
+func (Rename) CommandType() CommandType {
+	return RenameCmd
+}
+
+func (Rename) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toRename(data []any) (Rename, error) {
+	keys, err := toRenameKeys(RenameCmd, data)
+	if err != nil {
+		return Rename{}, err
+	}
+	return Rename{Key: keys[0], NewKey: keys[1]}, nil
+}
+
+// RenameNX moves the value at Key to NewKey, but only if NewKey doesn't exist yet
+type RenameNX struct {
+	Key    string
+	NewKey string
+}
+
+func (rename RenameNX) String() string {
+	return fmt.Sprintf("RENAMENX: %q -> %q", rename.Key, rename.NewKey)
+}
+
+func (rename RenameNX) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(RenameNXCmd), rename.Key, rename.NewKey})
+}
+
+func (RenameNX) CommandType() CommandType {
+	return RenameNXCmd
+}
+
+func (RenameNX) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toRenameNX(data []any) (RenameNX, error) {
+	keys, err := toRenameKeys(RenameNXCmd, data)
+	if err != nil {
+		return RenameNX{}, err
+	}
+	return RenameNX{Key: keys[0], NewKey: keys[1]}, nil
+}
+
+func toRenameKeys(cmdType CommandType, data []any) ([]string, error) {
+	if len(data) != 2 {
+		return nil, wrongNumberOfArgs(cmdType)
+	}
+
+	keys, err := toStrings(data)
+	if err != nil {
+		return nil, fmt.Errorf("expected the keys of the %s command to be strings: %w", cmdType, err)
+	}
+	return keys, nil
+}
@@ -27,6 +27,10 @@ func (ReplConf) CommandType() CommandType {
 	return ReplConfCmd
 }
 
+func (ReplConf) Flags() CommandFlags {
+	return 0
+}
+
 func (conf ReplConf) IsGetAck() bool {
 	if len(conf.Payload) != 2 {
 		return false
@@ -0,0 +1,63 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ReplicaOf changes the replication role of a node at runtime. `REPLICAOF host port` makes the node a replica
+// of the provided master while `REPLICAOF NO ONE` turns a replica into a master. SLAVEOF is an alias of REPLICAOF
+type ReplicaOf struct {
+	Host string
+	Port string
+}
+
+func (r ReplicaOf) String() string {
+	return fmt.Sprintf("REPLICAOF %s %s", r.Host, r.Port)
+}
+
+func (r ReplicaOf) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(ReplicaOfCmd), r.Host, r.Port})
+}
+
+func (ReplicaOf) CommandType() CommandType {
+	return ReplicaOfCmd
+}
+
+func (ReplicaOf) Flags() CommandFlags {
+	return 0
+}
+
+// IsNoOne is true for `REPLICAOF NO ONE`, which promotes a replica to a master
+func (r ReplicaOf) IsNoOne() bool {
+	return strings.EqualFold(r.Host, "no") && strings.EqualFold(r.Port, "one")
+}
+
+func toReplicaOf(data []any) (ReplicaOf, error) {
+	if len(data) != 2 {
+		return ReplicaOf{}, wrongNumberOfArgs(ReplicaOfCmd)
+	}
+
+	host, ok := data[0].(string)
+	if !ok {
+		return ReplicaOf{}, fmt.Errorf("expected REPLICAOF host to be a string, but got %v", data[0])
+	}
+
+	port, ok := data[1].(string)
+	if !ok {
+		return ReplicaOf{}, fmt.Errorf("expected REPLICAOF port to be a string, but got %v", data[1])
+	}
+
+	replicaOf := ReplicaOf{Host: host, Port: port}
+	if replicaOf.IsNoOne() {
+		return replicaOf, nil
+	}
+
+	if portNum, err := strconv.Atoi(port); err != nil || portNum < 0 || portNum > 65535 {
+		return ReplicaOf{}, ErrorReply("ERR Invalid master port")
+	}
+
+	return replicaOf, nil
+}
@@ -0,0 +1,36 @@
+package command
+
+// The RESP protocol versions that a client can negotiate with HELLO. Clients start out using RESP2
+const (
+	RESP2 = 2
+	RESP3 = 3
+)
+
+// Null is the RESP3 null. Under RESP2 it's encoded as a null bulk string
+type Null struct{}
+
+// NullArray is the RESP3 null for replies that would otherwise be an array. Under RESP2 it's encoded as a null array
+type NullArray struct{}
+
+// MapReply is a RESP3 map. The entries keep the order they were read or added in. Under RESP2 it's encoded as a
+// flat array of alternating keys and values
+type MapReply []MapEntry
+
+type MapEntry struct {
+	Key   any
+	Value any
+}
+
+// SetReply is a RESP3 set. Under RESP2 it's encoded as an array
+type SetReply []any
+
+// PushReply is a RESP3 push message, sent to a client without it having sent a command. Under RESP2 it's encoded
+// as an array
+type PushReply []any
+
+// VerbatimString is a RESP3 string that comes with a three letter format such as "txt" or "mkd". Under RESP2 only
+// the text is encoded, as a bulk string
+type VerbatimString struct {
+	Format string
+	Text   string
+}
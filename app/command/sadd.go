@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SAdd adds Members to the set stored at Key, creating the set if it doesn't exist.
+// `SADD key member [member ...]`
+type SAdd struct {
+	Key     string
+	Members []string
+}
+
+func (sadd SAdd) String() string {
+	return fmt.Sprintf("SADD: %q %q", sadd.Key, strings.Join(sadd.Members, " "))
+}
+
+func (sadd SAdd) EncodedCommand() (string, error) {
+	cmdList := []any{string(SAddCmd), sadd.Key}
+	for _, member := range sadd.Members {
+		cmdList = append(cmdList, member)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SAdd) CommandType() CommandType {
+	return SAddCmd
+}
+
+func (SAdd) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toSAdd(data []any) (SAdd, error) {
+	if len(data) < 2 {
+		return SAdd{}, wrongNumberOfArgs(SAddCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SAdd{}, fmt.Errorf("expected the inputs to the SADD command to be strings: %w", err)
+	}
+
+	return SAdd{Key: args[0], Members: args[1:]}, nil
+}
@@ -0,0 +1,79 @@
+package command
+
+type Save struct{}
+
+func (Save) String() string {
+	return "SAVE"
+}
+
+func (Save) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SaveCmd)})
+}
+
+func (Save) CommandType() CommandType {
+	return SaveCmd
+}
+
+func (Save) Flags() CommandFlags {
+	return 0
+}
+
+func toSave(data []any) (Save, error) {
+	if len(data) != 0 {
+		return Save{}, wrongNumberOfArgs(SaveCmd)
+	}
+	return Save{}, nil
+}
+
+type BgSave struct{}
+
+func (BgSave) String() string {
+	return "BGSAVE"
+}
+
+func (BgSave) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(BgSaveCmd)})
+}
+
+func (BgSave) CommandType() CommandType {
+	return BgSaveCmd
+}
+
+func (BgSave) Flags() CommandFlags {
+	return 0
+}
+
+func toBgSave(data []any) (BgSave, error) {
+	if len(data) != 0 {
+		return BgSave{}, wrongNumberOfArgs(BgSaveCmd)
+	}
+	return BgSave{}, nil
+}
+
+type LastSave struct{}
+
+func (LastSave) String() string {
+	return "LASTSAVE"
+}
+
+func (LastSave) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(LastSaveCmd)})
+}
+
+func (LastSave) CommandType() CommandType {
+	return LastSaveCmd
+}
+
+func (LastSave) Flags() CommandFlags {
+	return 0
+}
+
+func toLastSave(data []any) (LastSave, error) {
+	if len(data) != 0 {
+		return LastSave{}, wrongNumberOfArgs(LastSaveCmd)
+	}
+	return LastSave{}, nil
+}
@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+)
+
+// SCard returns the number of members in the set stored at Key
+type SCard struct {
+	Key string
+}
+
+func (s SCard) String() string {
+	return fmt.Sprintf("SCARD: %q", s.Key)
+}
+
+func (s SCard) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SCardCmd), s.Key})
+}
+
+func (SCard) CommandType() CommandType {
+	return SCardCmd
+}
+
+func (SCard) Flags() CommandFlags {
+	return 0
+}
+
+func toSCard(data []any) (SCard, error) {
+	if len(data) != 1 {
+		return SCard{}, wrongNumberOfArgs(SCardCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return SCard{}, fmt.Errorf("expected the key of the SCARD command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+	return SCard{Key: key}, nil
+}
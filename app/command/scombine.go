@@ -0,0 +1,125 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetOperation is how the sets stored at several keys are combined into one
+type SetOperation string
+
+const (
+	// The members that are in every one of the sets
+	SetIntersection SetOperation = "INTER"
+	// The members that are in any of the sets
+	SetUnion SetOperation = "UNION"
+	// The members of the first set that aren't in any of the others
+	SetDifference SetOperation = "DIFF"
+)
+
+// SCombine returns the members of the sets stored at Keys combined into one. It covers SINTER, SUNION and SDIFF,
+// which take the intersection, union and difference of the sets. Cmd is the one that was sent.
+// `SINTER key [key ...]`
+type SCombine struct {
+	Cmd  CommandType
+	Keys []string
+}
+
+func (s SCombine) String() string {
+	return fmt.Sprintf("%s: %q", strings.ToUpper(string(s.Cmd)), s.Keys)
+}
+
+func (s SCombine) EncodedCommand() (string, error) {
+	cmdList := []any{string(s.Cmd)}
+	for _, key := range s.Keys {
+		cmdList = append(cmdList, key)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (s SCombine) CommandType() CommandType {
+	return s.Cmd
+}
+
+func (SCombine) Flags() CommandFlags {
+	return 0
+}
+
+// Operation is how the sets are combined
+func (s SCombine) Operation() SetOperation {
+	return setOperationOf(s.Cmd)
+}
+
+func toSCombine(cmdType CommandType, data []any) (SCombine, error) {
+	if len(data) < 1 {
+		return SCombine{}, wrongNumberOfArgs(cmdType)
+	}
+
+	keys, err := toStrings(data)
+	if err != nil {
+		return SCombine{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	return SCombine{Cmd: cmdType, Keys: keys}, nil
+}
+
+// SCombineStore combines the sets stored at Keys like SCombine, but stores the result at Destination instead of
+// returning it. It covers SINTERSTORE, SUNIONSTORE and SDIFFSTORE. Cmd is the one that was sent.
+// `SINTERSTORE destination key [key ...]`
+type SCombineStore struct {
+	Cmd         CommandType
+	Destination string
+	Keys        []string
+}
+
+func (s SCombineStore) String() string {
+	return fmt.Sprintf("%s: %q into %q", strings.ToUpper(string(s.Cmd)), s.Keys, s.Destination)
+}
+
+func (s SCombineStore) EncodedCommand() (string, error) {
+	cmdList := []any{string(s.Cmd), s.Destination}
+	for _, key := range s.Keys {
+		cmdList = append(cmdList, key)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (s SCombineStore) CommandType() CommandType {
+	return s.Cmd
+}
+
+func (SCombineStore) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// Operation is how the sets are combined
+func (s SCombineStore) Operation() SetOperation {
+	return setOperationOf(s.Cmd)
+}
+
+func toSCombineStore(cmdType CommandType, data []any) (SCombineStore, error) {
+	if len(data) < 2 {
+		return SCombineStore{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SCombineStore{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	return SCombineStore{Cmd: cmdType, Destination: args[0], Keys: args[1:]}, nil
+}
+
+func setOperationOf(cmdType CommandType) SetOperation {
+	switch cmdType {
+	case SInterCmd, SInterStoreCmd:
+		return SetIntersection
+	case SUnionCmd, SUnionStoreCmd:
+		return SetUnion
+	}
+	return SetDifference
+}
@@ -4,25 +4,57 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
+// SetCondition makes SET only store its value depending on whether the key already exists
+type SetCondition string
+
+const (
+	SetAlways SetCondition = ""
+	// SetIfMissing only stores the value if the key doesn't exist
+	SetIfMissing SetCondition = "NX"
+	// SetIfExists only stores the value if the key already exists
+	SetIfExists SetCondition = "XX"
+)
+
+// Set stores a string value at a key.
+// `SET key value [NX | XX] [GET] [EX seconds | PX milliseconds | EXAT unix-time-seconds | PXAT unix-time-milliseconds | KEEPTTL]`
 type Set struct {
 	KeyPayload   string
-	ValuePayload any
+	ValuePayload []byte
 
-	// Set a lifetime for the existence of this key value
-	ExpiryTimeMs int64
+	// Set a lifetime for the existence of this key value. The unit and meaning of ExpiryTime depends on ExpiryOption
+	ExpiryOption ExpiryOption
+	ExpiryTime   int64
+
+	Condition SetCondition
+
+	// Reply with the string that was stored at the key before, instead of OK
+	Get bool
 }
 
 func (set Set) String() string {
-	return fmt.Sprintf("SET: (%q -> %v) with expiration %d", set.KeyPayload, set.ValuePayload, set.ExpiryTimeMs)
+	return fmt.Sprintf(
+		"SET: (%q -> %q) with expiration %s %d, condition %q, get %t",
+		set.KeyPayload, set.ValuePayload, set.ExpiryOption, set.ExpiryTime, set.Condition, set.Get,
+	)
 }
 
 func (set Set) EncodedCommand() (string, error) {
-	// TODO: This only handles string value payloads properly
 	cmdList := []any{string(SetCmd), set.KeyPayload, set.ValuePayload}
-	if set.ExpiryTimeMs != 0 {
-		cmdList = append(cmdList, "px", fmt.Sprintf("%d", set.ExpiryTimeMs))
+	if set.Condition != SetAlways {
+		cmdList = append(cmdList, string(set.Condition))
+	}
+	if set.Get {
+		cmdList = append(cmdList, "GET")
+	}
+	switch set.ExpiryOption {
+	case NoExpiry:
+	case KeepTTL:
+		cmdList = append(cmdList, string(KeepTTL))
+	default:
+		cmdList = append(cmdList, string(set.ExpiryOption), strconv.FormatInt(set.ExpiryTime, 10))
 	}
 
 	e := Encoder{UseBulkStrings: true}
@@ -33,43 +65,133 @@ func (Set) CommandType() CommandType {
 	return SetCmd
 }
 
-// TODO: Should clean up this function
+func (Set) Flags() CommandFlags {
+	return WriteFlag
+}
+
+// ExpiresAt works out the absolute time that the value should expire at. It should only be used if the expiry
+// option has a time
+func (set Set) ExpiresAt(now time.Time) (time.Time, error) {
+	return set.ExpiryOption.expiresAt(now, set.ExpiryTime, SetCmd)
+}
+
 func toSet(data []any) (Set, error) {
-	if len(data) != 2 && len(data) != 4 {
-		return Set{}, fmt.Errorf("expected 1 key and 1 value entry to follow SET command but got %d entries: %v", len(data), data)
+	if len(data) < 2 {
+		return Set{}, wrongNumberOfArgs(SetCmd)
 	}
 
-	rawKey := data[0]
-	key, ok := rawKey.(string)
-	if !ok {
-		return Set{}, fmt.Errorf("expected the first element in the SET command to be a string key but it was %[1]v of type %[1]v", rawKey)
+	args, err := toStrings(data)
+	if err != nil {
+		return Set{}, fmt.Errorf("expected the inputs to the SET command to be strings: %w", err)
 	}
+	set := Set{KeyPayload: args[0], ValuePayload: []byte(args[1])}
 
-	// TODO: If there are more of these flags, I should make a better system for handling these
-	// For now just hard code a check for the px flag
-	timeout := int64(0)
-	if len(data) == 4 {
-		rawFlag := data[2]
-		rawFlagValue := data[3]
+	for i := 2; i < len(args); i++ {
+		switch option := strings.ToUpper(args[i]); option {
+		case string(SetIfMissing), string(SetIfExists):
+			if set.Condition != SetAlways && set.Condition != SetCondition(option) {
+				return Set{}, ErrSyntax
+			}
+			set.Condition = SetCondition(option)
+		case "GET":
+			set.Get = true
+		case string(KeepTTL):
+			if set.ExpiryOption != NoExpiry && set.ExpiryOption != KeepTTL {
+				return Set{}, ErrSyntax
+			}
+			set.ExpiryOption = KeepTTL
+		case string(ExpiryEX), string(ExpiryPX), string(ExpiryEXAT), string(ExpiryPXAT):
+			if (set.ExpiryOption != NoExpiry && set.ExpiryOption != ExpiryOption(option)) || i+1 == len(args) {
+				return Set{}, ErrSyntax
+			}
 
-		if flag, ok := rawFlag.(string); !ok || strings.ToLower(flag) != "px" {
-			return Set{}, fmt.Errorf("received invalid parameter for SET operation. flag %[1]v of type %[1]v is not a known option", rawFlag)
-		}
-		rawTimeoutStr, ok := rawFlagValue.(string)
-		if !ok {
-			return Set{}, fmt.Errorf("expected the value of the set PX option but it was %[1]v of type %[1]v", rawFlagValue)
-		}
+			expiryTime, err := parseExpiryTime(SetCmd, args[i+1])
+			if err != nil {
+				return Set{}, err
+			}
 
-		var err error
-		timeout, err = strconv.ParseInt(rawTimeoutStr, 10, 64)
-		if err != nil {
-			return Set{}, fmt.Errorf("expected the value of the SET - PX option to be an int64 but it was %q", rawTimeoutStr)
+			set.ExpiryOption, set.ExpiryTime = ExpiryOption(option), expiryTime
+			i++
+		default:
+			return Set{}, ErrSyntax
 		}
 	}
 
-	return Set{
-		KeyPayload:   key,
-		ValuePayload: data[1],
-		ExpiryTimeMs: timeout,
-	}, nil
+	return set, nil
+}
+
+// toSetEx parses `SETEX key seconds value` and `PSETEX key milliseconds value`, which are the same as a SET with
+// the EX or PX option
+func toSetEx(cmdType CommandType, data []any) (Set, error) {
+	if len(data) != 3 {
+		return Set{}, wrongNumberOfArgs(cmdType)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Set{}, fmt.Errorf("expected the inputs to the %s command to be strings: %w", cmdType, err)
+	}
+
+	expiryTime, err := parseExpiryTime(cmdType, args[1])
+	if err != nil {
+		return Set{}, err
+	}
+
+	expiryOption := ExpiryEX
+	if cmdType == PSetExCmd {
+		expiryOption = ExpiryPX
+	}
+
+	return Set{KeyPayload: args[0], ValuePayload: []byte(args[2]), ExpiryOption: expiryOption, ExpiryTime: expiryTime}, nil
+}
+
+// toGetSet parses `GETSET key value`, which is the same as a SET with the GET option
+func toGetSet(data []any) (Set, error) {
+	if len(data) != 2 {
+		return Set{}, wrongNumberOfArgs(GetSetCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return Set{}, fmt.Errorf("expected the inputs to the GETSET command to be strings: %w", err)
+	}
+
+	return Set{KeyPayload: args[0], ValuePayload: []byte(args[1]), Get: true}, nil
+}
+
+// SetNX stores a string value at a key if the key doesn't exist. Unlike SET with NX, it replies with whether the
+// value was stored
+type SetNX struct {
+	Key   string
+	Value []byte
+}
+
+func (set SetNX) String() string {
+	return fmt.Sprintf("SETNX: (%q -> %q)", set.Key, set.Value)
+}
+
+func (set SetNX) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SetNXCmd), set.Key, set.Value})
+}
+
+func (SetNX) CommandType() CommandType {
+	return SetNXCmd
+}
+
+func (SetNX) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toSetNX(data []any) (SetNX, error) {
+	if len(data) != 2 {
+		return SetNX{}, wrongNumberOfArgs(SetNXCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SetNX{}, fmt.Errorf("expected the inputs to the SETNX command to be strings: %w", err)
+	}
+
+	return SetNX{Key: args[0], Value: []byte(args[1])}, nil
 }
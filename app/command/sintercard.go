@@ -0,0 +1,74 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SInterCard returns the number of members in the intersection of the sets stored at Keys. A Limit other than 0
+// stops counting once the count reaches it.
+// `SINTERCARD numkeys key [key ...] [LIMIT limit]`
+type SInterCard struct {
+	Keys  []string
+	Limit int64
+}
+
+func (s SInterCard) String() string {
+	return fmt.Sprintf("SINTERCARD: %q limit %d", s.Keys, s.Limit)
+}
+
+func (s SInterCard) EncodedCommand() (string, error) {
+	cmdList := []any{string(SInterCardCmd), strconv.Itoa(len(s.Keys))}
+	for _, key := range s.Keys {
+		cmdList = append(cmdList, key)
+	}
+	if s.Limit != 0 {
+		cmdList = append(cmdList, "LIMIT", strconv.FormatInt(s.Limit, 10))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SInterCard) CommandType() CommandType {
+	return SInterCardCmd
+}
+
+func (SInterCard) Flags() CommandFlags {
+	return 0
+}
+
+func toSInterCard(data []any) (SInterCard, error) {
+	if len(data) < 2 {
+		return SInterCard{}, wrongNumberOfArgs(SInterCardCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SInterCard{}, fmt.Errorf("expected the inputs to the SINTERCARD command to be strings: %w", err)
+	}
+
+	numKeys, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || numKeys <= 0 {
+		return SInterCard{}, ErrorReply("ERR numkeys should be greater than 0")
+	}
+	if numKeys > int64(len(args)-1) {
+		return SInterCard{}, ErrorReply("ERR Number of keys can't be greater than number of args")
+	}
+
+	sintercard := SInterCard{Keys: args[1 : numKeys+1]}
+	for i := numKeys + 1; i < int64(len(args)); i++ {
+		if strings.ToUpper(args[i]) != "LIMIT" || i+1 == int64(len(args)) {
+			return SInterCard{}, ErrSyntax
+		}
+
+		sintercard.Limit, err = strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil || sintercard.Limit < 0 {
+			return SInterCard{}, ErrorReply("ERR LIMIT can't be negative")
+		}
+		i++
+	}
+
+	return sintercard, nil
+}
@@ -0,0 +1,85 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SIsMember returns whether Member is in the set stored at Key.
+// `SISMEMBER key member`
+type SIsMember struct {
+	Key    string
+	Member string
+}
+
+func (s SIsMember) String() string {
+	return fmt.Sprintf("SISMEMBER: %q %q", s.Key, s.Member)
+}
+
+func (s SIsMember) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SIsMemberCmd), s.Key, s.Member})
+}
+
+func (SIsMember) CommandType() CommandType {
+	return SIsMemberCmd
+}
+
+func (SIsMember) Flags() CommandFlags {
+	return 0
+}
+
+func toSIsMember(data []any) (SIsMember, error) {
+	if len(data) != 2 {
+		return SIsMember{}, wrongNumberOfArgs(SIsMemberCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SIsMember{}, fmt.Errorf("expected the inputs to the SISMEMBER command to be strings: %w", err)
+	}
+
+	return SIsMember{Key: args[0], Member: args[1]}, nil
+}
+
+// SMIsMember returns whether each of Members is in the set stored at Key.
+// `SMISMEMBER key member [member ...]`
+type SMIsMember struct {
+	Key     string
+	Members []string
+}
+
+func (s SMIsMember) String() string {
+	return fmt.Sprintf("SMISMEMBER: %q %q", s.Key, strings.Join(s.Members, " "))
+}
+
+func (s SMIsMember) EncodedCommand() (string, error) {
+	cmdList := []any{string(SMIsMemberCmd), s.Key}
+	for _, member := range s.Members {
+		cmdList = append(cmdList, member)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SMIsMember) CommandType() CommandType {
+	return SMIsMemberCmd
+}
+
+func (SMIsMember) Flags() CommandFlags {
+	return 0
+}
+
+func toSMIsMember(data []any) (SMIsMember, error) {
+	if len(data) < 2 {
+		return SMIsMember{}, wrongNumberOfArgs(SMIsMemberCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SMIsMember{}, fmt.Errorf("expected the inputs to the SMISMEMBER command to be strings: %w", err)
+	}
+
+	return SMIsMember{Key: args[0], Members: args[1:]}, nil
+}
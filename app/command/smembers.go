@@ -0,0 +1,39 @@
+package command
+
+import (
+	"fmt"
+)
+
+// SMembers returns every member of the set stored at Key
+type SMembers struct {
+	Key string
+}
+
+func (s SMembers) String() string {
+	return fmt.Sprintf("SMEMBERS: %q", s.Key)
+}
+
+func (s SMembers) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SMembersCmd), s.Key})
+}
+
+func (SMembers) CommandType() CommandType {
+	return SMembersCmd
+}
+
+func (SMembers) Flags() CommandFlags {
+	return 0
+}
+
+func toSMembers(data []any) (SMembers, error) {
+	if len(data) != 1 {
+		return SMembers{}, wrongNumberOfArgs(SMembersCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return SMembers{}, fmt.Errorf("expected the key of the SMEMBERS command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+	return SMembers{Key: key}, nil
+}
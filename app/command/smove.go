@@ -0,0 +1,44 @@
+package command
+
+import (
+	"fmt"
+)
+
+// SMove moves Member from the set stored at Source to the set stored at Destination, creating the destination set
+// if it doesn't exist.
+// `SMOVE source destination member`
+type SMove struct {
+	Source      string
+	Destination string
+	Member      string
+}
+
+func (s SMove) String() string {
+	return fmt.Sprintf("SMOVE: %q from %q to %q", s.Member, s.Source, s.Destination)
+}
+
+func (s SMove) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(SMoveCmd), s.Source, s.Destination, s.Member})
+}
+
+func (SMove) CommandType() CommandType {
+	return SMoveCmd
+}
+
+func (SMove) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toSMove(data []any) (SMove, error) {
+	if len(data) != 3 {
+		return SMove{}, wrongNumberOfArgs(SMoveCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SMove{}, fmt.Errorf("expected the inputs to the SMOVE command to be strings: %w", err)
+	}
+
+	return SMove{Source: args[0], Destination: args[1], Member: args[2]}, nil
+}
@@ -0,0 +1,66 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SPop removes random members from the set stored at Key and returns them. Without a count, a single member is
+// returned rather than an array.
+// `SPOP key [count]`
+type SPop struct {
+	Key       string
+	Count     int64
+	WithCount bool
+}
+
+func (s SPop) String() string {
+	if !s.WithCount {
+		return fmt.Sprintf("SPOP: %q", s.Key)
+	}
+	return fmt.Sprintf("SPOP: %q count %d", s.Key, s.Count)
+}
+
+func (s SPop) EncodedCommand() (string, error) {
+	cmdList := []any{string(SPopCmd), s.Key}
+	if s.WithCount {
+		cmdList = append(cmdList, strconv.FormatInt(s.Count, 10))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SPop) CommandType() CommandType {
+	return SPopCmd
+}
+
+func (SPop) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toSPop(data []any) (SPop, error) {
+	if len(data) < 1 {
+		return SPop{}, wrongNumberOfArgs(SPopCmd)
+	}
+	if len(data) > 2 {
+		return SPop{}, ErrSyntax
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SPop{}, fmt.Errorf("expected the inputs to the SPOP command to be strings: %w", err)
+	}
+	spop := SPop{Key: args[0], Count: 1}
+	if len(args) == 1 {
+		return spop, nil
+	}
+
+	spop.Count, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil || spop.Count < 0 {
+		return SPop{}, ErrorReply("ERR value is out of range, must be positive")
+	}
+	spop.WithCount = true
+
+	return spop, nil
+}
@@ -0,0 +1,71 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SRandMember returns random members of the set stored at Key. Without a count, a single member is returned rather
+// than an array. A positive count returns up to that many distinct members, while a negative count returns exactly
+// that many members, which can repeat.
+// `SRANDMEMBER key [count]`
+type SRandMember struct {
+	Key       string
+	Count     int64
+	WithCount bool
+}
+
+func (s SRandMember) String() string {
+	if !s.WithCount {
+		return fmt.Sprintf("SRANDMEMBER: %q", s.Key)
+	}
+	return fmt.Sprintf("SRANDMEMBER: %q count %d", s.Key, s.Count)
+}
+
+func (s SRandMember) EncodedCommand() (string, error) {
+	cmdList := []any{string(SRandMemberCmd), s.Key}
+	if s.WithCount {
+		cmdList = append(cmdList, strconv.FormatInt(s.Count, 10))
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SRandMember) CommandType() CommandType {
+	return SRandMemberCmd
+}
+
+func (SRandMember) Flags() CommandFlags {
+	return 0
+}
+
+func toSRandMember(data []any) (SRandMember, error) {
+	if len(data) < 1 {
+		return SRandMember{}, wrongNumberOfArgs(SRandMemberCmd)
+	}
+	if len(data) > 2 {
+		return SRandMember{}, ErrSyntax
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SRandMember{}, fmt.Errorf("expected the inputs to the SRANDMEMBER command to be strings: %w", err)
+	}
+	srandmember := SRandMember{Key: args[0], Count: 1}
+	if len(args) == 1 {
+		return srandmember, nil
+	}
+
+	srandmember.Count, err = strconv.ParseInt(args[1], 10, 64)
+	if err != nil {
+		return SRandMember{}, ErrNotInteger
+	}
+	srandmember.WithCount = true
+
+	if srandmember.Count < -maxRandomRepeats {
+		return SRandMember{}, ErrorReply("ERR value is out of range")
+	}
+
+	return srandmember, nil
+}
@@ -0,0 +1,48 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SRem removes Members from the set stored at Key. The set is deleted once it has no members left.
+// `SREM key member [member ...]`
+type SRem struct {
+	Key     string
+	Members []string
+}
+
+func (srem SRem) String() string {
+	return fmt.Sprintf("SREM: %q %q", srem.Key, strings.Join(srem.Members, " "))
+}
+
+func (srem SRem) EncodedCommand() (string, error) {
+	cmdList := []any{string(SRemCmd), srem.Key}
+	for _, member := range srem.Members {
+		cmdList = append(cmdList, member)
+	}
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SRem) CommandType() CommandType {
+	return SRemCmd
+}
+
+func (SRem) Flags() CommandFlags {
+	return WriteFlag
+}
+
+func toSRem(data []any) (SRem, error) {
+	if len(data) < 2 {
+		return SRem{}, wrongNumberOfArgs(SRemCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SRem{}, fmt.Errorf("expected the inputs to the SREM command to be strings: %w", err)
+	}
+
+	return SRem{Key: args[0], Members: args[1:]}, nil
+}
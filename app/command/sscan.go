@@ -0,0 +1,66 @@
+package command
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// SScan iterates over the members of the set stored at Key. Each call returns some of the members along with the
+// cursor to continue from, and the iteration is complete once the returned cursor is 0.
+// `SSCAN key cursor [MATCH pattern] [COUNT count]`
+type SScan struct {
+	Key    string
+	Cursor uint64
+
+	// Only return the members that match this glob-style pattern. "*" matches every member
+	Match string
+
+	// A hint for how many members to look at
+	Count int64
+}
+
+func (s SScan) String() string {
+	return fmt.Sprintf("SSCAN: %q cursor %d match %q count %d", s.Key, s.Cursor, s.Match, s.Count)
+}
+
+func (s SScan) EncodedCommand() (string, error) {
+	cmdList := []any{string(SScanCmd), s.Key, strconv.FormatUint(s.Cursor, 10)}
+	cmdList = append(cmdList, encodeScanOptions(s.Match, s.Count)...)
+
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray(cmdList)
+}
+
+func (SScan) CommandType() CommandType {
+	return SScanCmd
+}
+
+func (SScan) Flags() CommandFlags {
+	return 0
+}
+
+func toSScan(data []any) (SScan, error) {
+	if len(data) < 2 {
+		return SScan{}, wrongNumberOfArgs(SScanCmd)
+	}
+
+	args, err := toStrings(data)
+	if err != nil {
+		return SScan{}, fmt.Errorf("expected the inputs to the SSCAN command to be strings: %w", err)
+	}
+
+	cursor, err := parseScanCursor(args[1])
+	if err != nil {
+		return SScan{}, err
+	}
+	sscan := SScan{Key: args[0], Cursor: cursor, Match: "*", Count: defaultScanCount}
+
+	for i := 2; i < len(args); i++ {
+		i, err = parseScanOption(args, i, &sscan.Match, &sscan.Count)
+		if err != nil {
+			return SScan{}, err
+		}
+	}
+
+	return sscan, nil
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"fmt"
+)
+
+// StrLen returns the length of the string stored at a key
+type StrLen struct {
+	Key string
+}
+
+func (s StrLen) String() string {
+	return fmt.Sprintf("STRLEN: %q", s.Key)
+}
+
+func (s StrLen) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(StrLenCmd), s.Key})
+}
+
+func (StrLen) CommandType() CommandType {
+	return StrLenCmd
+}
+
+func (StrLen) Flags() CommandFlags {
+	return 0
+}
+
+func toStrLen(data []any) (StrLen, error) {
+	if len(data) != 1 {
+		return StrLen{}, wrongNumberOfArgs(StrLenCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return StrLen{}, fmt.Errorf("expected the key of the STRLEN command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+
+	return StrLen{Key: key}, nil
+}
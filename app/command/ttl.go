@@ -0,0 +1,43 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+)
+
+// TTL returns when Key expires. It covers TTL and PTTL, which return the time left in seconds or milliseconds, and
+// EXPIRETIME and PEXPIRETIME, which return the unix time in seconds or milliseconds. Cmd is the one that was sent
+type TTL struct {
+	Cmd CommandType
+	Key string
+}
+
+func (t TTL) String() string {
+	return fmt.Sprintf("%s: %q", strings.ToUpper(string(t.Cmd)), t.Key)
+}
+
+func (t TTL) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(t.Cmd), t.Key})
+}
+
+func (t TTL) CommandType() CommandType {
+	return t.Cmd
+}
+
+func (TTL) Flags() CommandFlags {
+	return 0
+}
+
+func toTTL(cmdType CommandType, data []any) (TTL, error) {
+	if len(data) != 1 {
+		return TTL{}, wrongNumberOfArgs(cmdType)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return TTL{}, fmt.Errorf("expected the key of the %s command to be a string but it was %[2]v of type %[2]T", cmdType, data[0])
+	}
+
+	return TTL{Cmd: cmdType, Key: key}, nil
+}
@@ -0,0 +1,40 @@
+package command
+
+import (
+	"fmt"
+)
+
+// Type returns the name of the type of the value stored at a key
+type Type struct {
+	Key string
+}
+
+func (t Type) String() string {
+	return fmt.Sprintf("TYPE: %q", t.Key)
+}
+
+func (t Type) EncodedCommand() (string, error) {
+	e := Encoder{UseBulkStrings: true}
+	return e.EncodeArray([]any{string(TypeCmd), t.Key})
+}
+
+func (Type) CommandType() CommandType {
+	return TypeCmd
+}
+
+func (Type) Flags() CommandFlags {
+	return 0
+}
+
+func toType(data []any) (Type, error) {
+	if len(data) != 1 {
+		return Type{}, wrongNumberOfArgs(TypeCmd)
+	}
+
+	key, ok := data[0].(string)
+	if !ok {
+		return Type{}, fmt.Errorf("expected the key of the TYPE command to be a string but it was %[1]v of type %[1]T", data[0])
+	}
+
+	return Type{Key: key}, nil
+}
@@ -13,3 +13,16 @@ func ParseIntWithPrefix(intStr string, prefix string) (int64, error) {
 	}
 	return strconv.ParseInt(trimmedStr, 10, 64)
 }
+
+// toStrings converts the arguments of a command to strings
+func toStrings(data []any) ([]string, error) {
+	strs := make([]string, 0, len(data))
+	for _, rawStr := range data {
+		str, ok := rawStr.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected %[1]v of type %[1]T to be a string", rawStr)
+		}
+		strs = append(strs, str)
+	}
+	return strs, nil
+}
@@ -30,9 +30,13 @@ func (Wait) CommandType() CommandType {
 	return WaitCmd
 }
 
+func (Wait) Flags() CommandFlags {
+	return 0
+}
+
 func toWait(data []any) (Wait, error) {
 	if len(data) != 2 {
-		return Wait{}, fmt.Errorf("expected wait to have parameters <NumReplicas> and <WaitForMs> but got: %v", data)
+		return Wait{}, wrongNumberOfArgs(WaitCmd)
 	}
 
 	rawNumReplias := data[0]
@@ -43,7 +47,7 @@ func toWait(data []any) (Wait, error) {
 
 	numReplicas, err := strconv.ParseInt(rawNumReplicasString, 10, 64)
 	if err != nil {
-		return Wait{}, fmt.Errorf("expected <NumReplicas> Wait parameter to be an integer, but got %v", rawNumReplicasString)
+		return Wait{}, ErrNotInteger
 	}
 
 	rawWaitForMs := data[1]
@@ -54,7 +58,10 @@ func toWait(data []any) (Wait, error) {
 
 	waitForMs, err := strconv.ParseInt(rawWaitForMsString, 10, 64)
 	if err != nil {
-		return Wait{}, fmt.Errorf("expected <WaitForMs> Wait parameter to be an integer, but got %v", rawWaitForMsString)
+		return Wait{}, ErrNotInteger
+	}
+	if waitForMs < 0 {
+		return Wait{}, ErrorReply("ERR timeout is negative")
 	}
 
 	return Wait{
@@ -1,6 +1,11 @@
 package connection
 
-import "net"
+import (
+	"net"
+	"sync/atomic"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
 
 type ConnectionType string
 
@@ -13,8 +18,13 @@ const (
 type Connection interface {
 	WriteString(string) (int, error)
 
+	// ReadNextCmdString reads the next RESP value off of the connection and returns it exactly as it was sent
 	ReadNextCmdString() (string, error)
 
+	// ReadCommand reads the next command off of the connection. It returns the command's arguments along with
+	// the number of bytes that the command took up on the wire
+	ReadCommand() ([]any, int, error)
+
 	ReadRDBFile() (string, error)
 
 	ConnectionType() ConnectionType
@@ -24,4 +34,36 @@ type Connection interface {
 	LocalAddr() net.Addr
 
 	Close() error
+
+	// ClientState returns the state of the client on the other end of this connection
+	ClientState() *ClientState
+}
+
+// The ID of the most recently connected client
+var lastClientID atomic.Int64
+
+// ClientState holds information about a client that needs to be kept between the commands that it sends
+type ClientState struct {
+	// A unique ID for the client, assigned in the order that clients connect
+	ID int64
+
+	// The name that the client gave itself with HELLO SETNAME
+	Name string
+
+	// The RESP version that replies to the client are encoded with. Clients start on RESP2 and can switch with HELLO
+	Protocol int
+
+	// The master's replication offset immediately after the last write command sent by this client
+	LastWriteOffset int64
+
+	// The number of bytes that the command currently being executed took up on the wire
+	CommandSize int
+}
+
+// NewClientState returns the state for a newly connected client
+func NewClientState() *ClientState {
+	return &ClientState{
+		ID:       lastClientID.Add(1),
+		Protocol: command.RESP2,
+	}
 }
@@ -28,6 +28,11 @@ func (n LogNoopConn) ReadNextCmdString() (string, error) {
 	return "", nil
 }
 
+func (n LogNoopConn) ReadCommand() ([]any, int, error) {
+	n.Logger.Info("log noop conn ReadCommand() called")
+	return nil, 0, nil
+}
+
 func (n LogNoopConn) ReadRDBFile() (string, error) {
 	n.Logger.Info("log noop conn ReadRDBFile() called")
 	return "", nil
@@ -52,3 +57,8 @@ func (n LogNoopConn) ConnectionType() ConnectionType {
 	n.Logger.Info("log noop conn ConnectionType() called")
 	return n.ConnType
 }
+
+func (n LogNoopConn) ClientState() *ClientState {
+	n.Logger.Info("log noop conn ClientState() called")
+	return &ClientState{}
+}
@@ -4,11 +4,10 @@ import (
 	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"net"
 	"strings"
 
-	"go.uber.org/zap"
-
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	"github.com/codecrafters-io/redis-starter-go/app/log"
 )
@@ -16,30 +15,40 @@ import (
 type NetworkConn struct {
 	readWriter *bufio.ReadWriter
 
+	// Decodes RESP values out of the buffered reader of readWriter
+	respReader *command.Reader
+
 	conn net.Conn
 
 	connType ConnectionType
 
 	logger log.Logger
+
+	clientState *ClientState
 }
 
+// NewNetworkConn returns a pointer to a NetworkConn so that each connection has a unique identity
+// that can be compared and used as a map key
 func NewNetworkConn(conn net.Conn, connType ConnectionType, logger log.Logger) Connection {
-	return NetworkConn{
-		readWriter: bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn)),
-		conn:       conn,
-		connType:   connType,
-		logger:     logger,
+	reader := bufio.NewReader(conn)
+	return &NetworkConn{
+		readWriter:  bufio.NewReadWriter(reader, bufio.NewWriter(conn)),
+		respReader:  command.NewReader(reader),
+		conn:        conn,
+		connType:    connType,
+		logger:      logger,
+		clientState: NewClientState(),
 	}
 }
 
 func (c NetworkConn) WriteString(data string) (int, error) {
 	numBytes, err := c.readWriter.Write([]byte(data))
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
 	if err := c.readWriter.Flush(); err != nil {
-		return 0, nil
+		return 0, err
 	}
 	return numBytes, nil
 }
@@ -55,55 +64,11 @@ func getBulkStringLength(rawStr string) (int64, error) {
 }
 
 func (c NetworkConn) ReadNextCmdString() (string, error) {
-	firstReadRes, err := c.readWriter.ReadString('\n')
-	if err != nil {
-		return "", err
-	}
-	if len(firstReadRes) == 0 {
-		return "", nil
-	}
-	c.logger.Info("reading in first part of command string", zap.String("result", firstReadRes))
-
-	switch (firstReadRes)[0] {
-	case '*':
-		var sb strings.Builder
-
-		// If we have an array string, we need to continue reading the rest of the elements
-		arraySizeStrWithPrefix := strings.TrimSuffix(firstReadRes, "\r\n")
-		arraySize, err := command.ParseIntWithPrefix(arraySizeStrWithPrefix, "*")
-		if err != nil {
-			return "", fmt.Errorf("Error parsing array size %q to int: %w", arraySizeStrWithPrefix, err)
-		}
-
-		sb.WriteString(firstReadRes)
-		for idx := range arraySize { // +1 to include the terminating \r\n
-			nextRes, err := c.ReadNextCmdString()
-			if err != nil {
-				return "", fmt.Errorf("Error reading array entry %d from a bulk string with length %d: %w", arraySize, idx, err)
-			}
-			sb.WriteString(nextRes)
-
-		}
-		return sb.String(), nil
-	case '$':
-		bulkStringSize, err := getBulkStringLength(firstReadRes)
-		if err != nil {
-			return "", fmt.Errorf("failed to parse bulk string size: %w", err)
-		}
-
-		bulkString, err := c.readWriter.ReadString('\n')
-		if err != nil {
-			return "", fmt.Errorf("error reading %d bytes of bulk string: %w", bulkStringSize, err)
-		}
-		if int(bulkStringSize)+len("\r\n") != len(bulkString) {
-			return "", fmt.Errorf("tried to read bulk string of size %d, but got %d bytes", bulkStringSize, len(bulkString))
-		}
-
-		return firstReadRes + bulkString, nil
-	default:
-	}
+	return c.respReader.ReadRaw()
+}
 
-	return firstReadRes, nil
+func (c NetworkConn) ReadCommand() ([]any, int, error) {
+	return c.respReader.ReadCommand()
 }
 
 func (c NetworkConn) ReadRDBFile() (string, error) {
@@ -122,14 +87,11 @@ func (c NetworkConn) ReadRDBFile() (string, error) {
 			return "", fmt.Errorf("failed to parse bulk string size: %w", err)
 		}
 
-		// The RDB file will not be terminated with a \r\n
+		// The RDB file will not be terminated with a \r\n. A large file may take more than one read to arrive
 		bulkStringBytes := make([]byte, bulkStringSize)
-		numBytesRead, err := c.readWriter.Read(bulkStringBytes)
+		numBytesRead, err := io.ReadFull(c.readWriter, bulkStringBytes)
 		if err != nil {
-			return "", fmt.Errorf("error reading %d bytes of bulk string: %w", bulkStringSize, err)
-		}
-		if bulkStringSize != int64(numBytesRead) {
-			return "", fmt.Errorf("tried to read bulk string of size %d, but only got %d bytes", bulkStringSize, numBytesRead)
+			return "", fmt.Errorf("tried to read bulk string of size %d, but only got %d bytes: %w", bulkStringSize, numBytesRead, err)
 		}
 
 		return firstReadRes + string(bulkStringBytes), nil
@@ -154,3 +116,7 @@ func (c NetworkConn) LocalAddr() net.Addr {
 func (c NetworkConn) Close() error {
 	return c.conn.Close()
 }
+
+func (c NetworkConn) ClientState() *ClientState {
+	return c.clientState
+}
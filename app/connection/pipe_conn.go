@@ -3,27 +3,33 @@ package connection
 import (
 	"errors"
 	"net"
+	"strings"
 	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
 )
 
 type ChannelConn struct {
-	connType ConnectionType
-	dataChan chan string
+	connType    ConnectionType
+	dataChan    chan string
+	clientState *ClientState
 }
 
 func NewChannelConn(connType ConnectionType) Connection {
 	dataChan := make(chan string)
 	return ChannelConn{
-		dataChan: dataChan,
-		connType: connType,
+		dataChan:    dataChan,
+		connType:    connType,
+		clientState: NewClientState(),
 	}
 }
 
 func NewChannelConnWithBuffer(connType ConnectionType, bufferSize int) Connection {
 	dataChan := make(chan string, bufferSize)
 	return ChannelConn{
-		dataChan: dataChan,
-		connType: connType,
+		dataChan:    dataChan,
+		connType:    connType,
+		clientState: NewClientState(),
 	}
 }
 
@@ -40,6 +46,14 @@ func (p ChannelConn) ReadNextCmdString() (string, error) {
 	return p.readFromPipe()
 }
 
+func (p ChannelConn) ReadCommand() ([]any, int, error) {
+	data, err := p.readFromPipe()
+	if err != nil {
+		return nil, 0, err
+	}
+	return command.NewReader(strings.NewReader(data)).ReadCommand()
+}
+
 func (p ChannelConn) ReadRDBFile() (string, error) {
 	return p.readFromPipe()
 }
@@ -68,3 +82,7 @@ func (p ChannelConn) LocalAddr() net.Addr {
 func (p ChannelConn) Close() error {
 	return nil
 }
+
+func (p ChannelConn) ClientState() *ClientState {
+	return p.clientState
+}
@@ -20,6 +20,12 @@ func main() {
 
 	var replicaof string
 	flag.StringVar(&replicaof, "replicaof", "", "specify the hostname and port that this instance should be a replica of")
+	dir := flag.String("dir", server.DEFAULT_RDB_DIR, "specify the directory that the RDB file is stored in")
+	dbFilename := flag.String("dbfilename", server.DEFAULT_RDB_FILENAME, "specify the name of the RDB file")
+	replBacklogSize := flag.Int("repl-backlog-size", server.DEFAULT_REPL_BACKLOG_SIZE, "specify the size in bytes of the backlog used for partial resynchronization of replicas")
+	hashMaxListpackEntries := flag.Int("hash-max-listpack-entries", server.DEFAULT_HASH_MAX_LISTPACK_ENTRIES, "specify the most fields that a hash can hold before it stops using the compact encoding")
+	hashMaxListpackValue := flag.Int("hash-max-listpack-value", server.DEFAULT_HASH_MAX_LISTPACK_VALUE, "specify the longest field or value that a hash can hold before it stops using the compact encoding")
+	setMaxIntsetEntries := flag.Int("set-max-intset-entries", server.DEFAULT_SET_MAX_INTSET_ENTRIES, "specify the most integers that a set can hold before it stops using the intset encoding")
 	flag.Parse()
 
 	// This flag may be formatted as "hostname port" so we need to turn this into an actual address
@@ -34,32 +40,26 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	serverOpts := server.ServerOptions{
-		Port: port,
+		Port:            port,
+		Dir:             dir,
+		DBFilename:      dbFilename,
+		ReplBacklogSize: replBacklogSize,
+
+		HashMaxListpackEntries: hashMaxListpackEntries,
+		HashMaxListpackValue:   hashMaxListpackValue,
+		SetMaxIntsetEntries:    setMaxIntsetEntries,
 	}
 
 	logger.AddMetadata(zap.Int("serverListenPort", *port))
 
-	if replicaof == "" {
-		logger.AddMetadata(zap.String("nodeType", string(server.MasterNodeType)))
-		server, err := server.NewMasterServer(*logger, serverOpts)
-		if err != nil {
-			logger.Fatal("failed to initialize master server", zap.Error(err))
-		}
+	node, err := server.NewNode(*logger, replicaof, serverOpts)
+	if err != nil {
+		logger.Fatal("failed to initialize server", zap.Error(err))
+	}
 
-		err = server.Run(ctx)
-		if err != nil {
-			logger.Fatal("failed to run master server", zap.Error(err))
-		}
-	} else {
-		logger.AddMetadata(zap.String("nodeType", string(server.ReplicaNodeType)))
-		server, err := server.NewReplicaServer(*logger, replicaof, serverOpts)
-		if err != nil {
-			logger.Fatal("failed to initialize replica server", zap.Error(err))
-		}
-		err = server.Run(ctx)
-		if err != nil {
-			logger.Fatal("failed to run replica server", zap.Error(err))
-		}
+	err = node.Run(ctx)
+	if err != nil {
+		logger.Fatal("failed to run server", zap.Error(err))
 	}
 
 	sigShutdown := make(chan os.Signal, 1)
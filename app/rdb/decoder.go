@@ -0,0 +1,612 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DecodeFile reads the RDB file at the provided path and decodes it into a Snapshot
+func DecodeFile(path string) (Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot, err := Decode(data)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("error decoding RDB file %q: %w", path, err)
+	}
+	return snapshot, nil
+}
+
+// Decode decodes the contents of an RDB file
+func Decode(data []byte) (Snapshot, error) {
+	d := decoder{data: data}
+	return d.decode()
+}
+
+type decoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *decoder) decode() (Snapshot, error) {
+	version, err := d.readHeader()
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Snapshot{
+		Aux:     map[string]string{},
+		Entries: []Entry{},
+	}
+
+	curDB := 0
+	var expiresAt *time.Time
+	for {
+		opCode, err := d.readByte()
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("error reading opcode: %w", err)
+		}
+
+		switch opCode {
+		case opCodeEOF:
+			if err := d.verifyChecksum(version); err != nil {
+				return Snapshot{}, err
+			}
+			return snapshot, nil
+		case opCodeAux:
+			key, err := d.readString()
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("error reading AUX key: %w", err)
+			}
+			value, err := d.readString()
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("error reading value of AUX field %q: %w", key, err)
+			}
+			snapshot.Aux[string(key)] = string(value)
+		case opCodeSelectDB:
+			db, err := d.readPlainLength()
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("error reading SELECTDB index: %w", err)
+			}
+			curDB = int(db)
+		case opCodeResizeDB:
+			// The hash table sizes are only hints, so we can read and discard them
+			if _, err := d.readPlainLength(); err != nil {
+				return Snapshot{}, fmt.Errorf("error reading RESIZEDB database size: %w", err)
+			}
+			if _, err := d.readPlainLength(); err != nil {
+				return Snapshot{}, fmt.Errorf("error reading RESIZEDB expires size: %w", err)
+			}
+		case opCodeExpireTimeMs:
+			expiry, err := d.readMillisecondTime()
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("error reading millisecond expiry: %w", err)
+			}
+			expiresAt = &expiry
+		case opCodeExpireTime:
+			rawExpiry, err := d.readBytes(4)
+			if err != nil {
+				return Snapshot{}, fmt.Errorf("error reading second expiry: %w", err)
+			}
+			expiry := time.Unix(int64(binary.LittleEndian.Uint32(rawExpiry)), 0)
+			expiresAt = &expiry
+		case opCodeIdle:
+			// LRU idle time isn't tracked by this server
+			if _, err := d.readPlainLength(); err != nil {
+				return Snapshot{}, fmt.Errorf("error reading LRU idle time: %w", err)
+			}
+		case opCodeFreq:
+			// LFU frequency isn't tracked by this server
+			if _, err := d.readByte(); err != nil {
+				return Snapshot{}, fmt.Errorf("error reading LFU frequency: %w", err)
+			}
+		case opCodeModuleAux, opCodeFunction2:
+			return Snapshot{}, fmt.Errorf("RDB files containing modules or functions (opcode 0x%X) are not supported", opCode)
+		default:
+			entry, err := d.readEntry(ValueType(opCode))
+			if err != nil {
+				return Snapshot{}, err
+			}
+			entry.DB = curDB
+			entry.ExpiresAt = expiresAt
+			snapshot.Entries = append(snapshot.Entries, entry)
+
+			// An expiry only applies to the key that immediately follows it
+			expiresAt = nil
+		}
+	}
+}
+
+func (d *decoder) readHeader() (int, error) {
+	header, err := d.readBytes(len(magicString) + 4)
+	if err != nil {
+		return 0, fmt.Errorf("error reading RDB header: %w", err)
+	}
+
+	if string(header[:len(magicString)]) != magicString {
+		return 0, fmt.Errorf("RDB file has an invalid header %q", header)
+	}
+
+	version, err := strconv.Atoi(string(header[len(magicString):]))
+	if err != nil {
+		return 0, fmt.Errorf("RDB file has an invalid version %q: %w", header[len(magicString):], err)
+	}
+	if version < 1 || version > maxSupportedVersion {
+		return 0, fmt.Errorf("RDB version %d is not supported", version)
+	}
+
+	return version, nil
+}
+
+func (d *decoder) verifyChecksum(version int) error {
+	if version < minChecksumVersion {
+		return nil
+	}
+
+	checksumEnd := d.pos
+	rawChecksum, err := d.readBytes(8)
+	if err != nil {
+		return fmt.Errorf("error reading RDB checksum: %w", err)
+	}
+
+	// A checksum of 0 means that checksums were disabled when the file was written
+	expectedChecksum := binary.LittleEndian.Uint64(rawChecksum)
+	if expectedChecksum == 0 {
+		return nil
+	}
+
+	if actualChecksum := checksum(d.data[:checksumEnd]); actualChecksum != expectedChecksum {
+		return fmt.Errorf("RDB checksum mismatch: expected %x but got %x", expectedChecksum, actualChecksum)
+	}
+	return nil
+}
+
+func (d *decoder) readEntry(valueType ValueType) (Entry, error) {
+	key, err := d.readString()
+	if err != nil {
+		return Entry{}, fmt.Errorf("error reading key: %w", err)
+	}
+
+	entry := Entry{
+		Key:  string(key),
+		Type: valueType,
+	}
+
+	switch valueType {
+	case StringValueType:
+		entry.Value, err = d.readString()
+	case ListValueType:
+		entry.Value, err = d.readList()
+	case listQuicklist2ValueType:
+		entry.Type = ListValueType
+		entry.Value, err = d.readQuicklist2()
+	case SetValueType:
+		entry.Value, err = d.readSet()
+	case setIntsetValueType:
+		entry.Type = SetValueType
+		entry.Value, err = d.readSetIntset()
+	case setListpackValueType:
+		entry.Type = SetValueType
+		entry.Value, err = d.readSetListpack()
+	case HashValueType:
+		entry.Value, err = d.readHash()
+	case hashListpackValueType:
+		entry.Type = HashValueType
+		entry.Value, err = d.readHashListpack()
+	case hashMetadataValueType:
+		entry.Type = HashValueType
+		entry.Value, err = d.readHashMetadata()
+	case hashListpackExValueType:
+		entry.Type = HashValueType
+		entry.Value, err = d.readHashListpackEx()
+	default:
+		return Entry{}, fmt.Errorf("value type %d for key %q is not supported", valueType, key)
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("error reading value for key %q: %w", key, err)
+	}
+
+	return entry, nil
+}
+
+// readList reads a list that is stored as its length followed by each of its elements
+func (d *decoder) readList() ([][]byte, error) {
+	length, err := d.readPlainLength()
+	if err != nil {
+		return nil, fmt.Errorf("error reading list length: %w", err)
+	}
+	if length > uint64(len(d.data)) {
+		return nil, fmt.Errorf("list length %d is larger than the RDB data", length)
+	}
+
+	elements := make([][]byte, 0, length)
+	for range length {
+		element, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading list element: %w", err)
+		}
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
+// readQuicklist2 reads a list that is stored as a sequence of nodes, each of which is either a listpack of elements
+// or a single plain element
+func (d *decoder) readQuicklist2() ([][]byte, error) {
+	numNodes, err := d.readPlainLength()
+	if err != nil {
+		return nil, fmt.Errorf("error reading quicklist node count: %w", err)
+	}
+	if numNodes > uint64(len(d.data)) {
+		return nil, fmt.Errorf("quicklist node count %d is larger than the RDB data", numNodes)
+	}
+
+	var elements [][]byte
+	for range numNodes {
+		container, err := d.readPlainLength()
+		if err != nil {
+			return nil, fmt.Errorf("error reading quicklist node container: %w", err)
+		}
+		node, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading quicklist node: %w", err)
+		}
+
+		switch container {
+		case quicklistNodePlain:
+			elements = append(elements, node)
+		case quicklistNodePacked:
+			nodeElements, err := decodeListpack(node)
+			if err != nil {
+				return nil, fmt.Errorf("error decoding quicklist node: %w", err)
+			}
+			elements = append(elements, nodeElements...)
+		default:
+			return nil, fmt.Errorf("unknown quicklist node container %d", container)
+		}
+	}
+	return elements, nil
+}
+
+// readSet reads a set that is stored as its number of members followed by each member
+func (d *decoder) readSet() ([][]byte, error) {
+	length, err := d.readPlainLength()
+	if err != nil {
+		return nil, fmt.Errorf("error reading set length: %w", err)
+	}
+	if length > uint64(len(d.data)) {
+		return nil, fmt.Errorf("set length %d is larger than the RDB data", length)
+	}
+
+	members := make([][]byte, 0, length)
+	for range length {
+		member, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading set member: %w", err)
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// readSetIntset reads a set of integers that is stored as an intset. An intset is the number of bytes that each
+// integer takes up and the number of integers, both as little endian uint32s, followed by the sorted integers
+func (d *decoder) readSetIntset() ([][]byte, error) {
+	data, err := d.readString()
+	if err != nil {
+		return nil, fmt.Errorf("error reading intset: %w", err)
+	}
+	if len(data) < 8 {
+		return nil, fmt.Errorf("intset of %d bytes is too short to hold its header", len(data))
+	}
+
+	width := binary.LittleEndian.Uint32(data[0:4])
+	length := uint64(binary.LittleEndian.Uint32(data[4:8]))
+	if width != 2 && width != 4 && width != 8 {
+		return nil, fmt.Errorf("unknown intset encoding %d", width)
+	}
+	if uint64(len(data)-8) != length*uint64(width) {
+		return nil, fmt.Errorf("intset of %d bytes doesn't hold %d integers of %d bytes", len(data), length, width)
+	}
+
+	members := make([][]byte, 0, length)
+	for i := range length {
+		element := data[8+i*uint64(width):]
+		var value int64
+		switch width {
+		case 2:
+			value = int64(int16(binary.LittleEndian.Uint16(element)))
+		case 4:
+			value = int64(int32(binary.LittleEndian.Uint32(element)))
+		case 8:
+			value = int64(binary.LittleEndian.Uint64(element))
+		}
+		members = append(members, []byte(strconv.FormatInt(value, 10)))
+	}
+	return members, nil
+}
+
+// readSetListpack reads a set that is stored as a single listpack, which holds each of its members
+func (d *decoder) readSetListpack() ([][]byte, error) {
+	data, err := d.readString()
+	if err != nil {
+		return nil, fmt.Errorf("error reading set listpack: %w", err)
+	}
+
+	members, err := decodeListpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding set listpack: %w", err)
+	}
+	return members, nil
+}
+
+// readHash reads a hash that is stored as its number of fields followed by each field and its value
+func (d *decoder) readHash() ([]HashField, error) {
+	length, err := d.readPlainLength()
+	if err != nil {
+		return nil, fmt.Errorf("error reading hash length: %w", err)
+	}
+	if length > uint64(len(d.data)) {
+		return nil, fmt.Errorf("hash length %d is larger than the RDB data", length)
+	}
+
+	fields := make([]HashField, 0, length)
+	for range length {
+		field, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading hash field: %w", err)
+		}
+		value, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading hash value: %w", err)
+		}
+		fields = append(fields, HashField{Field: string(field), Value: value})
+	}
+	return fields, nil
+}
+
+// readHashListpack reads a hash that is stored as a single listpack, which holds each field followed by its value
+func (d *decoder) readHashListpack() ([]HashField, error) {
+	data, err := d.readString()
+	if err != nil {
+		return nil, fmt.Errorf("error reading hash listpack: %w", err)
+	}
+
+	elements, err := decodeListpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding hash listpack: %w", err)
+	}
+	if len(elements)%2 != 0 {
+		return nil, fmt.Errorf("hash listpack has %d elements, which is not a whole number of fields", len(elements))
+	}
+
+	fields := make([]HashField, 0, len(elements)/2)
+	for i := 0; i < len(elements); i += 2 {
+		fields = append(fields, HashField{Field: string(elements[i]), Value: elements[i+1]})
+	}
+	return fields, nil
+}
+
+// readHashMetadata reads a hash that has fields with expiries. It's stored as the earliest expiry of its fields and
+// its number of fields, followed by each field's expiry, the field and its value. An expiry is stored as the number
+// of milliseconds after the earliest one plus 1, or as 0 if the field doesn't expire
+func (d *decoder) readHashMetadata() ([]HashField, error) {
+	minExpiry, err := d.readMillisecondTime()
+	if err != nil {
+		return nil, fmt.Errorf("error reading hash minimum field expiry: %w", err)
+	}
+
+	length, err := d.readPlainLength()
+	if err != nil {
+		return nil, fmt.Errorf("error reading hash length: %w", err)
+	}
+	if length > uint64(len(d.data)) {
+		return nil, fmt.Errorf("hash length %d is larger than the RDB data", length)
+	}
+
+	fields := make([]HashField, 0, length)
+	for range length {
+		ttl, err := d.readPlainLength()
+		if err != nil {
+			return nil, fmt.Errorf("error reading hash field expiry: %w", err)
+		}
+		field, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading hash field: %w", err)
+		}
+		value, err := d.readString()
+		if err != nil {
+			return nil, fmt.Errorf("error reading hash value: %w", err)
+		}
+
+		hashField := HashField{Field: string(field), Value: value}
+		if ttl != 0 {
+			expiresAt := time.UnixMilli(minExpiry.UnixMilli() + int64(ttl) - 1)
+			hashField.ExpiresAt = &expiresAt
+		}
+		fields = append(fields, hashField)
+	}
+	return fields, nil
+}
+
+// readHashListpackEx reads a small hash that has fields with expiries. It's stored as the earliest expiry of its
+// fields followed by a single listpack, which holds each field followed by its value and the unix time in
+// milliseconds that it expires at, or 0 if it doesn't expire
+func (d *decoder) readHashListpackEx() ([]HashField, error) {
+	// The earliest expiry can be worked out from the fields, so there's no need to keep it
+	if _, err := d.readMillisecondTime(); err != nil {
+		return nil, fmt.Errorf("error reading hash minimum field expiry: %w", err)
+	}
+
+	data, err := d.readString()
+	if err != nil {
+		return nil, fmt.Errorf("error reading hash listpack: %w", err)
+	}
+
+	elements, err := decodeListpack(data)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding hash listpack: %w", err)
+	}
+	if len(elements)%3 != 0 {
+		return nil, fmt.Errorf("hash listpack has %d elements, which is not a whole number of fields with expiries", len(elements))
+	}
+
+	fields := make([]HashField, 0, len(elements)/3)
+	for i := 0; i < len(elements); i += 3 {
+		ttl, err := strconv.ParseInt(string(elements[i+2]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing hash field expiry %q: %w", elements[i+2], err)
+		}
+
+		hashField := HashField{Field: string(elements[i]), Value: elements[i+1]}
+		if ttl != 0 {
+			expiresAt := time.UnixMilli(ttl)
+			hashField.ExpiresAt = &expiresAt
+		}
+		fields = append(fields, hashField)
+	}
+	return fields, nil
+}
+
+// readMillisecondTime reads a unix time in milliseconds that is stored as an 8 byte little endian integer
+func (d *decoder) readMillisecondTime() (time.Time, error) {
+	raw, err := d.readBytes(8)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(int64(binary.LittleEndian.Uint64(raw))), nil
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if d.pos >= len(d.data) {
+		return 0, errors.New("unexpected end of RDB data")
+	}
+
+	b := d.data[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *decoder) readBytes(n int) ([]byte, error) {
+	if n < 0 || len(d.data)-d.pos < n {
+		return nil, fmt.Errorf("tried to read %d bytes but only %d remain", n, len(d.data)-d.pos)
+	}
+
+	// The result is a copy so that decoded values don't share the file's storage, which would let a value that is
+	// grown in place overwrite the ones after it
+	res := bytes.Clone(d.data[d.pos : d.pos+n])
+	d.pos += n
+	return res, nil
+}
+
+// readLength reads a length encoded value. If isEncoded is true, the returned value is
+// the encoding type of a specially encoded string rather than a length
+func (d *decoder) readLength() (length uint64, isEncoded bool, err error) {
+	first, err := d.readByte()
+	if err != nil {
+		return 0, false, err
+	}
+
+	switch first >> 6 {
+	case len6Bit:
+		return uint64(first & 0x3F), false, nil
+	case len14Bit:
+		next, err := d.readByte()
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(first&0x3F)<<8 | uint64(next), false, nil
+	case lenEncoded:
+		return uint64(first & 0x3F), true, nil
+	}
+
+	switch first {
+	case len32Bit:
+		raw, err := d.readBytes(4)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(binary.BigEndian.Uint32(raw)), false, nil
+	case len64Bit:
+		raw, err := d.readBytes(8)
+		if err != nil {
+			return 0, false, err
+		}
+		return binary.BigEndian.Uint64(raw), false, nil
+	}
+
+	return 0, false, fmt.Errorf("unknown length encoding 0x%X", first)
+}
+
+// readPlainLength reads a length that is not allowed to be a specially encoded string
+func (d *decoder) readPlainLength() (uint64, error) {
+	length, isEncoded, err := d.readLength()
+	if err != nil {
+		return 0, err
+	}
+	if isEncoded {
+		return 0, fmt.Errorf("expected a length, but found a string encoding of type %d", length)
+	}
+	return length, nil
+}
+
+func (d *decoder) readString() ([]byte, error) {
+	length, isEncoded, err := d.readLength()
+	if err != nil {
+		return nil, err
+	}
+
+	if !isEncoded {
+		if length > uint64(len(d.data)) {
+			return nil, fmt.Errorf("string length %d is larger than the RDB data", length)
+		}
+		return d.readBytes(int(length))
+	}
+
+	switch length {
+	case encodingInt8:
+		raw, err := d.readBytes(1)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int8(raw[0])), 10), nil
+	case encodingInt16:
+		raw, err := d.readBytes(2)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int16(binary.LittleEndian.Uint16(raw))), 10), nil
+	case encodingInt32:
+		raw, err := d.readBytes(4)
+		if err != nil {
+			return nil, err
+		}
+		return strconv.AppendInt(nil, int64(int32(binary.LittleEndian.Uint32(raw))), 10), nil
+	case encodingLZF:
+		compressedLength, err := d.readPlainLength()
+		if err != nil {
+			return nil, fmt.Errorf("error reading compressed length of LZF string: %w", err)
+		}
+		uncompressedLength, err := d.readPlainLength()
+		if err != nil {
+			return nil, fmt.Errorf("error reading uncompressed length of LZF string: %w", err)
+		}
+		if compressedLength > uint64(len(d.data)) {
+			return nil, fmt.Errorf("compressed length %d is larger than the RDB data", compressedLength)
+		}
+		compressed, err := d.readBytes(int(compressedLength))
+		if err != nil {
+			return nil, err
+		}
+		return lzfDecompress(compressed, uncompressedLength)
+	}
+
+	return nil, fmt.Errorf("unknown string encoding %d", length)
+}
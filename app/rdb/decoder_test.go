@@ -0,0 +1,432 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// An empty RDB file written by redis 7.2.0
+const emptyRDBHex = "524544495330303131fa0972656469732d76657205372e322e30fa0a72656469732d62697473c040fa056374696d65c26d08bc65fa08757365642d6d656dc2b0c41000fa08616f662d62617365c000fff06e3bfec0ff5aa2"
+
+// buildRDB wraps the provided body in an RDB header, EOF opcode and checksum
+func buildRDB(body ...[]byte) []byte {
+	data := []byte("REDIS0011")
+	for _, b := range body {
+		data = append(data, b...)
+	}
+	data = append(data, opCodeEOF)
+	return binary.LittleEndian.AppendUint64(data, checksum(data))
+}
+
+func TestDecodeEmptyRDB(t *testing.T) {
+	data, err := hex.DecodeString(emptyRDBHex)
+	require.NoError(t, err)
+
+	snapshot, err := Decode(data)
+	assert.NoError(t, err)
+	assert.Empty(t, snapshot.Entries)
+	assert.Equal(t, "7.2.0", snapshot.Aux["redis-ver"])
+	assert.Equal(t, "64", snapshot.Aux["redis-bits"])
+	assert.Equal(t, "0", snapshot.Aux["aof-base"])
+}
+
+func TestDecodeStrings(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		encodedString []byte
+		expectedValue string
+	}{
+		{
+			name:          "6 bit length",
+			encodedString: []byte{0x03, 'b', 'a', 'r'},
+			expectedValue: "bar",
+		},
+		{
+			name:          "14 bit length",
+			encodedString: append([]byte{0x41, 0x00}, make([]byte, 256)...),
+			expectedValue: string(make([]byte, 256)),
+		},
+		{
+			name:          "32 bit length",
+			encodedString: []byte{0x80, 0x00, 0x00, 0x00, 0x02, 'h', 'i'},
+			expectedValue: "hi",
+		},
+		{
+			name:          "8 bit integer",
+			encodedString: []byte{0xC0, 0xF6},
+			expectedValue: "-10",
+		},
+		{
+			name:          "16 bit integer",
+			encodedString: []byte{0xC1, 0x39, 0x30},
+			expectedValue: "12345",
+		},
+		{
+			name:          "32 bit integer",
+			encodedString: []byte{0xC2, 0x87, 0xD6, 0x12, 0x00},
+			expectedValue: "1234567",
+		},
+		{
+			name:          "LZF compressed",
+			encodedString: []byte{0xC3, 0x05, 0x0A, 0x00, 'a', 0xE0, 0x00, 0x00},
+			expectedValue: "aaaaaaaaaa",
+		},
+	} {
+		t.Run(fmt.Sprintf("should decode a string with a %s", tc.name), func(t *testing.T) {
+			data := buildRDB(
+				[]byte{opCodeSelectDB, 0x00, opCodeResizeDB, 0x01, 0x00},
+				[]byte{byte(StringValueType), 0x03, 'f', 'o', 'o'},
+				tc.encodedString,
+			)
+
+			snapshot, err := Decode(data)
+			require.NoError(t, err)
+			require.Len(t, snapshot.Entries, 1)
+			assert.Equal(t, "foo", snapshot.Entries[0].Key)
+			assert.Equal(t, []byte(tc.expectedValue), snapshot.Entries[0].Value)
+			assert.Nil(t, snapshot.Entries[0].ExpiresAt)
+		})
+	}
+}
+
+func TestDecodedValuesDontShareStorage(t *testing.T) {
+	listpack := []byte{0, 0, 0, 0, 2, 0, 0x81, 'a', 0x02, 0x81, 'b', 0x02, listpackEnd}
+	binary.LittleEndian.PutUint32(listpack, uint32(len(listpack)))
+
+	e := encoder{}
+	e.writeByte(byte(StringValueType))
+	e.writeString([]byte("a"))
+	e.writeString([]byte("foo"))
+	e.writeByte(byte(StringValueType))
+	e.writeString([]byte("b"))
+	e.writeString([]byte("bar"))
+	e.writeByte(byte(setListpackValueType))
+	e.writeString([]byte("s"))
+	e.writeString(listpack)
+
+	snapshot, err := Decode(buildRDB(e.data))
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 3)
+
+	// Growing a value in place shouldn't change the values decoded after it
+	_ = append(snapshot.Entries[0].Value.([]byte), "XXXXXXXXXX"...)
+	elements := snapshot.Entries[2].Value.([][]byte)
+	_ = append(elements[0], "XXXXXXXXXX"...)
+
+	assert.Equal(t, []byte("bar"), snapshot.Entries[1].Value)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, snapshot.Entries[2].Value)
+}
+
+func TestDecodeExpiry(t *testing.T) {
+	msExpiry := binary.LittleEndian.AppendUint64([]byte{opCodeExpireTimeMs}, 1713824559637)
+	secondExpiry := binary.LittleEndian.AppendUint32([]byte{opCodeExpireTime}, 1713824559)
+
+	data := buildRDB(
+		[]byte{opCodeSelectDB, 0x00, opCodeResizeDB, 0x03, 0x02},
+		msExpiry, []byte{byte(StringValueType), 0x01, 'a', 0x01, '1'},
+		secondExpiry, []byte{byte(StringValueType), 0x01, 'b', 0x01, '2'},
+		[]byte{byte(StringValueType), 0x01, 'c', 0x01, '3'},
+		[]byte{opCodeSelectDB, 0x01},
+		[]byte{byte(StringValueType), 0x01, 'd', 0x01, '4'},
+	)
+
+	snapshot, err := Decode(data)
+	require.NoError(t, err)
+	require.Len(t, snapshot.Entries, 4)
+
+	assert.Equal(t, time.UnixMilli(1713824559637), *snapshot.Entries[0].ExpiresAt)
+	assert.Equal(t, time.Unix(1713824559, 0), *snapshot.Entries[1].ExpiresAt)
+	assert.Nil(t, snapshot.Entries[2].ExpiresAt)
+	assert.Equal(t, 0, snapshot.Entries[2].DB)
+	assert.Equal(t, 1, snapshot.Entries[3].DB)
+}
+
+func TestDecodeLists(t *testing.T) {
+	t.Run("should decode a plain list", func(t *testing.T) {
+		data := buildRDB([]byte{byte(ListValueType), 0x01, 'l', 0x03, 0x01, 'a', 0xC0, 0x05, 0x00})
+
+		snapshot, err := Decode(data)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, ListValueType, snapshot.Entries[0].Type)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("5"), {}}, snapshot.Entries[0].Value)
+	})
+
+	t.Run("should decode a quicklist of listpacks and plain nodes", func(t *testing.T) {
+		long := make([]byte, 100)
+		entries := [][]byte{
+			{0x05, 0x01},                                      // 7 bit unsigned integer
+			{0x82, 'a', 'b', 0x03},                            // string with a 6 bit length
+			{0xDF, 0x9C, 0x02},                                // 13 bit integer
+			{0xF1, 0xE8, 0x03, 0x03},                          // 16 bit integer
+			{0xF2, 0x60, 0x79, 0xFE, 0x04},                    // 24 bit integer
+			{0xF4, 0, 0, 0, 0, 0, 1, 0, 0, 0x09},              // 64 bit integer
+			append(append([]byte{0xE0, 0x64}, long...), 0x66), // string with a 12 bit length
+		}
+
+		listpack := []byte{0, 0, 0, 0, byte(len(entries)), 0}
+		for _, entry := range entries {
+			listpack = append(listpack, entry...)
+		}
+		listpack = append(listpack, listpackEnd)
+		binary.LittleEndian.PutUint32(listpack, uint32(len(listpack)))
+
+		e := encoder{}
+		e.writeByte(byte(listQuicklist2ValueType))
+		e.writeString([]byte("l"))
+		e.writeLength(2)
+		e.writeLength(quicklistNodePacked)
+		e.writeString(listpack)
+		e.writeLength(quicklistNodePlain)
+		e.writeString([]byte("plain"))
+
+		snapshot, err := Decode(buildRDB(e.data))
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, ListValueType, snapshot.Entries[0].Type)
+		assert.Equal(t, [][]byte{
+			[]byte("5"), []byte("ab"), []byte("-100"), []byte("1000"), []byte("-100000"), []byte("1099511627776"), long,
+			[]byte("plain"),
+		}, snapshot.Entries[0].Value)
+	})
+
+	t.Run("should fail to decode a corrupt listpack", func(t *testing.T) {
+		e := encoder{}
+		e.writeByte(byte(listQuicklist2ValueType))
+		e.writeString([]byte("l"))
+		e.writeLength(1)
+		e.writeLength(quicklistNodePacked)
+		e.writeString([]byte{0x09, 0, 0, 0, 0x01, 0, 0x82, 'a', 0x03})
+
+		_, err := Decode(buildRDB(e.data))
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeSets(t *testing.T) {
+	t.Run("should decode a plain set", func(t *testing.T) {
+		data := buildRDB([]byte{byte(SetValueType), 0x01, 's', 0x02, 0x01, 'a', 0xC0, 0x05})
+
+		snapshot, err := Decode(data)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, SetValueType, snapshot.Entries[0].Type)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("5")}, snapshot.Entries[0].Value)
+	})
+
+	for _, tc := range []struct {
+		width  uint32
+		values []int64
+	}{
+		{width: 2, values: []int64{-3, 7, 30000}},
+		{width: 4, values: []int64{-70000, 1, 2000000000}},
+		{width: 8, values: []int64{-5000000000, 0, 9223372036854775807}},
+	} {
+		t.Run(fmt.Sprintf("should decode an intset of %d byte integers", tc.width), func(t *testing.T) {
+			intset := binary.LittleEndian.AppendUint32(nil, tc.width)
+			intset = binary.LittleEndian.AppendUint32(intset, uint32(len(tc.values)))
+			expected := make([][]byte, 0, len(tc.values))
+			for _, value := range tc.values {
+				switch tc.width {
+				case 2:
+					intset = binary.LittleEndian.AppendUint16(intset, uint16(value))
+				case 4:
+					intset = binary.LittleEndian.AppendUint32(intset, uint32(value))
+				case 8:
+					intset = binary.LittleEndian.AppendUint64(intset, uint64(value))
+				}
+				expected = append(expected, []byte(fmt.Sprint(value)))
+			}
+
+			e := encoder{}
+			e.writeByte(byte(setIntsetValueType))
+			e.writeString([]byte("s"))
+			e.writeString(intset)
+
+			snapshot, err := Decode(buildRDB(e.data))
+			require.NoError(t, err)
+			require.Len(t, snapshot.Entries, 1)
+			assert.Equal(t, SetValueType, snapshot.Entries[0].Type)
+			assert.Equal(t, expected, snapshot.Entries[0].Value)
+		})
+	}
+
+	t.Run("should fail to decode an intset with the wrong number of bytes", func(t *testing.T) {
+		intset := binary.LittleEndian.AppendUint32(nil, 2)
+		intset = binary.LittleEndian.AppendUint32(intset, 2)
+		intset = binary.LittleEndian.AppendUint16(intset, 1)
+
+		e := encoder{}
+		e.writeByte(byte(setIntsetValueType))
+		e.writeString([]byte("s"))
+		e.writeString(intset)
+
+		_, err := Decode(buildRDB(e.data))
+		assert.Error(t, err)
+	})
+
+	t.Run("should decode a set listpack", func(t *testing.T) {
+		entries := [][]byte{{0x81, 'a', 0x02}, {0x05, 0x01}}
+		listpack := []byte{0, 0, 0, 0, byte(len(entries)), 0}
+		for _, entry := range entries {
+			listpack = append(listpack, entry...)
+		}
+		listpack = append(listpack, listpackEnd)
+		binary.LittleEndian.PutUint32(listpack, uint32(len(listpack)))
+
+		e := encoder{}
+		e.writeByte(byte(setListpackValueType))
+		e.writeString([]byte("s"))
+		e.writeString(listpack)
+
+		snapshot, err := Decode(buildRDB(e.data))
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, SetValueType, snapshot.Entries[0].Type)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("5")}, snapshot.Entries[0].Value)
+	})
+}
+
+func TestDecodeHashes(t *testing.T) {
+	t.Run("should decode a plain hash", func(t *testing.T) {
+		data := buildRDB([]byte{byte(HashValueType), 0x01, 'h', 0x02, 0x01, 'a', 0x01, 'b', 0x01, 'c', 0xC0, 0x05})
+
+		snapshot, err := Decode(data)
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, HashValueType, snapshot.Entries[0].Type)
+		assert.Equal(t, []HashField{{Field: "a", Value: []byte("b")}, {Field: "c", Value: []byte("5")}}, snapshot.Entries[0].Value)
+	})
+
+	t.Run("should decode a hash listpack", func(t *testing.T) {
+		entries := [][]byte{{0x81, 'a', 0x02}, {0x05, 0x01}, {0x81, 'b', 0x02}, {0x82, 'x', 'y', 0x03}}
+		listpack := []byte{0, 0, 0, 0, byte(len(entries)), 0}
+		for _, entry := range entries {
+			listpack = append(listpack, entry...)
+		}
+		listpack = append(listpack, listpackEnd)
+		binary.LittleEndian.PutUint32(listpack, uint32(len(listpack)))
+
+		e := encoder{}
+		e.writeByte(byte(hashListpackValueType))
+		e.writeString([]byte("h"))
+		e.writeString(listpack)
+
+		snapshot, err := Decode(buildRDB(e.data))
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, HashValueType, snapshot.Entries[0].Type)
+		assert.Equal(t, []HashField{{Field: "a", Value: []byte("5")}, {Field: "b", Value: []byte("xy")}}, snapshot.Entries[0].Value)
+	})
+
+	t.Run("should decode a hash with field expiries", func(t *testing.T) {
+		e := encoder{}
+		e.writeByte(byte(hashMetadataValueType))
+		e.writeString([]byte("h"))
+		e.data = binary.LittleEndian.AppendUint64(e.data, 1_700_000_000_000)
+		e.writeLength(2)
+		for _, field := range []struct {
+			ttl   uint64
+			field string
+		}{{ttl: 0, field: "a"}, {ttl: 501, field: "b"}} {
+			e.writeLength(field.ttl)
+			e.writeString([]byte(field.field))
+			e.writeString([]byte("v"))
+		}
+
+		snapshot, err := Decode(buildRDB(e.data))
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+		assert.Equal(t, HashValueType, snapshot.Entries[0].Type)
+
+		expiresAt := time.UnixMilli(1_700_000_000_500)
+		assert.Equal(t, []HashField{{Field: "a", Value: []byte("v")}, {Field: "b", Value: []byte("v"), ExpiresAt: &expiresAt}}, snapshot.Entries[0].Value)
+	})
+
+	t.Run("should decode a hash listpack with field expiries", func(t *testing.T) {
+		entries := [][]byte{{0x81, 'a', 0x02}, {0x01, 0x01}, {0x00, 0x01}, {0x81, 'b', 0x02}, {0x02, 0x01}, {0xF4, 0x00, 0x68, 0xE5, 0xCF, 0x8B, 0x01, 0x00, 0x00, 0x09}}
+		listpack := []byte{0, 0, 0, 0, byte(len(entries)), 0}
+		for _, entry := range entries {
+			listpack = append(listpack, entry...)
+		}
+		listpack = append(listpack, listpackEnd)
+		binary.LittleEndian.PutUint32(listpack, uint32(len(listpack)))
+
+		e := encoder{}
+		e.writeByte(byte(hashListpackExValueType))
+		e.writeString([]byte("h"))
+		e.data = binary.LittleEndian.AppendUint64(e.data, 1_700_000_000_000)
+		e.writeString(listpack)
+
+		snapshot, err := Decode(buildRDB(e.data))
+		require.NoError(t, err)
+		require.Len(t, snapshot.Entries, 1)
+
+		expiresAt := time.UnixMilli(1_700_000_000_000)
+		assert.Equal(t, []HashField{{Field: "a", Value: []byte("1")}, {Field: "b", Value: []byte("2"), ExpiresAt: &expiresAt}}, snapshot.Entries[0].Value)
+	})
+
+	t.Run("should fail to decode a hash listpack with a field that has no value", func(t *testing.T) {
+		listpack := []byte{0, 0, 0, 0, 1, 0, 0x81, 'a', 0x02, listpackEnd}
+		binary.LittleEndian.PutUint32(listpack, uint32(len(listpack)))
+
+		e := encoder{}
+		e.writeByte(byte(hashListpackValueType))
+		e.writeString([]byte("h"))
+		e.writeString(listpack)
+
+		_, err := Decode(buildRDB(e.data))
+		assert.Error(t, err)
+	})
+}
+
+func TestDecodeChecksum(t *testing.T) {
+	t.Run("a checksum of 0 should be ignored", func(t *testing.T) {
+		data := []byte("REDIS0011")
+		data = append(data, opCodeEOF, 0, 0, 0, 0, 0, 0, 0, 0)
+		_, err := Decode(data)
+		assert.NoError(t, err)
+	})
+
+	t.Run("a corrupted file should fail the checksum", func(t *testing.T) {
+		data := buildRDB([]byte{byte(StringValueType), 0x01, 'a', 0x01, '1'})
+		data[len(data)-10] = '2'
+		_, err := Decode(data)
+		assert.ErrorContains(t, err, "checksum mismatch")
+	})
+}
+
+func TestDecodeInvalidData(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		data []byte
+	}{
+		{
+			name: "a missing header",
+			data: []byte("REDIS"),
+		},
+		{
+			name: "an invalid magic string",
+			data: []byte("RREDIS0011"),
+		},
+		{
+			name: "a truncated value",
+			data: []byte("REDIS0011\x00\x01a\x05ab"),
+		},
+		{
+			name: "no EOF opcode",
+			data: []byte("REDIS0011\x00\x01a\x01b"),
+		},
+	} {
+		t.Run(fmt.Sprintf("should fail to decode %s", tc.name), func(t *testing.T) {
+			_, err := Decode(tc.data)
+			assert.Error(t, err)
+		})
+	}
+}
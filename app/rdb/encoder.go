@@ -0,0 +1,238 @@
+package rdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+)
+
+// Version is the RDB format version written by Encode. It's the version that redis 7.4 writes, which is the first
+// that can hold hashes with field expiries
+const Version = 12
+
+// WriteFile encodes the snapshot and atomically replaces the file at path with it. The data is written
+// to a temporary file in the same directory first so that a failed save never corrupts an existing file
+func WriteFile(path string, snapshot Snapshot) error {
+	data, err := Encode(snapshot)
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), "temp-*.rdb")
+	if err != nil {
+		return fmt.Errorf("error creating temporary RDB file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error writing temporary RDB file: %w", err)
+	}
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return fmt.Errorf("error syncing temporary RDB file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		return fmt.Errorf("error closing temporary RDB file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), path); err != nil {
+		return fmt.Errorf("error moving temporary RDB file to %q: %w", path, err)
+	}
+	return nil
+}
+
+// Encode serializes a snapshot into the RDB format
+func Encode(snapshot Snapshot) ([]byte, error) {
+	e := encoder{data: fmt.Appendf(nil, "%s%04d", magicString, Version)}
+
+	// Sort the AUX fields so that the output is deterministic
+	auxKeys := make([]string, 0, len(snapshot.Aux))
+	for key := range snapshot.Aux {
+		auxKeys = append(auxKeys, key)
+	}
+	slices.Sort(auxKeys)
+	for _, key := range auxKeys {
+		e.writeByte(opCodeAux)
+		e.writeString([]byte(key))
+		e.writeString([]byte(snapshot.Aux[key]))
+	}
+
+	entriesByDB := map[int][]Entry{}
+	for _, entry := range snapshot.Entries {
+		entriesByDB[entry.DB] = append(entriesByDB[entry.DB], entry)
+	}
+	dbs := make([]int, 0, len(entriesByDB))
+	for db := range entriesByDB {
+		dbs = append(dbs, db)
+	}
+	slices.Sort(dbs)
+
+	for _, db := range dbs {
+		entries := entriesByDB[db]
+
+		numExpires := 0
+		for _, entry := range entries {
+			if entry.ExpiresAt != nil {
+				numExpires++
+			}
+		}
+
+		e.writeByte(opCodeSelectDB)
+		e.writeLength(uint64(db))
+		e.writeByte(opCodeResizeDB)
+		e.writeLength(uint64(len(entries)))
+		e.writeLength(uint64(numExpires))
+
+		for _, entry := range entries {
+			if err := e.writeEntry(entry); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	e.writeByte(opCodeEOF)
+	e.data = binary.LittleEndian.AppendUint64(e.data, checksum(e.data))
+
+	return e.data, nil
+}
+
+type encoder struct {
+	data []byte
+}
+
+func (e *encoder) writeEntry(entry Entry) error {
+	if entry.ExpiresAt != nil {
+		e.writeByte(opCodeExpireTimeMs)
+		e.data = binary.LittleEndian.AppendUint64(e.data, uint64(entry.ExpiresAt.UnixMilli()))
+	}
+
+	e.writeByte(byte(storedType(entry)))
+	e.writeString([]byte(entry.Key))
+
+	switch entry.Type {
+	case StringValueType:
+		value, ok := entry.Value.([]byte)
+		if !ok {
+			return fmt.Errorf("expected string value for key %q to be a []byte but it was %T", entry.Key, entry.Value)
+		}
+		e.writeString(value)
+	case ListValueType:
+		elements, ok := entry.Value.([][]byte)
+		if !ok {
+			return fmt.Errorf("expected list value for key %q to be a [][]byte but it was %T", entry.Key, entry.Value)
+		}
+		e.writeLength(uint64(len(elements)))
+		for _, element := range elements {
+			e.writeString(element)
+		}
+	case SetValueType:
+		members, ok := entry.Value.([][]byte)
+		if !ok {
+			return fmt.Errorf("expected set value for key %q to be a [][]byte but it was %T", entry.Key, entry.Value)
+		}
+		e.writeLength(uint64(len(members)))
+		for _, member := range members {
+			e.writeString(member)
+		}
+	case HashValueType:
+		fields, ok := entry.Value.([]HashField)
+		if !ok {
+			return fmt.Errorf("expected hash value for key %q to be a []HashField but it was %T", entry.Key, entry.Value)
+		}
+
+		minExpiry, hasExpiries := minFieldExpiry(fields)
+		if hasExpiries {
+			e.data = binary.LittleEndian.AppendUint64(e.data, uint64(minExpiry))
+		}
+		e.writeLength(uint64(len(fields)))
+		for _, field := range fields {
+			if hasExpiries {
+				ttl := uint64(0)
+				if field.ExpiresAt != nil {
+					ttl = uint64(field.ExpiresAt.UnixMilli()-minExpiry) + 1
+				}
+				e.writeLength(ttl)
+			}
+			e.writeString([]byte(field.Field))
+			e.writeString(field.Value)
+		}
+	default:
+		return fmt.Errorf("value type %d for key %q is not supported", entry.Type, entry.Key)
+	}
+
+	return nil
+}
+
+// storedType returns the value type that entry is written as, which differs from its type for hashes that have
+// fields with expiries
+func storedType(entry Entry) ValueType {
+	if fields, ok := entry.Value.([]HashField); ok && entry.Type == HashValueType {
+		if _, hasExpiries := minFieldExpiry(fields); hasExpiries {
+			return hashMetadataValueType
+		}
+	}
+	return entry.Type
+}
+
+// minFieldExpiry returns the earliest expiry of fields as a unix time in milliseconds, along with whether any of
+// them have one
+func minFieldExpiry(fields []HashField) (int64, bool) {
+	minExpiry, hasExpiries := int64(0), false
+	for _, field := range fields {
+		if field.ExpiresAt != nil && (!hasExpiries || field.ExpiresAt.UnixMilli() < minExpiry) {
+			minExpiry, hasExpiries = field.ExpiresAt.UnixMilli(), true
+		}
+	}
+	return minExpiry, hasExpiries
+}
+
+func (e *encoder) writeByte(b byte) {
+	e.data = append(e.data, b)
+}
+
+func (e *encoder) writeLength(length uint64) {
+	switch {
+	case length < 1<<6:
+		e.writeByte(byte(length))
+	case length < 1<<14:
+		e.data = append(e.data, byte(len14Bit<<6|length>>8), byte(length))
+	case length <= math.MaxUint32:
+		e.writeByte(len32Bit)
+		e.data = binary.BigEndian.AppendUint32(e.data, uint32(length))
+	default:
+		e.writeByte(len64Bit)
+		e.data = binary.BigEndian.AppendUint64(e.data, length)
+	}
+}
+
+// writeString writes a length prefixed string. Strings that hold small integers are written using the more
+// compact integer encodings
+func (e *encoder) writeString(str []byte) {
+	if len(str) <= 11 {
+		if value, err := strconv.ParseInt(string(str), 10, 32); err == nil && strconv.FormatInt(value, 10) == string(str) {
+			e.writeInt(value)
+			return
+		}
+	}
+
+	e.writeLength(uint64(len(str)))
+	e.data = append(e.data, str...)
+}
+
+func (e *encoder) writeInt(value int64) {
+	switch {
+	case value >= math.MinInt8 && value <= math.MaxInt8:
+		e.data = append(e.data, lenEncoded<<6|encodingInt8, byte(int8(value)))
+	case value >= math.MinInt16 && value <= math.MaxInt16:
+		e.writeByte(lenEncoded<<6 | encodingInt16)
+		e.data = binary.LittleEndian.AppendUint16(e.data, uint16(int16(value)))
+	default:
+		e.writeByte(lenEncoded<<6 | encodingInt32)
+		e.data = binary.LittleEndian.AppendUint32(e.data, uint32(int32(value)))
+	}
+}
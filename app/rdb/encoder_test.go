@@ -0,0 +1,146 @@
+package rdb
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeRoundTrip(t *testing.T) {
+	expiry := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	laterExpiry := time.UnixMilli(expiry.UnixMilli() + 1500)
+
+	for _, tc := range []struct {
+		name     string
+		snapshot Snapshot
+	}{
+		{
+			name:     "an empty snapshot",
+			snapshot: Snapshot{Aux: map[string]string{}, Entries: []Entry{}},
+		},
+		{
+			name: "a snapshot with AUX fields",
+			snapshot: Snapshot{
+				Aux:     map[string]string{"redis-ver": "7.2.0", "ctime": "1713824559"},
+				Entries: []Entry{},
+			},
+		},
+		{
+			name: "a snapshot with string values",
+			snapshot: Snapshot{
+				Aux: map[string]string{},
+				Entries: []Entry{
+					{Key: "a", Type: StringValueType, Value: []byte("b")},
+					{Key: "int8", Type: StringValueType, Value: []byte("-100")},
+					{Key: "int16", Type: StringValueType, Value: []byte("30000")},
+					{Key: "int32", Type: StringValueType, Value: []byte("2000000000")},
+					{Key: "not an int", Type: StringValueType, Value: []byte("0100")},
+					{Key: "binary", Type: StringValueType, Value: []byte("\x00\r\n\xff")},
+					{Key: "long", Type: StringValueType, Value: make([]byte, 20000)},
+				},
+			},
+		},
+		{
+			name: "a snapshot with list values",
+			snapshot: Snapshot{
+				Aux: map[string]string{},
+				Entries: []Entry{
+					{Key: "list", Type: ListValueType, Value: [][]byte{[]byte("a"), []byte("100"), {}, make([]byte, 300)}},
+					{Key: "empty", Type: ListValueType, Value: [][]byte{}},
+				},
+			},
+		},
+		{
+			name: "a snapshot with set values",
+			snapshot: Snapshot{
+				Aux: map[string]string{},
+				Entries: []Entry{
+					{Key: "set", Type: SetValueType, Value: [][]byte{[]byte("a"), []byte("-5"), make([]byte, 300)}},
+				},
+			},
+		},
+		{
+			name: "a snapshot with hash values",
+			snapshot: Snapshot{
+				Aux: map[string]string{},
+				Entries: []Entry{
+					{Key: "hash", Type: HashValueType, Value: []HashField{
+						{Field: "a", Value: []byte("1")},
+						{Field: "", Value: make([]byte, 300)},
+					}},
+				},
+			},
+		},
+		{
+			name: "a snapshot with hash field expiries",
+			snapshot: Snapshot{
+				Aux: map[string]string{},
+				Entries: []Entry{
+					{Key: "hash", Type: HashValueType, Value: []HashField{
+						{Field: "a", Value: []byte("1"), ExpiresAt: &expiry},
+						{Field: "b", Value: []byte("2")},
+						{Field: "c", Value: []byte("3"), ExpiresAt: &laterExpiry},
+					}},
+				},
+			},
+		},
+		{
+			name: "a snapshot with expiries and multiple databases",
+			snapshot: Snapshot{
+				Aux: map[string]string{},
+				Entries: []Entry{
+					{Key: "a", Type: StringValueType, Value: []byte("b"), ExpiresAt: &expiry},
+					{DB: 2, Key: "c", Type: StringValueType, Value: []byte("d")},
+				},
+			},
+		},
+	} {
+		t.Run(fmt.Sprintf("should be able to decode an encoded version of %s", tc.name), func(t *testing.T) {
+			data, err := Encode(tc.snapshot)
+			require.NoError(t, err)
+
+			snapshot, err := Decode(data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.snapshot, snapshot)
+		})
+	}
+}
+
+func TestEncodeVersion(t *testing.T) {
+	expiry := time.UnixMilli(time.Now().Add(time.Hour).UnixMilli())
+	snapshot := Snapshot{
+		Aux: map[string]string{},
+		Entries: []Entry{
+			{Key: "hash", Type: HashValueType, Value: []HashField{{Field: "a", Value: []byte("1"), ExpiresAt: &expiry}}},
+		},
+	}
+
+	data, err := Encode(snapshot)
+	require.NoError(t, err)
+
+	// Hashes with field expiries are stored as a type that only exists from version 12
+	assert.Equal(t, "REDIS0012", string(data[:9]))
+	assert.Contains(t, string(data), string([]byte{byte(hashMetadataValueType), 0x04, 'h', 'a', 's', 'h'}))
+
+	res, err := Decode(data)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, res)
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.rdb")
+	snapshot := Snapshot{
+		Aux:     map[string]string{},
+		Entries: []Entry{{Key: "a", Type: StringValueType, Value: []byte("b")}},
+	}
+
+	require.NoError(t, WriteFile(path, snapshot))
+
+	res, err := DecodeFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, res)
+}
@@ -0,0 +1,122 @@
+package rdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// A listpack starts with its total size in bytes and its number of elements, and ends with this byte
+const (
+	listpackHeaderSize = 6
+	listpackEnd        = 0xFF
+)
+
+// decodeListpack decodes the elements of a listpack. Each element is stored as an encoding byte, the element
+// itself, and then the length of the encoding and element so that the listpack can be walked backwards. Integers
+// are converted back to the strings that they were stored from
+func decodeListpack(data []byte) ([][]byte, error) {
+	if len(data) < listpackHeaderSize+1 {
+		return nil, fmt.Errorf("listpack of %d bytes is too short", len(data))
+	}
+	if totalBytes := binary.LittleEndian.Uint32(data); int(totalBytes) != len(data) {
+		return nil, fmt.Errorf("listpack header says it has %d bytes but it has %d", totalBytes, len(data))
+	}
+
+	var elements [][]byte
+	pos := listpackHeaderSize
+	for {
+		if pos >= len(data) {
+			return nil, errors.New("listpack is missing its end byte")
+		}
+		if data[pos] == listpackEnd {
+			return elements, nil
+		}
+
+		element, entryLength, err := decodeListpackEntry(data[pos:])
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+
+		pos += entryLength + listpackBacklenSize(entryLength)
+	}
+}
+
+// decodeListpackEntry decodes the element at the start of data, and returns it along with the number of bytes
+// that its encoding and contents take up
+func decodeListpackEntry(data []byte) ([]byte, int, error) {
+	first := data[0]
+
+	var headerSize, strLength int
+	switch {
+	case first&0x80 == 0:
+		// 7 bit unsigned integer
+		return strconv.AppendInt(nil, int64(first&0x7F), 10), 1, nil
+	case first&0xC0 == 0x80:
+		// String with a 6 bit length
+		headerSize, strLength = 1, int(first&0x3F)
+	case first&0xE0 == 0xC0:
+		// 13 bit signed integer
+		if len(data) < 2 {
+			return nil, 0, errors.New("listpack entry is truncated")
+		}
+		value := int64(first&0x1F)<<8 | int64(data[1])
+		if value >= 1<<12 {
+			value -= 1 << 13
+		}
+		return strconv.AppendInt(nil, value, 10), 2, nil
+	case first&0xF0 == 0xE0:
+		// String with a 12 bit length
+		if len(data) < 2 {
+			return nil, 0, errors.New("listpack entry is truncated")
+		}
+		headerSize, strLength = 2, int(first&0x0F)<<8|int(data[1])
+	case first == 0xF0:
+		// String with a 32 bit length
+		if len(data) < 5 {
+			return nil, 0, errors.New("listpack entry is truncated")
+		}
+		headerSize, strLength = 5, int(binary.LittleEndian.Uint32(data[1:]))
+	case first >= 0xF1 && first <= 0xF4:
+		// 16, 24, 32 or 64 bit signed integer
+		numBytes := map[byte]int{0xF1: 2, 0xF2: 3, 0xF3: 4, 0xF4: 8}[first]
+		if len(data) < 1+numBytes {
+			return nil, 0, errors.New("listpack entry is truncated")
+		}
+
+		var raw uint64
+		for i := numBytes; i > 0; i-- {
+			raw = raw<<8 | uint64(data[i])
+		}
+		// Sign extend the integer from however many bits it was stored in
+		shift := 64 - 8*numBytes
+		value := int64(raw<<shift) >> shift
+		return strconv.AppendInt(nil, value, 10), 1 + numBytes, nil
+	default:
+		return nil, 0, fmt.Errorf("unknown listpack entry encoding 0x%X", first)
+	}
+
+	if len(data)-headerSize < strLength {
+		return nil, 0, errors.New("listpack entry is truncated")
+	}
+	return bytes.Clone(data[headerSize : headerSize+strLength]), headerSize + strLength, nil
+}
+
+// listpackBacklenSize is the number of bytes that the length of an entry takes up after it. The length is stored
+// with 7 bits in each byte
+func listpackBacklenSize(entryLength int) int {
+	switch {
+	case entryLength < 1<<7:
+		return 1
+	case entryLength < 1<<14:
+		return 2
+	case entryLength < 1<<21:
+		return 3
+	case entryLength < 1<<28:
+		return 4
+	}
+	return 5
+}
@@ -0,0 +1,60 @@
+package rdb
+
+import (
+	"errors"
+	"fmt"
+)
+
+// lzfDecompress decompresses data that was compressed with the LZF algorithm. The format is a sequence of chunks, each
+// starting with a control byte. Control bytes below 32 are followed by a run of (ctrl + 1) literal bytes, while any
+// other control byte is a back reference to data that has already been decompressed
+func lzfDecompress(in []byte, uncompressedLength uint64) ([]byte, error) {
+	if uncompressedLength > uint64(len(in))*256 {
+		return nil, fmt.Errorf("LZF uncompressed length %d is too large for %d bytes of input", uncompressedLength, len(in))
+	}
+
+	out := make([]byte, 0, uncompressedLength)
+	for idx := 0; idx < len(in); {
+		ctrl := int(in[idx])
+		idx++
+
+		if ctrl < 32 {
+			literalLength := ctrl + 1
+			if idx+literalLength > len(in) {
+				return nil, errors.New("LZF literal run extends past the end of the input")
+			}
+			out = append(out, in[idx:idx+literalLength]...)
+			idx += literalLength
+			continue
+		}
+
+		refLength := ctrl >> 5
+		if refLength == 7 {
+			if idx >= len(in) {
+				return nil, errors.New("LZF back reference length extends past the end of the input")
+			}
+			refLength += int(in[idx])
+			idx++
+		}
+		refLength += 2
+
+		if idx >= len(in) {
+			return nil, errors.New("LZF back reference offset extends past the end of the input")
+		}
+		refStart := len(out) - ((ctrl & 0x1F) << 8) - int(in[idx]) - 1
+		idx++
+		if refStart < 0 {
+			return nil, errors.New("LZF back reference points before the start of the output")
+		}
+
+		// The reference may overlap with the bytes being written, so this has to be copied byte by byte
+		for i := range refLength {
+			out = append(out, out[refStart+i])
+		}
+	}
+
+	if uint64(len(out)) != uncompressedLength {
+		return nil, fmt.Errorf("LZF data decompressed to %d bytes but expected %d", len(out), uncompressedLength)
+	}
+	return out, nil
+}
@@ -0,0 +1,121 @@
+package rdb
+
+import (
+	"hash/crc64"
+	"time"
+)
+
+const (
+	magicString = "REDIS"
+
+	// The newest RDB format version that this package is able to read
+	maxSupportedVersion = 12
+
+	// RDB versions before 5 did not include a checksum at the end of the file
+	minChecksumVersion = 5
+)
+
+// Opcodes that can appear in place of a value type in an RDB file
+const (
+	opCodeFunction2    byte = 0xF5
+	opCodeModuleAux    byte = 0xF7
+	opCodeIdle         byte = 0xF8
+	opCodeFreq         byte = 0xF9
+	opCodeAux          byte = 0xFA
+	opCodeResizeDB     byte = 0xFB
+	opCodeExpireTimeMs byte = 0xFC
+	opCodeExpireTime   byte = 0xFD
+	opCodeSelectDB     byte = 0xFE
+	opCodeEOF          byte = 0xFF
+)
+
+// The two most significant bits of the first byte of a length determine how it is encoded
+const (
+	len6Bit    = 0
+	len14Bit   = 1
+	len32Or64  = 2
+	lenEncoded = 3
+
+	len32Bit = 0x80
+	len64Bit = 0x81
+)
+
+// If a length is "encoded", the remaining 6 bits of the first byte determine the string's encoding
+const (
+	encodingInt8  = 0
+	encodingInt16 = 1
+	encodingInt32 = 2
+	encodingLZF   = 3
+)
+
+type ValueType byte
+
+const (
+	StringValueType ValueType = 0
+	ListValueType   ValueType = 1
+	SetValueType    ValueType = 2
+	HashValueType   ValueType = 4
+)
+
+// Value types that are only ever read. Redis 7 saves lists as quicklists of listpacks, small sets as an intset or a
+// single listpack and small hashes as a single listpack, which are decoded into an Entry with the ListValueType,
+// SetValueType or HashValueType
+const (
+	setIntsetValueType      ValueType = 11
+	hashListpackValueType   ValueType = 16
+	listQuicklist2ValueType ValueType = 18
+	setListpackValueType    ValueType = 20
+	hashListpackExValueType ValueType = 25
+)
+
+// Hashes that have fields with expiries are saved as this type, which redis 7.4 added, and are decoded into an Entry
+// with the HashValueType
+const hashMetadataValueType ValueType = 24
+
+// The container types of the nodes in a quicklist. A packed node holds a listpack, while a plain node holds a single
+// large element
+const (
+	quicklistNodePlain  = 1
+	quicklistNodePacked = 2
+)
+
+// Snapshot is the decoded contents of an RDB file
+type Snapshot struct {
+	// Aux holds the auxiliary metadata fields (redis-ver, ctime, etc.) found in the file
+	Aux map[string]string
+
+	Entries []Entry
+}
+
+// Entry is a single key and value read from an RDB file
+type Entry struct {
+	// The index of the database that this entry was found in
+	DB int
+
+	Key  string
+	Type ValueType
+
+	// Value holds the decoded value. For StringValueType this is a []byte, for ListValueType and SetValueType it's a
+	// [][]byte and for HashValueType it's a []HashField
+	Value any
+
+	// ExpiresAt is nil if the key does not have an expiry
+	ExpiresAt *time.Time
+}
+
+// HashField is a single field of a hash and the value stored at it
+type HashField struct {
+	Field string
+	Value []byte
+
+	// ExpiresAt is nil if the field does not have an expiry
+	ExpiresAt *time.Time
+}
+
+// Redis uses the Jones polynomial with no initial or final inversion, so we store the reflected form of the polynomial
+// and undo the inversions that the standard library applies
+var crcTable = crc64.MakeTable(0x95ac9329ac4bc9b5)
+
+func checksum(data []byte) uint64 {
+	return ^crc64.Update(^uint64(0), crcTable, data)
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"sync"
+
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+// blockedClients tracks the client connections that are waiting on some condition (for example a WAIT command)
+// before they are sent a reply. While a client is blocked, its clientHandler stops sending its commands to the
+// event loop, but the event loop itself keeps serving every other client
+type blockedClients struct {
+	mu      sync.Mutex
+	clients map[connection.Connection]*blockedClient
+}
+
+type blockedClient struct {
+	// unblocked is closed once the client has been sent its reply
+	unblocked chan struct{}
+
+	// onDisconnect is run on the event loop if the client disconnects while it is still blocked
+	onDisconnect func()
+}
+
+func newBlockedClients() *blockedClients {
+	return &blockedClients{
+		clients: map[connection.Connection]*blockedClient{},
+	}
+}
+
+// block marks a client as blocked until unblock is called for its connection
+func (b *blockedClients) block(conn connection.Connection, onDisconnect func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.clients[conn] = &blockedClient{
+		unblocked:    make(chan struct{}),
+		onDisconnect: onDisconnect,
+	}
+}
+
+// unblock allows a blocked client to send commands again
+func (b *blockedClients) unblock(conn connection.Connection) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	client, ok := b.clients[conn]
+	if !ok {
+		return
+	}
+	close(client.unblocked)
+	delete(b.clients, conn)
+}
+
+// unblocked returns a channel that is closed once the client is no longer blocked
+func (b *blockedClients) unblocked(conn connection.Connection) <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	client, ok := b.clients[conn]
+	if !ok {
+		closedChan := make(chan struct{})
+		close(closedChan)
+		return closedChan
+	}
+	return client.unblocked
+}
+
+// disconnect cleans up after a client that disconnected. This must be called from the event loop
+func (b *blockedClients) disconnect(conn connection.Connection) {
+	b.mu.Lock()
+	client, ok := b.clients[conn]
+	delete(b.clients, conn)
+	b.mu.Unlock()
+
+	if ok && client.onDisconnect != nil {
+		client.onDisconnect()
+	}
+}
@@ -1,11 +1,11 @@
 package server
 
 import (
-	"errors"
 	"fmt"
 	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -37,6 +37,11 @@ type commandExecutor struct {
 	conn   connection.Connection
 }
 
+// encoder returns an encoder for the RESP version that the client has negotiated
+func (e commandExecutor) encoder(useBulkStrings bool) command.Encoder {
+	return command.Encoder{UseBulkStrings: useBulkStrings, Protocol: e.conn.ClientState().Protocol}
+}
+
 func (e commandExecutor) execute(cmd command.Command) error {
 	switch typedCommand := cmd.(type) {
 	case command.Ping:
@@ -51,32 +56,157 @@ func (e commandExecutor) execute(cmd command.Command) error {
 		return e.executeGet(typedCommand)
 	case command.Set:
 		return e.executeSet(typedCommand)
+	case command.IncrBy:
+		return e.executeIncrBy(typedCommand)
+	case command.IncrByFloat:
+		return e.executeIncrByFloat(typedCommand)
+	case command.SetNX:
+		return e.executeSetNX(typedCommand)
+	case command.MGet:
+		return e.executeMGet(typedCommand)
+	case command.MSet:
+		return e.executeMSet(typedCommand)
+	case command.MSetNX:
+		return e.executeMSetNX(typedCommand)
+	case command.Append:
+		return e.executeAppend(typedCommand)
+	case command.StrLen:
+		return e.executeStrLen(typedCommand)
+	case command.GetRange:
+		return e.executeGetRange(typedCommand)
+	case command.SetRange:
+		return e.executeSetRange(typedCommand)
+	case command.GetDel:
+		return e.executeGetDel(typedCommand)
+	case command.GetEx:
+		return e.executeGetEx(typedCommand)
+	case command.Push:
+		return e.executePush(typedCommand)
+	case command.Pop:
+		return e.executePop(typedCommand)
+	case command.LRange:
+		return e.executeLRange(typedCommand)
+	case command.LLen:
+		return e.executeLLen(typedCommand)
+	case command.LIndex:
+		return e.executeLIndex(typedCommand)
+	case command.LSet:
+		return e.executeLSet(typedCommand)
+	case command.LInsert:
+		return e.executeLInsert(typedCommand)
+	case command.LRem:
+		return e.executeLRem(typedCommand)
+	case command.LTrim:
+		return e.executeLTrim(typedCommand)
+	case command.LPos:
+		return e.executeLPos(typedCommand)
+	case command.LMove:
+		return e.executeLMove(typedCommand)
+	case command.BPop:
+		return e.executeBPop(typedCommand)
+	case command.BLMove:
+		return e.executeBLMove(typedCommand)
+	case command.LMPop:
+		return e.executeLMPop(typedCommand)
+	case command.HSet:
+		return e.executeHSet(typedCommand)
+	case command.HSetNX:
+		return e.executeHSetNX(typedCommand)
+	case command.HGet:
+		return e.executeHGet(typedCommand)
+	case command.HMGet:
+		return e.executeHMGet(typedCommand)
+	case command.HDel:
+		return e.executeHDel(typedCommand)
+	case command.HGetAll:
+		return e.executeHGetAll(typedCommand)
+	case command.HLen:
+		return e.executeHLen(typedCommand)
+	case command.HExists:
+		return e.executeHExists(typedCommand)
+	case command.HIncrBy:
+		return e.executeHIncrBy(typedCommand)
+	case command.HIncrByFloat:
+		return e.executeHIncrByFloat(typedCommand)
+	case command.HRandField:
+		return e.executeHRandField(typedCommand)
+	case command.HScan:
+		return e.executeHScan(typedCommand)
+	case command.HExpire:
+		return e.executeHExpire(typedCommand)
+	case command.HTTL:
+		return e.executeHTTL(typedCommand)
+	case command.HPersist:
+		return e.executeHPersist(typedCommand)
+	case command.SAdd:
+		return e.executeSAdd(typedCommand)
+	case command.SRem:
+		return e.executeSRem(typedCommand)
+	case command.SMembers:
+		return e.executeSMembers(typedCommand)
+	case command.SIsMember:
+		return e.executeSIsMember(typedCommand)
+	case command.SMIsMember:
+		return e.executeSMIsMember(typedCommand)
+	case command.SCard:
+		return e.executeSCard(typedCommand)
+	case command.SPop:
+		return e.executeSPop(typedCommand)
+	case command.SRandMember:
+		return e.executeSRandMember(typedCommand)
+	case command.SMove:
+		return e.executeSMove(typedCommand)
+	case command.SCombine:
+		return e.executeSCombine(typedCommand)
+	case command.SCombineStore:
+		return e.executeSCombineStore(typedCommand)
+	case command.SInterCard:
+		return e.executeSInterCard(typedCommand)
+	case command.SScan:
+		return e.executeSScan(typedCommand)
+	case command.Del:
+		return e.executeDel(typedCommand)
+	case command.Unlink:
+		return e.executeUnlink(typedCommand)
+	case command.Exists:
+		return e.executeExists(typedCommand)
+	case command.Type:
+		return e.executeType(typedCommand)
+	case command.Rename:
+		return e.executeRename(typedCommand)
+	case command.RenameNX:
+		return e.executeRenameNX(typedCommand)
+	case command.Copy:
+		return e.executeCopy(typedCommand)
+	case command.Expire:
+		return e.executeExpire(typedCommand)
+	case command.TTL:
+		return e.executeTTL(typedCommand)
+	case command.Persist:
+		return e.executePersist(typedCommand)
 	case command.ReplConf:
 		return e.executeReplConf(typedCommand)
 	case command.PSync:
 		return e.executePSync(typedCommand)
+	case command.Save:
+		return e.executeSave(typedCommand)
+	case command.BgSave:
+		return e.executeBgSave(typedCommand)
+	case command.LastSave:
+		return e.executeLastSave(typedCommand)
+	case command.Hello:
+		return e.executeHello(typedCommand)
 	}
 
 	return fmt.Errorf("unknown command: %T", cmd)
 }
 
 func (e commandExecutor) executePing(_ command.Ping) error {
-	if _, err := e.conn.WriteString("+PONG\r\n"); err != nil {
-		return fmt.Errorf("error writing reponse to PING command to client: %w", err)
-	}
-	return nil
+	return e.writeReply(command.PingCmd, "PONG")
 }
 
 func (e commandExecutor) executeEcho(echo command.Echo) error {
-	resStr, err := command.Encoder{}.EncodePrimitive(echo.Payload)
-	if err != nil {
-		return fmt.Errorf("error encoding response for ECHO command: %w", err)
-	}
-
-	if _, err := e.conn.WriteString(resStr); err != nil {
-		return fmt.Errorf("error writing reponse to ECHO command to client: %w", err)
-	}
-	return nil
+	return e.writeReply(command.EchoCmd, []byte(echo.Payload))
 }
 
 // TODO: Testing once fn returns are a bit more stable
@@ -93,82 +223,974 @@ func (e commandExecutor) executeInfo(info command.Info) error {
 	}
 	slices.Sort(infoToEncode)
 
-	encoder := command.Encoder{UseBulkStrings: true}
-	res, err := encoder.Encode(strings.Join(infoToEncode, ""))
+	return e.writeReply(command.InfoCmd, command.VerbatimString{Format: "txt", Text: strings.Join(infoToEncode, "")})
+}
+
+func (e commandExecutor) executeWait(wait command.Wait) error {
+	master, ok := e.server.(*MasterServer)
+	if !ok {
+		return command.ErrorReply("ERR WAIT cannot be used with replica instances")
+	}
+
+	return master.waitForReplicas(e.conn, wait.NumReplicas, time.Duration(wait.WaitForMs)*time.Millisecond)
+}
+
+func (e commandExecutor) executeGet(get command.Get) error {
+	value, ok, err := e.server.Get(get.Payload)
 	if err != nil {
-		return fmt.Errorf("error encoding response for INFO command: %w", err)
+		return err
 	}
 
-	if _, err := e.conn.WriteString(res); err != nil {
-		return fmt.Errorf("error writing reponse to INFO command to client: %w", err)
+	var data any = command.Null{}
+	if ok {
+		data = value
 	}
 
-	return nil
+	return e.writeReply(command.GetCmd, data)
 }
 
-func (e commandExecutor) executeWait(_ command.Wait) error {
-	switch typedServer := e.server.(type) {
-	case *MasterServer:
-		waitRes, err := command.Encoder{}.EncodePrimitive(len(typedServer.registeredReplicaConns))
+// executeSet replies with OK, or with a null if NX or XX stopped the value from being stored. With GET, it replies
+// with the string that was at the key before instead
+func (e commandExecutor) executeSet(set command.Set) error {
+	options := SetOptions{KeepTTL: set.ExpiryOption == command.KeepTTL, Condition: set.Condition, Get: set.Get}
+	if set.ExpiryOption.HasTime() {
+		expiresAt, err := set.ExpiresAt(time.Now())
 		if err != nil {
-			return fmt.Errorf("failed to encode response to wait command: %w", err)
+			return err
+		}
+		options.ExpiresAt = &expiresAt
+	}
+
+	stored, previous, err := e.server.Set(set.KeyPayload, set.ValuePayload, options)
+	if err != nil {
+		return err
+	}
+
+	var data any = "OK"
+	if set.Get || !stored {
+		data = command.Null{}
+		if previous != nil {
+			data = previous
 		}
+	}
+	return e.writeReply(command.SetCmd, data)
+}
+
+func (e commandExecutor) executeIncrBy(incr command.IncrBy) error {
+	result, err := e.server.IncrBy(incr.Key, incr.Increment)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(incr.Cmd, result)
+}
 
-		if _, err := e.conn.WriteString(waitRes); err != nil {
-			return fmt.Errorf("error writing reponse to WAIT command to client: %w", err)
+func (e commandExecutor) executeIncrByFloat(incr command.IncrByFloat) error {
+	result, err := e.server.IncrByFloat(incr.Key, incr.Increment)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.IncrByFloatCmd, result)
+}
+
+func (e commandExecutor) executeSetNX(set command.SetNX) error {
+	stored, _, err := e.server.Set(set.Key, set.Value, SetOptions{Condition: command.SetIfMissing})
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.SetNXCmd, boolToInt(stored))
+}
+
+func (e commandExecutor) executeMGet(mget command.MGet) error {
+	values := e.server.MGet(mget.Keys...)
+
+	data := make([]any, 0, len(values))
+	for _, value := range values {
+		if value == nil {
+			data = append(data, command.Null{})
+		} else {
+			data = append(data, value)
 		}
-		return nil
-	case *ReplicaServer:
 	}
-	return errors.New("server was an invalid type while processing wait command")
+
+	return e.writeReply(command.MGetCmd, data)
 }
 
-func (e commandExecutor) executeGet(get command.Get) error {
-	responseString := command.NullBulkString
+func (e commandExecutor) executeMSet(mset command.MSet) error {
+	e.server.MSet(mset.Pairs, false)
+
+	return e.writeReply(command.MSetCmd, "OK")
+}
+
+func (e commandExecutor) executeMSetNX(mset command.MSetNX) error {
+	return e.writeReply(command.MSetNXCmd, boolToInt(e.server.MSet(mset.Pairs, true)))
+}
+
+func (e commandExecutor) executeAppend(appendCmd command.Append) error {
+	result, err := e.server.Append(appendCmd.Key, appendCmd.Value)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.AppendCmd, result)
+}
+
+func (e commandExecutor) executeStrLen(strLen command.StrLen) error {
+	result, err := e.server.StrLen(strLen.Key)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.StrLenCmd, result)
+}
+
+func (e commandExecutor) executeGetRange(getRange command.GetRange) error {
+	result, err := e.server.GetRange(getRange.Key, getRange.Start, getRange.End)
+	if err != nil {
+		return err
+	}
 
-	data, ok := e.server.Get(get.Payload)
+	return e.writeReply(command.GetRangeCmd, result)
+}
+
+func (e commandExecutor) executeSetRange(setRange command.SetRange) error {
+	result, err := e.server.SetRange(setRange.Key, setRange.Offset, setRange.Value)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.SetRangeCmd, result)
+}
+
+func (e commandExecutor) executeGetDel(getDel command.GetDel) error {
+	value, ok, err := e.server.GetDel(getDel.Key)
+	if err != nil {
+		return err
+	}
+
+	var data any = command.Null{}
 	if ok {
-		var err error
-		responseString, err = command.Encoder{}.EncodePrimitive(data)
+		data = value
+	}
+
+	return e.writeReply(command.GetDelCmd, data)
+}
+
+func (e commandExecutor) executeGetEx(getEx command.GetEx) error {
+	var expiresAt *time.Time
+	if getEx.ExpiryOption.HasTime() {
+		expiryTime, err := getEx.ExpiresAt(time.Now())
 		if err != nil {
-			return fmt.Errorf("error encoding response for GET command: %w", err)
+			return err
 		}
+		expiresAt = &expiryTime
+	}
+
+	value, ok, err := e.server.GetEx(getEx.Key, expiresAt, getEx.ExpiryOption == command.ExpiryPersist)
+	if err != nil {
+		return err
+	}
+
+	var data any = command.Null{}
+	if ok {
+		data = value
 	}
 
-	if _, err := e.conn.WriteString(responseString); err != nil {
-		return fmt.Errorf("error writing reponse to GET command to client: %w", err)
+	return e.writeReply(command.GetExCmd, data)
+}
+
+func (e commandExecutor) executePush(push command.Push) error {
+	length, err := e.server.ListPush(push.Key, push.End(), push.Values, push.OnlyIfExists())
+	if err != nil {
+		return err
 	}
 
+	return e.writeReply(push.Cmd, length)
+}
+
+// executePop replies with the popped element, or with an array of the popped elements if a count was provided. A
+// missing list is a null either way
+func (e commandExecutor) executePop(pop command.Pop) error {
+	popped, ok, err := e.server.ListPop(pop.Key, pop.End(), pop.Count)
+	if err != nil {
+		return err
+	}
+
+	var data any
+	switch {
+	case !ok && pop.WithCount:
+		data = command.NullArray{}
+	case !ok:
+		data = command.Null{}
+	case pop.WithCount:
+		elements := make([]any, 0, len(popped))
+		for _, element := range popped {
+			elements = append(elements, element)
+		}
+		data = elements
+	default:
+		data = popped[0]
+	}
+	return e.writeReply(pop.Cmd, data)
+}
+
+func (e commandExecutor) executeLRange(lrange command.LRange) error {
+	elements, err := e.server.LRange(lrange.Key, lrange.Start, lrange.End)
+	if err != nil {
+		return err
+	}
+
+	data := make([]any, 0, len(elements))
+	for _, element := range elements {
+		data = append(data, element)
+	}
+
+	return e.writeReply(command.LRangeCmd, data)
+}
+
+func (e commandExecutor) executeLLen(llen command.LLen) error {
+	length, err := e.server.LLen(llen.Key)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.LLenCmd, length)
+}
+
+func (e commandExecutor) executeLIndex(lindex command.LIndex) error {
+	element, ok, err := e.server.LIndex(lindex.Key, lindex.Index)
+	if err != nil {
+		return err
+	}
+
+	var data any = command.Null{}
+	if ok {
+		data = element
+	}
+
+	return e.writeReply(command.LIndexCmd, data)
+}
+
+func (e commandExecutor) executeLSet(lset command.LSet) error {
+	if err := e.server.LSet(lset.Key, lset.Index, lset.Value); err != nil {
+		return err
+	}
+
+	return e.writeReply(command.LSetCmd, "OK")
+}
+
+func (e commandExecutor) executeLInsert(linsert command.LInsert) error {
+	length, err := e.server.LInsert(linsert.Key, linsert.Before, linsert.Pivot, linsert.Value)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.LInsertCmd, length)
+}
+
+func (e commandExecutor) executeLRem(lrem command.LRem) error {
+	removed, err := e.server.LRem(lrem.Key, lrem.Count, lrem.Value)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.LRemCmd, removed)
+}
+
+func (e commandExecutor) executeLTrim(ltrim command.LTrim) error {
+	if err := e.server.LTrim(ltrim.Key, ltrim.Start, ltrim.End); err != nil {
+		return err
+	}
+
+	return e.writeReply(command.LTrimCmd, "OK")
+}
+
+// executeLPos replies with the index of the first match, or a null if there isn't one. With a count, it replies
+// with an array of the indexes of the matches instead
+func (e commandExecutor) executeLPos(lpos command.LPos) error {
+	count := lpos.Count
+	if !lpos.WithCount {
+		count = 1
+	}
+
+	matches, err := e.server.LPos(lpos.Key, lpos.Value, lpos.Rank, count, lpos.MaxLen)
+	if err != nil {
+		return err
+	}
+
+	var data any
+	switch {
+	case lpos.WithCount:
+		data = intsToArray(matches)
+	case len(matches) == 0:
+		data = command.Null{}
+	default:
+		data = matches[0]
+	}
+	return e.writeReply(command.LPosCmd, data)
+}
+
+func (e commandExecutor) executeLMove(lmove command.LMove) error {
+	element, ok, err := e.server.LMove(lmove.Source, lmove.Destination, lmove.From, lmove.To)
+	if err != nil {
+		return err
+	}
+
+	var data any = command.Null{}
+	if ok {
+		data = element
+	}
+
+	return e.writeReply(command.LMoveCmd, data)
+}
+
+func (e commandExecutor) executeBPop(bpop command.BPop) error {
+	return e.executeBlockingListCommand(bpop, bpop.Keys, bpop.TimeoutMs, command.NullArray{})
+}
+
+func (e commandExecutor) executeBLMove(blmove command.BLMove) error {
+	return e.executeBlockingListCommand(blmove, []string{blmove.Source}, blmove.TimeoutMs, command.Null{})
+}
+
+// executeLMPop pops from the first of the command's keys that holds a list. If none of them do, BLMPOP blocks the
+// client and LMPOP replies with a null straight away
+func (e commandExecutor) executeLMPop(lmpop command.LMPop) error {
+	if lmpop.Cmd == command.BLMPopCmd {
+		return e.executeBlockingListCommand(lmpop, lmpop.Keys, lmpop.TimeoutMs, command.NullArray{})
+	}
+
+	served, err := e.serveListCommand(lmpop)
+	if err != nil || served {
+		return err
+	}
+	return e.writeReply(lmpop.Cmd, command.NullArray{})
+}
+
+// executeBlockingListCommand serves a blocking list command from the first of its keys that holds a list. If none
+// of them do, a master blocks the client until one of keys is pushed to or the timeout passes, at which point the
+// client is sent timeoutReply. Replicas never block clients, so they send timeoutReply straight away
+func (e commandExecutor) executeBlockingListCommand(cmd command.Command, keys []string, timeoutMs int64, timeoutReply any) error {
+	served, err := e.serveListCommand(cmd)
+	if err != nil || served {
+		return err
+	}
+
+	master, ok := e.server.(*MasterServer)
+	if !ok {
+		return e.writeReply(cmd.CommandType(), timeoutReply)
+	}
+
+	master.blockOnLists(e.conn, cmd, keys, time.Duration(timeoutMs)*time.Millisecond, timeoutReply)
 	return nil
 }
 
-func (e commandExecutor) executeSet(set command.Set) error {
-	e.server.Set(set.KeyPayload, set.ValuePayload, set.ExpiryTimeMs)
+// serveListCommand runs a blocking list command, or an LMPOP, against the first of its keys that holds a list and
+// replies to it. It returns false without replying if none of its keys hold a list. This is also used to serve
+// clients that are blocked on the command once one of its keys is pushed to
+func (e commandExecutor) serveListCommand(cmd command.Command) (bool, error) {
+	switch typedCommand := cmd.(type) {
+	case command.BPop:
+		key, popped, err := e.popFirstList(typedCommand.Keys, typedCommand.End(), 1)
+		if err != nil || popped == nil {
+			return false, err
+		}
+		return true, e.writeReply(typedCommand.Cmd, []any{[]byte(key), popped[0]})
+	case command.LMPop:
+		key, popped, err := e.popFirstList(typedCommand.Keys, typedCommand.End, typedCommand.Count)
+		if err != nil || popped == nil {
+			return false, err
+		}
 
-	if _, err := e.conn.WriteString(command.OKString); err != nil {
-		return fmt.Errorf("error writing reponse to SET command to client: %w", err)
+		elements := make([]any, 0, len(popped))
+		for _, element := range popped {
+			elements = append(elements, element)
+		}
+		return true, e.writeReply(typedCommand.Cmd, []any{[]byte(key), elements})
+	case command.BLMove:
+		element, ok, err := e.server.LMove(typedCommand.Source, typedCommand.Destination, typedCommand.From, typedCommand.To)
+		if err != nil || !ok {
+			return false, err
+		}
+		return true, e.writeReply(command.BLMoveCmd, element)
+	}
+
+	return false, fmt.Errorf("%v is not a blocking list command", cmd)
+}
+
+// popFirstList pops up to count elements from an end of the first of keys that holds a list, and returns that key
+// along with the popped elements. The popped elements are nil if none of keys hold a list
+func (e commandExecutor) popFirstList(keys []string, end command.ListEnd, count int64) (string, [][]byte, error) {
+	for _, key := range keys {
+		popped, ok, err := e.server.ListPop(key, end, count)
+		if err != nil {
+			return "", nil, err
+		}
+		if ok {
+			return key, popped, nil
+		}
+	}
+	return "", nil, nil
+}
+
+// writeReply encodes the reply to a command and sends it
+func (e commandExecutor) writeReply(cmdType command.CommandType, data any) error {
+	res, err := e.encoder(false).Encode(data)
+	if err != nil {
+		return fmt.Errorf("error encoding response for %s command: %w", cmdType, err)
+	}
+
+	if _, err := e.conn.WriteString(res); err != nil {
+		return fmt.Errorf("error writing reponse to %s command to client: %w", cmdType, err)
 	}
 
 	return nil
 }
 
+func (e commandExecutor) executeHSet(hset command.HSet) error {
+	added, err := e.server.HSet(hset.Key, hset.Pairs)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HSetCmd, added)
+}
+
+func (e commandExecutor) executeHSetNX(hset command.HSetNX) error {
+	stored, err := e.server.HSetNX(hset.Key, hset.Field, hset.Value)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HSetNXCmd, boolToInt(stored))
+}
+
+func (e commandExecutor) executeHGet(hget command.HGet) error {
+	value, ok, err := e.server.HGet(hget.Key, hget.Field)
+	if err != nil {
+		return err
+	}
+
+	var data any = command.Null{}
+	if ok {
+		data = value
+	}
+	return e.writeReply(command.HGetCmd, data)
+}
+
+func (e commandExecutor) executeHMGet(hmget command.HMGet) error {
+	values, err := e.server.HMGet(hmget.Key, hmget.Fields...)
+	if err != nil {
+		return err
+	}
+
+	data := make([]any, 0, len(values))
+	for _, value := range values {
+		if value == nil {
+			data = append(data, command.Null{})
+		} else {
+			data = append(data, value)
+		}
+	}
+	return e.writeReply(command.HMGetCmd, data)
+}
+
+func (e commandExecutor) executeHDel(hdel command.HDel) error {
+	removed, err := e.server.HDel(hdel.Key, hdel.Fields...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HDelCmd, removed)
+}
+
+// executeHGetAll replies with the fields of a hash and their values for HGETALL, which is a map under RESP3, or
+// with just the fields or just the values for HKEYS and HVALS
+func (e commandExecutor) executeHGetAll(hgetall command.HGetAll) error {
+	entries, err := e.server.HGetAll(hgetall.Key)
+	if err != nil {
+		return err
+	}
+
+	switch hgetall.Cmd {
+	case command.HKeysCmd, command.HValsCmd:
+		data := make([]any, 0, len(entries))
+		for _, entry := range entries {
+			if hgetall.Cmd == command.HKeysCmd {
+				data = append(data, []byte(entry.Field))
+			} else {
+				data = append(data, entry.Value)
+			}
+		}
+		return e.writeReply(hgetall.Cmd, data)
+	}
+
+	data := make(command.MapReply, 0, len(entries))
+	for _, entry := range entries {
+		data = append(data, command.MapEntry{Key: []byte(entry.Field), Value: entry.Value})
+	}
+	return e.writeReply(hgetall.Cmd, data)
+}
+
+func (e commandExecutor) executeHLen(hlen command.HLen) error {
+	length, err := e.server.HLen(hlen.Key)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HLenCmd, length)
+}
+
+func (e commandExecutor) executeHExists(hexists command.HExists) error {
+	_, ok, err := e.server.HGet(hexists.Key, hexists.Field)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HExistsCmd, boolToInt(ok))
+}
+
+func (e commandExecutor) executeHIncrBy(hincr command.HIncrBy) error {
+	result, err := e.server.HIncrBy(hincr.Key, hincr.Field, hincr.Increment)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HIncrByCmd, result)
+}
+
+func (e commandExecutor) executeHIncrByFloat(hincr command.HIncrByFloat) error {
+	result, err := e.server.HIncrByFloat(hincr.Key, hincr.Field, hincr.Increment)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HIncrByFloatCmd, result)
+}
+
+// executeHRandField replies with a single random field, or a null if the hash doesn't exist. With a count, it
+// replies with an array of fields instead. WITHVALUES adds the value after each field, or pairs each field with its
+// value under RESP3
+func (e commandExecutor) executeHRandField(hrand command.HRandField) error {
+	count := hrand.Count
+	if !hrand.WithCount {
+		count = 1
+	}
+
+	entries, err := e.server.HRandField(hrand.Key, count)
+	if err != nil {
+		return err
+	}
+
+	if !hrand.WithCount {
+		if len(entries) == 0 {
+			return e.writeReply(command.HRandFieldCmd, command.Null{})
+		}
+		return e.writeReply(command.HRandFieldCmd, []byte(entries[0].Field))
+	}
+
+	resp3 := e.conn.ClientState().Protocol >= command.RESP3
+	data := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		switch {
+		case !hrand.WithValues:
+			data = append(data, []byte(entry.Field))
+		case resp3:
+			data = append(data, []any{[]byte(entry.Field), entry.Value})
+		default:
+			data = append(data, []byte(entry.Field), entry.Value)
+		}
+	}
+	return e.writeReply(command.HRandFieldCmd, data)
+}
+
+// executeHScan replies with the cursor to continue the scan from, followed by an array of the fields that were
+// scanned, each followed by its value unless NOVALUES is set
+func (e commandExecutor) executeHScan(hscan command.HScan) error {
+	cursor, entries, err := e.server.HScan(hscan.Key, hscan.Cursor, hscan.Match, hscan.Count)
+	if err != nil {
+		return err
+	}
+
+	data := make([]any, 0, len(entries)*2)
+	for _, entry := range entries {
+		data = append(data, []byte(entry.Field))
+		if !hscan.NoValues {
+			data = append(data, entry.Value)
+		}
+	}
+	return e.writeReply(command.HScanCmd, []any{[]byte(strconv.FormatUint(cursor, 10)), data})
+}
+
+func (e commandExecutor) executeSAdd(sadd command.SAdd) error {
+	added, err := e.server.SAdd(sadd.Key, sadd.Members...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SAddCmd, added)
+}
+
+func (e commandExecutor) executeSRem(srem command.SRem) error {
+	removed, err := e.server.SRem(srem.Key, srem.Members...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SRemCmd, removed)
+}
+
+// executeSMembers replies with the members of a set, which is a set under RESP3
+func (e commandExecutor) executeSMembers(smembers command.SMembers) error {
+	members, err := e.server.SMembers(smembers.Key)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SMembersCmd, command.SetReply(membersToArray(members)))
+}
+
+func (e commandExecutor) executeSIsMember(sismember command.SIsMember) error {
+	found, err := e.server.SIsMember(sismember.Key, sismember.Member)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SIsMemberCmd, boolToInt(found[0]))
+}
+
+func (e commandExecutor) executeSMIsMember(smismember command.SMIsMember) error {
+	found, err := e.server.SIsMember(smismember.Key, smismember.Members...)
+	if err != nil {
+		return err
+	}
+
+	data := make([]any, 0, len(found))
+	for _, isMember := range found {
+		data = append(data, boolToInt(isMember))
+	}
+	return e.writeReply(command.SMIsMemberCmd, data)
+}
+
+func (e commandExecutor) executeSCard(scard command.SCard) error {
+	length, err := e.server.SCard(scard.Key)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SCardCmd, length)
+}
+
+// executeSPop replies with a single popped member, or a null if the set doesn't exist. With a count, it replies
+// with the popped members instead, which are a set under RESP3
+func (e commandExecutor) executeSPop(spop command.SPop) error {
+	popped, err := e.server.SPop(spop.Key, spop.Count)
+	if err != nil {
+		return err
+	}
+
+	if spop.WithCount {
+		return e.writeReply(command.SPopCmd, command.SetReply(membersToArray(popped)))
+	}
+	if len(popped) == 0 {
+		return e.writeReply(command.SPopCmd, command.Null{})
+	}
+	return e.writeReply(command.SPopCmd, []byte(popped[0]))
+}
+
+// executeSRandMember replies with a single random member, or a null if the set doesn't exist. With a count, it
+// replies with an array of members instead
+func (e commandExecutor) executeSRandMember(srand command.SRandMember) error {
+	members, err := e.server.SRandMember(srand.Key, srand.Count)
+	if err != nil {
+		return err
+	}
+
+	if srand.WithCount {
+		return e.writeReply(command.SRandMemberCmd, membersToArray(members))
+	}
+	if len(members) == 0 {
+		return e.writeReply(command.SRandMemberCmd, command.Null{})
+	}
+	return e.writeReply(command.SRandMemberCmd, []byte(members[0]))
+}
+
+func (e commandExecutor) executeSMove(smove command.SMove) error {
+	moved, err := e.server.SMove(smove.Source, smove.Destination, smove.Member)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SMoveCmd, boolToInt(moved))
+}
+
+// executeSCombine replies with the members of the combined sets, which are a set under RESP3
+func (e commandExecutor) executeSCombine(scombine command.SCombine) error {
+	members, err := e.server.SetCombine(scombine.Operation(), scombine.Keys...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(scombine.Cmd, command.SetReply(membersToArray(members)))
+}
+
+func (e commandExecutor) executeSCombineStore(scombine command.SCombineStore) error {
+	length, err := e.server.SetCombineStore(scombine.Operation(), scombine.Destination, scombine.Keys...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(scombine.Cmd, length)
+}
+
+func (e commandExecutor) executeSInterCard(sintercard command.SInterCard) error {
+	count, err := e.server.SInterCard(sintercard.Limit, sintercard.Keys...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SInterCardCmd, count)
+}
+
+// executeSScan replies with the cursor to continue the scan from, followed by an array of the members that were
+// scanned
+func (e commandExecutor) executeSScan(sscan command.SScan) error {
+	cursor, members, err := e.server.SScan(sscan.Key, sscan.Cursor, sscan.Match, sscan.Count)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.SScanCmd, []any{[]byte(strconv.FormatUint(cursor, 10)), membersToArray(members)})
+}
+
+// membersToArray converts the members of a set to the array of bulk strings that a reply is encoded from
+func membersToArray(members []string) []any {
+	data := make([]any, 0, len(members))
+	for _, member := range members {
+		data = append(data, []byte(member))
+	}
+	return data
+}
+
+func (e commandExecutor) executeDel(del command.Del) error {
+	return e.writeReply(command.DelCmd, e.server.Delete(del.Keys...))
+}
+
+func (e commandExecutor) executeUnlink(unlink command.Unlink) error {
+	return e.writeReply(command.UnlinkCmd, e.server.Unlink(unlink.Keys...))
+}
+
+func (e commandExecutor) executeExists(exists command.Exists) error {
+	return e.writeReply(command.ExistsCmd, e.server.Exists(exists.Keys...))
+}
+
+func (e commandExecutor) executeType(typeCmd command.Type) error {
+	return e.writeReply(command.TypeCmd, e.server.Type(typeCmd.Key))
+}
+
+func (e commandExecutor) executeRename(rename command.Rename) error {
+	if _, err := e.server.Rename(rename.Key, rename.NewKey, false); err != nil {
+		return err
+	}
+
+	return e.writeReply(command.RenameCmd, "OK")
+}
+
+func (e commandExecutor) executeRenameNX(rename command.RenameNX) error {
+	renamed, err := e.server.Rename(rename.Key, rename.NewKey, true)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.RenameNXCmd, boolToInt(renamed))
+}
+
+func (e commandExecutor) executeCopy(copyCmd command.Copy) error {
+	copied, err := e.server.Copy(copyCmd.Source, copyCmd.Destination, copyCmd.Replace)
+	if err != nil {
+		return err
+	}
+
+	return e.writeReply(command.CopyCmd, boolToInt(copied))
+}
+
+func (e commandExecutor) executeHExpire(hexpire command.HExpire) error {
+	expiresAt, err := hexpire.ExpiresAt(time.Now())
+	if err != nil {
+		return err
+	}
+
+	results, err := e.server.HExpire(hexpire.Key, expiresAt, hexpire.Options, hexpire.Fields...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(hexpire.Cmd, intsToArray(results))
+}
+
+// executeHTTL replies with an array that has the TTL of each of the fields, in the same form as executeTTL: -2 if
+// the field doesn't exist and -1 if it never expires
+func (e commandExecutor) executeHTTL(httl command.HTTL) error {
+	expiries, err := e.server.HExpiry(httl.Key, httl.Fields...)
+	if err != nil {
+		return err
+	}
+
+	data := make([]any, 0, len(expiries))
+	for _, expiry := range expiries {
+		var reply int64
+		switch {
+		case !expiry.Exists:
+			reply = -2
+		case expiry.ExpiresAt == nil:
+			reply = -1
+		case httl.Cmd == command.HTTLCmd:
+			reply = (remainingMs(*expiry.ExpiresAt) + 500) / 1000
+		case httl.Cmd == command.HPTTLCmd:
+			reply = remainingMs(*expiry.ExpiresAt)
+		case httl.Cmd == command.HExpireTimeCmd:
+			reply = expiry.ExpiresAt.Unix()
+		default:
+			reply = expiry.ExpiresAt.UnixMilli()
+		}
+		data = append(data, reply)
+	}
+	return e.writeReply(httl.Cmd, data)
+}
+
+func (e commandExecutor) executeHPersist(hpersist command.HPersist) error {
+	results, err := e.server.HPersist(hpersist.Key, hpersist.Fields...)
+	if err != nil {
+		return err
+	}
+	return e.writeReply(command.HPersistCmd, intsToArray(results))
+}
+
+func (e commandExecutor) executeExpire(expire command.Expire) error {
+	expiresAt, err := expire.ExpiresAt(time.Now())
+	if err != nil {
+		return err
+	}
+
+	set := e.server.Expire(expire.Key, expiresAt, expire.Options)
+
+	return e.writeReply(expire.Cmd, boolToInt(set))
+}
+
+// executeTTL replies with -2 if the key doesn't exist and -1 if it never expires
+func (e commandExecutor) executeTTL(ttl command.TTL) error {
+	expiresAt, ok := e.server.Expiry(ttl.Key)
+
+	var reply int64
+	switch {
+	case !ok:
+		reply = -2
+	case expiresAt == nil:
+		reply = -1
+	case ttl.Cmd == command.TTLCmd:
+		// Round to the nearest second like redis does
+		reply = (remainingMs(*expiresAt) + 500) / 1000
+	case ttl.Cmd == command.PTTLCmd:
+		reply = remainingMs(*expiresAt)
+	case ttl.Cmd == command.ExpireTimeCmd:
+		reply = expiresAt.Unix()
+	default:
+		reply = expiresAt.UnixMilli()
+	}
+
+	return e.writeReply(ttl.Cmd, reply)
+}
+
+// remainingMs returns the number of milliseconds until expiresAt, or 0 if it has passed. Unlike time.Until, this
+// doesn't overflow for expiries that are hundreds of years away
+func remainingMs(expiresAt time.Time) int64 {
+	return max(expiresAt.UnixMilli()-time.Now().UnixMilli(), 0)
+}
+
+func (e commandExecutor) executePersist(persist command.Persist) error {
+	return e.writeReply(command.PersistCmd, boolToInt(e.server.Persist(persist.Key)))
+}
+
+func (e commandExecutor) executeSave(_ command.Save) error {
+	if err := e.server.Save(); err != nil {
+		e.server.Logger().Error("failed to save RDB file", zap.Error(err))
+		return command.ErrorReply("ERR " + err.Error())
+	}
+
+	return e.writeReply(command.SaveCmd, "OK")
+}
+
+func (e commandExecutor) executeBgSave(_ command.BgSave) error {
+	if err := e.server.BackgroundSave(); err != nil {
+		return command.ErrorReply("ERR " + err.Error())
+	}
+
+	return e.writeReply(command.BgSaveCmd, "Background saving started")
+}
+
+func (e commandExecutor) executeLastSave(_ command.LastSave) error {
+	return e.writeReply(command.LastSaveCmd, int(e.server.LastSave().Unix()))
+}
+
+func (e commandExecutor) executeHello(hello command.Hello) error {
+	switch {
+	case hello.ProtocolVersion != 0 && hello.ProtocolVersion != command.RESP2 && hello.ProtocolVersion != command.RESP3:
+		return command.ErrorReply("NOPROTO unsupported protocol version")
+	case hello.HasAuth() && hello.Username != defaultUsername:
+		// There is no ACL support, so the default user is the only user and it doesn't need a password
+		return command.ErrorReply("WRONGPASS invalid username-password pair or user is disabled.")
+	case !isValidClientName(hello.ClientName):
+		return command.ErrorReply("ERR Client names cannot contain spaces, newlines or special characters.")
+	}
+
+	clientState := e.conn.ClientState()
+	if hello.ProtocolVersion != 0 {
+		clientState.Protocol = hello.ProtocolVersion
+	}
+	if hello.ClientName != "" {
+		clientState.Name = hello.ClientName
+	}
+
+	role := "master"
+	if e.server.NodeType() != MasterNodeType {
+		role = "replica"
+	}
+
+	return e.writeReply(command.HelloCmd, command.MapReply{
+		{Key: []byte("server"), Value: []byte("redis")},
+		{Key: []byte("version"), Value: []byte(redisVersion)},
+		{Key: []byte("proto"), Value: clientState.Protocol},
+		{Key: []byte("id"), Value: clientState.ID},
+		{Key: []byte("mode"), Value: []byte("standalone")},
+		{Key: []byte("role"), Value: []byte(role)},
+		{Key: []byte("modules"), Value: []any{}},
+	})
+}
+
+// boolToInt converts a bool to the 1 or 0 that redis replies with for commands that report whether they did something
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// intsToArray converts a slice of ints to the array of integers that a reply is encoded from
+func intsToArray(ints []int) []any {
+	data := make([]any, 0, len(ints))
+	for _, i := range ints {
+		data = append(data, i)
+	}
+	return data
+}
+
+// isValidClientName is true if name only contains printable characters other than spaces
+func isValidClientName(name string) bool {
+	for _, char := range name {
+		if char < '!' || char > '~' {
+			return false
+		}
+	}
+	return true
+}
+
 func (e commandExecutor) executeReplConf(replConf command.ReplConf) error {
-	nodeType := e.server.NodeType()
 	switch typedServer := e.server.(type) {
 	case *MasterServer:
 		if replConf.IsAck() {
-			e.server.Logger().Info("Master node received ACK from replica", zap.String("offset", replConf.Payload[1]))
-			return nil
-		} else if replConf.IsListeningPort() {
-			if _, err := e.conn.WriteString(command.OKString); err != nil {
-				return fmt.Errorf("error writing reponse to REPLCONF command to client: %w", err)
-			}
-			return nil
-		} else if replConf.IsCapa() {
-			if _, err := e.conn.WriteString(command.OKString); err != nil {
-				return fmt.Errorf("error writing reponse to REPLCONF command to client: %w", err)
+			offset, err := strconv.ParseInt(replConf.Payload[1], 10, 64)
+			if err != nil {
+				// Like redis, a bad ACK is ignored rather than replied to since replicas don't read replies to ACKs
+				e.server.Logger().Error("failed to parse offset in REPLCONF ACK", zap.Error(err))
+				return nil
 			}
+			typedServer.handleReplicaAck(e.conn, offset)
 			return nil
+		} else if replConf.IsListeningPort() || replConf.IsCapa() {
+			return e.writeReply(command.ReplConfCmd, "OK")
 		}
 	case *ReplicaServer:
 		if replConf.IsGetAck() {
@@ -185,42 +1207,65 @@ func (e commandExecutor) executeReplConf(replConf command.ReplConf) error {
 				return fmt.Errorf("error writing reponse to REPLCONF command to master: %w", err)
 			}
 
-			replica, ok := e.server.(*ReplicaServer)
-			if !ok {
-				return errors.New("REPLCONF GETACK processed for node with type ReplicaNode, but failed to cast to ReplicaServer")
-			}
-
-			// After getting a replconf, we should no longer respond to the master's messages
-			replica.SetShouldIgnoreMaster(true)
-
 			return nil
 		}
 	}
 
-	return fmt.Errorf("node of type %q received unknown REPLCONF command: %v", nodeType, replConf)
+	option := ""
+	if len(replConf.Payload) > 0 {
+		option = replConf.Payload[0]
+	}
+	return command.ErrorReply(fmt.Sprintf("ERR Unrecognized REPLCONF option: %s", option))
 }
 
 //////////////////////////
 // Master Only Commands //
 //////////////////////////
 
-// TODO: Send full rdb file to replica
-func (e commandExecutor) executePSync(_ command.PSync) error {
+func (e commandExecutor) executePSync(psync command.PSync) error {
 	master, ok := e.server.(*MasterServer)
 	if !ok {
-		return errors.New("received a PSYNC command on a non-master server")
+		return command.ErrorReply("ERR PSYNC is only supported by masters")
+	}
+
+	if missingData, ok := master.partialResyncData(psync); ok {
+		return e.continuePSync(master, missingData)
 	}
 
-	if _, err := e.conn.WriteString(fmt.Sprintf("+FULLRESYNC %s 0\r\n", command.HARDCODE_REPL_ID)); err != nil {
+	if _, err := e.conn.WriteString(fmt.Sprintf("+FULLRESYNC %s %d\r\n", master.replicationID, master.replicationOffset)); err != nil {
 		return fmt.Errorf("error writing reponse to PSYNC command to client: %w", err)
 	}
 
-	emptyRDB := command.GetHardedCodedEmptyRDBBytes()
-	if _, err := e.conn.WriteString(fmt.Sprintf("$%d\r\n%s", len(emptyRDB), emptyRDB)); err != nil {
+	rdbData, err := master.encodedSnapshot()
+	if err != nil {
+		return fmt.Errorf("error encoding RDB snapshot for PSYNC command: %w", err)
+	}
+
+	// Unlike a normal bulk string, the RDB file is not terminated with a \r\n
+	if _, err := e.conn.WriteString(fmt.Sprintf("$%d\r\n%s", len(rdbData), rdbData)); err != nil {
 		return fmt.Errorf("error writing RDB file response to PSYNC command to client: %w", err)
 	}
 
-	master.registeredReplicaConns = append(master.registeredReplicaConns, e.conn)
+	master.createBacklogIfNeeded()
+	master.registerReplica(e.conn, master.replicationOffset)
+
+	return nil
+}
+
+// continuePSync partially resyncs a replica by sending it only the part of the replication stream that it missed
+func (e commandExecutor) continuePSync(master *MasterServer, missingData []byte) error {
+	if _, err := e.conn.WriteString(fmt.Sprintf("+CONTINUE %s\r\n", master.replicationID)); err != nil {
+		return fmt.Errorf("error writing CONTINUE reponse to PSYNC command to client: %w", err)
+	}
+
+	if len(missingData) > 0 {
+		if _, err := e.conn.WriteString(string(missingData)); err != nil {
+			return fmt.Errorf("error writing backlog to replica during partial resync: %w", err)
+		}
+	}
+
+	master.Logger().Info("partially resynced replica", zap.Int("backlogBytes", len(missingData)))
+	master.registerReplica(e.conn, master.replicationOffset-int64(len(missingData)))
 
 	return nil
 }
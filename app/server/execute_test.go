@@ -1,39 +1,79 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	"github.com/codecrafters-io/redis-starter-go/app/connection"
 	"github.com/codecrafters-io/redis-starter-go/app/log"
+	"github.com/codecrafters-io/redis-starter-go/app/rdb"
 )
 
+func getTestBaseServer(initialData serverStore) BaseServer {
+	return BaseServer{
+		eventQueue:     make(chan Event, eventQueueSize),
+		blockedClients: newBlockedClients(),
+		listWaiters:    newListWaiters(),
+
+		storeData:   initialData,
+		storeDataMu: &sync.Mutex{},
+		hashLimits:  defaultHashLimits,
+		setLimits:   defaultSetLimits,
+		propagation: newPendingPropagation(),
+		rdbDir:      os.TempDir(),
+		rdbFilename: DEFAULT_RDB_FILENAME,
+		persistence: newPersistenceState(),
+		logger:      log.NewNoOpLogger(),
+
+		replBacklogSize: DEFAULT_REPL_BACKLOG_SIZE,
+	}
+}
+
+const testReplicationID = "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb"
+
 func getTestMasterServer(initialData serverStore) Server {
 	return &MasterServer{
-		BaseServer: BaseServer{
-			storeData:   initialData,
-			storeDataMu: &sync.Mutex{},
-			logger:      log.NewNoOpLogger(),
-		},
-		registeredReplicaConns: []connection.Connection{},
+		BaseServer:         getTestBaseServer(initialData),
+		registeredReplicas: []*registeredReplica{},
+		replicationID:      testReplicationID,
 	}
 }
 
 func getTestReplicaServer(initialData serverStore) Server {
+	baseServer := getTestBaseServer(initialData)
+	baseServer.keepsExpiredKeys = true
+
 	return &ReplicaServer{
-		BaseServer: BaseServer{
-			storeData:   initialData,
-			storeDataMu: &sync.Mutex{},
-			logger:      log.NewNoOpLogger(),
-		},
+		BaseServer: baseServer,
+		masterLink: newMasterLink(),
 	}
 }
 
+// startTestEventLoop runs the event loop for a server until the test finishes
+func startTestEventLoop(t *testing.T, srv Server, eventQueue chan Event) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go EventLoop(ctx, srv.Logger(), eventQueue, srv.ExecuteCommand)
+}
+
+// infoResponse builds the bulk string response of an INFO command from a list of sorted `key:value` lines
+func infoResponse(lines ...string) string {
+	body := strings.Join(lines, "\n") + "\n"
+	return fmt.Sprintf("$%d\r\n%s\r\n", len(body), body)
+}
+
 func runCommandAndCheckOutput(t *testing.T, cmd command.Command, expectedOutput string) {
 	runCommandAndCheckOutputWithServer(t, getTestMasterServer(serverStore{}), cmd, expectedOutput)
 }
@@ -85,23 +125,23 @@ func TestExecuteEcho(t *testing.T) {
 	}{
 		{
 			payload:     "",
-			expectedRes: "+\r\n",
+			expectedRes: "$0\r\n\r\n",
 		},
 		{
 			payload:     "a",
-			expectedRes: "+a\r\n",
+			expectedRes: "$1\r\na\r\n",
 		},
 		{
 			payload:     "123",
-			expectedRes: "+123\r\n",
+			expectedRes: "$3\r\n123\r\n",
 		},
 		{
 			payload:     "[1,2,3]",
-			expectedRes: "+[1,2,3]\r\n",
+			expectedRes: "$7\r\n[1,2,3]\r\n",
 		},
 		{
 			payload:     "\r\n",
-			expectedRes: "+\r\n\r\n",
+			expectedRes: "$2\r\n\r\n\r\n",
 		},
 	} {
 		t.Run(fmt.Sprintf("ECHO with value %q should return the encoded state", tc.payload), func(t *testing.T) {
@@ -114,27 +154,188 @@ func TestExecuteInfo(t *testing.T) {
 	runCommandAndCheckOutput(
 		t,
 		command.Info{Payload: "replication"},
-		"$88\r\nmaster_repl_offset:0\nmaster_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\nrole:master\n\r\n",
+		infoResponse(
+			"master_repl_offset:0",
+			"master_replid2:0000000000000000000000000000000000000000",
+			"master_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb",
+			"repl_backlog_active:0",
+			"repl_backlog_first_byte_offset:0",
+			"repl_backlog_histlen:0",
+			"repl_backlog_size:1048576",
+			"role:master",
+			"second_repl_offset:-1",
+		),
 	)
+
+	t.Run("the master's replication offset should grow by the size of each propagated command", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		replicaConn := connection.NewChannelConnWithBuffer(connection.ReplicaConnection, 10)
+		master.registerReplica(replicaConn, 0)
+
+		setCmd := command.Set{KeyPayload: "a", ValuePayload: []byte("b")}
+		encodedSet, err := setCmd.EncodedCommand()
+		assert.NoError(t, err)
+
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		assert.NoError(t, master.ExecuteCommand(clientConn, setCmd))
+		assert.NoError(t, master.ExecuteCommand(clientConn, command.Get{Payload: "a"}))
+		assert.NoError(t, master.ExecuteCommand(clientConn, setCmd))
+
+		assert.Equal(t, int64(2*len(encodedSet)), master.replicationOffset)
+		runCommandAndCheckOutputWithServer(
+			t,
+			master,
+			command.Info{Payload: "replication"},
+			infoResponse(
+				"master_repl_offset:54",
+				"master_replid2:0000000000000000000000000000000000000000",
+				"master_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb",
+				"repl_backlog_active:0",
+				"repl_backlog_first_byte_offset:0",
+				"repl_backlog_histlen:0",
+				"repl_backlog_size:1048576",
+				"role:master",
+				"second_repl_offset:-1",
+			),
+		)
+	})
+
+	t.Run("a replica should report the offset and replication ID of its master", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		replica.masterReplicationID = testReplicationID
+		replica.bytesProcessed = 100
+
+		runCommandAndCheckOutputWithServer(
+			t,
+			replica,
+			command.Info{Payload: "replication"},
+			infoResponse(
+				"master_last_io_seconds_ago:-1",
+				"master_link_down_since_seconds:-1",
+				"master_link_status:down",
+				"master_repl_offset:100",
+				"master_replid:8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb",
+				"role:slave",
+			),
+		)
+	})
 }
 
 func TestExecuteWait(t *testing.T) {
-	for _, tc := range []struct {
-		numReplicas int64
-		waitTimeMs  int64
-	}{
-		{
-			numReplicas: 10,
-			waitTimeMs:  20,
-		},
+	runCommandAndCheckOutput(t, command.Wait{NumReplicas: 0, WaitForMs: 0}, ":0\r\n")
 
-		{
-			numReplicas: 0,
-			waitTimeMs:  0,
-		},
-	} {
-		runCommandAndCheckOutput(t, command.Wait{NumReplicas: tc.numReplicas, WaitForMs: tc.waitTimeMs}, ":0\r\n")
+	// getMasterWithReplicas returns a master with two replicas that have acknowledged offset 0 and a client
+	// whose last write was at offset 100
+	getMasterWithReplicas := func() (*MasterServer, []connection.Connection, connection.Connection) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		master.replicationOffset = 100
+
+		replicaConns := []connection.Connection{
+			connection.NewChannelConnWithBuffer(connection.ReplicaConnection, 10),
+			connection.NewChannelConnWithBuffer(connection.ReplicaConnection, 10),
+		}
+		for _, replicaConn := range replicaConns {
+			master.registerReplica(replicaConn, 0)
+		}
+
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		clientConn.ClientState().LastWriteOffset = 100
+
+		return master, replicaConns, clientConn
 	}
+
+	t.Run("WAIT should block until enough replicas acknowledge the client's last write", func(t *testing.T) {
+		master, replicaConns, clientConn := getMasterWithReplicas()
+		assert.NoError(t, RunCommand(master, clientConn, command.Wait{NumReplicas: 2, WaitForMs: 0}))
+
+		// Every replica should be asked for an ACK
+		for _, replicaConn := range replicaConns {
+			msg, err := replicaConn.ReadNextCmdString()
+			assert.NoError(t, err)
+			assert.Equal(t, "*3\r\n$8\r\nreplconf\r\n$6\r\nGETACK\r\n$1\r\n*\r\n", msg)
+		}
+
+		master.handleReplicaAck(replicaConns[0], 100)
+		assert.NotNil(t, master.blockedClients.clients[clientConn])
+		assert.Len(t, master.ackWaiters, 1)
+
+		master.handleReplicaAck(replicaConns[1], 150)
+		msg, err := clientConn.ReadNextCmdString()
+		assert.NoError(t, err)
+		assert.Equal(t, ":2\r\n", msg)
+		assert.Nil(t, master.blockedClients.clients[clientConn])
+		assert.Empty(t, master.ackWaiters)
+	})
+
+	t.Run("WAIT should reply immediately if enough replicas have already acknowledged the client's last write", func(t *testing.T) {
+		master, replicaConns, clientConn := getMasterWithReplicas()
+		master.handleReplicaAck(replicaConns[0], 100)
+
+		assert.NoError(t, RunCommand(master, clientConn, command.Wait{NumReplicas: 1, WaitForMs: 0}))
+		msg, err := clientConn.ReadNextCmdString()
+		assert.NoError(t, err)
+		assert.Equal(t, ":1\r\n", msg)
+
+		// No GETACK should have been needed
+		assert.Equal(t, int64(100), master.replicationOffset)
+
+		// A client that has not written anything doesn't need to wait for any replica
+		runCommandAndCheckOutputWithServer(t, master, command.Wait{NumReplicas: 2, WaitForMs: 0}, ":2\r\n")
+	})
+
+	t.Run("WAIT should reply with the number of replicas that acknowledged once its timeout passes", func(t *testing.T) {
+		master, replicaConns, clientConn := getMasterWithReplicas()
+		startTestEventLoop(t, master, master.eventQueue)
+
+		master.eventQueue <- Event{Callback: func() {
+			assert.NoError(t, RunCommand(master, clientConn, command.Wait{NumReplicas: 2, WaitForMs: 50}))
+			master.handleReplicaAck(replicaConns[0], 100)
+		}}
+
+		msg, err := clientConn.ReadNextCmdString()
+		assert.NoError(t, err)
+		assert.Equal(t, ":1\r\n", msg)
+
+		<-master.blockedClients.unblocked(clientConn)
+	})
+
+	t.Run("WAIT should wait for its timeout if it asks for more replicas than are connected", func(t *testing.T) {
+		for _, tc := range []struct {
+			numConnected int
+			expectedRes  string
+		}{
+			{numConnected: 0, expectedRes: ":0\r\n"},
+			{numConnected: 2, expectedRes: ":2\r\n"},
+		} {
+			master, replicaConns, clientConn := getMasterWithReplicas()
+			master.registeredReplicas = master.registeredReplicas[:tc.numConnected]
+			for _, replicaConn := range replicaConns[:tc.numConnected] {
+				master.handleReplicaAck(replicaConn, 100)
+			}
+			startTestEventLoop(t, master, master.eventQueue)
+
+			start := time.Now()
+			master.eventQueue <- Event{Callback: func() {
+				assert.NoError(t, RunCommand(master, clientConn, command.Wait{NumReplicas: 3, WaitForMs: 50}))
+			}}
+
+			msg, err := clientConn.ReadNextCmdString()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedRes, msg)
+			assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+
+			<-master.blockedClients.unblocked(clientConn)
+		}
+	})
+
+	t.Run("a WAIT client that disconnects should stop waiting", func(t *testing.T) {
+		master, _, clientConn := getMasterWithReplicas()
+		assert.NoError(t, RunCommand(master, clientConn, command.Wait{NumReplicas: 2, WaitForMs: 0}))
+		assert.Len(t, master.ackWaiters, 1)
+
+		master.blockedClients.disconnect(clientConn)
+		assert.Empty(t, master.ackWaiters)
+	})
 }
 
 func TestExecuteGet(t *testing.T) {
@@ -145,26 +346,32 @@ func TestExecuteGet(t *testing.T) {
 	}{
 		{
 			inputKey:                "c",
-			initialServerStoreState: serverStore{"a": {data: "b"}},
+			initialServerStoreState: serverStore{"a": {data: stringValue("b")}},
 			expectedRes:             "$-1\r\n",
 		},
 		{
 			inputKey:                "a",
-			initialServerStoreState: serverStore{"a": {data: 2}},
-			expectedRes:             ":2\r\n",
+			initialServerStoreState: serverStore{"a": {data: stringValue("2")}},
+			expectedRes:             "$1\r\n2\r\n",
 		},
 		{
 			inputKey:                "a",
-			initialServerStoreState: serverStore{"a": {data: "b"}},
-			expectedRes:             "+b\r\n",
+			initialServerStoreState: serverStore{"a": {data: stringValue("b")}},
+			expectedRes:             "$1\r\nb\r\n",
 		},
 		{
 			inputKey: "a",
 			initialServerStoreState: serverStore{
-				"a": {data: "b"},
-				"c": {data: "d"},
+				"a": {data: stringValue("b")},
+				"c": {data: stringValue("d")},
 			},
-			expectedRes: "+b\r\n",
+			expectedRes: "$1\r\nb\r\n",
+		},
+		// Values are returned exactly as they were stored, even if they contain CRLFs or aren't valid UTF-8
+		{
+			inputKey:                "a",
+			initialServerStoreState: serverStore{"a": {data: stringValue("line 1\r\nline 2\x00\xff")}},
+			expectedRes:             "$16\r\nline 1\r\nline 2\x00\xff\r\n",
 		},
 	} {
 		t.Run(fmt.Sprintf("GET with key %q and inital state %v and no expiry should succeed", tc.inputKey, tc.initialServerStoreState), func(t *testing.T) {
@@ -175,57 +382,66 @@ func TestExecuteGet(t *testing.T) {
 
 	t.Run("GET on a key that has expired should delete it and return a null bulk string", func(t *testing.T) {
 		pastTime := time.Now().Add(-time.Hour)
-		server := getTestMasterServer(serverStore{"a": {data: "b", expiresAt: &pastTime}})
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("b"), expiresAt: &pastTime}})
 		runCommandAndCheckOutputWithServer(t, server, command.Get{Payload: "a"}, command.NullBulkString)
-		_, ok := server.Get("a")
+		_, ok, err := server.Get("a")
+		require.NoError(t, err)
 		assert.False(t, ok)
 	})
 
 	t.Run("GET on a key that has not expired should return it and should not modify the store state", func(t *testing.T) {
 		futureTime := time.Now().Add(time.Hour)
-		server := getTestMasterServer(serverStore{"a": {data: "b", expiresAt: &futureTime}})
-		runCommandAndCheckOutputWithServer(t, server, command.Get{Payload: "a"}, "+b\r\n")
-		value, ok := server.Get("a")
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("b"), expiresAt: &futureTime}})
+		runCommandAndCheckOutputWithServer(t, server, command.Get{Payload: "a"}, "$1\r\nb\r\n")
+		value, ok, err := server.Get("a")
+		require.NoError(t, err)
 		assert.True(t, ok)
-		assert.Equal(t, "b", value)
+		assert.Equal(t, []byte("b"), value)
+	})
+
+	t.Run("GET on a key that holds a list should fail with a WRONGTYPE error", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: listOf("b")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.Get{Payload: "a"}))
 	})
 }
 
 func TestExecuteSet(t *testing.T) {
 	for _, tc := range []struct {
 		inputKey         string
-		inputValue       any
+		inputValue       []byte
 		initialMapState  map[string]storeValue
 		expectedMapState map[string]storeValue
 	}{
 		{
 			inputKey:         "a",
-			inputValue:       1,
+			inputValue:       []byte("1"),
 			initialMapState:  map[string]storeValue{},
-			expectedMapState: map[string]storeValue{"a": {data: 1}},
+			expectedMapState: map[string]storeValue{"a": {data: stringValue("1")}},
 		},
 		{
 			inputKey:         "a",
-			inputValue:       1,
-			initialMapState:  map[string]storeValue{"a": {data: 2}},
-			expectedMapState: map[string]storeValue{"a": {data: 1}},
+			inputValue:       []byte("1"),
+			initialMapState:  map[string]storeValue{"a": {data: stringValue("2")}},
+			expectedMapState: map[string]storeValue{"a": {data: stringValue("1")}},
 		},
 		{
 			inputKey:         "a",
-			inputValue:       "b",
-			initialMapState:  map[string]storeValue{"a": {data: 1}},
-			expectedMapState: map[string]storeValue{"a": {data: "b"}},
+			inputValue:       []byte("b"),
+			initialMapState:  map[string]storeValue{"a": {data: stringValue("1")}},
+			expectedMapState: map[string]storeValue{"a": {data: stringValue("b")}},
 		},
 	} {
-		t.Run(fmt.Sprintf("SET with key %q and value %v should properly update the server state", tc.inputKey, tc.inputValue), func(t *testing.T) {
+		t.Run(fmt.Sprintf("SET with key %q and value %q should properly update the server state", tc.inputKey, tc.inputValue), func(t *testing.T) {
 			server := getTestMasterServer(tc.initialMapState)
 			runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: tc.inputKey, ValuePayload: tc.inputValue}, command.OKString)
 
 			assert.Equal(t, len(tc.expectedMapState), server.Size())
 			for expectedKey, expetedValue := range tc.expectedMapState {
-				value, ok := server.Get(expectedKey)
+				value, ok, err := server.Get(expectedKey)
+				require.NoError(t, err)
 				assert.True(t, ok)
-				assert.Equal(t, expetedValue.data, value)
+				assert.Equal(t, expetedValue.data, stringValue(value))
 			}
 		})
 	}
@@ -234,48 +450,1662 @@ func TestExecuteSet(t *testing.T) {
 		server := getTestMasterServer(serverStore{})
 		runCommandAndCheckOutputWithServer(t, server, command.Set{
 			KeyPayload:   "a",
-			ValuePayload: "b",
-			ExpiryTimeMs: 10000,
+			ValuePayload: []byte("b"),
+			ExpiryOption: command.ExpiryPX,
+			ExpiryTime:   10000,
 		}, command.OKString)
 
 		assert.Equal(t, 1, server.Size())
 
-		value, ok := server.Get("a")
+		value, ok, err := server.Get("a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("b"), value)
+	})
+
+	t.Run("SET should set the expiry from each of the expiry options", func(t *testing.T) {
+		now := time.Now()
+		for _, tc := range []struct {
+			option            command.ExpiryOption
+			expiryTime        int64
+			expectedExpiresAt time.Time
+		}{
+			{option: command.ExpiryEX, expiryTime: 100, expectedExpiresAt: now.Add(100 * time.Second)},
+			{option: command.ExpiryPX, expiryTime: 100, expectedExpiresAt: now.Add(100 * time.Millisecond)},
+			{option: command.ExpiryEXAT, expiryTime: now.Add(time.Hour).Unix(), expectedExpiresAt: now.Add(time.Hour)},
+			{option: command.ExpiryPXAT, expiryTime: now.Add(time.Hour).UnixMilli(), expectedExpiresAt: now.Add(time.Hour)},
+		} {
+			server := getTestMasterServer(serverStore{}).(*MasterServer)
+			cmd := command.Set{KeyPayload: "a", ValuePayload: []byte("b"), ExpiryOption: tc.option, ExpiryTime: tc.expiryTime}
+			runCommandAndCheckOutputWithServer(t, server, cmd, command.OKString)
+
+			require.NotNil(t, server.storeData["a"].expiresAt)
+			assert.WithinDuration(t, tc.expectedExpiresAt, *server.storeData["a"].expiresAt, time.Second, "unexpected expiry for %s", tc.option)
+		}
+	})
+
+	t.Run("SET should only keep the existing expiry with KEEPTTL", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{
+			"a": {data: stringValue("1"), expiresAt: &futureTime},
+			"b": {data: stringValue("2"), expiresAt: &futureTime},
+		}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "a", ValuePayload: []byte("3"), ExpiryOption: command.KeepTTL}, command.OKString)
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "b", ValuePayload: []byte("4")}, command.OKString)
+
+		assert.Equal(t, &futureTime, server.storeData["a"].expiresAt)
+		assert.Nil(t, server.storeData["b"].expiresAt)
+	})
+
+	t.Run("SET with NX or XX should reply with a null when the value isn't stored", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "a", ValuePayload: []byte("2"), Condition: command.SetIfMissing}, command.NullBulkString)
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "b", ValuePayload: []byte("2"), Condition: command.SetIfExists}, command.NullBulkString)
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "a", ValuePayload: []byte("2"), Condition: command.SetIfExists}, command.OKString)
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "b", ValuePayload: []byte("3"), Condition: command.SetIfMissing}, command.OKString)
+
+		for key, expectedValue := range map[string]string{"a": "2", "b": "3"} {
+			value, ok, err := server.Get(key)
+			require.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, []byte(expectedValue), value)
+		}
+	})
+
+	t.Run("SET with GET should reply with the previous value, even if NX stops the value from being stored", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "a", ValuePayload: []byte("2"), Get: true}, "$1\r\n1\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "b", ValuePayload: []byte("3"), Get: true}, command.NullBulkString)
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "a", ValuePayload: []byte("4"), Get: true, Condition: command.SetIfMissing}, "$1\r\n2\r\n")
+
+		value, ok, err := server.Get("a")
+		require.NoError(t, err)
 		assert.True(t, ok)
-		assert.Equal(t, "b", value)
+		assert.Equal(t, []byte("2"), value)
+	})
+
+	t.Run("SET with GET should fail without storing anything if the key doesn't hold a string", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: largeValue{}}}).(*MasterServer)
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		err := RunCommand(server, conn, command.Set{KeyPayload: "a", ValuePayload: []byte("1"), Get: true})
+		assert.Equal(t, command.ErrWrongType, err)
+		assert.Equal(t, largeValue{}, server.storeData["a"].data)
+	})
+
+	t.Run("SET with an expiry in the past should delete the key", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+		pastTime := time.Now().Add(-time.Hour).UnixMilli()
+		runCommandAndCheckOutputWithServer(t, server, command.Set{KeyPayload: "a", ValuePayload: []byte("2"), ExpiryOption: command.ExpiryPXAT, ExpiryTime: pastTime}, command.OKString)
+		assert.Equal(t, 0, server.Size())
 	})
 }
 
-func TestExecuteReplConf(t *testing.T) {
+func TestExecuteSetNX(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+	runCommandAndCheckOutputWithServer(t, server, command.SetNX{Key: "a", Value: []byte("2")}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SetNX{Key: "b", Value: []byte("2")}, ":1\r\n")
+
+	value, ok, err := server.Get("a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestExecuteMGet(t *testing.T) {
+	pastTime := time.Now().Add(-time.Hour)
+	server := getTestMasterServer(serverStore{
+		"a": {data: stringValue("1")},
+		"b": {data: largeValue{}},
+		"c": {data: stringValue("3"), expiresAt: &pastTime},
+		"d": {data: stringValue("")},
+	})
+
+	runCommandAndCheckOutputWithServer(
+		t, server, command.MGet{Keys: []string{"a", "b", "c", "d", "missing"}},
+		"*5\r\n$1\r\n1\r\n$-1\r\n$-1\r\n$0\r\n\r\n$-1\r\n",
+	)
+}
+
+func TestExecuteMSet(t *testing.T) {
+	t.Run("MSET should store every pair and remove any existing expiry", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1"), expiresAt: &futureTime}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.MSet{Pairs: []command.KeyValue{
+			{Key: "a", Value: []byte("2")},
+			{Key: "b", Value: []byte("3")},
+			{Key: "b", Value: []byte("4")},
+		}}, command.OKString)
+
+		assert.Equal(t, serverStore{"a": {data: stringValue("2")}, "b": {data: stringValue("4")}}, server.storeData)
+	})
+
+	t.Run("MSETNX should store nothing if any of the keys exist", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.MSetNX{Pairs: []command.KeyValue{
+			{Key: "b", Value: []byte("2")},
+			{Key: "a", Value: []byte("3")},
+		}}, ":0\r\n")
+		assert.Equal(t, serverStore{"a": {data: stringValue("1")}}, server.storeData)
+
+		runCommandAndCheckOutputWithServer(t, server, command.MSetNX{Pairs: []command.KeyValue{
+			{Key: "b", Value: []byte("2")},
+			{Key: "c", Value: []byte("3")},
+		}}, ":1\r\n")
+		assert.Equal(t, 3, server.Size())
+	})
+}
+
+func TestExecuteAppend(t *testing.T) {
+	futureTime := time.Now().Add(time.Hour)
+	server := getTestMasterServer(serverStore{"a": {data: stringValue("Hello"), expiresAt: &futureTime}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.Append{Key: "a", Value: []byte(" World")}, ":11\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Append{Key: "b", Value: []byte("new")}, ":3\r\n")
+
+	assert.Equal(t, storeValue{data: stringValue("Hello World"), expiresAt: &futureTime}, server.storeData["a"])
+	assert.Equal(t, stringValue("new"), server.storeData["b"].data)
+}
+
+func TestExecuteStrLen(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: stringValue("Hello")}, "b": {data: largeValue{}}})
+	runCommandAndCheckOutputWithServer(t, server, command.StrLen{Key: "a"}, ":5\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.StrLen{Key: "missing"}, ":0\r\n")
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.StrLen{Key: "b"}))
+}
+
+func TestExecuteGetRange(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: stringValue("This is a string")}})
+
 	for _, tc := range []struct {
-		key         string
-		value       string
-		server      Server
-		expectedRes string
+		start          int64
+		end            int64
+		expectedOutput string
 	}{
-		{
-			key:         "capa",
-			value:       "psync2",
-			server:      getTestMasterServer(serverStore{}),
-			expectedRes: command.OKString,
-		},
-		{
-			key:         "GetAck",
-			value:       "*",
-			server:      getTestReplicaServer(serverStore{}),
-			expectedRes: "*3\r\n+REPLCONF\r\n+ACK\r\n+0\r\n",
-		},
+		{start: 0, end: 3, expectedOutput: "$4\r\nThis\r\n"},
+		{start: -3, end: -1, expectedOutput: "$3\r\ning\r\n"},
+		{start: 0, end: -1, expectedOutput: "$16\r\nThis is a string\r\n"},
+		{start: 10, end: 100, expectedOutput: "$6\r\nstring\r\n"},
+		{start: -100, end: 3, expectedOutput: "$4\r\nThis\r\n"},
+		{start: 5, end: 3, expectedOutput: "$0\r\n\r\n"},
+		{start: -1, end: -5, expectedOutput: "$0\r\n\r\n"},
 	} {
-		t.Run(fmt.Sprintf("PSYNC with key %q and value %q should return the expected res", tc.key, tc.value), func(t *testing.T) {
-			runCommandAndCheckOutputWithServer(t, tc.server, command.ReplConf{Payload: []string{tc.key, tc.value}}, tc.expectedRes)
-		})
+		runCommandAndCheckOutputWithServer(t, server, command.GetRange{Key: "a", Start: tc.start, End: tc.end}, tc.expectedOutput)
 	}
+	runCommandAndCheckOutputWithServer(t, server, command.GetRange{Key: "missing", Start: 0, End: -1}, "$0\r\n\r\n")
 }
 
-func TestExecutePSync(t *testing.T) {
-	runCommandAndCheckOutputs(
-		t,
-		command.PSync{ReplicationID: command.HARDCODE_REPL_ID, MasterOffset: "0"},
-		[]string{"+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb 0\r\n", fmt.Sprintf("$88\r\n%s", command.GetHardedCodedEmptyRDBBytes())},
-	)
+func TestExecuteSetRange(t *testing.T) {
+	t.Run("SETRANGE should overwrite part of the string and keep the key's expiry", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("Hello World"), expiresAt: &futureTime}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.SetRange{Key: "a", Offset: 6, Value: []byte("Redis")}, ":11\r\n")
+		assert.Equal(t, storeValue{data: stringValue("Hello Redis"), expiresAt: &futureTime}, server.storeData["a"])
+	})
+
+	t.Run("SETRANGE past the end of the string should pad it with zero bytes", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("ab")}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.SetRange{Key: "a", Offset: 4, Value: []byte("c")}, ":5\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.SetRange{Key: "b", Offset: 2, Value: []byte("d")}, ":3\r\n")
+
+		assert.Equal(t, stringValue("ab\x00\x00c"), server.storeData["a"].data)
+		assert.Equal(t, stringValue("\x00\x00d"), server.storeData["b"].data)
+	})
+
+	t.Run("SETRANGE with an empty value should not change anything", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("ab")}})
+		runCommandAndCheckOutputWithServer(t, server, command.SetRange{Key: "a", Offset: 10, Value: []byte{}}, ":2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.SetRange{Key: "b", Offset: 10, Value: []byte{}}, ":0\r\n")
+		assert.Equal(t, 1, server.Size())
+	})
+
+	t.Run("SETRANGE should not grow a string past the maximum size", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		err := RunCommand(server, conn, command.SetRange{Key: "a", Offset: command.MaxBulkLength, Value: []byte("a")})
+		assert.Equal(t, command.ErrorReply("ERR string exceeds maximum allowed size (proto-max-bulk-len)"), err)
+		assert.Equal(t, 0, server.Size())
+	})
+}
+
+func TestExecuteGrowLoadedStrings(t *testing.T) {
+	// Values decoded from the same RDB file can share their storage, so growing one shouldn't change the others
+	data := []byte("foobarbaz")
+	server := getTestMasterServer(serverStore{}).(*MasterServer)
+	server.loadSnapshot(rdb.Snapshot{Entries: []rdb.Entry{
+		{Key: "a", Type: rdb.StringValueType, Value: data[0:3]},
+		{Key: "b", Type: rdb.StringValueType, Value: data[3:6]},
+		{Key: "c", Type: rdb.StringValueType, Value: data[6:9]},
+	}})
+
+	runCommandAndCheckOutputWithServer(t, server, command.Append{Key: "a", Value: []byte("XXX")}, ":6\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SetRange{Key: "b", Offset: 3, Value: []byte("YYY")}, ":6\r\n")
+
+	assert.Equal(t, stringValue("fooXXX"), server.storeData["a"].data)
+	assert.Equal(t, stringValue("barYYY"), server.storeData["b"].data)
+	assert.Equal(t, stringValue("baz"), server.storeData["c"].data)
+}
+
+func TestExecuteGetDel(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}, "b": {data: largeValue{}}})
+	runCommandAndCheckOutputWithServer(t, server, command.GetDel{Key: "a"}, "$1\r\n1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.GetDel{Key: "a"}, command.NullBulkString)
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.GetDel{Key: "b"}))
+	assert.Equal(t, 1, server.Size())
+}
+
+func TestExecuteGetEx(t *testing.T) {
+	t.Run("GETEX should change the key's expiry", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.GetEx{Key: "a"}, "$1\r\n1\r\n")
+		assert.Nil(t, server.storeData["a"].expiresAt)
+
+		runCommandAndCheckOutputWithServer(t, server, command.GetEx{Key: "a", ExpiryOption: command.ExpiryEX, ExpiryTime: 100}, "$1\r\n1\r\n")
+		require.NotNil(t, server.storeData["a"].expiresAt)
+		assert.WithinDuration(t, time.Now().Add(100*time.Second), *server.storeData["a"].expiresAt, time.Second)
+
+		runCommandAndCheckOutputWithServer(t, server, command.GetEx{Key: "a", ExpiryOption: command.ExpiryPersist}, "$1\r\n1\r\n")
+		assert.Nil(t, server.storeData["a"].expiresAt)
+	})
+
+	t.Run("GETEX on a missing key should reply with a null", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{})
+		runCommandAndCheckOutputWithServer(t, server, command.GetEx{Key: "a", ExpiryOption: command.ExpiryEX, ExpiryTime: 100}, command.NullBulkString)
+		assert.Equal(t, 0, server.Size())
+	})
+}
+
+func TestExecuteIncrBy(t *testing.T) {
+	t.Run("the counter commands should treat a missing key as 0", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{})
+		runCommandAndCheckOutputWithServer(t, server, command.IncrBy{Cmd: command.IncrCmd, Key: "a", Increment: 1}, ":1\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.IncrBy{Cmd: command.IncrByCmd, Key: "a", Increment: 10}, ":11\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.IncrBy{Cmd: command.DecrCmd, Key: "b", Increment: -1}, ":-1\r\n")
+
+		value, ok, err := server.Get("a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("11"), value)
+	})
+
+	t.Run("the counter commands should keep the key's expiry", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("5"), expiresAt: &futureTime}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.IncrBy{Cmd: command.DecrByCmd, Key: "a", Increment: -2}, ":3\r\n")
+		assert.Equal(t, &futureTime, server.storeData["a"].expiresAt)
+	})
+
+	t.Run("the counter commands should fail on values that aren't 64 bit integers", func(t *testing.T) {
+		for _, tc := range []struct {
+			value         value
+			increment     int64
+			expectedError error
+		}{
+			{value: stringValue("abc"), increment: 1, expectedError: command.ErrNotInteger},
+			{value: stringValue("1.5"), increment: 1, expectedError: command.ErrNotInteger},
+			{value: stringValue("+1"), increment: 1, expectedError: command.ErrNotInteger},
+			{value: stringValue("01"), increment: 1, expectedError: command.ErrNotInteger},
+			{value: stringValue("9223372036854775808"), increment: 1, expectedError: command.ErrNotInteger},
+			{value: stringValue("9223372036854775807"), increment: 1, expectedError: command.ErrOverflow},
+			{value: stringValue("-9223372036854775808"), increment: -1, expectedError: command.ErrOverflow},
+			{value: largeValue{}, increment: 1, expectedError: command.ErrWrongType},
+		} {
+			server := getTestMasterServer(serverStore{"a": {data: tc.value}}).(*MasterServer)
+			conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+			err := RunCommand(server, conn, command.IncrBy{Cmd: command.IncrByCmd, Key: "a", Increment: tc.increment})
+			assert.Equal(t, tc.expectedError, err, "unexpected error for %v", tc.value)
+			assert.Equal(t, tc.value, server.storeData["a"].data)
+		}
+	})
+}
+
+func TestExecuteIncrByFloat(t *testing.T) {
+	t.Run("INCRBYFLOAT should store and reply with the result without an exponent", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{
+			"a": {data: stringValue("10.5"), expiresAt: &futureTime},
+			"b": {data: stringValue("5.0e3")},
+		}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.IncrByFloat{Key: "a", Increment: 0.1}, "$4\r\n10.6\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.IncrByFloat{Key: "b", Increment: 200}, "$4\r\n5200\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.IncrByFloat{Key: "c", Increment: -1.5}, "$4\r\n-1.5\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.IncrByFloat{Key: "d", Increment: 1e21}, "$22\r\n1000000000000000000000\r\n")
+
+		assert.Equal(t, &futureTime, server.storeData["a"].expiresAt)
+	})
+
+	t.Run("INCRBYFLOAT should fail on values that aren't numbers or that would overflow", func(t *testing.T) {
+		for _, tc := range []struct {
+			value         value
+			increment     float64
+			expectedError error
+		}{
+			{value: stringValue("abc"), increment: 1, expectedError: command.ErrNotFloat},
+			{value: stringValue("nan"), increment: 1, expectedError: command.ErrNotFloat},
+			{value: stringValue("1.7e308"), increment: 1.7e308, expectedError: command.ErrorReply("ERR increment would produce NaN or Infinity")},
+			{value: largeValue{}, increment: 1, expectedError: command.ErrWrongType},
+		} {
+			server := getTestMasterServer(serverStore{"a": {data: tc.value}}).(*MasterServer)
+			conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+			err := RunCommand(server, conn, command.IncrByFloat{Key: "a", Increment: tc.increment})
+			assert.Equal(t, tc.expectedError, err, "unexpected error for %v", tc.value)
+			assert.Equal(t, tc.value, server.storeData["a"].data)
+		}
+	})
+}
+
+func TestExecutePush(t *testing.T) {
+	server := getTestMasterServer(serverStore{"s": {data: stringValue("1")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.Push{Cmd: command.RPushCmd, Key: "a", Values: [][]byte{[]byte("b"), []byte("c")}}, ":2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Push{Cmd: command.LPushCmd, Key: "a", Values: [][]byte{[]byte("x"), []byte("y")}}, ":4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Push{Cmd: command.LPushXCmd, Key: "a", Values: [][]byte{[]byte("z")}}, ":5\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Push{Cmd: command.RPushXCmd, Key: "missing", Values: [][]byte{[]byte("z")}}, ":0\r\n")
+
+	requireListEquals(t, listOf("z", "y", "x", "b", "c").elements(), server.storeData["a"].data.(*listValue))
+	assert.Equal(t, 2, server.Size())
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.Push{Cmd: command.LPushCmd, Key: "s", Values: [][]byte{[]byte("1")}}))
+}
+
+func TestExecutePop(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("1", "2", "3", "4")}, "s": {data: stringValue("1")}})
+	runCommandAndCheckOutputWithServer(t, server, command.Pop{Cmd: command.LPopCmd, Key: "a", Count: 1}, "$1\r\n1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Pop{Cmd: command.RPopCmd, Key: "a", Count: 1}, "$1\r\n4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Pop{Cmd: command.LPopCmd, Key: "a", Count: 0, WithCount: true}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Pop{Cmd: command.RPopCmd, Key: "a", Count: 10, WithCount: true}, "*2\r\n$1\r\n3\r\n$1\r\n2\r\n")
+
+	// Popping the last element should delete the list
+	assert.Equal(t, 1, server.Size())
+	runCommandAndCheckOutputWithServer(t, server, command.Pop{Cmd: command.LPopCmd, Key: "a", Count: 1}, command.NullBulkString)
+	runCommandAndCheckOutputWithServer(t, server, command.Pop{Cmd: command.LPopCmd, Key: "a", Count: 2, WithCount: true}, "*-1\r\n")
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.Pop{Cmd: command.LPopCmd, Key: "s", Count: 1}))
+}
+
+func TestExecuteLRange(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("a", "b", "c", "d")}})
+
+	for _, tc := range []struct {
+		start          int64
+		end            int64
+		expectedOutput string
+	}{
+		{start: 0, end: -1, expectedOutput: "*4\r\n$1\r\na\r\n$1\r\nb\r\n$1\r\nc\r\n$1\r\nd\r\n"},
+		{start: 1, end: 2, expectedOutput: "*2\r\n$1\r\nb\r\n$1\r\nc\r\n"},
+		{start: -2, end: 100, expectedOutput: "*2\r\n$1\r\nc\r\n$1\r\nd\r\n"},
+		{start: 3, end: 1, expectedOutput: "*0\r\n"},
+		{start: 10, end: 20, expectedOutput: "*0\r\n"},
+	} {
+		runCommandAndCheckOutputWithServer(t, server, command.LRange{Key: "a", Start: tc.start, End: tc.end}, tc.expectedOutput)
+	}
+	runCommandAndCheckOutputWithServer(t, server, command.LRange{Key: "missing", Start: 0, End: -1}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LLen{Key: "a"}, ":4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LLen{Key: "missing"}, ":0\r\n")
+}
+
+func TestExecuteLIndex(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("a", "b", "c")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.LIndex{Key: "a", Index: 0}, "$1\r\na\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LIndex{Key: "a", Index: -1}, "$1\r\nc\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LIndex{Key: "a", Index: 3}, command.NullBulkString)
+	runCommandAndCheckOutputWithServer(t, server, command.LIndex{Key: "missing", Index: 0}, command.NullBulkString)
+
+	runCommandAndCheckOutputWithServer(t, server, command.LSet{Key: "a", Index: -2, Value: []byte("x")}, command.OKString)
+	requireListEquals(t, listOf("a", "x", "c").elements(), server.storeData["a"].data.(*listValue))
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrorReply("ERR index out of range"), RunCommand(server, conn, command.LSet{Key: "a", Index: 3, Value: []byte("x")}))
+	assert.Equal(t, command.ErrNoSuchKey, RunCommand(server, conn, command.LSet{Key: "missing", Index: 0, Value: []byte("x")}))
+}
+
+func TestExecuteLInsert(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("a", "b", "b")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.LInsert{Key: "a", Before: true, Pivot: []byte("b"), Value: []byte("x")}, ":4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LInsert{Key: "a", Pivot: []byte("b"), Value: []byte("y")}, ":5\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LInsert{Key: "a", Pivot: []byte("missing"), Value: []byte("z")}, ":-1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LInsert{Key: "missing", Pivot: []byte("b"), Value: []byte("z")}, ":0\r\n")
+
+	requireListEquals(t, listOf("a", "x", "b", "y", "b").elements(), server.storeData["a"].data.(*listValue))
+}
+
+func TestExecuteLRem(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("a", "b", "a", "c", "a")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.LRem{Key: "a", Count: -1, Value: []byte("a")}, ":1\r\n")
+	requireListEquals(t, listOf("a", "b", "a", "c").elements(), server.storeData["a"].data.(*listValue))
+
+	runCommandAndCheckOutputWithServer(t, server, command.LRem{Key: "a", Count: 0, Value: []byte("a")}, ":2\r\n")
+	requireListEquals(t, listOf("b", "c").elements(), server.storeData["a"].data.(*listValue))
+
+	runCommandAndCheckOutputWithServer(t, server, command.LRem{Key: "a", Count: math.MinInt64, Value: []byte("b")}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LRem{Key: "a", Count: 1, Value: []byte("c")}, ":1\r\n")
+	assert.Equal(t, 0, server.Size())
+}
+
+func TestExecuteLTrim(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("a", "b", "c", "d")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.LTrim{Key: "a", Start: 1, End: -2}, command.OKString)
+	requireListEquals(t, listOf("b", "c").elements(), server.storeData["a"].data.(*listValue))
+
+	runCommandAndCheckOutputWithServer(t, server, command.LTrim{Key: "missing", Start: 0, End: 1}, command.OKString)
+	runCommandAndCheckOutputWithServer(t, server, command.LTrim{Key: "a", Start: 5, End: 10}, command.OKString)
+	assert.Equal(t, 0, server.Size())
+}
+
+func TestExecuteLPos(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("a", "b", "c", "1", "2", "3", "c", "c")}})
+
+	for _, tc := range []struct {
+		cmd            command.LPos
+		expectedOutput string
+	}{
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: 1}, expectedOutput: ":2\r\n"},
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: 2}, expectedOutput: ":6\r\n"},
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: -1}, expectedOutput: ":7\r\n"},
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: 4}, expectedOutput: command.NullBulkString},
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: 1, WithCount: true}, expectedOutput: "*3\r\n:2\r\n:6\r\n:7\r\n"},
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: -1, Count: 2, WithCount: true}, expectedOutput: "*2\r\n:7\r\n:6\r\n"},
+		{cmd: command.LPos{Key: "a", Value: []byte("c"), Rank: 1, Count: 0, WithCount: true, MaxLen: 7}, expectedOutput: "*2\r\n:2\r\n:6\r\n"},
+		{cmd: command.LPos{Key: "a", Value: []byte("x"), Rank: 1, WithCount: true}, expectedOutput: "*0\r\n"},
+		{cmd: command.LPos{Key: "missing", Value: []byte("c"), Rank: 1}, expectedOutput: command.NullBulkString},
+	} {
+		runCommandAndCheckOutputWithServer(t, server, tc.cmd, tc.expectedOutput)
+	}
+}
+
+func TestExecuteLMove(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: listOf("1", "2", "3")}, "s": {data: stringValue("1")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.LMove{Source: "a", Destination: "b", From: command.ListRight, To: command.ListLeft}, "$1\r\n3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LMove{Source: "a", Destination: "b", From: command.ListLeft, To: command.ListRight}, "$1\r\n1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LMove{Source: "b", Destination: "b", From: command.ListLeft, To: command.ListRight}, "$1\r\n3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.LMove{Source: "missing", Destination: "b", From: command.ListLeft, To: command.ListRight}, command.NullBulkString)
+
+	requireListEquals(t, listOf("2").elements(), server.storeData["a"].data.(*listValue))
+	requireListEquals(t, listOf("1", "3").elements(), server.storeData["b"].data.(*listValue))
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.LMove{Source: "a", Destination: "s", From: command.ListLeft, To: command.ListLeft}))
+	requireListEquals(t, listOf("2").elements(), server.storeData["a"].data.(*listValue))
+
+	runCommandAndCheckOutputWithServer(t, server, command.LMove{Source: "a", Destination: "b", From: command.ListLeft, To: command.ListLeft}, "$1\r\n2\r\n")
+	_, ok := server.storeData["a"]
+	assert.False(t, ok)
+}
+
+func TestExecuteBlockingListCommands(t *testing.T) {
+	// requireReply checks that the next reply sent to a client is expected
+	requireReply := func(t *testing.T, conn connection.Connection, expected string) {
+		t.Helper()
+		msg, err := conn.ReadNextCmdString()
+		require.NoError(t, err)
+		assert.Equal(t, expected, msg)
+	}
+
+	t.Run("blocking pops should pop straight away from the first of their keys that holds a list", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"b": {data: listOf("1", "2", "3", "4")}, "c": {data: listOf("5")}})
+		runCommandAndCheckOutputWithServer(t, server, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a", "b", "c"}}, "*2\r\n$1\r\nb\r\n$1\r\n1\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.BPop{Cmd: command.BRPopCmd, Keys: []string{"b"}}, "*2\r\n$1\r\nb\r\n$1\r\n4\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.BLMove{Source: "c", Destination: "b", From: command.ListLeft, To: command.ListLeft}, "$1\r\n5\r\n")
+		runCommandAndCheckOutputWithServer(
+			t, server, command.LMPop{Cmd: command.BLMPopCmd, Keys: []string{"c", "b"}, End: command.ListLeft, Count: 2},
+			"*2\r\n$1\r\nb\r\n*2\r\n$1\r\n5\r\n$1\r\n2\r\n",
+		)
+		runCommandAndCheckOutputWithServer(t, server, command.LMPop{Cmd: command.LMPopCmd, Keys: []string{"b"}, End: command.ListRight, Count: 5}, "*2\r\n$1\r\nb\r\n*1\r\n$1\r\n3\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.LMPop{Cmd: command.LMPopCmd, Keys: []string{"b"}, End: command.ListRight, Count: 1}, "*-1\r\n")
+		assert.Equal(t, 0, server.Size())
+	})
+
+	t.Run("blocking pops should fail on keys that hold the wrong type before a later key is popped from", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"s": {data: stringValue("1")}, "b": {data: listOf("1")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"s", "b"}}))
+		assert.Equal(t, 2, server.Size())
+	})
+
+	t.Run("blocked clients should be served in the order that they blocked once their key is pushed to", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		firstConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		secondConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		thirdConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(firstConn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a"}}))
+		require.NoError(t, master.ExecuteCommand(secondConn, command.BPop{Cmd: command.BRPopCmd, Keys: []string{"b", "a"}}))
+		require.NoError(t, master.ExecuteCommand(thirdConn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a"}}))
+		for _, conn := range []connection.Connection{firstConn, secondConn, thirdConn} {
+			assert.NotNil(t, master.blockedClients.clients[conn])
+		}
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Push{Cmd: command.RPushCmd, Key: "a", Values: [][]byte{[]byte("1"), []byte("2")}}))
+		requireReply(t, clientConn, ":2\r\n")
+		requireReply(t, firstConn, "*2\r\n$1\r\na\r\n$1\r\n1\r\n")
+		requireReply(t, secondConn, "*2\r\n$1\r\na\r\n$1\r\n2\r\n")
+		assert.Nil(t, master.blockedClients.clients[firstConn])
+		assert.Nil(t, master.blockedClients.clients[secondConn])
+		assert.NotNil(t, master.blockedClients.clients[thirdConn])
+		assert.Equal(t, 0, master.Size())
+
+		// The second client should no longer be waiting on any of its keys
+		assert.NotContains(t, master.listWaiters.byKey, "b")
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Push{Cmd: command.LPushCmd, Key: "a", Values: [][]byte{[]byte("3")}}))
+		requireReply(t, clientConn, ":1\r\n")
+		requireReply(t, thirdConn, "*2\r\n$1\r\na\r\n$1\r\n3\r\n")
+		assert.Empty(t, master.listWaiters.byKey)
+	})
+
+	t.Run("a served BLMOVE should serve the clients blocked on its destination", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		moveConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		popConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(popConn, command.LMPop{Cmd: command.BLMPopCmd, Keys: []string{"destination"}, End: command.ListLeft, Count: 10}))
+		require.NoError(t, master.ExecuteCommand(moveConn, command.BLMove{Source: "source", Destination: "destination", From: command.ListRight, To: command.ListLeft}))
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Push{Cmd: command.RPushCmd, Key: "source", Values: [][]byte{[]byte("1"), []byte("2")}}))
+		requireReply(t, clientConn, ":2\r\n")
+		requireReply(t, moveConn, "$1\r\n2\r\n")
+		requireReply(t, popConn, "*2\r\n$11\r\ndestination\r\n*1\r\n$1\r\n2\r\n")
+
+		elements, err := master.LRange("source", 0, -1)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("1")}, elements)
+		assert.Equal(t, 1, master.Size())
+	})
+
+	t.Run("a BLMOVE whose destination holds the wrong type once it's served should be sent an error", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		moveConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(moveConn, command.BLMove{Source: "source", Destination: "destination", From: command.ListLeft, To: command.ListLeft}))
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Set{KeyPayload: "destination", ValuePayload: []byte("1")}))
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Push{Cmd: command.RPushCmd, Key: "source", Values: [][]byte{[]byte("1")}}))
+
+		requireReply(t, moveConn, "-"+string(command.ErrWrongType)+"\r\n")
+		assert.Nil(t, master.blockedClients.clients[moveConn])
+		assert.Equal(t, 2, master.Size())
+	})
+
+	t.Run("blocked clients should be sent a null once their timeout passes", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		startTestEventLoop(t, master, master.eventQueue)
+		popConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		moveConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		moveConn.ClientState().Protocol = command.RESP3
+
+		master.eventQueue <- Event{Callback: func() {
+			assert.NoError(t, master.ExecuteCommand(popConn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a"}, TimeoutMs: 20}))
+			assert.NoError(t, master.ExecuteCommand(moveConn, command.BLMove{Source: "a", Destination: "b", From: command.ListLeft, To: command.ListLeft, TimeoutMs: 20}))
+		}}
+
+		requireReply(t, popConn, "*-1\r\n")
+		requireReply(t, moveConn, "_\r\n")
+		<-master.blockedClients.unblocked(popConn)
+		<-master.blockedClients.unblocked(moveConn)
+		assert.Empty(t, master.listWaiters.byKey)
+	})
+
+	t.Run("a blocked client that disconnects should stop waiting", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		blockedConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(blockedConn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a", "b"}}))
+		master.blockedClients.disconnect(blockedConn)
+		assert.Empty(t, master.listWaiters.byKey)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Push{Cmd: command.RPushCmd, Key: "a", Values: [][]byte{[]byte("1")}}))
+		length, err := master.LLen("a")
+		require.NoError(t, err)
+		assert.Equal(t, 1, length)
+	})
+
+	t.Run("blocked clients should be sent an error when their master becomes a replica", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		blockedConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(blockedConn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a"}, TimeoutMs: 60000}))
+		master.unblockListWaiters()
+		requireReply(t, blockedConn, unblockedByRoleChangeError)
+		assert.Nil(t, master.blockedClients.clients[blockedConn])
+		assert.Empty(t, master.listWaiters.byKey)
+	})
+
+	t.Run("replicas should reply with a null instead of blocking", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		runCommandAndCheckOutputWithServer(t, replica, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"a"}}, "*-1\r\n")
+		runCommandAndCheckOutputWithServer(t, replica, command.BLMove{Source: "a", Destination: "b", From: command.ListLeft, To: command.ListLeft}, command.NullBulkString)
+		assert.Empty(t, replica.blockedClients.clients)
+	})
+}
+
+func TestExecuteHSet(t *testing.T) {
+	server := getTestMasterServer(serverStore{"s": {data: stringValue("1")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.HSet{Key: "h", Pairs: []command.FieldValue{
+		{Field: "a", Value: []byte("1")}, {Field: "b", Value: []byte("2")}, {Field: "a", Value: []byte("3")},
+	}}, ":2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HSet{Key: "h", Pairs: []command.FieldValue{{Field: "c", Value: []byte("4")}}}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HSetNX{Key: "h", Field: "a", Value: []byte("x")}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HSetNX{Key: "h", Field: "d", Value: []byte("5")}, ":1\r\n")
+
+	runCommandAndCheckOutputWithServer(t, server, command.HGet{Key: "h", Field: "a"}, "$1\r\n3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HGet{Key: "h", Field: "missing"}, command.NullBulkString)
+	runCommandAndCheckOutputWithServer(t, server, command.HGet{Key: "missing", Field: "a"}, command.NullBulkString)
+	runCommandAndCheckOutputWithServer(t, server, command.HMGet{Key: "h", Fields: []string{"b", "missing", "d"}}, "*3\r\n$1\r\n2\r\n$-1\r\n$1\r\n5\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HExists{Key: "h", Field: "c"}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HExists{Key: "h", Field: "missing"}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HLen{Key: "h"}, ":4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HLen{Key: "missing"}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Type{Key: "h"}, "+hash\r\n")
+
+	// Deleting the last fields should delete the hash
+	runCommandAndCheckOutputWithServer(t, server, command.HDel{Key: "h", Fields: []string{"a", "missing"}}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HDel{Key: "h", Fields: []string{"b", "c", "d"}}, ":3\r\n")
+	assert.Equal(t, 1, server.Size())
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	for _, cmd := range []command.Command{
+		command.HSet{Key: "s", Pairs: []command.FieldValue{{Field: "a", Value: []byte("1")}}},
+		command.HSetNX{Key: "s", Field: "a", Value: []byte("1")},
+		command.HGet{Key: "s", Field: "a"},
+		command.HMGet{Key: "s", Fields: []string{"a"}},
+		command.HDel{Key: "s", Fields: []string{"a"}},
+		command.HGetAll{Cmd: command.HGetAllCmd, Key: "s"},
+		command.HLen{Key: "s"},
+		command.HRandField{Key: "s"},
+		command.HScan{Key: "s", Match: "*", Count: 10},
+	} {
+		assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, cmd), "unexpected error for %v", cmd)
+	}
+}
+
+func TestExecuteHGetAll(t *testing.T) {
+	server := getTestMasterServer(serverStore{"h": {data: hashOf("a", "1", "b", "2")}})
+	runCommandAndCheckOutputWithServer(t, server, command.HGetAll{Cmd: command.HGetAllCmd, Key: "h"}, "*4\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HGetAll{Cmd: command.HKeysCmd, Key: "h"}, "*2\r\n$1\r\na\r\n$1\r\nb\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HGetAll{Cmd: command.HValsCmd, Key: "h"}, "*2\r\n$1\r\n1\r\n$1\r\n2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HGetAll{Cmd: command.HGetAllCmd, Key: "missing"}, "*0\r\n")
+
+	t.Run("HGETALL should reply with a map under RESP3", func(t *testing.T) {
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		conn.ClientState().Protocol = command.RESP3
+		require.NoError(t, RunCommand(server, conn, command.HGetAll{Cmd: command.HGetAllCmd, Key: "h"}))
+
+		res, err := conn.ReadNextCmdString()
+		require.NoError(t, err)
+		assert.Equal(t, "%2\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n", res)
+	})
+}
+
+func TestExecuteHIncrBy(t *testing.T) {
+	server := getTestMasterServer(serverStore{"h": {data: hashOf("a", "5", "f", "1.5", "s", "abc", "big", "9223372036854775807")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.HIncrBy{Key: "h", Field: "a", Increment: -7}, ":-2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HIncrBy{Key: "h", Field: "new", Increment: 3}, ":3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HIncrBy{Key: "created", Field: "a", Increment: 1}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HIncrByFloat{Key: "h", Field: "f", Increment: 0.1}, "$3\r\n1.6\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HIncrByFloat{Key: "h", Field: "a", Increment: 1e21}, "$22\r\n1000000000000000000000\r\n")
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	assert.Equal(t, command.ErrorReply("ERR hash value is not an integer"), RunCommand(server, conn, command.HIncrBy{Key: "h", Field: "f", Increment: 1}))
+	assert.Equal(t, command.ErrOverflow, RunCommand(server, conn, command.HIncrBy{Key: "h", Field: "big", Increment: 1}))
+	assert.Equal(t, command.ErrorReply("ERR hash value is not a float"), RunCommand(server, conn, command.HIncrByFloat{Key: "h", Field: "s", Increment: 1}))
+	assert.Equal(t, command.ErrorReply("ERR increment would produce NaN or Infinity"), RunCommand(server, conn, command.HIncrByFloat{Key: "h", Field: "a", Increment: math.Inf(1)}))
+
+	// A failed increment shouldn't leave an empty hash behind
+	assert.Equal(t, command.ErrorReply("ERR increment would produce NaN or Infinity"), RunCommand(server, conn, command.HIncrByFloat{Key: "missing", Field: "a", Increment: math.Inf(1)}))
+	assert.Equal(t, 2, server.Size())
+}
+
+func TestExecuteHRandField(t *testing.T) {
+	server := getTestMasterServer(serverStore{"h": {data: hashOf("a", "1", "b", "2", "c", "3")}})
+	runCommandAndCheckOutputWithServer(t, server, command.HRandField{Key: "missing"}, command.NullBulkString)
+	runCommandAndCheckOutputWithServer(t, server, command.HRandField{Key: "missing", Count: 2, WithCount: true}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HRandField{Key: "h", Count: 0, WithCount: true}, "*0\r\n")
+
+	// A count that covers the whole hash returns every field
+	runCommandAndCheckOutputWithServer(t, server, command.HRandField{Key: "h", Count: 5, WithCount: true, WithValues: true},
+		"*6\r\n$1\r\na\r\n$1\r\n1\r\n$1\r\nb\r\n$1\r\n2\r\n$1\r\nc\r\n$1\r\n3\r\n")
+
+	t.Run("a positive count should return distinct fields", func(t *testing.T) {
+		for range 20 {
+			entries, err := server.HRandField("h", 2)
+			require.NoError(t, err)
+			require.Len(t, entries, 2)
+			assert.NotEqual(t, entries[0].Field, entries[1].Field)
+		}
+	})
+
+	t.Run("a negative count should return exactly that many fields", func(t *testing.T) {
+		entries, err := server.HRandField("h", -10)
+		require.NoError(t, err)
+		require.Len(t, entries, 10)
+		for _, entry := range entries {
+			assert.Contains(t, []string{"a", "b", "c"}, entry.Field)
+		}
+	})
+
+	t.Run("WITHVALUES should pair each field with its value under RESP3", func(t *testing.T) {
+		single := getTestMasterServer(serverStore{"h": {data: hashOf("a", "1")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		conn.ClientState().Protocol = command.RESP3
+		require.NoError(t, RunCommand(single, conn, command.HRandField{Key: "h", Count: -2, WithCount: true, WithValues: true}))
+
+		res, err := conn.ReadNextCmdString()
+		require.NoError(t, err)
+		assert.Equal(t, "*2\r\n*2\r\n$1\r\na\r\n$1\r\n1\r\n*2\r\n$1\r\na\r\n$1\r\n1\r\n", res)
+	})
+
+	t.Run("a hash that a replica has kept after all of its fields expired should have no fields to return", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2")
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		hash.setFieldExpiry("b", time.Now().Add(-time.Second))
+		replica := getTestReplicaServer(serverStore{"h": {data: hash}})
+
+		runCommandAndCheckOutputWithServer(t, replica, command.HRandField{Key: "h"}, command.NullBulkString)
+		runCommandAndCheckOutputWithServer(t, replica, command.HRandField{Key: "h", Count: -3, WithCount: true}, "*0\r\n")
+		runCommandAndCheckOutputWithServer(t, replica, command.HRandField{Key: "h", Count: 3, WithCount: true}, "*0\r\n")
+	})
+}
+
+func TestExecuteHScan(t *testing.T) {
+	t.Run("a compact hash should be scanned in a single page", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"h": {data: hashOf("a1", "1", "b1", "2", "a2", "3")}})
+		runCommandAndCheckOutputWithServer(t, server, command.HScan{Key: "h", Match: "*", Count: 1},
+			"*2\r\n$1\r\n0\r\n*6\r\n$2\r\na1\r\n$1\r\n1\r\n$2\r\nb1\r\n$1\r\n2\r\n$2\r\na2\r\n$1\r\n3\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HScan{Key: "h", Match: "a*", Count: 10, NoValues: true},
+			"*2\r\n$1\r\n0\r\n*2\r\n$2\r\na1\r\n$2\r\na2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HScan{Key: "missing", Match: "*", Count: 10}, "*2\r\n$1\r\n0\r\n*0\r\n")
+	})
+
+	t.Run("scanning a large hash with a cursor should return every matching field", func(t *testing.T) {
+		hash := newHashValue()
+		for i := range 500 {
+			hash.set(fmt.Sprint("field", i), []byte("v"), defaultHashLimits, time.Now())
+		}
+		require.False(t, hash.isCompact())
+		server := getTestMasterServer(serverStore{"h": {data: hash}})
+
+		scanned := map[string]bool{}
+		cursor := uint64(0)
+		for {
+			next, entries, err := server.HScan("h", cursor, "field1*", 25)
+			require.NoError(t, err)
+			for _, entry := range entries {
+				assert.False(t, scanned[entry.Field], "field %q was scanned twice", entry.Field)
+				scanned[entry.Field] = true
+			}
+
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+
+		// field1, field10 to field19 and field100 to field199
+		assert.Len(t, scanned, 111)
+	})
+}
+
+func TestExecuteHExpire(t *testing.T) {
+	t.Run("HEXPIRE should set an expiry on each field that exists", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"h": {data: hashOf("a", "1", "b", "2")}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.HExpire{Cmd: command.HExpireCmd, Key: "h", Time: 100, Fields: []string{"a", "missing"}},
+			"*2\r\n:1\r\n:-2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HExpire{Cmd: command.HExpireCmd, Key: "missing", Time: 100, Fields: []string{"a", "b"}},
+			"*2\r\n:-2\r\n:-2\r\n")
+
+		hash := server.storeData["h"].data.(*hashValue)
+		require.NotNil(t, hash.fieldExpiry("a"))
+		assert.WithinDuration(t, time.Now().Add(100*time.Second), *hash.fieldExpiry("a"), time.Second)
+		assert.Nil(t, hash.fieldExpiry("b"))
+	})
+
+	t.Run("the NX, XX, GT and LT options should only set the expiry of fields that meet their conditions", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2")
+		hash.setFieldExpiry("a", time.Now().Add(time.Hour))
+		server := getTestMasterServer(serverStore{"h": {data: hash}})
+
+		for _, tc := range []struct {
+			options       command.ExpireOptions
+			seconds       int64
+			expectedReply string
+		}{
+			{options: command.ExpireNX, seconds: 100, expectedReply: "*2\r\n:0\r\n:1\r\n"},
+			{options: command.ExpireXX, seconds: 7200, expectedReply: "*2\r\n:1\r\n:1\r\n"},
+			{options: command.ExpireGT, seconds: 3600, expectedReply: "*2\r\n:0\r\n:0\r\n"},
+			{options: command.ExpireLT, seconds: 3600, expectedReply: "*2\r\n:1\r\n:1\r\n"},
+		} {
+			cmd := command.HExpire{Cmd: command.HExpireCmd, Key: "h", Time: tc.seconds, Options: tc.options, Fields: []string{"a", "b"}}
+			runCommandAndCheckOutputWithServer(t, server, cmd, tc.expectedReply)
+		}
+	})
+
+	t.Run("an expiry in the past should delete the fields, and the key along with the last of them", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"h": {data: hashOf("a", "1", "b", "2")}})
+		runCommandAndCheckOutputWithServer(t, server, command.HExpire{Cmd: command.HPExpireAtCmd, Key: "h", Time: 0, Fields: []string{"a"}}, "*1\r\n:2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HLen{Key: "h"}, ":1\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HExpire{Cmd: command.HPExpireAtCmd, Key: "h", Time: 0, Fields: []string{"b"}}, "*1\r\n:2\r\n")
+		assert.Equal(t, 0, server.Size())
+	})
+
+	t.Run("an expiry past 2^48 milliseconds should fail", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"h": {data: hashOf("a", "1")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		err := RunCommand(server, conn, command.HExpire{Cmd: command.HPExpireAtCmd, Key: "h", Time: 1<<48 + 1, Fields: []string{"a"}})
+		assert.Equal(t, command.ErrorReply("ERR invalid expire time, must be >= 0 and <= 2^48"), err)
+	})
+
+	t.Run("expired fields should be deleted when they're found, and the key along with the last of them", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2")
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		server := getTestMasterServer(serverStore{"h": {data: hash}}).(*MasterServer)
+
+		runCommandAndCheckOutputWithServer(t, server, command.HGet{Key: "h", Field: "a"}, command.NullBulkString)
+		runCommandAndCheckOutputWithServer(t, server, command.HLen{Key: "h"}, ":1\r\n")
+		assert.Equal(t, 1, hash.storedLen())
+
+		hash.setFieldExpiry("b", time.Now().Add(-time.Second))
+		runCommandAndCheckOutputWithServer(t, server, command.Get{Payload: "h"}, command.NullBulkString)
+		assert.Equal(t, 0, server.Size())
+	})
+
+	t.Run("the expiry loop should delete expired fields, and the key along with the last of them", func(t *testing.T) {
+		partlyExpired := hashOf("a", "1", "b", "2")
+		partlyExpired.setFieldExpiry("a", time.Now().Add(-time.Second))
+		expired := hashOf("a", "1")
+		expired.setFieldExpiry("a", time.Now().Add(-time.Second))
+		server := getTestMasterServer(serverStore{"h1": {data: partlyExpired}, "h2": {data: expired}}).(*MasterServer)
+
+		server.activeExpire()
+		assert.Equal(t, 1, server.Size())
+		assert.Equal(t, []command.FieldValue{{Field: "b", Value: []byte("2")}}, partlyExpired.entries(time.Now()))
+		assert.Equal(t, 1, partlyExpired.storedLen())
+	})
+
+	t.Run("incrementing a field should keep its expiry", func(t *testing.T) {
+		hash := hashOf("a", "1")
+		expiresAt := time.Now().Add(time.Hour)
+		hash.setFieldExpiry("a", expiresAt)
+		server := getTestMasterServer(serverStore{"h": {data: hash}})
+
+		runCommandAndCheckOutputWithServer(t, server, command.HIncrBy{Key: "h", Field: "a", Increment: 1}, ":2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HIncrByFloat{Key: "h", Field: "a", Increment: 0.5}, "$3\r\n2.5\r\n")
+		require.NotNil(t, hash.fieldExpiry("a"))
+		assert.Equal(t, expiresAt, *hash.fieldExpiry("a"))
+
+		runCommandAndCheckOutputWithServer(t, server, command.HSet{Key: "h", Pairs: []command.FieldValue{{Field: "a", Value: []byte("1")}}}, ":0\r\n")
+		assert.Nil(t, hash.fieldExpiry("a"))
+	})
+}
+
+func TestExecuteHTTL(t *testing.T) {
+	hash := hashOf("a", "1", "b", "2")
+	expiresAt := time.Now().Add(time.Hour)
+	hash.setFieldExpiry("a", expiresAt)
+	server := getTestMasterServer(serverStore{"h": {data: hash}})
+
+	for _, cmdType := range []command.CommandType{command.HTTLCmd, command.HPTTLCmd, command.HExpireTimeCmd, command.HPExpireTimeCmd} {
+		runCommandAndCheckOutputWithServer(t, server, command.HTTL{Cmd: cmdType, Key: "h", Fields: []string{"b", "missing"}}, "*2\r\n:-1\r\n:-2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.HTTL{Cmd: cmdType, Key: "missing", Fields: []string{"a"}}, "*1\r\n:-2\r\n")
+	}
+
+	runCommandAndCheckOutputWithServer(t, server, command.HTTL{Cmd: command.HTTLCmd, Key: "h", Fields: []string{"a"}}, "*1\r\n:3600\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HTTL{Cmd: command.HExpireTimeCmd, Key: "h", Fields: []string{"a"}}, fmt.Sprintf("*1\r\n:%d\r\n", expiresAt.Unix()))
+	runCommandAndCheckOutputWithServer(t, server, command.HTTL{Cmd: command.HPExpireTimeCmd, Key: "h", Fields: []string{"a"}}, fmt.Sprintf("*1\r\n:%d\r\n", expiresAt.UnixMilli()))
+}
+
+func TestExecuteHPersist(t *testing.T) {
+	hash := hashOf("a", "1", "b", "2")
+	hash.setFieldExpiry("a", time.Now().Add(time.Hour))
+	server := getTestMasterServer(serverStore{"h": {data: hash}})
+
+	runCommandAndCheckOutputWithServer(t, server, command.HPersist{Key: "h", Fields: []string{"a", "b", "missing"}}, "*3\r\n:1\r\n:-1\r\n:-2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.HPersist{Key: "missing", Fields: []string{"a"}}, "*1\r\n:-2\r\n")
+	assert.False(t, hash.hasFieldExpiries())
+}
+
+func TestExecuteSAdd(t *testing.T) {
+	server := getTestMasterServer(serverStore{"str": {data: stringValue("1")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.SAdd{Key: "s", Members: []string{"3", "1", "2", "1"}}, ":3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SAdd{Key: "s", Members: []string{"2"}}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SMembers{Key: "s"}, "*3\r\n$1\r\n1\r\n$1\r\n2\r\n$1\r\n3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SMembers{Key: "missing"}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SIsMember{Key: "s", Member: "2"}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SIsMember{Key: "missing", Member: "2"}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SMIsMember{Key: "s", Members: []string{"1", "a", "3"}}, "*3\r\n:1\r\n:0\r\n:1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SCard{Key: "s"}, ":3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SCard{Key: "missing"}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Type{Key: "s"}, "+set\r\n")
+
+	// Adding a member that isn't an integer should convert the intset
+	runCommandAndCheckOutputWithServer(t, server, command.SAdd{Key: "s", Members: []string{"a"}}, ":1\r\n")
+	assert.False(t, server.storeData["s"].data.(*setValue).isIntset())
+	runCommandAndCheckOutputWithServer(t, server, command.SIsMember{Key: "s", Member: "a"}, ":1\r\n")
+
+	// Removing the last members should delete the set
+	runCommandAndCheckOutputWithServer(t, server, command.SRem{Key: "s", Members: []string{"1", "missing"}}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SRem{Key: "s", Members: []string{"2", "3", "a"}}, ":3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SRem{Key: "s", Members: []string{"2"}}, ":0\r\n")
+	assert.Equal(t, 1, server.Size())
+
+	t.Run("SMEMBERS should reply with a set under RESP3", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"s": {data: setOf("1", "2")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		conn.ClientState().Protocol = command.RESP3
+		require.NoError(t, RunCommand(server, conn, command.SMembers{Key: "s"}))
+
+		res, err := conn.ReadNextCmdString()
+		require.NoError(t, err)
+		assert.Equal(t, "~2\r\n$1\r\n1\r\n$1\r\n2\r\n", res)
+	})
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	for _, cmd := range []command.Command{
+		command.SAdd{Key: "str", Members: []string{"a"}},
+		command.SRem{Key: "str", Members: []string{"a"}},
+		command.SMembers{Key: "str"},
+		command.SIsMember{Key: "str", Member: "a"},
+		command.SCard{Key: "str"},
+		command.SPop{Key: "str"},
+		command.SRandMember{Key: "str"},
+		command.SMove{Source: "str", Destination: "s", Member: "a"},
+		command.SMove{Source: "missing", Destination: "str", Member: "a"},
+		command.SCombine{Cmd: command.SUnionCmd, Keys: []string{"missing", "str"}},
+		command.SCombineStore{Cmd: command.SInterStoreCmd, Destination: "d", Keys: []string{"missing", "str"}},
+		command.SInterCard{Keys: []string{"missing", "str"}},
+		command.SScan{Key: "str", Match: "*", Count: 10},
+	} {
+		assert.Equal(t, command.ErrWrongType, RunCommand(server, conn, cmd), "unexpected error for %v", cmd)
+	}
+}
+
+func TestExecuteSPop(t *testing.T) {
+	server := getTestMasterServer(serverStore{"s": {data: setOf("a", "b", "c")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.SPop{Key: "missing", Count: 1}, command.NullBulkString)
+	runCommandAndCheckOutputWithServer(t, server, command.SPop{Key: "missing", Count: 2, WithCount: true}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SPop{Key: "s", Count: 0, WithCount: true}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SRandMember{Key: "missing", Count: 1}, command.NullBulkString)
+
+	popped, err := server.SPop("s", 2)
+	require.NoError(t, err)
+	require.Len(t, popped, 2)
+	assert.NotEqual(t, popped[0], popped[1])
+
+	remaining, err := server.SMembers("s")
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.NotContains(t, popped, remaining[0])
+
+	// Popping the last member should delete the set
+	runCommandAndCheckOutputWithServer(t, server, command.SPop{Key: "s", Count: 1}, fmt.Sprintf("$1\r\n%s\r\n", remaining[0]))
+	assert.Equal(t, 0, server.Size())
+
+	t.Run("SRANDMEMBER should return distinct members for a positive count and repeat them for a negative one", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"s": {data: setOf("1", "2", "3")}})
+		runCommandAndCheckOutputWithServer(t, server, command.SRandMember{Key: "s", Count: 5, WithCount: true}, "*3\r\n$1\r\n1\r\n$1\r\n2\r\n$1\r\n3\r\n")
+
+		for range 20 {
+			members, err := server.SRandMember("s", 2)
+			require.NoError(t, err)
+			require.Len(t, members, 2)
+			assert.NotEqual(t, members[0], members[1])
+		}
+
+		members, err := server.SRandMember("s", -10)
+		require.NoError(t, err)
+		require.Len(t, members, 10)
+		for _, member := range members {
+			assert.Contains(t, []string{"1", "2", "3"}, member)
+		}
+	})
+}
+
+func TestExecuteSMove(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: setOf("1", "2")}, "b": {data: setOf("x")}}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.SMove{Source: "a", Destination: "b", Member: "1"}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SMove{Source: "a", Destination: "b", Member: "1"}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SMove{Source: "a", Destination: "a", Member: "2"}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SMove{Source: "a", Destination: "c", Member: "2"}, ":1\r\n")
+
+	// Moving the last member should delete the source and create the destination
+	assert.Equal(t, 2, server.Size())
+	runCommandAndCheckOutputWithServer(t, server, command.SMembers{Key: "c"}, "*1\r\n$1\r\n2\r\n")
+	members, err := server.SMembers("b")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "x"}, members)
+}
+
+func TestExecuteSCombine(t *testing.T) {
+	server := getTestMasterServer(serverStore{
+		"a": {data: setOf("1", "2", "3", "4")},
+		"b": {data: setOf("2", "3", "5")},
+		"c": {data: setOf("3", "4", "x")},
+	}).(*MasterServer)
+	runCommandAndCheckOutputWithServer(t, server, command.SCombine{Cmd: command.SInterCmd, Keys: []string{"a", "b"}}, "*2\r\n$1\r\n2\r\n$1\r\n3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SCombine{Cmd: command.SInterCmd, Keys: []string{"a", "missing"}}, "*0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SCombine{Cmd: command.SDiffCmd, Keys: []string{"a", "b", "missing"}}, "*2\r\n$1\r\n1\r\n$1\r\n4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SCombine{Cmd: command.SDiffCmd, Keys: []string{"missing", "a"}}, "*0\r\n")
+
+	members, err := server.SetCombine(command.SetUnion, "a", "c", "missing")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4", "x"}, members)
+
+	t.Run("the STORE variants should replace the destination and delete it for an empty result", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour)
+		server.storeData["d"] = storeValue{data: stringValue("old"), expiresAt: &expiresAt}
+
+		runCommandAndCheckOutputWithServer(t, server, command.SCombineStore{Cmd: command.SUnionStoreCmd, Destination: "d", Keys: []string{"a", "b"}}, ":5\r\n")
+		assert.Nil(t, server.storeData["d"].expiresAt)
+		runCommandAndCheckOutputWithServer(t, server, command.SMembers{Key: "d"}, "*5\r\n$1\r\n1\r\n$1\r\n2\r\n$1\r\n3\r\n$1\r\n4\r\n$1\r\n5\r\n")
+
+		// The destination can also be one of the sources
+		runCommandAndCheckOutputWithServer(t, server, command.SCombineStore{Cmd: command.SInterStoreCmd, Destination: "d", Keys: []string{"d", "c"}}, ":2\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.SCombineStore{Cmd: command.SDiffStoreCmd, Destination: "d", Keys: []string{"d", "a"}}, ":0\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.Exists{Keys: []string{"d"}}, ":0\r\n")
+	})
+}
+
+func TestExecuteSInterCard(t *testing.T) {
+	server := getTestMasterServer(serverStore{
+		"a": {data: setOf("1", "2", "3", "4")},
+		"b": {data: setOf("2", "3", "4", "5")},
+	})
+	runCommandAndCheckOutputWithServer(t, server, command.SInterCard{Keys: []string{"a", "b"}}, ":3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SInterCard{Keys: []string{"a", "b"}, Limit: 2}, ":2\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SInterCard{Keys: []string{"a", "b"}, Limit: 10}, ":3\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SInterCard{Keys: []string{"a"}}, ":4\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.SInterCard{Keys: []string{"a", "missing"}}, ":0\r\n")
+}
+
+func TestExecuteSScan(t *testing.T) {
+	t.Run("an intset should be scanned in a single page", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"s": {data: setOf("10", "2", "11")}})
+		runCommandAndCheckOutputWithServer(t, server, command.SScan{Key: "s", Match: "*", Count: 1},
+			"*2\r\n$1\r\n0\r\n*3\r\n$1\r\n2\r\n$2\r\n10\r\n$2\r\n11\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.SScan{Key: "s", Match: "1*", Count: 10},
+			"*2\r\n$1\r\n0\r\n*2\r\n$2\r\n10\r\n$2\r\n11\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.SScan{Key: "missing", Match: "*", Count: 10}, "*2\r\n$1\r\n0\r\n*0\r\n")
+	})
+
+	t.Run("scanning a large set with a cursor should return every matching member", func(t *testing.T) {
+		set := newSetValue()
+		for i := range 500 {
+			set.add(fmt.Sprint("member", i), defaultSetLimits)
+		}
+		server := getTestMasterServer(serverStore{"s": {data: set}})
+
+		scanned := map[string]bool{}
+		cursor := uint64(0)
+		for {
+			next, members, err := server.SScan("s", cursor, "member1*", 25)
+			require.NoError(t, err)
+			for _, member := range members {
+				assert.False(t, scanned[member], "member %q was scanned twice", member)
+				scanned[member] = true
+			}
+
+			if next == 0 {
+				break
+			}
+			cursor = next
+		}
+
+		// member1, member10 to member19 and member100 to member199
+		assert.Len(t, scanned, 111)
+	})
+}
+
+func TestExecuteDel(t *testing.T) {
+	t.Run("DEL should return the number of keys that existed and delete them", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}, "b": {data: stringValue("2")}, "c": {data: stringValue("3")}})
+		runCommandAndCheckOutputWithServer(t, server, command.Del{Keys: []string{"a", "b", "d"}}, ":2\r\n")
+
+		_, ok, err := server.Get("a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		_, ok, err = server.Get("c")
+		require.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("DEL should not count keys that have expired", func(t *testing.T) {
+		pastTime := time.Now().Add(-time.Hour)
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1"), expiresAt: &pastTime}})
+		runCommandAndCheckOutputWithServer(t, server, command.Del{Keys: []string{"a"}}, ":0\r\n")
+	})
+}
+
+// largeValue is a value that is expensive enough to free that UNLINK frees it in the background
+type largeValue struct {
+	freed chan struct{}
+}
+
+func (largeValue) typeName() string { return "large" }
+func (v largeValue) clone() value   { return v }
+func (largeValue) freeEffort() int  { return lazyFreeThreshold + 1 }
+func (v largeValue) free()          { close(v.freed) }
+
+func TestExecuteUnlink(t *testing.T) {
+	t.Run("UNLINK should return the number of keys that existed and delete them", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}, "b": {data: stringValue("2")}})
+		runCommandAndCheckOutputWithServer(t, server, command.Unlink{Keys: []string{"a", "c"}}, ":1\r\n")
+		assert.Equal(t, 1, server.Size())
+	})
+
+	t.Run("UNLINK should free large values in the background", func(t *testing.T) {
+		large := largeValue{freed: make(chan struct{})}
+		server := getTestMasterServer(serverStore{"a": {data: large}})
+		runCommandAndCheckOutputWithServer(t, server, command.Unlink{Keys: []string{"a"}}, ":1\r\n")
+
+		select {
+		case <-large.freed:
+		case <-time.After(time.Second):
+			assert.Fail(t, "expected the unlinked value to be freed")
+		}
+	})
+}
+
+func TestExecuteExists(t *testing.T) {
+	pastTime := time.Now().Add(-time.Hour)
+	server := getTestMasterServer(serverStore{
+		"a": {data: stringValue("1")},
+		"b": {data: stringValue("2"), expiresAt: &pastTime},
+	})
+
+	// Keys are counted every time they are provided
+	runCommandAndCheckOutputWithServer(t, server, command.Exists{Keys: []string{"a", "a", "b", "c"}}, ":2\r\n")
+}
+
+func TestExecuteType(t *testing.T) {
+	server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}, "l": {data: listOf("1")}})
+	runCommandAndCheckOutputWithServer(t, server, command.Type{Key: "a"}, "+string\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Type{Key: "l"}, "+list\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Type{Key: "b"}, "+none\r\n")
+}
+
+func TestExecuteRename(t *testing.T) {
+	t.Run("RENAME should move the value and its expiry to the new key", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{
+			"a": {data: stringValue("1"), expiresAt: &futureTime},
+			"b": {data: stringValue("2")},
+		}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.Rename{Key: "a", NewKey: "b"}, command.OKString)
+
+		assert.Equal(t, serverStore{"b": {data: stringValue("1"), expiresAt: &futureTime}}, server.storeData)
+	})
+
+	t.Run("RENAME on a missing key should fail", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		assert.Equal(t, command.ErrNoSuchKey, RunCommand(server, conn, command.Rename{Key: "a", NewKey: "b"}))
+	})
+
+	t.Run("RENAMENX should only move the value if the new key does not exist", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}, "b": {data: stringValue("2")}})
+		runCommandAndCheckOutputWithServer(t, server, command.RenameNX{Key: "a", NewKey: "b"}, ":0\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.RenameNX{Key: "a", NewKey: "c"}, ":1\r\n")
+
+		assert.Equal(t, 0, server.Exists("a"))
+		value, ok, err := server.Get("c")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+	})
+}
+
+func TestExecuteCopy(t *testing.T) {
+	t.Run("COPY should copy the value and its expiry to the destination", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1"), expiresAt: &futureTime}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.Copy{Source: "a", Destination: "b"}, ":1\r\n")
+
+		assert.Equal(t, server.storeData["a"], server.storeData["b"])
+
+		// The copy should not share any memory with the original
+		server.storeData["b"].data.(stringValue)[0] = '2'
+		assert.Equal(t, stringValue("1"), server.storeData["a"].data)
+		assert.NotSame(t, server.storeData["a"].expiresAt, server.storeData["b"].expiresAt)
+	})
+
+	t.Run("COPY should only replace an existing destination with REPLACE", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}, "b": {data: stringValue("2")}})
+		runCommandAndCheckOutputWithServer(t, server, command.Copy{Source: "a", Destination: "b"}, ":0\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.Copy{Source: "missing", Destination: "b", Replace: true}, ":0\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.Copy{Source: "a", Destination: "b", Replace: true}, ":1\r\n")
+
+		value, ok, err := server.Get("b")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+	})
+
+	t.Run("COPY to the same key should fail", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		err := RunCommand(server, conn, command.Copy{Source: "a", Destination: "a"})
+		assert.Equal(t, command.ErrorReply("ERR source and destination objects are the same"), err)
+	})
+}
+
+func TestExecuteExpire(t *testing.T) {
+	t.Run("EXPIRE should set an expiry relative to now", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}}).(*MasterServer)
+		runCommandAndCheckOutputWithServer(t, server, command.Expire{Cmd: command.ExpireCmd, Key: "a", Time: 100}, ":1\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.Expire{Cmd: command.ExpireCmd, Key: "b", Time: 100}, ":0\r\n")
+
+		require.NotNil(t, server.storeData["a"].expiresAt)
+		assert.WithinDuration(t, time.Now().Add(100*time.Second), *server.storeData["a"].expiresAt, time.Second)
+	})
+
+	t.Run("PEXPIREAT should set an absolute expiry", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}}).(*MasterServer)
+		expiresAt := time.Now().Add(time.Hour).UnixMilli()
+		runCommandAndCheckOutputWithServer(t, server, command.Expire{Cmd: command.PExpireAtCmd, Key: "a", Time: expiresAt}, ":1\r\n")
+
+		assert.Equal(t, expiresAt, server.storeData["a"].expiresAt.UnixMilli())
+	})
+
+	t.Run("an expiry in the past should delete the key", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+		runCommandAndCheckOutputWithServer(t, server, command.Expire{Cmd: command.ExpireCmd, Key: "a", Time: -1}, ":1\r\n")
+		assert.Equal(t, 0, server.Size())
+	})
+
+	t.Run("the NX, XX, GT and LT options should only set the expiry under their conditions", func(t *testing.T) {
+		futureTime := time.Now().Add(time.Hour)
+		for _, tc := range []struct {
+			options       command.ExpireOptions
+			hasExpiry     bool
+			seconds       int64
+			expectedReply string
+		}{
+			{options: command.ExpireNX, hasExpiry: false, seconds: 100, expectedReply: ":1\r\n"},
+			{options: command.ExpireNX, hasExpiry: true, seconds: 100, expectedReply: ":0\r\n"},
+			{options: command.ExpireXX, hasExpiry: false, seconds: 100, expectedReply: ":0\r\n"},
+			{options: command.ExpireXX, hasExpiry: true, seconds: 100, expectedReply: ":1\r\n"},
+			{options: command.ExpireGT, hasExpiry: false, seconds: 100, expectedReply: ":0\r\n"},
+			{options: command.ExpireGT, hasExpiry: true, seconds: 100, expectedReply: ":0\r\n"},
+			{options: command.ExpireGT, hasExpiry: true, seconds: 7200, expectedReply: ":1\r\n"},
+			{options: command.ExpireLT, hasExpiry: false, seconds: 7200, expectedReply: ":1\r\n"},
+			{options: command.ExpireLT, hasExpiry: true, seconds: 7200, expectedReply: ":0\r\n"},
+			{options: command.ExpireLT, hasExpiry: true, seconds: 100, expectedReply: ":1\r\n"},
+			{options: command.ExpireXX | command.ExpireGT, hasExpiry: false, seconds: 7200, expectedReply: ":0\r\n"},
+		} {
+			value := storeValue{data: stringValue("1")}
+			if tc.hasExpiry {
+				value.expiresAt = &futureTime
+			}
+			server := getTestMasterServer(serverStore{"a": value})
+
+			cmd := command.Expire{Cmd: command.ExpireCmd, Key: "a", Time: tc.seconds, Options: tc.options}
+			runCommandAndCheckOutputWithServer(t, server, cmd, tc.expectedReply)
+		}
+	})
+
+	t.Run("an expiry that can't be represented should fail", func(t *testing.T) {
+		server := getTestMasterServer(serverStore{"a": {data: stringValue("1")}})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		err := RunCommand(server, conn, command.Expire{Cmd: command.ExpireCmd, Key: "a", Time: math.MaxInt64})
+		assert.Equal(t, command.ErrorReply("ERR invalid expire time in 'expire' command"), err)
+	})
+}
+
+func TestExecuteTTL(t *testing.T) {
+	expiresAt := time.Now().Add(time.Hour)
+	server := getTestMasterServer(serverStore{
+		"a": {data: stringValue("1"), expiresAt: &expiresAt},
+		"b": {data: stringValue("2")},
+	})
+
+	for _, cmdType := range []command.CommandType{command.TTLCmd, command.PTTLCmd, command.ExpireTimeCmd, command.PExpireTimeCmd} {
+		runCommandAndCheckOutputWithServer(t, server, command.TTL{Cmd: cmdType, Key: "b"}, ":-1\r\n")
+		runCommandAndCheckOutputWithServer(t, server, command.TTL{Cmd: cmdType, Key: "missing"}, ":-2\r\n")
+	}
+
+	runCommandAndCheckOutputWithServer(t, server, command.TTL{Cmd: command.TTLCmd, Key: "a"}, ":3600\r\n")
+
+	// Expiries that are too far away for a time.Duration should still be reported correctly
+	farFuture := time.UnixMilli(99_999_999_999_999)
+	server.(*MasterServer).storeData["c"] = storeValue{data: stringValue("3"), expiresAt: &farFuture}
+	runCommandAndCheckOutputWithServer(t, server, command.TTL{Cmd: command.TTLCmd, Key: "c"}, fmt.Sprintf(":%d\r\n", (farFuture.UnixMilli()-time.Now().UnixMilli()+500)/1000))
+	runCommandAndCheckOutputWithServer(t, server, command.TTL{Cmd: command.ExpireTimeCmd, Key: "a"}, fmt.Sprintf(":%d\r\n", expiresAt.Unix()))
+	runCommandAndCheckOutputWithServer(t, server, command.TTL{Cmd: command.PExpireTimeCmd, Key: "a"}, fmt.Sprintf(":%d\r\n", expiresAt.UnixMilli()))
+}
+
+func TestExecutePersist(t *testing.T) {
+	futureTime := time.Now().Add(time.Hour)
+	server := getTestMasterServer(serverStore{
+		"a": {data: stringValue("1"), expiresAt: &futureTime},
+		"b": {data: stringValue("2")},
+	}).(*MasterServer)
+
+	runCommandAndCheckOutputWithServer(t, server, command.Persist{Key: "a"}, ":1\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Persist{Key: "b"}, ":0\r\n")
+	runCommandAndCheckOutputWithServer(t, server, command.Persist{Key: "missing"}, ":0\r\n")
+	assert.Nil(t, server.storeData["a"].expiresAt)
+}
+
+func TestExecuteSave(t *testing.T) {
+	futureTime := time.Now().Add(time.Hour)
+	pastTime := time.Now().Add(-time.Hour)
+
+	hash := hashOf("a", "1", "b", "2", "c", "3", "d", "4")
+	hash.setFieldExpiry("c", futureTime)
+	hash.setFieldExpiry("d", pastTime)
+
+	srv := &MasterServer{BaseServer: getTestBaseServer(serverStore{
+		"a": {data: stringValue("b")},
+		"c": {data: stringValue("d"), expiresAt: &futureTime},
+		"e": {data: stringValue("f"), expiresAt: &pastTime},
+		"l": {data: listOf("1", "two", "3"), expiresAt: &futureTime},
+		"h": {data: hash},
+		"s": {data: setOf("3", "1", "2")},
+		"t": {data: setOf("a", "b")},
+	})}
+	srv.rdbDir = t.TempDir()
+	srv.Set("g", []byte("h"), SetOptions{})
+
+	runCommandAndCheckOutputWithServer(t, srv, command.Save{}, command.OKString)
+	assert.Equal(t, "0", srv.PersistenceInfo()["rdb_changes_since_last_save"])
+	assert.Equal(t, "1", srv.PersistenceInfo()["rdb_saves"])
+
+	loadedSrv := getTestBaseServer(serverStore{})
+	loadedSrv.rdbDir = srv.rdbDir
+	assert.NoError(t, loadedSrv.loadRDBFile())
+
+	// The expired key should not have been saved
+	assert.Equal(t, 7, loadedSrv.Size())
+	for key, expectedValue := range map[string]string{"a": "b", "c": "d", "g": "h"} {
+		value, ok, err := loadedSrv.Get(key)
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte(expectedValue), value)
+	}
+	assert.Equal(t, futureTime.UnixMilli(), loadedSrv.storeData["c"].expiresAt.UnixMilli())
+
+	requireListEquals(t, listOf("1", "two", "3").elements(), loadedSrv.storeData["l"].data.(*listValue))
+	assert.Equal(t, futureTime.UnixMilli(), loadedSrv.storeData["l"].expiresAt.UnixMilli())
+
+	// The expired field should not have been saved either
+	entries, err := loadedSrv.HGetAll("h")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []command.FieldValue{{Field: "a", Value: []byte("1")}, {Field: "b", Value: []byte("2")}, {Field: "c", Value: []byte("3")}}, entries)
+
+	loadedHash := loadedSrv.storeData["h"].data.(*hashValue)
+	assert.True(t, loadedHash.isCompact())
+	assert.Equal(t, 3, loadedHash.storedLen())
+	require.NotNil(t, loadedHash.fieldExpiry("c"))
+	assert.Equal(t, futureTime.UnixMilli(), loadedHash.fieldExpiry("c").UnixMilli())
+
+	// A set of integers should be loaded back as an intset
+	loadedSet := loadedSrv.storeData["s"].data.(*setValue)
+	assert.True(t, loadedSet.isIntset())
+	assert.Equal(t, []string{"1", "2", "3"}, loadedSet.members())
+	members, err := loadedSrv.SMembers("t")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"a", "b"}, members)
+}
+
+func TestExecuteBgSave(t *testing.T) {
+	srv := &MasterServer{BaseServer: getTestBaseServer(serverStore{"a": {data: stringValue("b")}})}
+	srv.rdbDir = t.TempDir()
+
+	runCommandAndCheckOutputWithServer(t, srv, command.BgSave{}, "+Background saving started\r\n")
+
+	assert.Eventually(t, func() bool {
+		return srv.PersistenceInfo()["rdb_bgsave_in_progress"] == "0"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "ok", srv.PersistenceInfo()["rdb_last_bgsave_status"])
+	assert.FileExists(t, filepath.Join(srv.rdbDir, DEFAULT_RDB_FILENAME))
+
+	t.Run("BGSAVE while a save is in progress should return an error", func(t *testing.T) {
+		srv.persistence.bgSaveInProgress = true
+		defer func() { srv.persistence.bgSaveInProgress = false }()
+
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		assert.Equal(t, command.ErrorReply("ERR Background save already in progress"), RunCommand(srv, conn, command.BgSave{}))
+		assert.Equal(t, command.ErrorReply("ERR Background save already in progress"), RunCommand(srv, conn, command.Save{}))
+	})
+}
+
+func TestExecuteLastSave(t *testing.T) {
+	srv := getTestMasterServer(serverStore{})
+	runCommandAndCheckOutputWithServer(t, srv, command.LastSave{}, fmt.Sprintf(":%d\r\n", srv.LastSave().Unix()))
+}
+
+func TestExecuteHello(t *testing.T) {
+	// runOnConn runs a command on the same connection each time so that the protocol version carries over
+	runOnConn := func(t *testing.T, srv Server, conn connection.Connection, cmd command.Command) string {
+		t.Helper()
+		require.NoError(t, RunCommand(srv, conn, cmd))
+		res, err := conn.ReadNextCmdString()
+		require.NoError(t, err)
+		return res
+	}
+
+	helloResponse := func(protocol int, id int64, role string) command.MapReply {
+		return command.MapReply{
+			{Key: "server", Value: "redis"},
+			{Key: "version", Value: redisVersion},
+			{Key: "proto", Value: protocol},
+			{Key: "id", Value: id},
+			{Key: "mode", Value: "standalone"},
+			{Key: "role", Value: role},
+			{Key: "modules", Value: []any{}},
+		}
+	}
+
+	t.Run("HELLO 3 should switch the connection to RESP3 and reply with a map", func(t *testing.T) {
+		srv := getTestMasterServer(serverStore{})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+		id := conn.ClientState().ID
+
+		res := runOnConn(t, srv, conn, command.Hello{ProtocolVersion: 3, ClientName: "cli"})
+		encoder := command.Encoder{UseBulkStrings: true, Protocol: command.RESP3}
+		assert.Equal(t, encoder.MustEncode(helloResponse(3, id, "master")), res)
+		assert.True(t, strings.HasPrefix(res, "%7\r\n"))
+		assert.Equal(t, "cli", conn.ClientState().Name)
+
+		assert.Equal(t, "_\r\n", runOnConn(t, srv, conn, command.Get{Payload: "missing"}))
+		assert.True(t, strings.HasPrefix(runOnConn(t, srv, conn, command.Info{Payload: "persistence"}), "="))
+
+		// Switching back should go back to RESP2 replies
+		res = runOnConn(t, srv, conn, command.Hello{ProtocolVersion: 2})
+		assert.Equal(t, command.Encoder{UseBulkStrings: true}.MustEncode(helloResponse(2, id, "master")), res)
+		assert.Equal(t, command.NullBulkString, runOnConn(t, srv, conn, command.Get{Payload: "missing"}))
+	})
+
+	t.Run("HELLO without a protocol version should reply with a flat array under RESP2", func(t *testing.T) {
+		srv := getTestReplicaServer(serverStore{})
+		conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+
+		res := runOnConn(t, srv, conn, command.Hello{})
+		assert.Equal(t, command.Encoder{UseBulkStrings: true}.MustEncode(helloResponse(2, conn.ClientState().ID, "replica")), res)
+		assert.True(t, strings.HasPrefix(res, "*14\r\n"))
+		assert.Equal(t, command.RESP2, conn.ClientState().Protocol)
+	})
+
+	for _, tc := range []struct {
+		name        string
+		hello       command.Hello
+		expectedErr error
+	}{
+		{
+			name:        "an unsupported protocol version",
+			hello:       command.Hello{ProtocolVersion: 4},
+			expectedErr: command.ErrorReply("NOPROTO unsupported protocol version"),
+		},
+		{
+			name:        "an unknown user",
+			hello:       command.Hello{ProtocolVersion: 3, Username: "someone", Password: "pass"},
+			expectedErr: command.ErrorReply("WRONGPASS invalid username-password pair or user is disabled."),
+		},
+		{
+			name:        "a client name with a space",
+			hello:       command.Hello{ProtocolVersion: 3, ClientName: "a b"},
+			expectedErr: command.ErrorReply("ERR Client names cannot contain spaces, newlines or special characters."),
+		},
+	} {
+		t.Run(fmt.Sprintf("HELLO with %s should fail without changing the protocol", tc.name), func(t *testing.T) {
+			srv := getTestMasterServer(serverStore{})
+			conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+
+			assert.Equal(t, tc.expectedErr, RunCommand(srv, conn, tc.hello))
+			assert.Equal(t, command.RESP2, conn.ClientState().Protocol)
+		})
+	}
+}
+
+func TestExecuteReplConf(t *testing.T) {
+	for _, tc := range []struct {
+		key         string
+		value       string
+		server      Server
+		expectedRes string
+	}{
+		{
+			key:         "capa",
+			value:       "psync2",
+			server:      getTestMasterServer(serverStore{}),
+			expectedRes: command.OKString,
+		},
+		{
+			key:         "GetAck",
+			value:       "*",
+			server:      getTestReplicaServer(serverStore{}),
+			expectedRes: "*3\r\n+REPLCONF\r\n+ACK\r\n+0\r\n",
+		},
+	} {
+		t.Run(fmt.Sprintf("PSYNC with key %q and value %q should return the expected res", tc.key, tc.value), func(t *testing.T) {
+			runCommandAndCheckOutputWithServer(t, tc.server, command.ReplConf{Payload: []string{tc.key, tc.value}}, tc.expectedRes)
+		})
+	}
+}
+
+func TestExecutePSync(t *testing.T) {
+	futureTime := time.Now().Add(time.Hour)
+	master := getTestMasterServer(serverStore{
+		"a": {data: stringValue("b")},
+		"c": {data: stringValue("d"), expiresAt: &futureTime},
+	})
+	conn := connection.NewChannelConn(connection.ClientConnection)
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		assert.NoError(t, RunCommand(master, conn, command.PSync{ReplicationID: "?", MasterOffset: "-1"}))
+		wg.Done()
+	}()
+
+	msg, err := conn.ReadNextCmdString()
+	assert.NoError(t, err)
+	assert.Equal(t, "+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb 0\r\n", msg)
+
+	rdbPayload, err := conn.ReadRDBFile()
+	assert.NoError(t, err)
+	wg.Wait()
+
+	t.Run("the RDB file sent by the master should be loaded by a replica", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{"stale": {data: stringValue("data")}}).(*ReplicaServer)
+		assert.NoError(t, replica.loadRDBPayload(rdbPayload))
+
+		assert.Equal(t, 2, replica.Size())
+		value, ok, err := replica.Get("a")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("b"), value)
+		assert.Equal(t, futureTime.UnixMilli(), replica.storeData["c"].expiresAt.UnixMilli())
+	})
+
+	t.Run("a replica should reject an RDB payload with the wrong length", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		assert.Error(t, replica.loadRDBPayload("$1000\r\nREDIS0011"))
+	})
+}
+
+func TestExecutePSyncPartialResync(t *testing.T) {
+	setCmd := command.Set{KeyPayload: "a", ValuePayload: []byte("b")}
+	encodedSet, err := setCmd.EncodedCommand()
+	assert.NoError(t, err)
+
+	// getMasterWithHistory returns a master whose backlog holds two SET commands
+	getMasterWithHistory := func() *MasterServer {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		master.createBacklogIfNeeded()
+
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		assert.NoError(t, master.ExecuteCommand(clientConn, setCmd))
+		assert.NoError(t, master.ExecuteCommand(clientConn, setCmd))
+		return master
+	}
+
+	for _, tc := range []struct {
+		offset       int64
+		expectedData string
+	}{
+		{
+			offset:       1,
+			expectedData: encodedSet + encodedSet,
+		},
+		{
+			offset:       int64(len(encodedSet)) + 1,
+			expectedData: encodedSet,
+		},
+	} {
+		t.Run(fmt.Sprintf("PSYNC from offset %d should send the missing data from the backlog", tc.offset), func(t *testing.T) {
+			master := getMasterWithHistory()
+			runCommandAndCheckOutputsWithServer(
+				t,
+				master,
+				command.PSync{ReplicationID: testReplicationID, MasterOffset: fmt.Sprint(tc.offset)},
+				[]string{"+CONTINUE 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\r\n", tc.expectedData},
+			)
+			assert.Len(t, master.registeredReplicas, 1)
+		})
+	}
+
+	t.Run("PSYNC from the current offset should continue without sending any data", func(t *testing.T) {
+		master := getMasterWithHistory()
+		runCommandAndCheckOutputWithServer(
+			t,
+			master,
+			command.PSync{ReplicationID: testReplicationID, MasterOffset: fmt.Sprint(master.replicationOffset + 1)},
+			"+CONTINUE 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\r\n",
+		)
+		assert.Len(t, master.registeredReplicas, 1)
+	})
+
+	for _, tc := range []struct {
+		name  string
+		psync command.PSync
+	}{
+		{
+			name:  "an unknown replication ID",
+			psync: command.PSync{ReplicationID: "0000000000000000000000000000000000000000", MasterOffset: "1"},
+		},
+		{
+			name:  "an offset that is no longer in the backlog",
+			psync: command.PSync{ReplicationID: testReplicationID, MasterOffset: "0"},
+		},
+		{
+			name:  "an offset that the master has not reached yet",
+			psync: command.PSync{ReplicationID: testReplicationID, MasterOffset: "1000"},
+		},
+	} {
+		t.Run(fmt.Sprintf("PSYNC with %s should fall back to a full resync", tc.name), func(t *testing.T) {
+			master := getMasterWithHistory()
+			conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+			assert.NoError(t, RunCommand(master, conn, tc.psync))
+
+			msg, err := conn.ReadNextCmdString()
+			assert.NoError(t, err)
+			assert.Equal(t, "+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb 54\r\n", msg)
+		})
+	}
 }
@@ -0,0 +1,95 @@
+package server
+
+// globMatch is true if str matches the glob-style pattern, following the rules that redis uses for commands such as
+// SCAN and KEYS:
+//   - '*' matches any number of bytes, including none
+//   - '?' matches exactly one byte
+//   - '[abc]' matches one of the bytes in the brackets, '[a-z]' matches a range of bytes, and '[^abc]' matches any
+//     byte that isn't in the brackets
+//   - '\' matches the byte after it literally
+//
+// Matching is case sensitive and works on bytes rather than runes
+func globMatch(pattern string, str string) bool {
+	p, s := 0, 0
+
+	// Where the last '*' was in the pattern, and where it started matching in str. On a mismatch, the '*' is
+	// extended by a byte and matching picks up again from after it
+	starP, starS := -1, 0
+
+	for s < len(str) {
+		if p < len(pattern) {
+			if pattern[p] == '*' {
+				starP, starS = p, s
+				p++
+				continue
+			}
+			if width, ok := matchGlobByte(pattern[p:], str[s]); ok {
+				p += width
+				s++
+				continue
+			}
+		}
+
+		if starP == -1 {
+			return false
+		}
+		starS++
+		p, s = starP+1, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchGlobByte matches a single byte against the element at the start of pattern, which is anything but a '*', and
+// returns the length of the element in pattern
+func matchGlobByte(pattern string, c byte) (int, bool) {
+	switch pattern[0] {
+	case '?':
+		return 1, true
+	case '\\':
+		if len(pattern) < 2 {
+			return 1, c == '\\'
+		}
+		return 2, pattern[1] == c
+	case '[':
+		return matchGlobClass(pattern, c)
+	}
+	return 1, pattern[0] == c
+}
+
+// matchGlobClass matches a single byte against the bracketed class at the start of pattern and returns the length
+// of the class in pattern. Like in redis, a class that is never closed runs to the end of the pattern
+func matchGlobClass(pattern string, c byte) (int, bool) {
+	i := 1
+	negate := i < len(pattern) && pattern[i] == '^'
+	if negate {
+		i++
+	}
+
+	matched := false
+	for i < len(pattern) && pattern[i] != ']' {
+		switch {
+		case pattern[i] == '\\' && i+1 < len(pattern):
+			matched = matched || pattern[i+1] == c
+			i += 2
+		case i+2 < len(pattern) && pattern[i+1] == '-':
+			low, high := pattern[i], pattern[i+2]
+			if low > high {
+				low, high = high, low
+			}
+			matched = matched || (c >= low && c <= high)
+			i += 3
+		default:
+			matched = matched || pattern[i] == c
+			i++
+		}
+	}
+
+	if i < len(pattern) {
+		i++
+	}
+	return i, matched != negate
+}
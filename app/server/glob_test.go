@@ -0,0 +1,46 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGlobMatch(t *testing.T) {
+	for _, tc := range []struct {
+		pattern  string
+		str      string
+		expected bool
+	}{
+		{pattern: "*", str: "", expected: true},
+		{pattern: "*", str: "anything", expected: true},
+		{pattern: "", str: "", expected: true},
+		{pattern: "", str: "a", expected: false},
+		{pattern: "abc", str: "abc", expected: true},
+		{pattern: "abc", str: "ABC", expected: false},
+		{pattern: "a*", str: "abc", expected: true},
+		{pattern: "*c", str: "abc", expected: true},
+		{pattern: "a*c", str: "abbbc", expected: true},
+		{pattern: "a*c", str: "abcd", expected: false},
+		{pattern: "*a*b*", str: "xaxxbx", expected: true},
+		{pattern: "**b", str: "ab", expected: true},
+		{pattern: "h?llo", str: "hello", expected: true},
+		{pattern: "h?llo", str: "hllo", expected: false},
+		{pattern: "h[ae]llo", str: "hallo", expected: true},
+		{pattern: "h[ae]llo", str: "hillo", expected: false},
+		{pattern: "h[^e]llo", str: "hallo", expected: true},
+		{pattern: "h[^e]llo", str: "hello", expected: false},
+		{pattern: "h[a-c]llo", str: "hbllo", expected: true},
+		{pattern: "h[c-a]llo", str: "hbllo", expected: true},
+		{pattern: "h[a-c]llo", str: "hdllo", expected: false},
+		{pattern: "[\\]]", str: "]", expected: true},
+		{pattern: "[abc", str: "b", expected: true},
+		{pattern: "a\\*", str: "a*", expected: true},
+		{pattern: "a\\*", str: "ab", expected: false},
+		{pattern: "a\\", str: "a\\", expected: true},
+		{pattern: "*[0-9]", str: "field10", expected: true},
+		{pattern: "field1?", str: "field1", expected: false},
+	} {
+		assert.Equal(t, tc.expected, globMatch(tc.pattern, tc.str), "unexpected match of %q against %q", tc.str, tc.pattern)
+	}
+}
@@ -2,7 +2,10 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"go.uber.org/zap"
@@ -19,22 +22,33 @@ const (
 	// How many keys we should check per expiry check
 	samplesPerExpiry = 100
 
+	// Values that take more effort than this to free are freed in the background by UNLINK. This matches
+	// the LAZYFREE_THRESHOLD of redis
+	lazyFreeThreshold = 64
+
 	// The size of the event queue. Note that a smaller number here can be used
 	// in order to apply backpressure on the connectionHandlers
 	eventQueueSize = 10
-
-	// Max bytes in a message
-	MaxMessageSize = 1024
 )
 
 type ExecuteCommand func(conn connection.Connection, cmd command.Command) error
 
 type Event struct {
-	// The event string to be handled
-	Command string
+	// The arguments of the command to be handled
+	Args []any
+
+	// The number of bytes that the command took up on the wire
+	Size int
 
 	// The client connection that this event came from
 	Conn connection.Connection
+
+	// If set, Done is closed once the event loop has finished executing the command or callback
+	Done chan struct{}
+
+	// If set, Callback is run on the event loop in place of executing a command. This allows work that started
+	// outside of the event loop (timers, disconnects, etc.) to safely modify state owned by the event loop
+	Callback func()
 }
 
 func EventLoop(ctx context.Context, logger log.Logger, eventQueue chan Event, execute ExecuteCommand) {
@@ -45,68 +59,115 @@ func EventLoop(ctx context.Context, logger log.Logger, eventQueue chan Event, ex
 			logger.Error("event loop exiting", zap.Error(ctx.Err()))
 			return
 		case event := <-eventQueue:
-			logger.Info(
-				"processing event",
-				zap.String("command", event.Command),
-				zap.Stringer("remoteAddress", event.Conn.RemoteAddr()),
-			)
-
-			parser, err := command.NewParser(event.Command)
-			if err != nil {
-				logger.Error("error building parser from client command", zap.Error(err))
-				continue
-			}
-			cmd, err := parser.Parse()
-			if err != nil {
-				logger.Error("error parsing client command", zap.Error(err))
-				continue
+			if event.Callback != nil {
+				event.Callback()
+			} else {
+				handleCommandEvent(logger, event, execute)
 			}
 
-			logger.Info("executing command", zap.Stringer("command", cmd))
-
-			err = execute(event.Conn, cmd)
-			if err != nil {
-				logger.Error("error executing client command, skipping execution", zap.Error(err))
+			if event.Done != nil {
+				close(event.Done)
 			}
 		}
 	}
 }
 
-// ExpiryLoop will check a random sampling of at most `samplesPerExpiry` keys in the server's store to
-// see if they are expired. Any found expired keys are deleted from the store
-func (s BaseServer) ExpiryLoop(ctx context.Context) {
+func handleCommandEvent(logger log.Logger, event Event, execute ExecuteCommand) {
+	logger.Info(
+		"processing event",
+		zap.Int("numArgs", len(event.Args)),
+		zap.Stringer("remoteAddress", event.Conn.RemoteAddr()),
+	)
+
+	// Like redis, an empty command is silently skipped
+	if len(event.Args) == 0 {
+		return
+	}
+
+	cmd, err := command.ToCommand(event.Args)
+	if err != nil {
+		logger.Error("error parsing client command", zap.Error(err))
+		replyWithError(logger, event.Conn, err)
+		return
+	}
+
+	logger.Info("executing command", zap.Stringer("command", cmd))
+
+	event.Conn.ClientState().CommandSize = event.Size
+	err = execute(event.Conn, cmd)
+	if err != nil {
+		logger.Error("error executing client command, skipping execution", zap.Error(err))
+		replyWithError(logger, event.Conn, err)
+	}
+}
+
+// replyWithError sends err to the client as a RESP error. Errors that are command.ErrorReplys are sent as they are,
+// and any other error is sent as a generic ERR. A replica never replies to its master, so nothing is sent on a
+// master connection
+func replyWithError(logger log.Logger, conn connection.Connection, err error) {
+	if conn.ConnectionType() == connection.MasterConnection {
+		return
+	}
+
+	var reply command.ErrorReply
+	if !errors.As(err, &reply) {
+		reply = command.ErrorReply(fmt.Sprintf("ERR %s", err))
+	}
+
+	res, err := command.Encoder{Protocol: conn.ClientState().Protocol}.EncodePrimitive(reply)
+	if err != nil {
+		logger.Error("error encoding error reply", zap.Error(err))
+		return
+	}
+
+	if _, err := conn.WriteString(res); err != nil {
+		logger.Error("error writing error reply to client", zap.Error(err))
+	}
+}
+
+// runLoops starts the goroutines shared by every server: the event loop, which runs commands with execute,
+// the connection handler and the expiry loop, which runs expire. The expiry loop is not started if expire is nil
+func (s BaseServer) runLoops(ctx context.Context, execute ExecuteCommand, expire func()) {
+	go EventLoop(ctx, s.logger, s.eventQueue, execute)
+	go s.ConnectionHandler(ctx)
+	if expire != nil {
+		go s.ExpiryLoop(ctx, expire)
+	}
+}
+
+// runOnEventLoop queues a function to be run by the event loop
+func (s BaseServer) runOnEventLoop(callback func()) {
+	s.eventQueue <- Event{Callback: callback}
+}
+
+// runOnEventLoopAndWait queues a function to be run by the event loop and waits for it to finish
+func (s BaseServer) runOnEventLoopAndWait(ctx context.Context, callback func()) error {
+	done := make(chan struct{})
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case s.eventQueue <- Event{Callback: callback, Done: done}:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-done:
+		return nil
+	}
+}
+
+// ExpiryLoop periodically runs expire on the event loop so that keys are deleted once they expire, even if
+// they are never accessed again
+func (s BaseServer) ExpiryLoop(ctx context.Context, expire func()) {
 	s.logger.Info("starting expiry loop")
 	for {
 		select {
 		case <-ctx.Done():
-			s.logger.Error("event loop exiting", zap.Error(ctx.Err()))
+			s.logger.Error("expiry loop exiting", zap.Error(ctx.Err()))
 			return
 		case <-time.After(expiryThreadPeriod):
-			s.storeDataMu.Lock()
-
-			// NOTE: Map itterations in go are pseudo-random so
-			// there's no need to explictly randomize this itteration
-			inspectedKeys := int64(0)
-			expiredKeys := int64(0)
-			for key, value := range s.storeData {
-				inspectedKeys++
-				if value.isExpired() {
-					expiredKeys++
-					s.logger.Debug(fmt.Sprintf("expiry loop deleting expired key %q", key))
-					delete(s.storeData, key)
-				}
-
-				if inspectedKeys > samplesPerExpiry {
-					break
-				}
-			}
-			s.storeDataMu.Unlock()
-
-			s.logger.Info(
-				"expiry loop completed a run",
-				zap.Int64("inspectedKeys", inspectedKeys),
-				zap.Int64("expiredKeys", expiredKeys),
-			)
+			s.runOnEventLoop(expire)
 		}
 	}
 }
@@ -152,7 +213,8 @@ func (s BaseServer) waitUntilCanHandleConnections(ctx context.Context) error {
 }
 
 // clienHandler is responsible for reading messages off of a connection and turning them into events
-// which are then placed on the event queue
+// which are then placed on the event queue. Each command is fully handled before the next one from the
+// same connection is queued, so a blocked client will not have any more of its commands executed
 func (s BaseServer) clientHandler(ctx context.Context, conn connection.Connection) {
 	defer conn.Close()
 
@@ -164,35 +226,124 @@ func (s BaseServer) clientHandler(ctx context.Context, conn connection.Connectio
 
 	s.logger.Info("starting client handler", zap.Stringer("remoteAddress", conn.RemoteAddr()))
 
+	requests, readErrs := readCommands(ctx, conn)
 	for {
 		select {
 		case <-ctx.Done():
 			s.logger.Error("client handler exiting", zap.Error(ctx.Err()))
 			return
-		default:
-			command, err := conn.ReadNextCmdString()
-			if err != nil {
-				s.logger.Error("error reading next command from client connection", zap.Error(err))
+		case err := <-readErrs:
+			s.logger.Error("error reading next command from client connection", zap.Error(err))
+			s.handleReadError(ctx, conn, err)
+			return
+		case request := <-requests:
+			done := make(chan struct{})
+			s.eventQueue <- Event{
+				Args: request.args,
+				Size: request.size,
+				Conn: conn,
+				Done: done,
+			}
+
+			select {
+			case <-ctx.Done():
+				s.logger.Error("client handler exiting", zap.Error(ctx.Err()))
 				return
+			case <-done:
 			}
-			s.logger.Info("received command", zap.String("command", command))
 
-			s.eventQueue <- Event{
-				Command: command,
-				Conn:    conn,
+			// If the command blocked this client, hold off on its next command until it has been answered
+			select {
+			case <-ctx.Done():
+				s.logger.Error("client handler exiting", zap.Error(ctx.Err()))
+				return
+			case err := <-readErrs:
+				s.logger.Error("blocked client disconnected", zap.Error(err))
+				s.handleReadError(ctx, conn, err)
+				return
+			case <-s.blockedClients.unblocked(conn):
 			}
 		}
 	}
 }
 
+// handleReadError cleans up after a connection that can no longer be read from. If the client sent malformed data,
+// it's told why before the connection is closed, since nothing else can be read from it
+func (s BaseServer) handleReadError(ctx context.Context, conn connection.Connection, readErr error) {
+	err := s.runOnEventLoopAndWait(ctx, func() {
+		if errors.Is(readErr, command.ErrProtocol) {
+			replyWithError(s.logger, conn, readErr)
+		}
+		s.blockedClients.disconnect(conn)
+	})
+	if err != nil {
+		s.logger.Error("failed to clean up after client connection", zap.Error(err))
+	}
+}
+
+// commandRequest is a command that was read off of a connection
+type commandRequest struct {
+	args []any
+	size int
+}
+
+// readCommands reads commands off of the connection in the background so that a disconnect can be
+// noticed even while the client is blocked
+func readCommands(ctx context.Context, conn connection.Connection) (<-chan commandRequest, <-chan error) {
+	requests := make(chan commandRequest)
+	readErrs := make(chan error, 1)
+
+	go func() {
+		for {
+			args, size, err := conn.ReadCommand()
+			if err != nil {
+				readErrs <- err
+				return
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case requests <- commandRequest{args: args, size: size}:
+			}
+		}
+	}()
+
+	return requests, readErrs
+}
+
 func GetServerInfo(server Server, infoType string) (map[string]string, error) {
-	if infoType != "replication" {
-		return nil, fmt.Errorf("received unexpected info type %q", infoType)
+	switch infoType {
+	case "replication":
+		info := map[string]string{
+			"role": string(server.NodeType()),
+		}
+
+		switch typedServer := server.(type) {
+		case *MasterServer:
+			info["master_replid"] = typedServer.replicationID
+			info["master_replid2"] = strings.Repeat("0", replicationIDLength)
+			info["second_repl_offset"] = "-1"
+			if typedServer.secondReplicationID != "" {
+				info["master_replid2"] = typedServer.secondReplicationID
+				info["second_repl_offset"] = strconv.FormatInt(typedServer.secondReplicationOffset, 10)
+			}
+			info["master_repl_offset"] = strconv.FormatInt(typedServer.replicationOffset, 10)
+			for key, value := range typedServer.backlogInfo() {
+				info[key] = value
+			}
+		case *ReplicaServer:
+			info["master_replid"] = typedServer.masterReplicationID
+			info["master_repl_offset"] = strconv.FormatInt(typedServer.bytesProcessed, 10)
+			for key, value := range typedServer.masterLink.info() {
+				info[key] = value
+			}
+		}
+
+		return info, nil
+	case "persistence":
+		return server.PersistenceInfo(), nil
 	}
 
-	return map[string]string{
-		"role":               string(server.NodeType()),
-		"master_repl_offset": "0",
-		"master_replid":      command.HARDCODE_REPL_ID,
-	}, nil
+	return nil, fmt.Errorf("received unexpected info type %q", infoType)
 }
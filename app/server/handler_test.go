@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+func TestHandleCommandEventErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		srv         Server
+		args        []any
+		expectedRes string
+	}{
+		{
+			name:        "an unknown command",
+			srv:         getTestMasterServer(serverStore{}),
+			args:        []any{"FOO", "bar"},
+			expectedRes: "-ERR unknown command 'FOO', with args beginning with: 'bar' \r\n",
+		},
+		{
+			name:        "the wrong number of arguments",
+			srv:         getTestMasterServer(serverStore{}),
+			args:        []any{"GET"},
+			expectedRes: "-ERR wrong number of arguments for 'get' command\r\n",
+		},
+		{
+			name:        "a command that fails to execute",
+			srv:         getTestReplicaServer(serverStore{}),
+			args:        []any{"WAIT", "1", "0"},
+			expectedRes: "-ERR WAIT cannot be used with replica instances\r\n",
+		},
+	} {
+		t.Run(fmt.Sprintf("%s should be replied to with an error", tc.name), func(t *testing.T) {
+			conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+			handleCommandEvent(tc.srv.Logger(), Event{Args: tc.args, Conn: conn}, tc.srv.ExecuteCommand)
+
+			res, err := conn.ReadNextCmdString()
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedRes, res)
+		})
+	}
+
+	t.Run("errors should not be replied to on a replica's connection to its master", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{})
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 1)
+		handleCommandEvent(replica.Logger(), Event{Args: []any{"FOO"}, Conn: masterConn}, replica.ExecuteCommand)
+
+		_, err := masterConn.ReadNextCmdString()
+		assert.Error(t, err)
+	})
+}
+
+func TestHandleReadError(t *testing.T) {
+	master := getTestMasterServer(serverStore{}).(*MasterServer)
+	startTestEventLoop(t, master, master.eventQueue)
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	_, _, readErr := command.NewReader(strings.NewReader("*1\r\n+PING\r\n")).ReadCommand()
+	require.ErrorIs(t, readErr, command.ErrProtocol)
+
+	master.handleReadError(context.Background(), conn, readErr)
+
+	res, err := conn.ReadNextCmdString()
+	require.NoError(t, err)
+	assert.Equal(t, "-ERR Protocol error: expected '$', got '+'\r\n", res)
+}
@@ -0,0 +1,718 @@
+package server
+
+import (
+	"maps"
+	"math"
+	"math/rand"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
+
+const (
+	// The most fields that a hash can hold before it stops using the compact encoding
+	DEFAULT_HASH_MAX_LISTPACK_ENTRIES = 128
+
+	// The longest field or value that a hash can hold before it stops using the compact encoding
+	DEFAULT_HASH_MAX_LISTPACK_VALUE = 64
+)
+
+// hashLimits are the sizes past which a hash is converted from the compact encoding to a map
+type hashLimits struct {
+	maxListpackEntries int
+	maxListpackValue   int
+}
+
+var defaultHashLimits = hashLimits{
+	maxListpackEntries: DEFAULT_HASH_MAX_LISTPACK_ENTRIES,
+	maxListpackValue:   DEFAULT_HASH_MAX_LISTPACK_VALUE,
+}
+
+// hashValue is a map of fields to binary safe strings. Like in redis, a small hash is kept compact as a flat slice
+// of fields in the order that they were added, which is cheaper than a map to store and fast enough to search while
+// it's small. Once the hash has too many fields, or a field or value that is too long, it's converted to a map for
+// good.
+//
+// Fields can expire on their own. Like expired keys, expired fields are hidden from every read, and they're removed
+// by BaseServer.deleteExpiredFields once a master finds them
+type hashValue struct {
+	// The fields of the hash while it uses the compact encoding
+	listpack []command.FieldValue
+
+	// The fields of the hash once it has been converted to a map, or nil while it uses the compact encoding
+	table map[string][]byte
+
+	// When each of the fields that has an expiry expires, or nil if none of them have ever had one
+	expiries map[string]time.Time
+}
+
+func newHashValue() *hashValue {
+	return &hashValue{}
+}
+
+func (*hashValue) typeName() string {
+	return "hash"
+}
+
+func (h *hashValue) clone() value {
+	cloned := &hashValue{expiries: maps.Clone(h.expiries)}
+	if h.table == nil {
+		cloned.listpack = make([]command.FieldValue, 0, len(h.listpack))
+		for _, entry := range h.listpack {
+			cloned.listpack = append(cloned.listpack, command.FieldValue{Field: entry.Field, Value: slices.Clone(entry.Value)})
+		}
+		return cloned
+	}
+
+	cloned.table = make(map[string][]byte, len(h.table))
+	for field, value := range h.table {
+		cloned.table[field] = slices.Clone(value)
+	}
+	return cloned
+}
+
+func (h *hashValue) freeEffort() int {
+	return h.storedLen()
+}
+
+func (h *hashValue) free() {
+	clear(h.listpack)
+	clear(h.table)
+	clear(h.expiries)
+	*h = hashValue{}
+}
+
+// len returns the number of fields in the hash that haven't expired by now
+func (h *hashValue) len(now time.Time) int {
+	numExpired := 0
+	if len(h.expiries) > 0 {
+		for _, expiresAt := range h.expiries {
+			if !expiresAt.After(now) {
+				numExpired++
+			}
+		}
+	}
+	return h.storedLen() - numExpired
+}
+
+// storedLen returns the number of fields that the hash holds, including the ones that have expired
+func (h *hashValue) storedLen() int {
+	if h.table == nil {
+		return len(h.listpack)
+	}
+	return len(h.table)
+}
+
+// isCompact is true while the hash uses the compact encoding
+func (h *hashValue) isCompact() bool {
+	return h.table == nil
+}
+
+// get returns the value at field, unless it has expired by now
+func (h *hashValue) get(field string, now time.Time) ([]byte, bool) {
+	if h.isExpired(field, now) {
+		return nil, false
+	}
+
+	if h.table != nil {
+		value, ok := h.table[field]
+		return value, ok
+	}
+
+	if idx := h.listpackIndex(field); idx != -1 {
+		return h.listpack[idx].Value, true
+	}
+	return nil, false
+}
+
+// set stores value at field and returns whether the field is new, which a field that has expired by now counts as.
+// Like in redis, this removes the field's expiry. A compact hash is converted to a map if the field or value is
+// longer than the limits allow, or if the new field takes it past the most fields that it can hold
+func (h *hashValue) set(field string, value []byte, limits hashLimits, now time.Time) bool {
+	expired := h.isExpired(field, now)
+	delete(h.expiries, field)
+
+	if h.table == nil && (len(field) > limits.maxListpackValue || len(value) > limits.maxListpackValue) {
+		h.convertToTable()
+	}
+
+	if h.table != nil {
+		_, exists := h.table[field]
+		h.table[field] = value
+		return !exists || expired
+	}
+
+	if idx := h.listpackIndex(field); idx != -1 {
+		h.listpack[idx].Value = value
+		return expired
+	}
+
+	h.listpack = append(h.listpack, command.FieldValue{Field: field, Value: value})
+	if len(h.listpack) > limits.maxListpackEntries {
+		h.convertToTable()
+	}
+	return true
+}
+
+// remove deletes field and returns whether it existed and hadn't expired by now. A hash that has been converted to
+// a map stays a map
+func (h *hashValue) remove(field string, now time.Time) bool {
+	expired := h.isExpired(field, now)
+	delete(h.expiries, field)
+
+	if h.table != nil {
+		_, exists := h.table[field]
+		delete(h.table, field)
+		return exists && !expired
+	}
+
+	idx := h.listpackIndex(field)
+	if idx == -1 {
+		return false
+	}
+	h.listpack = slices.Delete(h.listpack, idx, idx+1)
+	return !expired
+}
+
+// entries returns every field of the hash that hasn't expired by now along with its value. A compact hash returns
+// them in the order that they were added
+func (h *hashValue) entries(now time.Time) []command.FieldValue {
+	entries := make([]command.FieldValue, 0, h.storedLen())
+	if h.table == nil {
+		for _, entry := range h.listpack {
+			if !h.isExpired(entry.Field, now) {
+				entries = append(entries, entry)
+			}
+		}
+		return entries
+	}
+
+	for field, value := range h.table {
+		if !h.isExpired(field, now) {
+			entries = append(entries, command.FieldValue{Field: field, Value: value})
+		}
+	}
+	return entries
+}
+
+// fieldExpiry returns when field expires, or nil if it never expires
+func (h *hashValue) fieldExpiry(field string) *time.Time {
+	expiresAt, ok := h.expiries[field]
+	if !ok {
+		return nil
+	}
+	return &expiresAt
+}
+
+// setFieldExpiry sets when an existing field expires
+func (h *hashValue) setFieldExpiry(field string, expiresAt time.Time) {
+	if h.expiries == nil {
+		h.expiries = map[string]time.Time{}
+	}
+	h.expiries[field] = expiresAt
+}
+
+// persistField removes the expiry from field
+func (h *hashValue) persistField(field string) {
+	delete(h.expiries, field)
+}
+
+// hasFieldExpiries is true if any of the fields of the hash have an expiry
+func (h *hashValue) hasFieldExpiries() bool {
+	return len(h.expiries) > 0
+}
+
+func (h *hashValue) isExpired(field string, now time.Time) bool {
+	expiresAt, ok := h.expiries[field]
+	return ok && !expiresAt.After(now)
+}
+
+// removeExpired deletes the fields that have expired by now and returns them
+func (h *hashValue) removeExpired(now time.Time) []string {
+	var expired []string
+	for field := range h.expiries {
+		if h.isExpired(field, now) {
+			expired = append(expired, field)
+		}
+	}
+
+	slices.Sort(expired)
+	for _, field := range expired {
+		h.remove(field, now)
+	}
+	return expired
+}
+
+func (h *hashValue) listpackIndex(field string) int {
+	return slices.IndexFunc(h.listpack, func(entry command.FieldValue) bool {
+		return entry.Field == field
+	})
+}
+
+func (h *hashValue) convertToTable() {
+	h.table = make(map[string][]byte, len(h.listpack))
+	for _, entry := range h.listpack {
+		h.table[entry.Field] = entry.Value
+	}
+	h.listpack = nil
+}
+
+// hashData returns the hash that the value holds, or nil for the empty storeValue that lookup returns for a missing
+// key. It fails with a WRONGTYPE error if the value holds another type
+func (v storeValue) hashData() (*hashValue, error) {
+	if v.data == nil {
+		return nil, nil
+	}
+
+	hash, ok := v.data.(*hashValue)
+	if !ok {
+		return nil, command.ErrWrongType
+	}
+	return hash, nil
+}
+
+// lookupHash returns the hash stored at key, or nil if the key doesn't exist. The caller must hold storeDataMu
+func (s *BaseServer) lookupHash(key string) (*hashValue, error) {
+	existing, _ := s.lookup(key)
+	return existing.hashData()
+}
+
+// ensureHash returns hash, or stores a new hash at key and returns that if hash is nil because the key doesn't
+// exist. The caller must hold storeDataMu
+func (s *BaseServer) ensureHash(key string, hash *hashValue) *hashValue {
+	if hash == nil {
+		hash = newHashValue()
+		s.storeData[key] = storeValue{data: hash}
+	}
+	return hash
+}
+
+// HSet stores each of the values at its field of the hash stored at key, creating the hash if it doesn't exist,
+// and returns how many of the fields are new
+func (s *BaseServer) HSet(key string, pairs []command.FieldValue) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	hash = s.ensureHash(key, hash)
+	added := 0
+	for _, pair := range pairs {
+		if hash.set(pair.Field, pair.Value, s.hashLimits, s.expiryTime()) {
+			added++
+		}
+	}
+	s.persistence.recordChange()
+
+	return added, nil
+}
+
+// HSetNX stores value at field of the hash stored at key if the field doesn't exist, creating the hash if it
+// doesn't exist either. It returns whether the value was stored
+func (s *BaseServer) HSetNX(key string, field string, value []byte) (bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return false, err
+	}
+	if _, ok := s.hashField(hash, field); ok {
+		return false, nil
+	}
+
+	s.ensureHash(key, hash).set(field, value, s.hashLimits, s.expiryTime())
+	s.persistence.recordChange()
+
+	return true, nil
+}
+
+// HGet returns the value at field of the hash stored at key, along with whether there is one
+func (s *BaseServer) HGet(key string, field string) ([]byte, bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	value, ok := hash.get(field, s.expiryTime())
+	return value, ok, nil
+}
+
+// HMGet returns the values at each of fields of the hash stored at key, with nil for fields that don't exist
+func (s *BaseServer) HMGet(key string, fields ...string) ([][]byte, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([][]byte, len(fields))
+	if hash == nil {
+		return values, nil
+	}
+	for i, field := range fields {
+		values[i], _ = hash.get(field, s.expiryTime())
+	}
+	return values, nil
+}
+
+// HDel removes fields from the hash stored at key and returns how many of them existed. The key is deleted once
+// the hash has no fields left, since redis never stores an empty hash
+func (s *BaseServer) HDel(key string, fields ...string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	removed := 0
+	for _, field := range fields {
+		if hash.remove(field, s.expiryTime()) {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		s.persistence.recordChange()
+	}
+
+	// On a replica, the fields might have expired already, in which case the key goes once the master's HDEL has
+	// removed the last of them
+	if hash.storedLen() == 0 {
+		delete(s.storeData, key)
+	}
+	return removed, nil
+}
+
+// HGetAll returns every field of the hash stored at key along with its value
+func (s *BaseServer) HGetAll(key string) ([]command.FieldValue, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil || !ok {
+		return []command.FieldValue{}, err
+	}
+	return hash.entries(s.expiryTime()), nil
+}
+
+// HLen returns the number of fields in the hash stored at key, or 0 if the key doesn't exist
+func (s *BaseServer) HLen(key string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil || !ok {
+		return 0, err
+	}
+	return hash.len(s.expiryTime()), nil
+}
+
+// HIncrBy adds increment to the integer at field of the hash stored at key and returns the result. A missing field
+// or key counts as 0, and an existing field keeps its expiry
+func (s *BaseServer) HIncrBy(key string, field string, increment int64) (int64, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return 0, err
+	}
+
+	current := int64(0)
+	if value, ok := s.hashField(hash, field); ok {
+		current, err = strconv.ParseInt(string(value), 10, 64)
+		if err != nil || strconv.FormatInt(current, 10) != string(value) {
+			return 0, command.ErrorReply("ERR hash value is not an integer")
+		}
+	}
+
+	if (increment > 0 && current > math.MaxInt64-increment) || (increment < 0 && current < math.MinInt64-increment) {
+		return 0, command.ErrOverflow
+	}
+	result := current + increment
+
+	hash = s.ensureHash(key, hash)
+	expiresAt := hash.fieldExpiry(field)
+	hash.set(field, []byte(strconv.FormatInt(result, 10)), s.hashLimits, s.expiryTime())
+	if expiresAt != nil {
+		hash.setFieldExpiry(field, *expiresAt)
+	}
+	s.persistence.recordChange()
+
+	return result, nil
+}
+
+// HIncrByFloat adds increment to the number at field of the hash stored at key and returns the result as it's
+// stored. A missing field or key counts as 0, and an existing field keeps its expiry. So that replicas store
+// exactly the same result, it's propagated as an HSET, followed by an HPEXPIREAT that restores the field's expiry
+func (s *BaseServer) HIncrByFloat(key string, field string, increment float64) ([]byte, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	current := 0.0
+	if value, ok := s.hashField(hash, field); ok {
+		current, ok = command.ParseFloat(string(value))
+		if !ok {
+			return nil, command.ErrorReply("ERR hash value is not a float")
+		}
+	}
+
+	result := current + increment
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return nil, command.ErrorReply("ERR increment would produce NaN or Infinity")
+	}
+
+	resultStr := []byte(strconv.FormatFloat(result, 'f', -1, 64))
+	hash = s.ensureHash(key, hash)
+	expiresAt := hash.fieldExpiry(field)
+	hash.set(field, resultStr, s.hashLimits, s.expiryTime())
+	s.persistence.recordChange()
+
+	propagated := []command.Command{command.HSet{Key: key, Pairs: []command.FieldValue{{Field: field, Value: resultStr}}}}
+	if expiresAt != nil {
+		hash.setFieldExpiry(field, *expiresAt)
+		propagated = append(propagated, command.HExpire{
+			Cmd: command.HPExpireAtCmd, Key: key, Time: expiresAt.UnixMilli(), Fields: []string{field},
+		})
+	}
+	s.propagateAs(propagated...)
+
+	return resultStr, nil
+}
+
+// HRandField returns random fields of the hash stored at key along with their values. A positive count returns
+// that many distinct fields, or every field if the hash is smaller, while a negative count returns exactly that
+// many fields, which can repeat
+func (s *BaseServer) HRandField(key string, count int64) ([]command.FieldValue, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil || !ok {
+		return []command.FieldValue{}, err
+	}
+
+	// A replica keeps a hash whose fields have all expired, so there may be nothing to pick from
+	entries := hash.entries(s.expiryTime())
+	if len(entries) == 0 {
+		return []command.FieldValue{}, nil
+	}
+	if count < 0 {
+		var picked []command.FieldValue
+		for range -count {
+			picked = append(picked, entries[rand.Intn(len(entries))])
+		}
+		return picked, nil
+	}
+
+	if count >= int64(len(entries)) {
+		return entries, nil
+	}
+	picked := make([]command.FieldValue, 0, count)
+	for _, idx := range rand.Perm(len(entries))[:count] {
+		picked = append(picked, entries[idx])
+	}
+	return picked, nil
+}
+
+// HScan returns a page of the fields of the hash stored at key that match the glob pattern in match, along with
+// the cursor to continue from, which is 0 once every field has been returned. A compact hash is returned in a
+// single page. Otherwise, fields are returned in the order of a hash of their name and the cursor is the hash to
+// continue from, so that every field that is in the hash for the whole scan is returned even if others are added
+// or removed in between. Like in redis, count is only a hint, and the pattern is applied after a page is picked so
+// pages can come back empty
+func (s *BaseServer) HScan(key string, cursor uint64, match string, count int64) (uint64, []command.FieldValue, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil || !ok {
+		return 0, []command.FieldValue{}, err
+	}
+
+	var page []command.FieldValue
+	nextCursor := uint64(0)
+	if hash.isCompact() {
+		page = hash.entries(s.expiryTime())
+	} else {
+		page, nextCursor = scanPage(hash.entries(s.expiryTime()), func(entry command.FieldValue) string { return entry.Field }, cursor, count)
+	}
+
+	matching := make([]command.FieldValue, 0, len(page))
+	for _, entry := range page {
+		if match == "*" || globMatch(match, entry.Field) {
+			matching = append(matching, entry)
+		}
+	}
+	return nextCursor, matching, nil
+}
+
+// FieldExpiry is when a field of a hash expires
+type FieldExpiry struct {
+	// Whether the field exists
+	Exists bool
+
+	// When the field expires, or nil if it never expires
+	ExpiresAt *time.Time
+}
+
+// HExpire sets when each of fields of the hash stored at key expires, as long as the field's current expiry meets
+// the conditions in options. For each field, it returns -2 if the field doesn't exist, 0 if the conditions weren't
+// met, 1 if the expiry was set, or 2 if the expiry has already passed and the field was deleted. Like Expire, a
+// replica keeps the fields hidden instead of deleting them. It's propagated as an absolute HPEXPIREAT for the
+// fields whose expiry was set, and as an HDEL for the fields that were deleted
+func (s *BaseServer) HExpire(key string, expiresAt time.Time, options command.ExpireOptions, fields ...string) ([]int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	hash, err := existing.hashData()
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, len(fields))
+	if !ok {
+		for i := range results {
+			results[i] = -2
+		}
+		return results, nil
+	}
+
+	deleteFields := s.deletesOnArrival(expiresAt)
+	var updated, deleted []string
+	for i, field := range fields {
+		switch _, exists := hash.get(field, s.expiryTime()); {
+		case !exists:
+			results[i] = -2
+		case !canReplaceExpiry(hash.fieldExpiry(field), expiresAt, options):
+			results[i] = 0
+		case deleteFields:
+			hash.remove(field, s.expiryTime())
+			deleted = append(deleted, field)
+			results[i] = 2
+		default:
+			hash.setFieldExpiry(field, expiresAt)
+			updated = append(updated, field)
+			results[i] = 1
+		}
+	}
+
+	if len(updated) == 0 && len(deleted) == 0 {
+		return results, nil
+	}
+	if hash.storedLen() == 0 {
+		delete(s.storeData, key)
+	}
+	s.persistence.recordChange()
+
+	var propagated []command.Command
+	if len(updated) > 0 {
+		propagated = append(propagated, command.HExpire{
+			Cmd: command.HPExpireAtCmd, Key: key, Time: expiresAt.UnixMilli(), Fields: updated,
+		})
+	}
+	if len(deleted) > 0 {
+		propagated = append(propagated, command.HDel{Key: key, Fields: deleted})
+	}
+	s.propagateAs(propagated...)
+
+	return results, nil
+}
+
+// HExpiry returns when each of fields of the hash stored at key expires
+func (s *BaseServer) HExpiry(key string, fields ...string) ([]FieldExpiry, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	expiries := make([]FieldExpiry, len(fields))
+	for i, field := range fields {
+		if _, ok := s.hashField(hash, field); ok {
+			expiries[i] = FieldExpiry{Exists: true, ExpiresAt: hash.fieldExpiry(field)}
+		}
+	}
+	return expiries, nil
+}
+
+// HPersist removes the expiry from each of fields of the hash stored at key. For each field, it returns -2 if the
+// field doesn't exist, -1 if it has no expiry, or 1 if its expiry was removed
+func (s *BaseServer) HPersist(key string, fields ...string) ([]int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	hash, err := s.lookupHash(key)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]int, len(fields))
+	for i, field := range fields {
+		switch _, exists := s.hashField(hash, field); {
+		case !exists:
+			results[i] = -2
+		case hash.fieldExpiry(field) == nil:
+			results[i] = -1
+		default:
+			hash.persistField(field)
+			s.persistence.recordChange()
+			results[i] = 1
+		}
+	}
+	return results, nil
+}
+
+// deleteExpiredFields deletes the fields of the hash stored at key that have expired, along with the key if that
+// leaves the hash empty, and propagates the deletion as an HDEL. It returns how many fields were deleted. The caller
+// must hold storeDataMu
+func (s *BaseServer) deleteExpiredFields(key string, hash *hashValue) int {
+	expired := hash.removeExpired(time.Now())
+	if len(expired) == 0 {
+		return 0
+	}
+
+	if hash.storedLen() == 0 {
+		delete(s.storeData, key)
+	}
+	s.persistence.recordChange()
+	s.alsoPropagate(command.HDel{Key: key, Fields: expired})
+
+	return len(expired)
+}
+
+// hashField returns the value at field of hash, which is nil if the key doesn't exist. The caller must hold
+// storeDataMu
+func (s *BaseServer) hashField(hash *hashValue, field string) ([]byte, bool) {
+	if hash == nil {
+		return nil, false
+	}
+	return hash.get(field, s.expiryTime())
+}
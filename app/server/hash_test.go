@@ -0,0 +1,127 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
+
+// hashOf returns a hash that holds the provided fields and values, which alternate
+func hashOf(fieldsAndValues ...string) *hashValue {
+	hash := newHashValue()
+	for i := 0; i < len(fieldsAndValues); i += 2 {
+		hash.set(fieldsAndValues[i], []byte(fieldsAndValues[i+1]), defaultHashLimits, time.Now())
+	}
+	return hash
+}
+
+func TestHashValue(t *testing.T) {
+	limits := hashLimits{maxListpackEntries: 3, maxListpackValue: 5}
+	now := time.Now()
+
+	t.Run("a small hash should stay compact and keep its fields in the order that they were added", func(t *testing.T) {
+		hash := newHashValue()
+		assert.True(t, hash.set("b", []byte("1"), limits, now))
+		assert.True(t, hash.set("a", []byte("2"), limits, now))
+		assert.False(t, hash.set("b", []byte("3"), limits, now))
+		assert.True(t, hash.set("c", []byte("12345"), limits, now))
+
+		assert.True(t, hash.isCompact())
+		assert.Equal(t, 3, hash.len(now))
+		assert.Equal(t, []command.FieldValue{
+			{Field: "b", Value: []byte("3")}, {Field: "a", Value: []byte("2")}, {Field: "c", Value: []byte("12345")},
+		}, hash.entries(now))
+
+		value, ok := hash.get("a", now)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("2"), value)
+		_, ok = hash.get("missing", now)
+		assert.False(t, ok)
+	})
+
+	t.Run("a hash should be converted to a map once it has too many fields", func(t *testing.T) {
+		hash := newHashValue()
+		for i := range 4 {
+			hash.set(fmt.Sprint(i), []byte("v"), limits, now)
+		}
+
+		assert.False(t, hash.isCompact())
+		assert.Equal(t, 4, hash.len(now))
+
+		// Removing fields shouldn't convert it back
+		assert.True(t, hash.remove("0", now))
+		assert.True(t, hash.remove("1", now))
+		assert.False(t, hash.remove("1", now))
+		assert.False(t, hash.isCompact())
+		assert.ElementsMatch(t, []command.FieldValue{{Field: "2", Value: []byte("v")}, {Field: "3", Value: []byte("v")}}, hash.entries(now))
+	})
+
+	t.Run("a hash should be converted to a map once it has a long field or value", func(t *testing.T) {
+		hash := newHashValue()
+		hash.set("a", []byte("1"), limits, now)
+		hash.set("a", []byte("123456"), limits, now)
+		assert.False(t, hash.isCompact())
+
+		hash = newHashValue()
+		hash.set("123456", []byte("1"), limits, now)
+		assert.False(t, hash.isCompact())
+
+		value, ok := hash.get("123456", now)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+	})
+
+	t.Run("removing fields from a compact hash should keep the order of the rest", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2", "c", "3")
+		assert.True(t, hash.remove("b", now))
+		assert.False(t, hash.remove("b", now))
+		assert.Equal(t, []command.FieldValue{{Field: "a", Value: []byte("1")}, {Field: "c", Value: []byte("3")}}, hash.entries(now))
+	})
+
+	t.Run("a clone should keep the encoding and not share values", func(t *testing.T) {
+		for _, hash := range []*hashValue{hashOf("a", "1"), hashOf("a", "1", "b", strings.Repeat("x", 100))} {
+			cloned := hash.clone().(*hashValue)
+			assert.Equal(t, hash.isCompact(), cloned.isCompact())
+			assert.ElementsMatch(t, hash.entries(now), cloned.entries(now))
+
+			value, _ := cloned.get("a", now)
+			value[0] = 'x'
+			original, _ := hash.get("a", now)
+			assert.Equal(t, []byte("1"), original)
+		}
+	})
+
+	t.Run("expired fields should be hidden until they're removed", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2", "c", "3")
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		hash.setFieldExpiry("b", time.Now().Add(time.Hour))
+
+		assert.Equal(t, 2, hash.len(now))
+		assert.Equal(t, []command.FieldValue{{Field: "b", Value: []byte("2")}, {Field: "c", Value: []byte("3")}}, hash.entries(now))
+		_, ok := hash.get("a", now)
+		assert.False(t, ok)
+		assert.False(t, hash.remove("a", now))
+
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		assert.Equal(t, []string{"a"}, hash.removeExpired(now))
+		assert.Equal(t, 2, hash.storedLen())
+		assert.Nil(t, hash.removeExpired(now))
+	})
+
+	t.Run("setting a field should remove its expiry and count an expired field as new", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2")
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		hash.setFieldExpiry("b", time.Now().Add(time.Hour))
+
+		assert.True(t, hash.set("a", []byte("3"), defaultHashLimits, now))
+		assert.False(t, hash.set("b", []byte("4"), defaultHashLimits, now))
+		assert.Nil(t, hash.fieldExpiry("a"))
+		assert.Nil(t, hash.fieldExpiry("b"))
+		assert.False(t, hash.hasFieldExpiries())
+	})
+}
@@ -0,0 +1,677 @@
+package server
+
+import (
+	"bytes"
+	"math"
+	"slices"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
+
+// The most elements that a single node of a list holds. Small nodes keep inserts in the middle of a node cheap,
+// while large nodes keep the number of allocations and pointers down
+const listNodeSize = 128
+
+// listValue is a list of binary safe strings. Like a redis quicklist, it's a doubly linked list of nodes that each
+// hold a chunk of the list's elements, so pushing and popping at either end is O(1) and finding an element by index
+// only needs to walk over nodes rather than over every element
+type listValue struct {
+	head   *listNode
+	tail   *listNode
+	length int
+}
+
+type listNode struct {
+	elements [][]byte
+	prev     *listNode
+	next     *listNode
+}
+
+func newListValue() *listValue {
+	return &listValue{}
+}
+
+func (*listValue) typeName() string {
+	return "list"
+}
+
+func (l *listValue) clone() value {
+	cloned := newListValue()
+	l.each(false, func(_ int, element []byte) bool {
+		cloned.pushBack(slices.Clone(element))
+		return true
+	})
+	return cloned
+}
+
+func (l *listValue) freeEffort() int {
+	return l.length
+}
+
+func (l *listValue) free() {
+	for node := l.head; node != nil; {
+		next := node.next
+		*node = listNode{}
+		node = next
+	}
+	*l = listValue{}
+}
+
+func (l *listValue) len() int {
+	return l.length
+}
+
+func (l *listValue) pushFront(element []byte) {
+	if l.head == nil || len(l.head.elements) >= listNodeSize {
+		l.insertNodeAfter(nil, &listNode{elements: make([][]byte, 0, listNodeSize)})
+	}
+	l.head.elements = slices.Insert(l.head.elements, 0, element)
+	l.length++
+}
+
+func (l *listValue) pushBack(element []byte) {
+	if l.tail == nil || len(l.tail.elements) >= listNodeSize {
+		l.insertNodeAfter(l.tail, &listNode{elements: make([][]byte, 0, listNodeSize)})
+	}
+	l.tail.elements = append(l.tail.elements, element)
+	l.length++
+}
+
+// push adds element to the provided end of the list
+func (l *listValue) push(end command.ListEnd, element []byte) {
+	if end == command.ListLeft {
+		l.pushFront(element)
+	} else {
+		l.pushBack(element)
+	}
+}
+
+// pop removes and returns the element at the provided end of the list. The list must not be empty
+func (l *listValue) pop(end command.ListEnd) []byte {
+	if end == command.ListLeft {
+		return l.popFront()
+	}
+	return l.popBack()
+}
+
+// popFront removes and returns the first element of the list. The list must not be empty
+func (l *listValue) popFront() []byte {
+	element := l.head.elements[0]
+	l.removeAt(l.head, 0)
+	return element
+}
+
+// popBack removes and returns the last element of the list. The list must not be empty
+func (l *listValue) popBack() []byte {
+	element := l.tail.elements[len(l.tail.elements)-1]
+	l.removeAt(l.tail, len(l.tail.elements)-1)
+	return element
+}
+
+// index returns the element at index, which must be within the list
+func (l *listValue) index(index int) []byte {
+	node, offset := l.locate(index)
+	return node.elements[offset]
+}
+
+// set replaces the element at index, which must be within the list
+func (l *listValue) set(index int, element []byte) {
+	node, offset := l.locate(index)
+	node.elements[offset] = element
+}
+
+// insert adds element so that it ends up at index, which can be anywhere from 0 to the length of the list. A full
+// node is split in two first, so nodes never grow past listNodeSize
+func (l *listValue) insert(index int, element []byte) {
+	switch index {
+	case 0:
+		l.pushFront(element)
+		return
+	case l.length:
+		l.pushBack(element)
+		return
+	}
+
+	node, offset := l.locate(index)
+	if len(node.elements) >= listNodeSize {
+		half := len(node.elements) / 2
+		newNode := &listNode{elements: make([][]byte, 0, listNodeSize)}
+		newNode.elements = append(newNode.elements, node.elements[half:]...)
+		clear(node.elements[half:])
+		node.elements = node.elements[:half]
+		l.insertNodeAfter(node, newNode)
+
+		if offset >= half {
+			node, offset = newNode, offset-half
+		}
+	}
+
+	node.elements = slices.Insert(node.elements, offset, element)
+	l.length++
+}
+
+// rangeElements returns the elements from start to end, inclusive. Both must be within the list
+func (l *listValue) rangeElements(start int, end int) [][]byte {
+	elements := make([][]byte, 0, end-start+1)
+	node, offset := l.locate(start)
+	for len(elements) < cap(elements) {
+		if offset == len(node.elements) {
+			node, offset = node.next, 0
+			continue
+		}
+		elements = append(elements, node.elements[offset])
+		offset++
+	}
+	return elements
+}
+
+// elements returns every element of the list, in order
+func (l *listValue) elements() [][]byte {
+	if l.length == 0 {
+		return [][]byte{}
+	}
+	return l.rangeElements(0, l.length-1)
+}
+
+// each calls fn with every element of the list and its index, starting from the end if fromBack is set, until fn
+// returns false
+func (l *listValue) each(fromBack bool, fn func(index int, element []byte) bool) {
+	if fromBack {
+		index := l.length - 1
+		for node := l.tail; node != nil; node = node.prev {
+			for i := len(node.elements) - 1; i >= 0; i-- {
+				if !fn(index, node.elements[i]) {
+					return
+				}
+				index--
+			}
+		}
+		return
+	}
+
+	index := 0
+	for node := l.head; node != nil; node = node.next {
+		for _, element := range node.elements {
+			if !fn(index, element) {
+				return
+			}
+			index++
+		}
+	}
+}
+
+// removeMatching removes elements that are equal to element and returns how many were removed. A positive count
+// removes at most that many starting from the front, a negative count removes at most that many starting from the
+// back, and 0 removes all of them
+func (l *listValue) removeMatching(element []byte, count int) int {
+	fromBack := count < 0
+	limit := count
+	if fromBack {
+		limit = -limit
+	}
+
+	step, node := 1, l.head
+	if fromBack {
+		step, node = -1, l.tail
+	}
+
+	removed := 0
+	for node != nil && (limit == 0 || removed < limit) {
+		next, i := node.next, 0
+		if fromBack {
+			next, i = node.prev, len(node.elements)-1
+		}
+
+		for i >= 0 && i < len(node.elements) && (limit == 0 || removed < limit) {
+			if bytes.Equal(node.elements[i], element) {
+				l.removeAt(node, i)
+				removed++
+				// Going forwards, the element after the removed one has moved into its place
+				if !fromBack {
+					continue
+				}
+			}
+			i += step
+		}
+
+		node = next
+	}
+	return removed
+}
+
+// trim removes the first numFront and the last numBack elements of the list. Whole nodes are dropped where
+// possible rather than popping their elements one at a time
+func (l *listValue) trim(numFront int, numBack int) {
+	for numFront > 0 && l.head != nil {
+		if numFront >= len(l.head.elements) {
+			numFront -= len(l.head.elements)
+			l.length -= len(l.head.elements)
+			l.unlinkNode(l.head)
+			continue
+		}
+		l.head.elements = slices.Delete(l.head.elements, 0, numFront)
+		l.length -= numFront
+		numFront = 0
+	}
+
+	for numBack > 0 && l.tail != nil {
+		if numBack >= len(l.tail.elements) {
+			numBack -= len(l.tail.elements)
+			l.length -= len(l.tail.elements)
+			l.unlinkNode(l.tail)
+			continue
+		}
+		remaining := len(l.tail.elements) - numBack
+		clear(l.tail.elements[remaining:])
+		l.tail.elements = l.tail.elements[:remaining]
+		l.length -= numBack
+		numBack = 0
+	}
+}
+
+// locate finds the node that holds the element at index, which must be within the list, and the element's offset
+// in that node. It walks from whichever end of the list is closer
+func (l *listValue) locate(index int) (*listNode, int) {
+	if index < l.length/2 {
+		node := l.head
+		for index >= len(node.elements) {
+			index -= len(node.elements)
+			node = node.next
+		}
+		return node, index
+	}
+
+	fromBack := l.length - 1 - index
+	node := l.tail
+	for fromBack >= len(node.elements) {
+		fromBack -= len(node.elements)
+		node = node.prev
+	}
+	return node, len(node.elements) - 1 - fromBack
+}
+
+// removeAt removes the element at offset in node, and removes the node too if that leaves it empty
+func (l *listValue) removeAt(node *listNode, offset int) {
+	node.elements = slices.Delete(node.elements, offset, offset+1)
+	l.length--
+	if len(node.elements) == 0 {
+		l.unlinkNode(node)
+	}
+}
+
+// insertNodeAfter links node into the list after prev, or at the front of the list if prev is nil
+func (l *listValue) insertNodeAfter(prev *listNode, node *listNode) {
+	node.prev = prev
+	if prev == nil {
+		node.next = l.head
+		l.head = node
+	} else {
+		node.next = prev.next
+		prev.next = node
+	}
+
+	if node.next == nil {
+		l.tail = node
+	} else {
+		node.next.prev = node
+	}
+}
+
+func (l *listValue) unlinkNode(node *listNode) {
+	if node.prev == nil {
+		l.head = node.next
+	} else {
+		node.prev.next = node.next
+	}
+	if node.next == nil {
+		l.tail = node.prev
+	} else {
+		node.next.prev = node.prev
+	}
+	node.prev, node.next = nil, nil
+}
+
+// listData returns the list that the value holds, or nil for the empty storeValue that lookup returns for a missing
+// key. It fails with a WRONGTYPE error if the value holds another type
+func (v storeValue) listData() (*listValue, error) {
+	if v.data == nil {
+		return nil, nil
+	}
+
+	list, ok := v.data.(*listValue)
+	if !ok {
+		return nil, command.ErrWrongType
+	}
+	return list, nil
+}
+
+// listRange converts the start and end offsets of a range of a list, which count back from the end of the list if
+// they're negative, to indexes within the list. It returns false if the range is empty
+func listRange(start int64, end int64, length int) (int, int, bool) {
+	if start < 0 {
+		start += int64(length)
+	}
+	if end < 0 {
+		end += int64(length)
+	}
+	start = max(start, 0)
+	end = min(end, int64(length)-1)
+
+	if start > end || start >= int64(length) {
+		return 0, 0, false
+	}
+	return int(start), int(end), true
+}
+
+// removeIfEmpty deletes key if the list stored at it has no elements left, since redis never stores an empty list.
+// The caller must hold storeDataMu
+func (s *BaseServer) removeIfEmpty(key string, list *listValue) {
+	if list.len() == 0 {
+		delete(s.storeData, key)
+	}
+}
+
+// ListPush adds elements to an end of the list stored at key, one at a time, and returns the length of the list
+// afterwards. A missing list is created, unless onlyIfExists is set
+func (s *BaseServer) ListPush(key string, end command.ListEnd, elements [][]byte, onlyIfExists bool) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		if onlyIfExists {
+			return 0, nil
+		}
+		list = newListValue()
+		s.storeData[key] = storeValue{data: list}
+	}
+
+	for _, element := range elements {
+		list.push(end, element)
+	}
+	s.persistence.recordChange()
+	s.listWaiters.signal(key)
+
+	return list.len(), nil
+}
+
+// ListPop removes up to count elements from an end of the list stored at key and returns them in the order that
+// they were popped, along with whether the list exists. The pop is propagated as an LPOP or RPOP of key, so that
+// pops that block or pick from several keys reach replicas as the pop that actually happened
+func (s *BaseServer) ListPop(key string, end command.ListEnd, count int64) ([][]byte, bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	popped := make([][]byte, 0, min(count, int64(list.len())))
+	for len(popped) < cap(popped) {
+		popped = append(popped, list.pop(end))
+	}
+
+	if len(popped) > 0 {
+		s.removeIfEmpty(key, list)
+		s.persistence.recordChange()
+		s.propagateAs(popCommand(key, end, count))
+	}
+	return popped, true, nil
+}
+
+// LRange returns the elements of the list stored at key from start to end, inclusive. Negative offsets count back
+// from the end of the list
+func (s *BaseServer) LRange(key string, start int64, end int64) ([][]byte, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return [][]byte{}, err
+	}
+
+	startIdx, endIdx, ok := listRange(start, end, list.len())
+	if !ok {
+		return [][]byte{}, nil
+	}
+	return list.rangeElements(startIdx, endIdx), nil
+}
+
+// LLen returns the length of the list stored at key, or 0 if the key doesn't exist
+func (s *BaseServer) LLen(key string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return 0, err
+	}
+	return list.len(), nil
+}
+
+// LIndex returns the element at index in the list stored at key, along with whether there is such an element. A
+// negative index counts back from the end of the list
+func (s *BaseServer) LIndex(key string, index int64) ([]byte, bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	if index < 0 {
+		index += int64(list.len())
+	}
+	if index < 0 || index >= int64(list.len()) {
+		return nil, false, nil
+	}
+	return list.index(int(index)), true, nil
+}
+
+// LSet replaces the element at index in the list stored at key. A negative index counts back from the end of the
+// list
+func (s *BaseServer) LSet(key string, index int64, element []byte) error {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return command.ErrNoSuchKey
+	}
+
+	if index < 0 {
+		index += int64(list.len())
+	}
+	if index < 0 || index >= int64(list.len()) {
+		return command.ErrorReply("ERR index out of range")
+	}
+
+	list.set(int(index), element)
+	s.persistence.recordChange()
+
+	return nil
+}
+
+// LInsert adds element to the list stored at key, right before or after the first element that is equal to pivot.
+// It returns the length of the list afterwards, 0 if the key doesn't exist, or -1 if pivot wasn't found
+func (s *BaseServer) LInsert(key string, before bool, pivot []byte, element []byte) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	pivotIndex := -1
+	list.each(false, func(index int, element []byte) bool {
+		if bytes.Equal(element, pivot) {
+			pivotIndex = index
+			return false
+		}
+		return true
+	})
+	if pivotIndex == -1 {
+		return -1, nil
+	}
+
+	if before {
+		list.insert(pivotIndex, element)
+	} else {
+		list.insert(pivotIndex+1, element)
+	}
+	s.persistence.recordChange()
+
+	return list.len(), nil
+}
+
+// LRem removes elements that are equal to element from the list stored at key, and returns how many were removed.
+// A positive count removes at most that many starting from the front, a negative count removes at most that many
+// starting from the back, and 0 removes all of them
+func (s *BaseServer) LRem(key string, count int64, element []byte) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return 0, err
+	}
+
+	// The count is negated to count matches from the back, which the smallest int64 can't be. No list is long
+	// enough for the difference to matter
+	count = max(count, -math.MaxInt64)
+	removed := list.removeMatching(element, int(count))
+	if removed > 0 {
+		s.removeIfEmpty(key, list)
+		s.persistence.recordChange()
+	}
+	return removed, nil
+}
+
+// LTrim removes every element of the list stored at key that isn't between start and end, inclusive. Negative
+// offsets count back from the end of the list
+func (s *BaseServer) LTrim(key string, start int64, end int64) error {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return err
+	}
+
+	startIdx, endIdx, ok := listRange(start, end, list.len())
+	if !ok {
+		delete(s.storeData, key)
+		s.persistence.recordChange()
+		return nil
+	}
+
+	if startIdx > 0 || endIdx < list.len()-1 {
+		list.trim(startIdx, list.len()-1-endIdx)
+		s.persistence.recordChange()
+	}
+	return nil
+}
+
+// LPos returns the indexes of the elements that are equal to element in the list stored at key. It skips the
+// first rank-1 matches, or searches from the end of the list if rank is negative. At most count matches are
+// returned, or all of them if count is 0, and at most maxLen elements are compared, or all of them if maxLen is 0
+func (s *BaseServer) LPos(key string, element []byte, rank int64, count int64, maxLen int64) ([]int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	list, err := existing.listData()
+	if err != nil || !ok {
+		return []int{}, err
+	}
+
+	fromBack := rank < 0
+	toSkip := rank - 1
+	if fromBack {
+		toSkip = -rank - 1
+	}
+
+	matches := []int{}
+	compared := int64(0)
+	list.each(fromBack, func(index int, current []byte) bool {
+		if maxLen != 0 && compared == maxLen {
+			return false
+		}
+		compared++
+
+		if !bytes.Equal(current, element) {
+			return true
+		}
+		if toSkip > 0 {
+			toSkip--
+			return true
+		}
+
+		matches = append(matches, index)
+		return count == 0 || int64(len(matches)) < count
+	})
+	return matches, nil
+}
+
+// LMove pops an element from an end of the list stored at source and pushes it to an end of the list stored at
+// destination, creating it if it doesn't exist. It returns the element that was moved, along with whether source
+// exists
+func (s *BaseServer) LMove(source string, destination string, from command.ListEnd, to command.ListEnd) ([]byte, bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existingSource, ok := s.lookup(source)
+	sourceList, err := existingSource.listData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	// Like redis, nothing is popped if the destination holds the wrong type
+	existingDestination, ok := s.lookup(destination)
+	destinationList, err := existingDestination.listData()
+	if err != nil {
+		return nil, false, err
+	}
+	if !ok {
+		destinationList = newListValue()
+		s.storeData[destination] = storeValue{data: destinationList}
+	}
+
+	element := sourceList.pop(from)
+	destinationList.push(to, element)
+	s.removeIfEmpty(source, sourceList)
+	s.persistence.recordChange()
+	s.listWaiters.signal(destination)
+
+	// A BLMOVE reaches replicas as the LMOVE that it ran
+	s.propagateAs(command.LMove{Source: source, Destination: destination, From: from, To: to})
+
+	return element, true, nil
+}
+
+// popCommand returns the LPOP or RPOP that pops count elements from an end of the list stored at key
+func popCommand(key string, end command.ListEnd, count int64) command.Pop {
+	cmdType := command.RPopCmd
+	if end == command.ListLeft {
+		cmdType = command.LPopCmd
+	}
+	return command.Pop{Cmd: cmdType, Key: key, Count: count, WithCount: count != 1}
+}
@@ -0,0 +1,208 @@
+package server
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// listOf returns a list that holds the provided elements
+func listOf(elements ...string) *listValue {
+	list := newListValue()
+	for _, element := range elements {
+		list.pushBack([]byte(element))
+	}
+	return list
+}
+
+// requireListEquals checks the elements of a list, and that every node of the list holds between 1 and
+// listNodeSize elements and is linked in both directions
+func requireListEquals(t *testing.T, expected [][]byte, list *listValue) {
+	t.Helper()
+
+	require.Equal(t, len(expected), list.len())
+	if len(expected) == 0 {
+		require.Nil(t, list.head)
+		require.Nil(t, list.tail)
+		return
+	}
+	require.Equal(t, expected, list.elements())
+
+	var prev *listNode
+	for node := list.head; node != nil; node = node.next {
+		require.NotEmpty(t, node.elements)
+		require.LessOrEqual(t, len(node.elements), listNodeSize)
+		require.Equal(t, prev, node.prev)
+		prev = node
+	}
+	require.Equal(t, prev, list.tail)
+}
+
+func TestListValue(t *testing.T) {
+	t.Run("pushes and pops at both ends should span multiple nodes", func(t *testing.T) {
+		list := newListValue()
+		var expected [][]byte
+		for i := range 3 * listNodeSize {
+			element := []byte(strconv.Itoa(i))
+			if i%2 == 0 {
+				list.pushFront(element)
+				expected = slices.Insert(expected, 0, element)
+			} else {
+				list.pushBack(element)
+				expected = append(expected, element)
+			}
+		}
+		requireListEquals(t, expected, list)
+
+		for i := range expected {
+			assert.Equal(t, expected[i], list.index(i))
+		}
+
+		for len(expected) > 0 {
+			assert.Equal(t, expected[0], list.popFront())
+			assert.Equal(t, expected[len(expected)-1], list.popBack())
+			expected = expected[1 : len(expected)-1]
+		}
+		requireListEquals(t, nil, list)
+	})
+
+	t.Run("inserting into a full node should split it", func(t *testing.T) {
+		var expected [][]byte
+		list := newListValue()
+		for i := range listNodeSize {
+			list.pushBack([]byte(strconv.Itoa(i)))
+			expected = append(expected, []byte(strconv.Itoa(i)))
+		}
+
+		for _, index := range []int{1, listNodeSize / 2, listNodeSize - 1, 3} {
+			list.insert(index, []byte("new"))
+			expected = slices.Insert(expected, index, []byte("new"))
+			requireListEquals(t, expected, list)
+		}
+		assert.NotEqual(t, list.head, list.tail)
+	})
+
+	t.Run("removeMatching should remove matches from the requested end", func(t *testing.T) {
+		for _, tc := range []struct {
+			count    int
+			expected []string
+			removed  int
+		}{
+			{count: 0, expected: []string{"b", "c"}, removed: 3},
+			{count: 2, expected: []string{"b", "a", "c"}, removed: 2},
+			{count: -2, expected: []string{"a", "b", "c"}, removed: 2},
+			{count: -10, expected: []string{"b", "c"}, removed: 3},
+		} {
+			list := listOf("a", "a", "b", "a", "c")
+			assert.Equal(t, tc.removed, list.removeMatching([]byte("a"), tc.count))
+			requireListEquals(t, listOf(tc.expected...).elements(), list)
+		}
+	})
+
+	t.Run("clone should not share elements with the original list", func(t *testing.T) {
+		list := listOf("a", "b")
+		cloned := list.clone().(*listValue)
+		list.index(0)[0] = 'z'
+		list.pushBack([]byte("c"))
+		requireListEquals(t, listOf("a", "b").elements(), cloned)
+	})
+
+	t.Run("random operations should match a slice", func(t *testing.T) {
+		random := rand.New(rand.NewSource(1))
+		list := newListValue()
+		var expected [][]byte
+
+		for i := range 20000 {
+			element := []byte(strconv.Itoa(random.Intn(10)))
+			switch op := random.Intn(8); {
+			case op == 0:
+				list.pushFront(element)
+				expected = slices.Insert(expected, 0, element)
+			case op == 1:
+				list.pushBack(element)
+				expected = append(expected, element)
+			case op == 2 && len(expected) > 0:
+				assert.Equal(t, expected[0], list.popFront())
+				expected = expected[1:]
+			case op == 3 && len(expected) > 0:
+				assert.Equal(t, expected[len(expected)-1], list.popBack())
+				expected = expected[:len(expected)-1]
+			case op == 4:
+				index := random.Intn(len(expected) + 1)
+				list.insert(index, element)
+				expected = slices.Insert(expected, index, element)
+			case op == 5 && len(expected) > 0:
+				index := random.Intn(len(expected))
+				list.set(index, element)
+				expected[index] = element
+			case op == 6 && i%50 == 0:
+				count := random.Intn(5) - 2
+				numBefore := len(expected)
+				expected = removeFromSlice(expected, element, count)
+				assert.Equal(t, numBefore-len(expected), list.removeMatching(element, count))
+			case op == 7 && len(expected) > 0 && i%20 == 0:
+				numFront := random.Intn(len(expected) + 1)
+				numBack := random.Intn(len(expected) - numFront + 1)
+				list.trim(numFront, numBack)
+				expected = expected[numFront : len(expected)-numBack]
+			default:
+				list.pushBack(element)
+				expected = append(expected, element)
+			}
+		}
+		requireListEquals(t, expected, list)
+	})
+}
+
+// removeFromSlice is a simple version of listValue.removeMatching that works on a slice
+func removeFromSlice(elements [][]byte, element []byte, count int) [][]byte {
+	indexes := []int{}
+	for i := range elements {
+		if string(elements[i]) == string(element) {
+			indexes = append(indexes, i)
+		}
+	}
+	if count < 0 {
+		slices.Reverse(indexes)
+		count = -count
+	}
+	if count > 0 && count < len(indexes) {
+		indexes = indexes[:count]
+	}
+	slices.Sort(indexes)
+
+	for i := len(indexes) - 1; i >= 0; i-- {
+		elements = slices.Delete(elements, indexes[i], indexes[i]+1)
+	}
+	return elements
+}
+
+func TestListRange(t *testing.T) {
+	for _, tc := range []struct {
+		start, end         int64
+		expectedStart      int
+		expectedEnd        int
+		expectedHasElement bool
+	}{
+		{start: 0, end: -1, expectedStart: 0, expectedEnd: 4, expectedHasElement: true},
+		{start: -3, end: 100, expectedStart: 2, expectedEnd: 4, expectedHasElement: true},
+		{start: -100, end: 1, expectedStart: 0, expectedEnd: 1, expectedHasElement: true},
+		{start: 5, end: 10},
+		{start: 3, end: 2},
+		{start: 0, end: -6},
+	} {
+		t.Run(fmt.Sprintf("range %d to %d of a list of 5 elements", tc.start, tc.end), func(t *testing.T) {
+			start, end, ok := listRange(tc.start, tc.end, 5)
+			assert.Equal(t, tc.expectedHasElement, ok)
+			if ok {
+				assert.Equal(t, tc.expectedStart, start)
+				assert.Equal(t, tc.expectedEnd, end)
+			}
+		})
+	}
+}
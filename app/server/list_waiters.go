@@ -0,0 +1,214 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+// listWaiters tracks the clients that are blocked on a blocking list command, such as BLPOP, by the keys that they
+// are waiting on. It is only used from the event loop
+type listWaiters struct {
+	// The clients waiting on each key, in the order that they blocked
+	byKey map[string][]*listWaiter
+
+	// The keys that clients are waiting on that have been pushed to since the waiting clients were last served, in
+	// the order that they were pushed to
+	readyKeys []string
+}
+
+// listWaiter is a client that is blocked on a blocking list command until one of its keys holds a list
+type listWaiter struct {
+	conn connection.Connection
+	cmd  command.Command
+	keys []string
+
+	// The reply sent to the client if its timeout passes
+	timeoutReply any
+
+	// The timer that unblocks the client once its timeout passes. This is nil if the client has no timeout
+	timer *time.Timer
+}
+
+func newListWaiters() *listWaiters {
+	return &listWaiters{
+		byKey: map[string][]*listWaiter{},
+	}
+}
+
+// add starts tracking a waiter on each of its keys
+func (w *listWaiters) add(waiter *listWaiter) {
+	for _, key := range waiter.keys {
+		if !slices.Contains(w.byKey[key], waiter) {
+			w.byKey[key] = append(w.byKey[key], waiter)
+		}
+	}
+}
+
+// remove stops tracking a waiter and returns false if it was not being tracked
+func (w *listWaiters) remove(waiter *listWaiter) bool {
+	removed := false
+	for _, key := range waiter.keys {
+		waiters := w.byKey[key]
+		idx := slices.Index(waiters, waiter)
+		if idx == -1 {
+			continue
+		}
+
+		removed = true
+		if len(waiters) == 1 {
+			delete(w.byKey, key)
+		} else {
+			w.byKey[key] = slices.Delete(waiters, idx, idx+1)
+		}
+	}
+
+	if removed && waiter.timer != nil {
+		waiter.timer.Stop()
+	}
+	return removed
+}
+
+// signal marks key as ready to serve the clients waiting on it, if there are any. It's called whenever a list is
+// pushed to or moved to key
+func (w *listWaiters) signal(key string) {
+	if _, ok := w.byKey[key]; ok && !slices.Contains(w.readyKeys, key) {
+		w.readyKeys = append(w.readyKeys, key)
+	}
+}
+
+// takeReadyKey returns the key that was marked as ready first and stops treating it as ready
+func (w *listWaiters) takeReadyKey() (string, bool) {
+	if len(w.readyKeys) == 0 {
+		return "", false
+	}
+
+	key := w.readyKeys[0]
+	w.readyKeys = w.readyKeys[1:]
+	return key, true
+}
+
+// waiting returns the clients waiting on key, in the order that they blocked
+func (w *listWaiters) waiting(key string) []*listWaiter {
+	return slices.Clone(w.byKey[key])
+}
+
+// takeAll stops tracking every waiter and returns them
+func (w *listWaiters) takeAll() []*listWaiter {
+	var all []*listWaiter
+	for _, waiters := range w.byKey {
+		for _, waiter := range waiters {
+			if !slices.Contains(all, waiter) {
+				all = append(all, waiter)
+			}
+		}
+	}
+
+	clear(w.byKey)
+	w.readyKeys = nil
+	return all
+}
+
+// blockOnLists blocks a client until one of keys holds a list that cmd can be served from, or the timeout passes and
+// the client is sent timeoutReply. A timeout of 0 waits forever. Like a WAIT, the client is blocked rather than the
+// event loop, and it's replied to later from the event loop
+func (s *MasterServer) blockOnLists(conn connection.Connection, cmd command.Command, keys []string, timeout time.Duration, timeoutReply any) {
+	waiter := &listWaiter{
+		conn:         conn,
+		cmd:          cmd,
+		keys:         keys,
+		timeoutReply: timeoutReply,
+	}
+	s.listWaiters.add(waiter)
+	s.blockedClients.block(conn, func() { s.listWaiters.remove(waiter) })
+
+	if timeout > 0 {
+		waiter.timer = time.AfterFunc(timeout, func() {
+			s.runOnEventLoop(func() { s.timeOutListWaiter(waiter) })
+		})
+	}
+}
+
+// serveListWaiters serves the clients that are waiting on the keys that have been pushed to. The clients waiting on
+// each key are served in the order that they blocked, for as long as the key holds a list. Serving a client can push
+// to another key, such as the destination of a BLMOVE, which then serves the clients waiting on that key too
+func (s *MasterServer) serveListWaiters() {
+	for {
+		key, ok := s.listWaiters.takeReadyKey()
+		if !ok {
+			return
+		}
+
+		for _, waiter := range s.listWaiters.waiting(key) {
+			if length, err := s.LLen(key); err != nil || length == 0 {
+				break
+			}
+			s.serveListWaiter(waiter)
+		}
+	}
+}
+
+// serveListWaiter runs the command that a waiter is blocked on again and unblocks the waiter if it was served. What
+// the command changed is propagated in the same way as it would have been if the command hadn't blocked
+func (s *MasterServer) serveListWaiter(waiter *listWaiter) {
+	changesBefore := s.persistence.numChanges()
+	served, serveErr := commandExecutor{server: s, conn: waiter.conn}.serveListCommand(waiter.cmd)
+
+	if err := s.handleCommandPropagation(waiter.conn, waiter.cmd, s.persistence.numChanges() != changesBefore); err != nil {
+		s.logger.Error("error propagating blocked list command", zap.Error(err))
+	}
+	if !served && serveErr == nil {
+		return
+	}
+
+	if serveErr != nil {
+		replyWithError(s.logger, waiter.conn, serveErr)
+	}
+	s.listWaiters.remove(waiter)
+	s.blockedClients.unblock(waiter.conn)
+}
+
+// timeOutListWaiter sends a waiter its timeout reply and unblocks it. Nothing happens if the waiter has already
+// been served
+func (s *MasterServer) timeOutListWaiter(waiter *listWaiter) {
+	if !s.listWaiters.remove(waiter) {
+		return
+	}
+
+	if err := writeBlockedReply(waiter.conn, waiter.timeoutReply); err != nil {
+		s.logger.Error("failed to send timeout response to blocked list command", zap.Error(err))
+	}
+	s.blockedClients.unblock(waiter.conn)
+}
+
+// unblockListWaiters sends every waiter an error and unblocks it. It's used when the master becomes a replica,
+// since replicas can't serve blocked clients
+func (s *MasterServer) unblockListWaiters() {
+	for _, waiter := range s.listWaiters.takeAll() {
+		if waiter.timer != nil {
+			waiter.timer.Stop()
+		}
+		if _, err := waiter.conn.WriteString(unblockedByRoleChangeError); err != nil {
+			s.logger.Error("failed to send response to blocked list command", zap.Error(err))
+		}
+		s.blockedClients.unblock(waiter.conn)
+	}
+}
+
+// writeBlockedReply encodes a reply for a client that was blocked and sends it
+func writeBlockedReply(conn connection.Connection, reply any) error {
+	res, err := command.Encoder{Protocol: conn.ClientState().Protocol}.EncodePrimitive(reply)
+	if err != nil {
+		return fmt.Errorf("error encoding response for blocked command: %w", err)
+	}
+
+	if _, err := conn.WriteString(res); err != nil {
+		return fmt.Errorf("error writing reponse to blocked command to client: %w", err)
+	}
+	return nil
+}
@@ -3,6 +3,9 @@ package server
 import (
 	"context"
 	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
 
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	"github.com/codecrafters-io/redis-starter-go/app/connection"
@@ -12,8 +15,35 @@ import (
 type MasterServer struct {
 	BaseServer
 
-	// A list of replica connections that are currently registered with this master
-	registeredReplicaConns []connection.Connection
+	// A list of replicas that are currently registered with this master
+	registeredReplicas []*registeredReplica
+
+	// A random ID identifying this master's replication history
+	replicationID string
+
+	// The replication ID that this server followed as a replica before it was promoted, and the last offset of that
+	// history that it shares. Replicas of the old master can partially resync up to this offset. The ID is empty if
+	// this server was never promoted
+	secondReplicationID     string
+	secondReplicationOffset int64
+
+	// The total number of bytes of commands that this master has propagated to its replicas. Because this is only
+	// updated in the event loop, there's no need to lock this/use a sync value
+	replicationOffset int64
+
+	// The backlog of recently propagated commands used to partially resync replicas. This is nil until
+	// the first replica connects
+	backlog *replicationBacklog
+
+	// The clients blocked on a WAIT command, in the order that they started waiting
+	ackWaiters []*ackWaiter
+}
+
+type registeredReplica struct {
+	conn connection.Connection
+
+	// The latest replication offset that this replica has acknowledged processing
+	ackOffset int64
 }
 
 func (s *MasterServer) NodeType() NodeType {
@@ -25,18 +55,32 @@ func NewMasterServer(logger log.Logger, opts ServerOptions) (MasterServer, error
 	if err != nil {
 		return MasterServer{}, fmt.Errorf("error initializing master server: %w", err)
 	}
+	replicationID, err := newReplicationID()
+	if err != nil {
+		return MasterServer{}, fmt.Errorf("error initializing master server: %w", err)
+	}
+
 	return MasterServer{
-		BaseServer: baseServer,
+		BaseServer:    baseServer,
+		replicationID: replicationID,
 	}, nil
 }
 
 func (s *MasterServer) ExecuteCommand(conn connection.Connection, cmd command.Command) error {
-	err := RunCommand(s, conn, cmd)
-	if err != nil {
-		return fmt.Errorf("error executing command: %w", err)
-	}
+	changesBefore := s.persistence.numChanges()
+
+	runErr := RunCommand(s, conn, cmd)
+
+	// Anything that the command changed has to reach the replicas, even if the command failed part way through
+	err := s.handleCommandPropagation(conn, cmd, s.persistence.numChanges() != changesBefore)
 
-	err = s.handleCommandPropagation(cmd)
+	// The clients blocked on lists that the command pushed to are served once the push has been propagated, so that
+	// replicas see the push before the pops that it unblocked
+	s.serveListWaiters()
+
+	if runErr != nil {
+		return fmt.Errorf("error executing command: %w", runErr)
+	}
 	if err != nil {
 		return fmt.Errorf("error propagating command: %w", err)
 	}
@@ -44,40 +88,151 @@ func (s *MasterServer) ExecuteCommand(conn connection.Connection, cmd command.Co
 	return nil
 }
 
-func (s *MasterServer) handleCommandPropagation(cmd command.Command) error {
-	switch cmd.(type) {
-	case command.Set:
-		res, err := cmd.EncodedCommand()
-		if err != nil {
-			return fmt.Errorf("error encoding command: %w", err)
+// handleCommandPropagation adds an executed command to the replication stream if it is a write command that changed
+// the store, along with any commands that the store added while it ran. If the store rewrote the command, what it
+// was rewritten to is propagated instead
+func (s *MasterServer) handleCommandPropagation(conn connection.Connection, cmd command.Command, changedStore bool) error {
+	commands := s.propagation.take()
+	rewritten, wasRewritten := s.propagation.takeRewritten()
+	if changedStore && cmd.Flags().Has(command.WriteFlag) {
+		if wasRewritten {
+			commands = append(commands, rewritten...)
+		} else {
+			commands = append(commands, cmd)
 		}
+	}
+	if len(commands) == 0 {
+		return nil
+	}
 
-		// Send the encoded command to all registered replica connections
-		for _, replicaConn := range s.registeredReplicaConns {
-			_, err := replicaConn.WriteString(res)
-			if err != nil {
-				return fmt.Errorf("error sending command to replica: %w", err)
-			}
+	for _, propagatedCmd := range commands {
+		if err := s.propagate(propagatedCmd); err != nil {
+			return err
 		}
-	default:
-		// this command does not need to be propagated
 	}
 
+	// Keep track of the client's last write so that a WAIT from this client knows what replicas need to reach
+	conn.ClientState().LastWriteOffset = s.replicationOffset
+
 	return nil
 }
 
-func (s *MasterServer) Run(ctx context.Context) error {
-	go EventLoop(
-		ctx,
-		s.logger,
-		s.eventQueue,
-		func(clientConn connection.Connection, cmd command.Command) error {
-			return s.ExecuteCommand(clientConn, cmd)
-		},
-	)
-	go s.ConnectionHandler(ctx)
-	go s.ExpiryLoop(ctx)
+// activeExpire deletes a sample of the keys that have expired and propagates their deletion to the replicas
+func (s *MasterServer) activeExpire() {
+	s.deleteExpiredKeys()
+	for _, cmd := range s.propagation.take() {
+		if err := s.propagate(cmd); err != nil {
+			s.logger.Error("error propagating expired key", zap.Error(err))
+		}
+	}
+}
+
+// propagate adds a command to the replication stream and sends it to all registered replicas. Replicas
+// that can no longer be written to are unregistered
+func (s *MasterServer) propagate(cmd command.Command) error {
+	res, err := cmd.EncodedCommand()
+	if err != nil {
+		return fmt.Errorf("error encoding command: %w", err)
+	}
 
+	s.replicationOffset += int64(len(res))
+	if s.backlog != nil {
+		s.backlog.write([]byte(res))
+	}
+
+	// Send the encoded command to all registered replica connections
+	connectedReplicas := s.registeredReplicas[:0]
+	for _, replica := range s.registeredReplicas {
+		if _, err := replica.conn.WriteString(res); err != nil {
+			s.logger.Error("error sending command to replica, unregistering it", zap.Error(err))
+			continue
+		}
+		connectedReplicas = append(connectedReplicas, replica)
+	}
+	clear(s.registeredReplicas[len(connectedReplicas):])
+	s.registeredReplicas = connectedReplicas
+
+	return nil
+}
+
+// registerReplica adds a replica that has finished syncing to the list of replicas that commands are propagated to
+func (s *MasterServer) registerReplica(conn connection.Connection, ackOffset int64) {
+	s.registeredReplicas = append(s.registeredReplicas, &registeredReplica{
+		conn:      conn,
+		ackOffset: ackOffset,
+	})
+}
+
+// disconnectReplicas drops every replica of this master and unblocks the clients that are waiting on them
+func (s *MasterServer) disconnectReplicas() {
+	for _, replica := range s.registeredReplicas {
+		replica.conn.Close()
+	}
+	s.registeredReplicas = nil
+
+	for _, waiter := range s.ackWaiters {
+		if waiter.timer != nil {
+			waiter.timer.Stop()
+		}
+		if _, err := waiter.conn.WriteString(unblockedByRoleChangeError); err != nil {
+			s.logger.Error("failed to send response to blocked WAIT command", zap.Error(err))
+		}
+		s.blockedClients.unblock(waiter.conn)
+	}
+	s.ackWaiters = nil
+}
+
+// createBacklogIfNeeded creates the replication backlog if it does not exist yet
+func (s *MasterServer) createBacklogIfNeeded() {
+	if s.backlog == nil {
+		s.backlog = newReplicationBacklog(s.replBacklogSize, s.replicationOffset)
+	}
+}
+
+// partialResyncData returns the bytes that a replica needs in order to continue from the provided PSYNC request.
+// The returned bool is false if the replica must do a full resync instead
+func (s *MasterServer) partialResyncData(psync command.PSync) ([]byte, bool) {
+	if s.backlog == nil {
+		return nil, false
+	}
+
+	offset, err := strconv.ParseInt(psync.MasterOffset, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	followsCurrentHistory := psync.ReplicationID == s.replicationID
+	followsPreviousHistory := s.secondReplicationID != "" &&
+		psync.ReplicationID == s.secondReplicationID &&
+		offset <= s.secondReplicationOffset
+	if !followsCurrentHistory && !followsPreviousHistory {
+		return nil, false
+	}
+
+	return s.backlog.readFrom(offset)
+}
+
+// backlogInfo returns the backlog fields for the replication section of the INFO command
+func (s *MasterServer) backlogInfo() map[string]string {
+	if s.backlog == nil {
+		return map[string]string{
+			"repl_backlog_active":            "0",
+			"repl_backlog_size":              strconv.Itoa(s.replBacklogSize),
+			"repl_backlog_first_byte_offset": "0",
+			"repl_backlog_histlen":           "0",
+		}
+	}
+
+	return map[string]string{
+		"repl_backlog_active":            "1",
+		"repl_backlog_size":              strconv.Itoa(len(s.backlog.buffer)),
+		"repl_backlog_first_byte_offset": strconv.FormatInt(s.backlog.firstByteOffset, 10),
+		"repl_backlog_histlen":           strconv.Itoa(s.backlog.histLen),
+	}
+}
+
+func (s *MasterServer) Run(ctx context.Context) error {
+	s.runLoops(ctx, s.ExecuteCommand, s.activeExpire)
 	return nil
 }
 
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// The delay before a replica's first attempt to reconnect to its master after the link drops. Every failed
+	// attempt doubles the delay, up to maxReconnectBackoff
+	minReconnectBackoff = 100 * time.Millisecond
+	maxReconnectBackoff = 10 * time.Second
+)
+
+// masterLink tracks the health of a replica's connection to its master. It is updated both by the goroutine that
+// maintains the connection and by the event loop, so all access goes through its mutex
+type masterLink struct {
+	mu sync.Mutex
+
+	isUp bool
+
+	// The last time that anything was received from the master
+	lastIOTime time.Time
+
+	// When the link last went down. This is the zero time if the link has never been up
+	downSinceTime time.Time
+}
+
+func newMasterLink() *masterLink {
+	return &masterLink{}
+}
+
+// setUp marks the link as up once the replica has finished syncing with its master
+func (l *masterLink) setUp() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.isUp = true
+	l.lastIOTime = time.Now()
+}
+
+// setDown marks the link as down after the connection to the master is lost
+func (l *masterLink) setDown() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.isUp {
+		return
+	}
+	l.isUp = false
+	l.downSinceTime = time.Now()
+}
+
+// recordIO notes that data was just received from the master
+func (l *masterLink) recordIO() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastIOTime = time.Now()
+}
+
+// info returns the link fields for the replication section of the INFO command. Like redis, the time since the
+// link went down is only reported while it is down, and is -1 if the replica has never been connected
+func (l *masterLink) info() map[string]string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.isUp {
+		return map[string]string{
+			"master_link_status":         "up",
+			"master_last_io_seconds_ago": strconv.FormatInt(int64(time.Since(l.lastIOTime).Seconds()), 10),
+		}
+	}
+
+	downSinceSeconds := int64(-1)
+	if !l.downSinceTime.IsZero() {
+		downSinceSeconds = int64(time.Since(l.downSinceTime).Seconds())
+	}
+	return map[string]string{
+		"master_link_status":             "down",
+		"master_last_io_seconds_ago":     "-1",
+		"master_link_down_since_seconds": strconv.FormatInt(downSinceSeconds, 10),
+	}
+}
+
+// nextReconnectBackoff doubles the reconnect delay, capping it at maxReconnectBackoff
+func nextReconnectBackoff(backoff time.Duration) time.Duration {
+	return min(backoff*2, maxReconnectBackoff)
+}
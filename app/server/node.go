@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"go.uber.org/zap"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+	"github.com/codecrafters-io/redis-starter-go/app/log"
+)
+
+// Node is the server that is run by the process. It forwards everything to either a MasterServer or a ReplicaServer
+// depending on its current role, which can be changed at runtime with the REPLICAOF command. The servers for each
+// role share the same BaseServer, so the dataset, listener and event loop outlive a role change
+type Node struct {
+	// The server for the node's current role. This is only replaced from the event loop
+	Server
+
+	base BaseServer
+
+	// The context that the node was run with, and the function used to stop the goroutines of the current role
+	ctx      context.Context
+	stopRole context.CancelFunc
+}
+
+// NewNode creates a node that starts out as a master if masterAddress is empty, or as a replica of masterAddress otherwise
+func NewNode(logger log.Logger, masterAddress string, opts ServerOptions) (*Node, error) {
+	if masterAddress == "" {
+		master, err := NewMasterServer(logger, opts)
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Server: &master, base: master.BaseServer}, nil
+	}
+
+	replica, err := NewReplicaServer(logger, masterAddress, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Node{Server: &replica, base: replica.BaseServer}, nil
+}
+
+func (n *Node) Run(ctx context.Context) error {
+	n.ctx = ctx
+	n.base.runLoops(ctx, n.ExecuteCommand, n.activeExpire)
+	n.startRole()
+
+	return nil
+}
+
+// ExecuteCommand runs a command on the server for the node's current role. REPLICAOF is handled by the node
+// itself since it replaces that server
+func (n *Node) ExecuteCommand(conn connection.Connection, cmd command.Command) error {
+	replicaOf, ok := cmd.(command.ReplicaOf)
+	if !ok {
+		return n.Server.ExecuteCommand(conn, cmd)
+	}
+
+	if err := n.executeReplicaOf(conn, replicaOf); err != nil {
+		return fmt.Errorf("error executing command: %w", err)
+	}
+	return nil
+}
+
+func (n *Node) executeReplicaOf(conn connection.Connection, replicaOf command.ReplicaOf) error {
+	reply := "OK"
+	switch {
+	case replicaOf.IsNoOne():
+		if err := n.promote(); err != nil {
+			n.base.logger.Error("failed to promote replica", zap.Error(err))
+			return command.ErrorReply("ERR " + err.Error())
+		}
+	case n.isReplicaOf(net.JoinHostPort(replicaOf.Host, replicaOf.Port)):
+		reply = "OK Already connected to specified master"
+	default:
+		n.follow(net.JoinHostPort(replicaOf.Host, replicaOf.Port))
+	}
+
+	res, err := command.Encoder{Protocol: conn.ClientState().Protocol}.EncodePrimitive(reply)
+	if err != nil {
+		return fmt.Errorf("error encoding response for REPLICAOF command: %w", err)
+	}
+
+	if _, err := conn.WriteString(res); err != nil {
+		return fmt.Errorf("error writing reponse to REPLICAOF command to client: %w", err)
+	}
+	return nil
+}
+
+func (n *Node) isReplicaOf(masterAddress string) bool {
+	replica, ok := n.Server.(*ReplicaServer)
+	return ok && replica.masterAddress == masterAddress
+}
+
+// promote turns a replica into a master that keeps the replica's dataset. The master starts a new replication
+// history, but remembers the one it followed so that the other replicas of its old master can partially resync
+func (n *Node) promote() error {
+	replica, ok := n.Server.(*ReplicaServer)
+	if !ok {
+		return nil
+	}
+
+	replicationID, err := newReplicationID()
+	if err != nil {
+		return err
+	}
+
+	n.stopCurrentRole()
+
+	master := &MasterServer{
+		BaseServer:        n.base,
+		replicationID:     replicationID,
+		replicationOffset: replica.bytesProcessed,
+	}
+	if replica.masterReplicationID != "" {
+		master.secondReplicationID = replica.masterReplicationID
+		master.secondReplicationOffset = replica.bytesProcessed + 1
+	}
+
+	// Replicas that were fully caught up with the old master can continue straight from the promoted replica
+	master.createBacklogIfNeeded()
+	master.keepsExpiredKeys = false
+
+	n.Server = master
+	n.base.logger.Info("promoted replica to master", zap.String("replicationID", replicationID))
+	return nil
+}
+
+// follow turns the node into a replica of the master at masterAddress. The node keeps serving its current dataset
+// until it has synced with its new master, and tries to continue from its own replication history to avoid a full
+// resync when the new master shares that history
+func (n *Node) follow(masterAddress string) {
+	replica := &ReplicaServer{
+		BaseServer:    n.base,
+		masterAddress: masterAddress,
+		masterLink:    newMasterLink(),
+		steadyState:   true,
+	}
+	replica.keepsExpiredKeys = true
+
+	switch typedServer := n.Server.(type) {
+	case *MasterServer:
+		typedServer.disconnectReplicas()
+		typedServer.unblockListWaiters()
+		replica.masterReplicationID = typedServer.replicationID
+		replica.bytesProcessed = typedServer.replicationOffset
+	case *ReplicaServer:
+		replica.masterReplicationID = typedServer.masterReplicationID
+		replica.bytesProcessed = typedServer.bytesProcessed
+	}
+
+	n.stopCurrentRole()
+	n.Server = replica
+	n.startRole()
+	n.base.logger.Info("following new master", zap.String("masterAddress", masterAddress))
+}
+
+// activeExpire deletes expired keys while the node is a master. Replicas wait for their master to delete expired keys
+func (n *Node) activeExpire() {
+	if master, ok := n.Server.(*MasterServer); ok {
+		master.activeExpire()
+	}
+}
+
+// startRole starts the goroutines needed by the node's current role
+func (n *Node) startRole() {
+	roleCtx, stopRole := context.WithCancel(n.ctx)
+	n.stopRole = stopRole
+
+	if replica, ok := n.Server.(*ReplicaServer); ok {
+		go replica.replicationLoop(roleCtx)
+	}
+}
+
+func (n *Node) stopCurrentRole() {
+	if n.stopRole != nil {
+		n.stopRole()
+	}
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+// getTestNode wraps a test server in a node that can change its role until the test finishes
+func getTestNode(t *testing.T, srv Server, base BaseServer) *Node {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	return &Node{Server: srv, base: base, ctx: ctx}
+}
+
+// executeOnNode runs a command on a node and returns its reply
+func executeOnNode(t *testing.T, node *Node, cmd command.Command) string {
+	t.Helper()
+
+	conn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 1)
+	require.NoError(t, node.ExecuteCommand(conn, cmd))
+
+	res, err := conn.ReadNextCmdString()
+	require.NoError(t, err)
+	return res
+}
+
+func TestReplicaOfNoOne(t *testing.T) {
+	t.Run("a promoted replica should keep its dataset and let replicas of its old master partially resync", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{"foo": {data: stringValue("bar")}}).(*ReplicaServer)
+		replica.masterReplicationID = testReplicationID
+		replica.bytesProcessed = 100
+		node := getTestNode(t, replica, replica.BaseServer)
+
+		assert.Equal(t, command.OKString, executeOnNode(t, node, command.ReplicaOf{Host: "NO", Port: "ONE"}))
+
+		master, ok := node.Server.(*MasterServer)
+		require.True(t, ok)
+		assert.Equal(t, MasterNodeType, node.NodeType())
+		assert.NotEqual(t, testReplicationID, master.replicationID)
+		assert.Equal(t, testReplicationID, master.secondReplicationID)
+		assert.Equal(t, int64(101), master.secondReplicationOffset)
+		assert.Equal(t, int64(100), master.replicationOffset)
+
+		value, ok, err := node.Get("foo")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, []byte("bar"), value)
+
+		missingData, ok := master.partialResyncData(command.PSync{ReplicationID: testReplicationID, MasterOffset: "101"})
+		assert.True(t, ok)
+		assert.Empty(t, missingData)
+
+		_, ok = master.partialResyncData(command.PSync{ReplicationID: testReplicationID, MasterOffset: "102"})
+		assert.False(t, ok)
+	})
+
+	t.Run("REPLICAOF NO ONE on a master should do nothing", func(t *testing.T) {
+		master := getTestMasterServer(serverStore{}).(*MasterServer)
+		node := getTestNode(t, master, master.BaseServer)
+
+		assert.Equal(t, command.OKString, executeOnNode(t, node, command.ReplicaOf{Host: "no", Port: "one"}))
+		assert.Same(t, master, node.Server)
+	})
+}
+
+func TestReplicaOfHostPort(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	host, port, err := net.SplitHostPort(listener.Addr().String())
+	require.NoError(t, err)
+
+	master := getTestMasterServer(serverStore{"foo": {data: stringValue("bar")}}).(*MasterServer)
+	master.replicationOffset = 50
+	replicaConn := connection.NewChannelConnWithBuffer(connection.ReplicaConnection, 10)
+	master.registerReplica(replicaConn, 50)
+	node := getTestNode(t, master, master.BaseServer)
+
+	assert.Equal(t, command.OKString, executeOnNode(t, node, command.ReplicaOf{Host: host, Port: port}))
+
+	replica, ok := node.Server.(*ReplicaServer)
+	require.True(t, ok)
+	assert.Equal(t, ReplicaNodeType, node.NodeType())
+	assert.Empty(t, master.registeredReplicas)
+
+	// The demoted master should try to continue from its own replication history
+	assert.Equal(t, &command.PSync{ReplicationID: testReplicationID, MasterOffset: "51"}, replica.psyncCommand())
+
+	// Until it syncs with its new master, the old dataset is still served
+	value, ok, err := node.Get("foo")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("bar"), value)
+
+	// The replica should connect to its new master and start the handshake
+	require.NoError(t, listener.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second)))
+	masterConn, err := listener.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { masterConn.Close() })
+	require.NoError(t, masterConn.SetReadDeadline(time.Now().Add(time.Second)))
+	line, err := bufio.NewReader(masterConn).ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "*1\r\n", line)
+
+	assert.Equal(
+		t,
+		"+OK Already connected to specified master\r\n",
+		executeOnNode(t, node, command.ReplicaOf{Host: host, Port: port}),
+	)
+	assert.Same(t, replica, node.Server)
+}
@@ -0,0 +1,350 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/codecrafters-io/redis-starter-go/app/rdb"
+)
+
+const (
+	DEFAULT_RDB_DIR      = "."
+	DEFAULT_RDB_FILENAME = "dump.rdb"
+
+	// The version reported in the AUX fields of RDB files written by this server, which has to be one that can load
+	// the RDB format version that's written
+	rdbRedisVersion = "7.4.0"
+)
+
+var ErrBackgroundSaveInProgress = errors.New("Background save already in progress")
+
+// persistenceState tracks the status of RDB saves. It is shared between copies of a BaseServer
+type persistenceState struct {
+	mu sync.Mutex
+
+	// The number of writes to the store since the last successful save
+	changesSinceLastSave int64
+
+	// The total number of writes to the store since the server started. Unlike changesSinceLastSave, this never
+	// goes down, so it can be used to tell whether a command changed the store
+	totalChanges int64
+
+	// The number of changes that had been made when the in progress background save started
+	changesAtBgSaveStart int64
+
+	// The time of the last successful save
+	lastSaveTime time.Time
+
+	bgSaveInProgress   bool
+	bgSaveStartTime    time.Time
+	lastBgSaveStatus   string
+	lastBgSaveDuration time.Duration
+
+	// The number of successful saves since the server started
+	numSaves int64
+}
+
+func newPersistenceState() *persistenceState {
+	return &persistenceState{
+		lastSaveTime:       time.Now(),
+		lastBgSaveStatus:   "ok",
+		lastBgSaveDuration: -time.Second,
+	}
+}
+
+func (p *persistenceState) recordChange() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.changesSinceLastSave++
+	p.totalChanges++
+}
+
+func (p *persistenceState) numChanges() int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.totalChanges
+}
+
+func (s *BaseServer) rdbFilePath() string {
+	return filepath.Join(s.rdbDir, s.rdbFilename)
+}
+
+// loadRDBFile populates the server's store from its RDB file. If there is no RDB file, the store is left empty
+func (s *BaseServer) loadRDBFile() error {
+	path := s.rdbFilePath()
+
+	snapshot, err := rdb.DecodeFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		s.logger.Info("no RDB file found, starting with an empty store", zap.String("path", path))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load RDB file: %w", err)
+	}
+
+	s.loadSnapshot(snapshot)
+	s.logger.Info("loaded RDB file", zap.String("path", path), zap.Int("keys", s.Size()))
+
+	return nil
+}
+
+// loadSnapshot replaces the contents of the server's store with the keys in the provided snapshot
+func (s *BaseServer) loadSnapshot(snapshot rdb.Snapshot) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	clear(s.storeData)
+
+	now := time.Now()
+	for _, entry := range snapshot.Entries {
+		// Only a single database is supported so keys in any other database are dropped
+		if entry.DB != 0 {
+			s.logger.Warn("skipping RDB key from unsupported database", zap.String("key", entry.Key), zap.Int("db", entry.DB))
+			continue
+		}
+
+		if entry.ExpiresAt != nil && entry.ExpiresAt.Before(now) {
+			continue
+		}
+
+		switch entry.Type {
+		case rdb.StringValueType:
+			s.storeData[entry.Key] = storeValue{
+				data:      stringValue(entry.Value.([]byte)),
+				expiresAt: entry.ExpiresAt,
+			}
+		case rdb.ListValueType:
+			elements := entry.Value.([][]byte)
+			if len(elements) == 0 {
+				continue
+			}
+
+			list := newListValue()
+			for _, element := range elements {
+				list.pushBack(element)
+			}
+			s.storeData[entry.Key] = storeValue{data: list, expiresAt: entry.ExpiresAt}
+		case rdb.SetValueType:
+			members := entry.Value.([][]byte)
+			if len(members) == 0 {
+				continue
+			}
+
+			set := newSetValue()
+			for _, member := range members {
+				set.add(string(member), s.setLimits)
+			}
+			s.storeData[entry.Key] = storeValue{data: set, expiresAt: entry.ExpiresAt}
+		case rdb.HashValueType:
+			fields := entry.Value.([]rdb.HashField)
+			if len(fields) == 0 {
+				continue
+			}
+
+			// Fields that have expired are dropped like expired keys are, along with the hash if none are left
+			hash := newHashValue()
+			for _, field := range fields {
+				if field.ExpiresAt != nil && field.ExpiresAt.Before(now) {
+					continue
+				}
+				hash.set(field.Field, field.Value, s.hashLimits, now)
+				if field.ExpiresAt != nil {
+					hash.setFieldExpiry(field.Field, *field.ExpiresAt)
+				}
+			}
+			if hash.len(now) == 0 {
+				continue
+			}
+			s.storeData[entry.Key] = storeValue{data: hash, expiresAt: entry.ExpiresAt}
+		default:
+			s.logger.Warn("skipping RDB key with unsupported type", zap.String("key", entry.Key), zap.Any("type", entry.Type))
+		}
+	}
+}
+
+// snapshot returns an RDB snapshot of the unexpired keys in the provided store
+func (store serverStore) snapshot() rdb.Snapshot {
+	snapshot := rdb.Snapshot{
+		Aux: map[string]string{
+			"redis-ver":  rdbRedisVersion,
+			"redis-bits": "64",
+			"ctime":      strconv.FormatInt(time.Now().Unix(), 10),
+		},
+		Entries: make([]rdb.Entry, 0, len(store)),
+	}
+
+	for key, value := range store {
+		if value.isExpired() {
+			continue
+		}
+
+		switch data := value.data.(type) {
+		case stringValue:
+			snapshot.Entries = append(snapshot.Entries, rdb.Entry{
+				Key:       key,
+				Type:      rdb.StringValueType,
+				Value:     []byte(data),
+				ExpiresAt: value.expiresAt,
+			})
+		case *listValue:
+			snapshot.Entries = append(snapshot.Entries, rdb.Entry{
+				Key:       key,
+				Type:      rdb.ListValueType,
+				Value:     data.elements(),
+				ExpiresAt: value.expiresAt,
+			})
+		case *setValue:
+			members := make([][]byte, 0, data.len())
+			for _, member := range data.members() {
+				members = append(members, []byte(member))
+			}
+			snapshot.Entries = append(snapshot.Entries, rdb.Entry{
+				Key:       key,
+				Type:      rdb.SetValueType,
+				Value:     members,
+				ExpiresAt: value.expiresAt,
+			})
+		case *hashValue:
+			now := time.Now()
+			fields := make([]rdb.HashField, 0, data.len(now))
+			for _, entry := range data.entries(now) {
+				fields = append(fields, rdb.HashField{Field: entry.Field, Value: entry.Value, ExpiresAt: data.fieldExpiry(entry.Field)})
+			}
+			if len(fields) == 0 {
+				continue
+			}
+			snapshot.Entries = append(snapshot.Entries, rdb.Entry{
+				Key:       key,
+				Type:      rdb.HashValueType,
+				Value:     fields,
+				ExpiresAt: value.expiresAt,
+			})
+		}
+	}
+
+	return snapshot
+}
+
+// encodedSnapshot returns the contents of the store encoded in the RDB format
+func (s *BaseServer) encodedSnapshot() ([]byte, error) {
+	s.storeDataMu.Lock()
+	snapshot := s.storeData.snapshot()
+	s.storeDataMu.Unlock()
+
+	return rdb.Encode(snapshot)
+}
+
+// Save synchronously writes the contents of the store to the server's RDB file
+func (s *BaseServer) Save() error {
+	s.persistence.mu.Lock()
+	defer s.persistence.mu.Unlock()
+
+	if s.persistence.bgSaveInProgress {
+		return ErrBackgroundSaveInProgress
+	}
+
+	s.storeDataMu.Lock()
+	snapshot := s.storeData.snapshot()
+	s.storeDataMu.Unlock()
+
+	if err := rdb.WriteFile(s.rdbFilePath(), snapshot); err != nil {
+		return fmt.Errorf("failed to save RDB file: %w", err)
+	}
+
+	s.persistence.changesSinceLastSave = 0
+	s.persistence.lastSaveTime = time.Now()
+	s.persistence.numSaves++
+	s.logger.Info("saved RDB file", zap.String("path", s.rdbFilePath()), zap.Int("keys", len(snapshot.Entries)))
+
+	return nil
+}
+
+// BackgroundSave writes the contents of the store to the server's RDB file in a separate goroutine. Only a copy
+// of the store is made while holding the store's lock so that clients are not blocked while the file is written
+func (s *BaseServer) BackgroundSave() error {
+	s.persistence.mu.Lock()
+	defer s.persistence.mu.Unlock()
+
+	if s.persistence.bgSaveInProgress {
+		return ErrBackgroundSaveInProgress
+	}
+
+	// Values can be changed in place, such as by APPEND, so the copy can't share them with the store
+	s.storeDataMu.Lock()
+	storeCopy := make(serverStore, len(s.storeData))
+	for key, value := range s.storeData {
+		storeCopy[key] = storeValue{data: value.data.clone(), expiresAt: value.expiresAt}
+	}
+	s.storeDataMu.Unlock()
+
+	s.persistence.bgSaveInProgress = true
+	s.persistence.bgSaveStartTime = time.Now()
+	s.persistence.changesAtBgSaveStart = s.persistence.changesSinceLastSave
+
+	go s.finishBackgroundSave(storeCopy)
+
+	return nil
+}
+
+func (s *BaseServer) finishBackgroundSave(storeCopy serverStore) {
+	err := rdb.WriteFile(s.rdbFilePath(), storeCopy.snapshot())
+
+	s.persistence.mu.Lock()
+	defer s.persistence.mu.Unlock()
+
+	s.persistence.bgSaveInProgress = false
+	s.persistence.lastBgSaveDuration = time.Since(s.persistence.bgSaveStartTime)
+
+	if err != nil {
+		s.persistence.lastBgSaveStatus = "err"
+		s.logger.Error("background save failed", zap.Error(err))
+		return
+	}
+
+	// Writes that happened while the save was running are not part of the file
+	s.persistence.changesSinceLastSave -= s.persistence.changesAtBgSaveStart
+	s.persistence.lastSaveTime = s.persistence.bgSaveStartTime
+	s.persistence.lastBgSaveStatus = "ok"
+	s.persistence.numSaves++
+	s.logger.Info("background save completed", zap.String("path", s.rdbFilePath()), zap.Int("keys", len(storeCopy)))
+}
+
+// LastSave returns the time of the last successful save
+func (s *BaseServer) LastSave() time.Time {
+	s.persistence.mu.Lock()
+	defer s.persistence.mu.Unlock()
+
+	return s.persistence.lastSaveTime
+}
+
+// PersistenceInfo returns the fields reported by the persistence section of the INFO command
+func (s *BaseServer) PersistenceInfo() map[string]string {
+	s.persistence.mu.Lock()
+	defer s.persistence.mu.Unlock()
+
+	bgSaveInProgress := "0"
+	currentBgSaveSeconds := int64(-1)
+	if s.persistence.bgSaveInProgress {
+		bgSaveInProgress = "1"
+		currentBgSaveSeconds = int64(time.Since(s.persistence.bgSaveStartTime).Seconds())
+	}
+
+	return map[string]string{
+		"rdb_changes_since_last_save": strconv.FormatInt(s.persistence.changesSinceLastSave, 10),
+		"rdb_bgsave_in_progress":      bgSaveInProgress,
+		"rdb_last_save_time":          strconv.FormatInt(s.persistence.lastSaveTime.Unix(), 10),
+		"rdb_last_bgsave_status":      s.persistence.lastBgSaveStatus,
+		"rdb_last_bgsave_time_sec":    strconv.FormatInt(int64(s.persistence.lastBgSaveDuration.Seconds()), 10),
+		"rdb_current_bgsave_time_sec": strconv.FormatInt(currentBgSaveSeconds, 10),
+		"rdb_saves":                   strconv.FormatInt(s.persistence.numSaves, 10),
+	}
+}
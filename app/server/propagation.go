@@ -0,0 +1,47 @@
+package server
+
+import (
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
+
+// pendingPropagation collects the commands that the store adds to the replication stream while a command is being
+// executed, such as DELs for keys that were found to be expired. It is only used from the event loop
+type pendingPropagation struct {
+	commands []command.Command
+
+	// The commands to propagate in place of the command that is being executed, if the store rewrote it
+	rewritten    []command.Command
+	wasRewritten bool
+}
+
+func newPendingPropagation() *pendingPropagation {
+	return &pendingPropagation{}
+}
+
+// take returns the pending commands and clears them
+func (p *pendingPropagation) take() []command.Command {
+	commands := p.commands
+	p.commands = nil
+	return commands
+}
+
+// takeRewritten returns the commands that the command being executed was rewritten to, if it was, and clears them
+func (p *pendingPropagation) takeRewritten() ([]command.Command, bool) {
+	rewritten, wasRewritten := p.rewritten, p.wasRewritten
+	p.rewritten, p.wasRewritten = nil, false
+	return rewritten, wasRewritten
+}
+
+// alsoPropagate adds commands to the replication stream ahead of the command that is being executed. Replicas
+// drop these commands since they never propagate anything themselves
+func (s *BaseServer) alsoPropagate(cmds ...command.Command) {
+	s.propagation.commands = append(s.propagation.commands, cmds...)
+}
+
+// propagateAs replaces the command that is being executed in the replication stream with cmds, for commands whose
+// effect depends on when or where they run, such as an EXPIRE that is relative to the current time. The
+// replacement is only propagated if the command would have been
+func (s *BaseServer) propagateAs(cmds ...command.Command) {
+	s.propagation.rewritten = cmds
+	s.propagation.wasRewritten = true
+}
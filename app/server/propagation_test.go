@@ -0,0 +1,432 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+// getTestMasterWithReplica returns a master with a single registered replica connection that receives
+// everything that the master propagates
+func getTestMasterWithReplica(initialData serverStore) (*MasterServer, connection.Connection) {
+	master := getTestMasterServer(initialData).(*MasterServer)
+	replicaConn := connection.NewChannelConnWithBuffer(connection.ReplicaConnection, 10)
+	master.registerReplica(replicaConn, 0)
+	return master, replicaConn
+}
+
+// requirePropagated checks that the next command propagated to a replica is cmd
+func requirePropagated(t *testing.T, replicaConn connection.Connection, cmd command.Command) {
+	t.Helper()
+
+	expected, err := cmd.EncodedCommand()
+	require.NoError(t, err)
+
+	res, err := replicaConn.ReadNextCmdString()
+	require.NoError(t, err)
+	assert.Equal(t, expected, res)
+}
+
+func TestWriteCommandPropagation(t *testing.T) {
+	t.Run("write commands that change the store should be propagated", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		for _, cmd := range []command.Command{
+			command.Set{KeyPayload: "b", ValuePayload: []byte("2")},
+			command.Rename{Key: "b", NewKey: "c"},
+			command.RenameNX{Key: "c", NewKey: "d"},
+			command.Copy{Source: "d", Destination: "e"},
+			command.Unlink{Keys: []string{"e"}},
+			command.Del{Keys: []string{"a", "d"}},
+		} {
+			require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+			requirePropagated(t, replicaConn, cmd)
+		}
+		assert.Equal(t, master.replicationOffset, clientConn.ClientState().LastWriteOffset)
+	})
+
+	t.Run("binary values should be propagated and applied by a replica byte for byte", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{})
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		value := []byte("\x89PNG\r\n\x1a\n\x00\xff")
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Set{KeyPayload: "image", ValuePayload: value}))
+
+		propagated, err := replicaConn.ReadNextCmdString()
+		require.NoError(t, err)
+		parser, err := command.NewParser(propagated)
+		require.NoError(t, err)
+		cmd, err := parser.Parse()
+		require.NoError(t, err)
+
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+		require.NoError(t, replica.ExecuteCommand(masterConn, cmd))
+
+		replicaValue, ok, err := replica.Get("image")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, value, replicaValue)
+	})
+
+	t.Run("list commands should be propagated and leave a replica with the same list", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{})
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+
+		for _, cmd := range []command.Command{
+			command.Push{Cmd: command.RPushCmd, Key: "a", Values: [][]byte{[]byte("1"), []byte("2"), []byte("3"), []byte("2")}},
+			command.Push{Cmd: command.LPushCmd, Key: "a", Values: [][]byte{[]byte("0")}},
+			command.Pop{Cmd: command.RPopCmd, Key: "a", Count: 1},
+			command.LSet{Key: "a", Index: 1, Value: []byte("one")},
+			command.LInsert{Key: "a", Pivot: []byte("2"), Value: []byte("2.5")},
+			command.LRem{Key: "a", Count: 1, Value: []byte("0")},
+			command.LMove{Source: "a", Destination: "b", From: command.ListLeft, To: command.ListRight},
+			command.LTrim{Key: "a", Start: 0, End: 1},
+		} {
+			require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+			requirePropagated(t, replicaConn, cmd)
+			require.NoError(t, replica.ExecuteCommand(masterConn, cmd))
+		}
+
+		for _, key := range []string{"a", "b"} {
+			masterList, err := master.LRange(key, 0, -1)
+			require.NoError(t, err)
+			replicaList, err := replica.LRange(key, 0, -1)
+			require.NoError(t, err)
+			assert.Equal(t, masterList, replicaList)
+		}
+		replicaList, err := replica.LRange("a", 0, -1)
+		require.NoError(t, err)
+		assert.Equal(t, [][]byte{[]byte("2"), []byte("2.5")}, replicaList)
+	})
+
+	t.Run("blocking list commands should be propagated as the non-blocking commands that served them", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{})
+		blockedConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		// Nothing is propagated while the client is blocked
+		require.NoError(t, master.ExecuteCommand(blockedConn, command.BPop{Cmd: command.BLPopCmd, Keys: []string{"missing", "a"}}))
+		assert.Equal(t, int64(0), master.replicationOffset)
+
+		push := command.Push{Cmd: command.RPushCmd, Key: "a", Values: [][]byte{[]byte("1"), []byte("2"), []byte("3")}}
+		require.NoError(t, master.ExecuteCommand(clientConn, push))
+		requirePropagated(t, replicaConn, push)
+		requirePropagated(t, replicaConn, command.Pop{Cmd: command.LPopCmd, Key: "a", Count: 1})
+		assert.Equal(t, master.replicationOffset, blockedConn.ClientState().LastWriteOffset)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.BLMove{Source: "a", Destination: "b", From: command.ListRight, To: command.ListLeft}))
+		requirePropagated(t, replicaConn, command.LMove{Source: "a", Destination: "b", From: command.ListRight, To: command.ListLeft})
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.LMPop{Cmd: command.BLMPopCmd, Keys: []string{"missing", "a"}, End: command.ListRight, Count: 5}))
+		requirePropagated(t, replicaConn, command.Pop{Cmd: command.RPopCmd, Key: "a", Count: 5, WithCount: true})
+		assert.Equal(t, 1, master.Size())
+	})
+
+	t.Run("INCRBYFLOAT should be propagated as a SET of its result that keeps the key's expiry", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("10.5")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.IncrByFloat{Key: "a", Increment: 0.1}))
+		requirePropagated(t, replicaConn, command.Set{KeyPayload: "a", ValuePayload: []byte("10.6"), ExpiryOption: command.KeepTTL})
+	})
+
+	t.Run("hash commands should be propagated and leave a replica with the same hash", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{})
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+
+		for _, cmd := range []command.Command{
+			command.HSet{Key: "h", Pairs: []command.FieldValue{{Field: "a", Value: []byte("1")}, {Field: "b", Value: []byte("2")}}},
+			command.HSetNX{Key: "h", Field: "c", Value: []byte("3")},
+			command.HIncrBy{Key: "h", Field: "a", Increment: 10},
+			command.HDel{Key: "h", Fields: []string{"b"}},
+		} {
+			require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+			requirePropagated(t, replicaConn, cmd)
+			require.NoError(t, replica.ExecuteCommand(masterConn, cmd))
+		}
+
+		masterHash, err := master.HGetAll("h")
+		require.NoError(t, err)
+		replicaHash, err := replica.HGetAll("h")
+		require.NoError(t, err)
+		assert.Equal(t, masterHash, replicaHash)
+		assert.Equal(t, []command.FieldValue{{Field: "a", Value: []byte("11")}, {Field: "c", Value: []byte("3")}}, replicaHash)
+	})
+
+	t.Run("HINCRBYFLOAT should be propagated as an HSET of its result", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"h": {data: hashOf("a", "10.5")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.HIncrByFloat{Key: "h", Field: "a", Increment: 0.1}))
+		requirePropagated(t, replicaConn, command.HSet{Key: "h", Pairs: []command.FieldValue{{Field: "a", Value: []byte("10.6")}}})
+	})
+
+	t.Run("set commands should be propagated and leave a replica with the same set", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{})
+		replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+
+		for _, cmd := range []command.Command{
+			command.SAdd{Key: "a", Members: []string{"1", "2", "3"}},
+			command.SAdd{Key: "b", Members: []string{"2", "x"}},
+			command.SRem{Key: "a", Members: []string{"1"}},
+			command.SMove{Source: "b", Destination: "a", Member: "x"},
+			command.SCombineStore{Cmd: command.SUnionStoreCmd, Destination: "c", Keys: []string{"a", "b"}},
+		} {
+			require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+			requirePropagated(t, replicaConn, cmd)
+			require.NoError(t, replica.ExecuteCommand(masterConn, cmd))
+		}
+
+		masterSet, err := master.SMembers("c")
+		require.NoError(t, err)
+		replicaSet, err := replica.SMembers("c")
+		require.NoError(t, err)
+		assert.ElementsMatch(t, masterSet, replicaSet)
+		assert.ElementsMatch(t, []string{"2", "3", "x"}, replicaSet)
+	})
+
+	t.Run("SPOP should be propagated as an SREM of the members that it popped", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"s": {data: setOf("1", "2", "3")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.SPop{Key: "s", Count: 5, WithCount: true}))
+		requirePropagated(t, replicaConn, command.SRem{Key: "s", Members: []string{"1", "2", "3"}})
+	})
+
+	t.Run("commands that don't change the store should not be propagated", func(t *testing.T) {
+		master, _ := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1")}, "h": {data: hashOf("a", "1")}, "s": {data: setOf("1")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 20)
+
+		for _, cmd := range []command.Command{
+			command.Get{Payload: "a"},
+			command.Exists{Keys: []string{"a"}},
+			command.Type{Key: "a"},
+			command.Del{Keys: []string{"missing"}},
+			command.Unlink{Keys: []string{"missing"}},
+			command.RenameNX{Key: "a", NewKey: "a"},
+			command.Copy{Source: "missing", Destination: "b"},
+			command.Push{Cmd: command.LPushXCmd, Key: "missing", Values: [][]byte{[]byte("1")}},
+			command.Pop{Cmd: command.LPopCmd, Key: "missing", Count: 1},
+			command.LRem{Key: "missing", Count: 0, Value: []byte("1")},
+			command.LMPop{Cmd: command.LMPopCmd, Keys: []string{"missing"}, End: command.ListLeft, Count: 1},
+			command.HSetNX{Key: "h", Field: "a", Value: []byte("2")},
+			command.HDel{Key: "h", Fields: []string{"missing"}},
+			command.SAdd{Key: "s", Members: []string{"1"}},
+			command.SRem{Key: "s", Members: []string{"missing"}},
+			command.SPop{Key: "missing", Count: 1},
+			command.SMove{Source: "s", Destination: "t", Member: "missing"},
+			command.SCombineStore{Cmd: command.SInterStoreCmd, Destination: "t", Keys: []string{"s", "missing"}},
+		} {
+			require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+		}
+		assert.Equal(t, int64(0), master.replicationOffset)
+	})
+}
+
+func TestExpiryPropagation(t *testing.T) {
+	t.Run("a master should propagate keys that it finds to be expired as DELs", func(t *testing.T) {
+		pastTime := time.Now().Add(-time.Hour)
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1"), expiresAt: &pastTime}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Get{Payload: "a"}))
+		requirePropagated(t, replicaConn, command.Del{Keys: []string{"a"}})
+	})
+
+	t.Run("the master's expiry loop should propagate the keys that it deletes as DELs", func(t *testing.T) {
+		pastTime := time.Now().Add(-time.Hour)
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1"), expiresAt: &pastTime}})
+
+		master.activeExpire()
+		requirePropagated(t, replicaConn, command.Del{Keys: []string{"a"}})
+		assert.Equal(t, 0, master.Size())
+	})
+
+	t.Run("relative and second based expiries should be propagated as a PEXPIREAT", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		for _, cmd := range []command.Expire{
+			{Cmd: command.ExpireCmd, Key: "a", Time: 100},
+			{Cmd: command.PExpireCmd, Key: "a", Time: 200_000},
+			{Cmd: command.ExpireAtCmd, Key: "a", Time: time.Now().Add(time.Hour).Unix(), Options: command.ExpireGT},
+		} {
+			require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+			requirePropagated(t, replicaConn, command.Expire{
+				Cmd:  command.PExpireAtCmd,
+				Key:  "a",
+				Time: master.storeData["a"].expiresAt.UnixMilli(),
+			})
+		}
+	})
+
+	t.Run("SET should be propagated with an absolute expiry and without the options that only affect its reply", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		cmd := command.Set{KeyPayload: "a", ValuePayload: []byte("1"), ExpiryOption: command.ExpiryEX, ExpiryTime: 100, Condition: command.SetIfMissing, Get: true}
+		require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+		requirePropagated(t, replicaConn, command.Set{
+			KeyPayload:   "a",
+			ValuePayload: []byte("1"),
+			ExpiryOption: command.ExpiryPXAT,
+			ExpiryTime:   master.storeData["a"].expiresAt.UnixMilli(),
+		})
+
+		// NX stops this from being stored so it shouldn't be propagated
+		require.NoError(t, master.ExecuteCommand(clientConn, cmd))
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Set{KeyPayload: "a", ValuePayload: []byte("2"), ExpiryOption: command.KeepTTL}))
+		requirePropagated(t, replicaConn, command.Set{KeyPayload: "a", ValuePayload: []byte("2"), ExpiryOption: command.KeepTTL})
+	})
+
+	t.Run("GETEX should be propagated as the change that it made to the key's expiry", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.GetEx{Key: "a", ExpiryOption: command.ExpiryEX, ExpiryTime: 100}))
+		requirePropagated(t, replicaConn, command.Expire{Cmd: command.PExpireAtCmd, Key: "a", Time: master.storeData["a"].expiresAt.UnixMilli()})
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.GetEx{Key: "a", ExpiryOption: command.ExpiryPersist}))
+		requirePropagated(t, replicaConn, command.Persist{Key: "a"})
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.GetDel{Key: "a"}))
+		requirePropagated(t, replicaConn, command.Del{Keys: []string{"a"}})
+	})
+
+	t.Run("hash field expiries should be propagated as an HPEXPIREAT, or as an HDEL if they have passed", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"h": {data: hashOf("a", "1", "b", "2", "c", "3")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.HExpire{Cmd: command.HExpireCmd, Key: "h", Time: 100, Fields: []string{"a", "missing", "b"}}))
+		hash := master.storeData["h"].data.(*hashValue)
+		requirePropagated(t, replicaConn, command.HExpire{
+			Cmd:    command.HPExpireAtCmd,
+			Key:    "h",
+			Time:   hash.fieldExpiry("a").UnixMilli(),
+			Fields: []string{"a", "b"},
+		})
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.HIncrByFloat{Key: "h", Field: "a", Increment: 1}))
+		requirePropagated(t, replicaConn, command.HSet{Key: "h", Pairs: []command.FieldValue{{Field: "a", Value: []byte("2")}}})
+		requirePropagated(t, replicaConn, command.HExpire{Cmd: command.HPExpireAtCmd, Key: "h", Time: hash.fieldExpiry("a").UnixMilli(), Fields: []string{"a"}})
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.HExpire{Cmd: command.HPExpireCmd, Key: "h", Time: 0, Fields: []string{"c"}}))
+		requirePropagated(t, replicaConn, command.HDel{Key: "h", Fields: []string{"c"}})
+	})
+
+	t.Run("a master should propagate hash fields that it finds to be expired as HDELs", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2", "c", "3")
+		hash.setFieldExpiry("b", time.Now().Add(-time.Second))
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		master, replicaConn := getTestMasterWithReplica(serverStore{"h": {data: hash}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.HGetAll{Cmd: command.HGetAllCmd, Key: "h"}))
+		requirePropagated(t, replicaConn, command.HDel{Key: "h", Fields: []string{"a", "b"}})
+
+		hash.setFieldExpiry("c", time.Now().Add(-time.Second))
+		master.activeExpire()
+		requirePropagated(t, replicaConn, command.HDel{Key: "h", Fields: []string{"c"}})
+		assert.Equal(t, 0, master.Size())
+	})
+
+	t.Run("a replica should apply its master's writes to the expired hash fields that it has kept", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2")
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		hash.setFieldExpiry("b", time.Now().Add(-time.Second))
+		replica := getTestReplicaServer(serverStore{"h": {data: hash}}).(*ReplicaServer)
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+
+		// The master hadn't expired either field when it sent these, so the replica has to end up with the same hash
+		futureTime := time.Now().Add(time.Hour).UnixMilli()
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.HExpire{Cmd: command.HPExpireAtCmd, Key: "h", Time: futureTime, Fields: []string{"a"}}))
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.HIncrBy{Key: "h", Field: "b", Increment: 1}))
+
+		runCommandAndCheckOutputWithServer(t, replica, command.HGet{Key: "h", Field: "a"}, "$1\r\n1\r\n")
+
+		// HINCRBY keeps the field's expiry, so it's still hidden from clients
+		runCommandAndCheckOutputWithServer(t, replica, command.HGet{Key: "h", Field: "b"}, command.NullBulkString)
+		value, _ := hash.get("b", time.Time{})
+		assert.Equal(t, []byte("3"), value)
+	})
+
+	t.Run("a replica should hide expired hash fields until its master deletes them", func(t *testing.T) {
+		hash := hashOf("a", "1", "b", "2")
+		hash.setFieldExpiry("a", time.Now().Add(-time.Second))
+		replica := getTestReplicaServer(serverStore{"h": {data: hash}}).(*ReplicaServer)
+
+		runCommandAndCheckOutputWithServer(t, replica, command.HGet{Key: "h", Field: "a"}, command.NullBulkString)
+		runCommandAndCheckOutputWithServer(t, replica, command.HLen{Key: "h"}, ":1\r\n")
+		assert.Equal(t, 2, hash.storedLen())
+
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.HDel{Key: "h", Fields: []string{"a"}}))
+		assert.Equal(t, 1, hash.storedLen())
+
+		// The key should go once the master deletes the last field, even if it has expired on the replica too
+		hash.setFieldExpiry("b", time.Now().Add(-time.Second))
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.HDel{Key: "h", Fields: []string{"b"}}))
+		assert.Equal(t, 0, replica.Size())
+	})
+
+	t.Run("an expiry in the past should be propagated as a DEL", func(t *testing.T) {
+		master, replicaConn := getTestMasterWithReplica(serverStore{"a": {data: stringValue("1")}})
+		clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+		require.NoError(t, master.ExecuteCommand(clientConn, command.Expire{Cmd: command.PExpireCmd, Key: "a", Time: -1}))
+		requirePropagated(t, replicaConn, command.Del{Keys: []string{"a"}})
+	})
+
+	t.Run("a replica should keep a key whose propagated expiry has passed until its master deletes it", func(t *testing.T) {
+		replica := getTestReplicaServer(serverStore{"a": {data: stringValue("1")}}).(*ReplicaServer)
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+
+		pastTime := time.Now().Add(-time.Second).UnixMilli()
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.Expire{Cmd: command.PExpireAtCmd, Key: "a", Time: pastTime}))
+		assert.Equal(t, 1, replica.Size())
+		assert.Equal(t, 0, replica.Exists("a"))
+	})
+
+	t.Run("a replica should apply its master's writes to the expired keys that it has kept", func(t *testing.T) {
+		pastTime := time.Now().Add(-time.Second)
+		replica := getTestReplicaServer(serverStore{
+			"a": {data: stringValue("v"), expiresAt: &pastTime},
+			"b": {data: stringValue("v"), expiresAt: &pastTime},
+		}).(*ReplicaServer)
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+
+		// The master hadn't expired either key when it sent these, so the replica has to end up with the same values
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.Append{Key: "a", Value: []byte("x")}))
+		futureTime := time.Now().Add(time.Hour).UnixMilli()
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.Expire{Cmd: command.PExpireAtCmd, Key: "b", Time: futureTime}))
+
+		runCommandAndCheckOutputWithServer(t, replica, command.Get{Payload: "b"}, "$1\r\nv\r\n")
+		assert.Equal(t, []byte("vx"), []byte(replica.storeData["a"].data.(stringValue)))
+	})
+
+	t.Run("a replica should hide expired keys until its master deletes them", func(t *testing.T) {
+		pastTime := time.Now().Add(-time.Hour)
+		replica := getTestReplicaServer(serverStore{"a": {data: stringValue("1"), expiresAt: &pastTime}}).(*ReplicaServer)
+
+		runCommandAndCheckOutputWithServer(t, replica, command.Get{Payload: "a"}, command.NullBulkString)
+		assert.Equal(t, 1, replica.Size())
+
+		masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+		require.NoError(t, replica.ExecuteCommand(masterConn, command.Del{Keys: []string{"a"}}))
+		assert.Equal(t, 0, replica.Size())
+	})
+}
@@ -5,12 +5,15 @@ import (
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
+	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	"github.com/codecrafters-io/redis-starter-go/app/connection"
 	"github.com/codecrafters-io/redis-starter-go/app/log"
+	"github.com/codecrafters-io/redis-starter-go/app/rdb"
 )
 
 type ReplicaServer struct {
@@ -20,14 +23,20 @@ type ReplicaServer struct {
 	// to the master of this replica's replica set
 	masterAddress string
 
+	// The current connection to the master. This is only used by the goroutine that maintains the connection
 	masterConnection connection.Connection
 
+	// The health of the connection to the master
+	masterLink *masterLink
+
 	steadyState bool
 
-	shouldIgnoreMaster bool
+	// The ID of the replication history that this replica is following, received from the master during PSYNC
+	masterReplicationID string
 
-	// The total number of bytes of commands that this replica has processed. Because this is only updated
-	// in the event loop, there's no need to lock this/use a sync value
+	// The replication offset of this replica. This starts at the offset sent by the master during PSYNC
+	// and grows by the total number of bytes of commands that this replica has processed from the master.
+	// Because this is only updated in the event loop, there's no need to lock this/use a sync value
 	bytesProcessed int64
 }
 
@@ -37,9 +46,13 @@ func NewReplicaServer(logger log.Logger, masterAddress string, opts ServerOption
 		return ReplicaServer{}, fmt.Errorf("error initializing replica server: %w", err)
 	}
 
+	// Replicas only delete expired keys once the master tells them to
+	baseServer.keepsExpiredKeys = true
+
 	return ReplicaServer{
 		BaseServer:    baseServer,
 		masterAddress: masterAddress,
+		masterLink:    newMasterLink(),
 	}, nil
 }
 
@@ -48,69 +61,190 @@ func (s *ReplicaServer) NodeType() NodeType {
 }
 
 func (s *ReplicaServer) Run(ctx context.Context) error {
-	conn, err := net.Dial("tcp", s.masterAddress)
+	// Start the connection handler for the replica before we sync with the master so that we can accept
+	// connections. We will not read requests from these connections until we're in steady state. Replicas don't
+	// run the expiry loop since they wait for the master to delete expired keys
+	s.runLoops(ctx, s.ExecuteCommand, nil)
+	go s.replicationLoop(ctx)
+
+	return nil
+}
+
+// replicationLoop keeps the replica connected to its master. Whenever the link drops, the replica goes back
+// through the handshake with an exponential backoff between failed attempts
+func (s *ReplicaServer) replicationLoop(ctx context.Context) {
+	backoff := minReconnectBackoff
+	for {
+		err := s.syncWithMaster(ctx)
+		if err == nil {
+			backoff = minReconnectBackoff
+			s.masterLink.setUp()
+			s.SetIsSteadyState(true)
+
+			// This only returns once the connection to the master has been lost
+			s.clientHandler(ctx, s.masterConnection)
+			s.masterLink.setDown()
+			s.logger.Error("lost connection to master", zap.String("masterAddress", s.masterAddress))
+		} else {
+			if s.masterConnection != nil {
+				s.masterConnection.Close()
+			}
+			s.logger.Error(
+				"failed to sync with master",
+				zap.String("masterAddress", s.masterAddress),
+				zap.Duration("retryIn", backoff),
+				zap.Error(err),
+			)
+
+			select {
+			case <-ctx.Done():
+			case <-time.After(backoff):
+			}
+			backoff = nextReconnectBackoff(backoff)
+		}
+
+		if ctx.Err() != nil {
+			s.logger.Error("replication loop exiting", zap.Error(ctx.Err()))
+			return
+		}
+	}
+}
+
+// syncWithMaster connects to the master and goes through the replication handshake. If this replica has already
+// followed the master before, it asks to continue from its current offset and only falls back to loading a full
+// copy of the master's dataset if the master can't serve the missing part of the replication stream
+func (s *ReplicaServer) syncWithMaster(ctx context.Context) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.masterAddress)
 	if err != nil {
-		return fmt.Errorf("failed to dial master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("failed to dial master at address %q: %w", s.masterAddress, err)
 	}
 	s.masterConnection = connection.NewNetworkConn(conn, connection.MasterConnection, s.logger)
 
-	// 0. Start the connection handler for the replica before we sync with the master
-	// so that we can accept connections. We will not read requests from these connections until we're in steady state
-	go s.ExpiryLoop(ctx)
-	go s.ConnectionHandler(ctx)
-	go EventLoop(
-		ctx,
-		s.logger,
-		s.eventQueue,
-		func(conn connection.Connection, cmd command.Command) error {
-			return s.ExecuteCommand(conn, cmd)
-		},
-	)
+	// Reads from the master don't watch the context, so close the connection to stop the handshake if the
+	// replica stops following this master part way through it
+	stopClosingConn := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stopClosingConn()
 
 	// 1. The replica sends a ping to it's master
 	res, err := s.SendCommandToMaster(ctx, &command.Ping{})
 	if err != nil {
-		return fmt.Errorf("failed to PING master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("failed to PING master at address %q: %w", s.masterAddress, err)
 	}
 	if res != "+PONG\r\n" {
-		return fmt.Errorf("unexpected response to PING to master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("unexpected response to PING to master at address %q: %q", s.masterAddress, res)
 	}
 
 	// 2. The replica sends it's port as a REPLCONF
 	res, err = s.SendCommandToMaster(ctx, &command.ReplConf{Payload: []string{"listening-port", strconv.Itoa(s.listenerPort)}})
 	if err != nil {
-		return fmt.Errorf("failed to send first REPLCONF to master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("failed to send first REPLCONF to master at address %q: %w", s.masterAddress, err)
 	}
 	if res != command.OKString {
-		return fmt.Errorf("unexpected response to first REPLCONF to master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("unexpected response to first REPLCONF to master at address %q: %q", s.masterAddress, res)
 	}
 
 	// 3. The replica sends it's capabilities
 	res, err = s.SendCommandToMaster(ctx, &command.ReplConf{Payload: []string{"capa", "psync2"}})
 	if err != nil {
-		return fmt.Errorf("failed to send first REPLCONF to master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("failed to send second REPLCONF to master at address %q: %w", s.masterAddress, err)
 	}
 	if res != command.OKString {
-		return fmt.Errorf("unexpected response to first REPLCONF to master at address %q: %s", s.masterAddress, err)
+		return fmt.Errorf("unexpected response to second REPLCONF to master at address %q: %q", s.masterAddress, res)
 	}
 
-	// 4a. The replica sends a PSYNC to master to get a replicationID
-	res, err = s.SendCommandToMaster(ctx, &command.PSync{ReplicationID: "?", MasterOffset: "-1"})
+	// 4. The replica sends a PSYNC to master, asking to continue from where it left off if it can
+	res, err = s.SendCommandToMaster(ctx, s.psyncCommand())
 	if err != nil {
 		return fmt.Errorf("failed to send PSYNC message to master: %w", err)
 	}
 	s.Logger().Info("received response to PSYNC command", zap.String("response", res))
 
-	// 4b. Read off the RDB file
+	if strings.HasPrefix(res, "+CONTINUE") {
+		// 5a. The master will send the part of the replication stream that we missed as normal commands
+		replicationID, err := parseContinue(res)
+		if err != nil {
+			return fmt.Errorf("unexpected response to PSYNC message: %w", err)
+		}
+
+		return s.runOnEventLoopAndWait(ctx, func() {
+			if ctx.Err() != nil {
+				return
+			}
+			if replicationID != "" {
+				s.masterReplicationID = replicationID
+			}
+			s.logger.Info("partially resynced with master", zap.Int64("offset", s.bytesProcessed))
+		})
+	}
+
+	replicationID, offset, err := parseFullResync(res)
+	if err != nil {
+		return fmt.Errorf("unexpected response to PSYNC message: %w", err)
+	}
+
+	// 5b. Read off the RDB file and replace our dataset with it
 	res, err = s.masterConnection.ReadRDBFile()
 	if err != nil {
 		return fmt.Errorf("failed to read off RDB file after sending PSYNC message: %w", err)
 	}
-	s.Logger().Info("received RDB data", zap.String("data", res))
 
-	// 5. Start up client handler for the master conn and set the replica to steady state
-	go s.clientHandler(ctx, s.masterConnection)
-	s.SetIsSteadyState(true)
+	var loadErr error
+	err = s.runOnEventLoopAndWait(ctx, func() {
+		// The replica may have stopped following this master while the RDB file was being read
+		if loadErr = ctx.Err(); loadErr != nil {
+			return
+		}
+		if loadErr = s.loadRDBPayload(res); loadErr != nil {
+			return
+		}
+		s.masterReplicationID = replicationID
+		s.bytesProcessed = offset
+		s.Logger().Info("loaded RDB data from master", zap.Int("keys", s.Size()))
+	})
+	if err != nil {
+		return err
+	}
+	if loadErr != nil {
+		return fmt.Errorf("failed to load RDB file sent by master: %w", loadErr)
+	}
+
+	return nil
+}
+
+// psyncCommand builds the PSYNC command for the handshake. A replica that hasn't followed a master yet asks for a
+// full resync, otherwise it asks for the stream starting at the first byte that it hasn't processed
+func (s *ReplicaServer) psyncCommand() *command.PSync {
+	if s.masterReplicationID == "" {
+		return &command.PSync{ReplicationID: "?", MasterOffset: "-1"}
+	}
+
+	return &command.PSync{
+		ReplicationID: s.masterReplicationID,
+		MasterOffset:  strconv.FormatInt(s.bytesProcessed+1, 10),
+	}
+}
+
+// loadRDBPayload decodes an RDB file sent by the master in the form `$<length>\r\n<contents>` and loads it into the store
+func (s *ReplicaServer) loadRDBPayload(payload string) error {
+	lengthStr, data, found := strings.Cut(payload, command.Delimeter)
+	if !found {
+		return fmt.Errorf("RDB payload is missing a length prefix: %q", payload)
+	}
+
+	length, err := command.ParseIntWithPrefix(lengthStr, "$")
+	if err != nil {
+		return fmt.Errorf("failed to parse RDB payload length: %w", err)
+	}
+	if length != int64(len(data)) {
+		return fmt.Errorf("expected RDB payload of length %d but got %d bytes", length, len(data))
+	}
+
+	snapshot, err := rdb.Decode([]byte(data))
+	if err != nil {
+		return err
+	}
+	s.loadSnapshot(snapshot)
 
 	return nil
 }
@@ -118,18 +252,24 @@ func (s *ReplicaServer) Run(ctx context.Context) error {
 func (s *ReplicaServer) ExecuteCommand(conn connection.Connection, cmd command.Command) error {
 	s.Logger().Info(fmt.Sprintf("replica executing command: %v", cmd))
 
+	s.applyingMasterStream = conn.ConnectionType() == connection.MasterConnection
 	err := RunCommand(s, conn, cmd)
-	if err != nil {
-		return fmt.Errorf("error executing command: %w", err)
+	s.applyingMasterStream = false
+
+	// Replicas never propagate anything, so drop whatever the command added to the replication stream
+	s.propagation.take()
+	s.propagation.takeRewritten()
+
+	// Only the commands in the master's replication stream count towards the replication offset. They count even if
+	// they fail, since the master's offset includes them either way
+	if conn.ConnectionType() == connection.MasterConnection {
+		s.masterLink.recordIO()
+		s.bytesProcessed += int64(conn.ClientState().CommandSize)
 	}
 
-	// TODO: I'm doing a lot of decoding/recoding for this command. Should cache this in the command itself
-	encodedCmd, err := cmd.EncodedCommand()
 	if err != nil {
-		return fmt.Errorf("failed to generate encoded command to update bytes processed in replica: %w", err)
+		return fmt.Errorf("error executing command: %w", err)
 	}
-	s.bytesProcessed += int64(len(encodedCmd))
-
 	return nil
 }
 
@@ -167,13 +307,9 @@ func (s *ReplicaServer) SetIsSteadyState(steadyState bool) {
 	s.steadyState = steadyState
 }
 
+// ShouldRespondToCommand is false for the commands propagated by the master, since the master only expects a reply
+// to REPLCONF GETACK
 func (s *ReplicaServer) ShouldRespondToCommand(conn connection.Connection, cmd command.Command) bool {
-	return !s.shouldIgnoreMaster ||
-		conn.ConnectionType() != connection.MasterConnection ||
+	return conn.ConnectionType() != connection.MasterConnection ||
 		cmd.CommandType() == command.ReplConfCmd
-
-}
-
-func (s *ReplicaServer) SetShouldIgnoreMaster(shouldIgnoreMaster bool) {
-	s.shouldIgnoreMaster = shouldIgnoreMaster
 }
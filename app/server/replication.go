@@ -0,0 +1,139 @@
+package server
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// The number of characters in a replication ID
+	replicationIDLength = 40
+
+	DEFAULT_REPL_BACKLOG_SIZE = 1024 * 1024
+)
+
+// newReplicationID generates a random replication ID that identifies a master's replication history
+func newReplicationID() (string, error) {
+	idBytes := make([]byte, replicationIDLength/2)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate replication ID: %w", err)
+	}
+	return hex.EncodeToString(idBytes), nil
+}
+
+// parseFullResync parses a `+FULLRESYNC <replid> <offset>` response to a PSYNC command
+func parseFullResync(res string) (string, int64, error) {
+	fields := strings.Fields(strings.TrimSuffix(res, "\r\n"))
+	if len(fields) != 3 || fields[0] != "+FULLRESYNC" {
+		return "", 0, fmt.Errorf("expected a FULLRESYNC response but got %q", res)
+	}
+
+	if len(fields[1]) != replicationIDLength {
+		return "", 0, fmt.Errorf("received invalid replication ID %q", fields[1])
+	}
+
+	offset, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to parse replication offset %q: %w", fields[2], err)
+	}
+
+	return fields[1], offset, nil
+}
+
+// parseContinue parses the `+CONTINUE [replid]` response to a PSYNC command. Older masters don't send their
+// replication ID, in which case the returned ID is empty
+func parseContinue(res string) (string, error) {
+	fields := strings.Fields(strings.TrimSuffix(res, "\r\n"))
+	if len(fields) == 0 || len(fields) > 2 || fields[0] != "+CONTINUE" {
+		return "", fmt.Errorf("expected a CONTINUE response but got %q", res)
+	}
+
+	if len(fields) == 1 {
+		return "", nil
+	}
+	if len(fields[1]) != replicationIDLength {
+		return "", fmt.Errorf("received invalid replication ID %q", fields[1])
+	}
+	return fields[1], nil
+}
+
+// replicationBacklog is a fixed size circular buffer holding the most recent bytes of the replication stream so
+// that a replica that briefly loses its connection can catch up without needing a full resync.
+//
+// Offsets follow the same convention as PSYNC, where an offset refers to a single byte of the stream, and the first
+// byte ever propagated by a master has offset 1
+type replicationBacklog struct {
+	buffer []byte
+
+	// The index in the buffer that the next byte will be written to
+	writeIdx int
+
+	// The number of bytes of history currently held in the buffer
+	histLen int
+
+	// The replication offset of the oldest byte held in the buffer
+	firstByteOffset int64
+}
+
+// newReplicationBacklog creates an empty backlog whose first byte will be the byte after masterOffset
+func newReplicationBacklog(size int, masterOffset int64) *replicationBacklog {
+	return &replicationBacklog{
+		buffer:          make([]byte, size),
+		firstByteOffset: masterOffset + 1,
+	}
+}
+
+// write appends data to the backlog, overwriting the oldest bytes if the backlog is full
+func (b *replicationBacklog) write(data []byte) {
+	size := len(b.buffer)
+	if size == 0 {
+		b.firstByteOffset += int64(len(data))
+		return
+	}
+
+	// If the data is larger than the backlog, only its tail can be kept
+	if len(data) > size {
+		b.firstByteOffset += int64(b.histLen + len(data) - size)
+		data = data[len(data)-size:]
+		b.histLen = 0
+		b.writeIdx = 0
+	}
+
+	for len(data) > 0 {
+		written := copy(b.buffer[b.writeIdx:], data)
+		data = data[written:]
+		b.writeIdx = (b.writeIdx + written) % size
+		b.histLen += written
+	}
+
+	if b.histLen > size {
+		b.firstByteOffset += int64(b.histLen - size)
+		b.histLen = size
+	}
+}
+
+// readFrom returns all of the bytes in the backlog starting at the provided offset. The returned bool is false
+// if the backlog no longer (or does not yet) hold the byte at that offset
+func (b *replicationBacklog) readFrom(offset int64) ([]byte, bool) {
+	if offset < b.firstByteOffset || offset > b.firstByteOffset+int64(b.histLen) {
+		return nil, false
+	}
+
+	skip := int(offset - b.firstByteOffset)
+	length := b.histLen - skip
+
+	// The oldest byte in the buffer is histLen bytes behind the write index
+	start := (b.writeIdx - b.histLen + skip + len(b.buffer)) % max(len(b.buffer), 1)
+
+	res := make([]byte, 0, length)
+	for len(res) < length {
+		end := min(start+length-len(res), len(b.buffer))
+		res = append(res, b.buffer[start:end]...)
+		start = 0
+	}
+
+	return res, true
+}
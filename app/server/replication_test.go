@@ -0,0 +1,176 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+func TestNewReplicationID(t *testing.T) {
+	firstID, err := newReplicationID()
+	assert.NoError(t, err)
+	assert.Len(t, firstID, replicationIDLength)
+
+	secondID, err := newReplicationID()
+	assert.NoError(t, err)
+	assert.NotEqual(t, firstID, secondID)
+}
+
+func TestParseFullResync(t *testing.T) {
+	replicationID, offset, err := parseFullResync("+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb 1234\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb", replicationID)
+	assert.Equal(t, int64(1234), offset)
+
+	for _, res := range []string{
+		"+OK\r\n",
+		"+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\r\n",
+		"+FULLRESYNC abc 0\r\n",
+		"+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb abc\r\n",
+	} {
+		t.Run(fmt.Sprintf("should fail to parse %q", res), func(t *testing.T) {
+			_, _, err := parseFullResync(res)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestParseContinue(t *testing.T) {
+	replicationID, err := parseContinue("+CONTINUE 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb\r\n")
+	assert.NoError(t, err)
+	assert.Equal(t, "8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb", replicationID)
+
+	replicationID, err = parseContinue("+CONTINUE\r\n")
+	assert.NoError(t, err)
+	assert.Empty(t, replicationID)
+
+	for _, res := range []string{
+		"+OK\r\n",
+		"+CONTINUE abc\r\n",
+		"+FULLRESYNC 8371b4fb1155b71f4a04d3e1bc3e18c4a990aeeb 0\r\n",
+	} {
+		t.Run(fmt.Sprintf("should fail to parse %q", res), func(t *testing.T) {
+			_, err := parseContinue(res)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestReplicaPSyncCommand(t *testing.T) {
+	replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+	assert.Equal(t, &command.PSync{ReplicationID: "?", MasterOffset: "-1"}, replica.psyncCommand())
+
+	replica.masterReplicationID = testReplicationID
+	replica.bytesProcessed = 100
+	assert.Equal(t, &command.PSync{ReplicationID: testReplicationID, MasterOffset: "101"}, replica.psyncCommand())
+}
+
+func TestMasterLink(t *testing.T) {
+	link := newMasterLink()
+	assert.Equal(
+		t,
+		map[string]string{
+			"master_link_status":             "down",
+			"master_last_io_seconds_ago":     "-1",
+			"master_link_down_since_seconds": "-1",
+		},
+		link.info(),
+	)
+
+	link.setUp()
+	assert.Equal(
+		t,
+		map[string]string{
+			"master_link_status":         "up",
+			"master_last_io_seconds_ago": "0",
+		},
+		link.info(),
+	)
+
+	link.setDown()
+	assert.Equal(
+		t,
+		map[string]string{
+			"master_link_status":             "down",
+			"master_last_io_seconds_ago":     "-1",
+			"master_link_down_since_seconds": "0",
+		},
+		link.info(),
+	)
+
+	assert.Equal(t, 200*time.Millisecond, nextReconnectBackoff(minReconnectBackoff))
+	assert.Equal(t, maxReconnectBackoff, nextReconnectBackoff(maxReconnectBackoff))
+}
+
+func TestReplicationBacklog(t *testing.T) {
+	t.Run("reads should return everything written after the requested offset", func(t *testing.T) {
+		backlog := newReplicationBacklog(10, 100)
+		backlog.write([]byte("abc"))
+		backlog.write([]byte("def"))
+
+		for offset, expected := range map[int64]string{101: "abcdef", 103: "cdef", 107: ""} {
+			res, ok := backlog.readFrom(offset)
+			assert.True(t, ok)
+			assert.Equal(t, expected, string(res))
+		}
+
+		for _, offset := range []int64{100, 108} {
+			_, ok := backlog.readFrom(offset)
+			assert.False(t, ok)
+		}
+	})
+
+	t.Run("writes past the size of the backlog should overwrite the oldest data", func(t *testing.T) {
+		backlog := newReplicationBacklog(5, 0)
+		backlog.write([]byte("abcd"))
+		backlog.write([]byte("efg"))
+
+		assert.Equal(t, int64(3), backlog.firstByteOffset)
+		assert.Equal(t, 5, backlog.histLen)
+
+		res, ok := backlog.readFrom(3)
+		assert.True(t, ok)
+		assert.Equal(t, "cdefg", string(res))
+
+		res, ok = backlog.readFrom(6)
+		assert.True(t, ok)
+		assert.Equal(t, "fg", string(res))
+
+		_, ok = backlog.readFrom(2)
+		assert.False(t, ok)
+	})
+
+	t.Run("a single write larger than the backlog should only keep its tail", func(t *testing.T) {
+		backlog := newReplicationBacklog(4, 0)
+		backlog.write([]byte("ab"))
+		backlog.write([]byte("cdefghij"))
+
+		assert.Equal(t, int64(7), backlog.firstByteOffset)
+		res, ok := backlog.readFrom(7)
+		assert.True(t, ok)
+		assert.Equal(t, "ghij", string(res))
+	})
+}
+
+func TestReplicaOffsetTracking(t *testing.T) {
+	replica := getTestReplicaServer(serverStore{}).(*ReplicaServer)
+	masterConn := connection.NewChannelConnWithBuffer(connection.MasterConnection, 10)
+	clientConn := connection.NewChannelConnWithBuffer(connection.ClientConnection, 10)
+
+	// The offset should use the size of the command as it was sent, even if it would be encoded differently
+	handleCommandEvent(replica.Logger(), Event{Args: []any{"SET", "a", "b"}, Size: 100, Conn: masterConn}, replica.ExecuteCommand)
+	assert.Equal(t, int64(100), replica.bytesProcessed)
+
+	// Commands from anything other than the master don't count towards the offset
+	handleCommandEvent(replica.Logger(), Event{Args: []any{"GET", "a"}, Size: 50, Conn: clientConn}, replica.ExecuteCommand)
+	assert.Equal(t, int64(100), replica.bytesProcessed)
+
+	// Commands from the master count even if they fail on the replica, since they're part of the master's offset
+	handleCommandEvent(replica.Logger(), Event{Args: []any{"LPUSH", "a", "b"}, Size: 30, Conn: masterConn}, replica.ExecuteCommand)
+	assert.Equal(t, int64(130), replica.bytesProcessed)
+}
@@ -0,0 +1,56 @@
+package server
+
+import (
+	"hash/fnv"
+	"math"
+	"slices"
+)
+
+// scanPage returns the entries whose name hashes to at least cursor, up to count of them in the order of their
+// hash, along with the cursor of the next page. Entries whose names hash to the same value are never split across
+// pages. It's shared by the SCAN-like commands, which provide the name of each of their entries, such as a hash's
+// field or a set's member
+func scanPage[T any](entries []T, name func(T) string, cursor uint64, count int64) ([]T, uint64) {
+	type hashedEntry struct {
+		hash  uint64
+		entry T
+	}
+
+	remaining := make([]hashedEntry, 0, len(entries))
+	for _, entry := range entries {
+		if nameHash := scanHash(name(entry)); nameHash >= cursor {
+			remaining = append(remaining, hashedEntry{hash: nameHash, entry: entry})
+		}
+	}
+	slices.SortFunc(remaining, func(a hashedEntry, b hashedEntry) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return 0
+	})
+
+	end := min(int(count), len(remaining))
+	for end > 0 && end < len(remaining) && remaining[end].hash == remaining[end-1].hash {
+		end++
+	}
+
+	page := make([]T, 0, end)
+	for _, hashed := range remaining[:end] {
+		page = append(page, hashed.entry)
+	}
+
+	if end == len(remaining) || remaining[end-1].hash == math.MaxUint64 {
+		return page, 0
+	}
+	return page, remaining[end-1].hash + 1
+}
+
+// scanHash is the hash of an entry's name that decides the order that it's scanned in
+func scanHash(name string) uint64 {
+	hasher := fnv.New64a()
+	hasher.Write([]byte(name))
+	return hasher.Sum64()
+}
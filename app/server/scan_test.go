@@ -0,0 +1,45 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
+
+func TestScanPage(t *testing.T) {
+	entries := make([]command.FieldValue, 0, 100)
+	for i := range 100 {
+		entries = append(entries, command.FieldValue{Field: fmt.Sprint("field", i), Value: []byte("v")})
+	}
+
+	for _, count := range []int64{1, 7, 10, 100, 1000} {
+		var scanned []string
+		cursor := uint64(0)
+		for {
+			page, nextCursor := scanPage(entries, func(entry command.FieldValue) string { return entry.Field }, cursor, count)
+			require.LessOrEqual(t, int64(len(page)), count)
+			for _, entry := range page {
+				scanned = append(scanned, entry.Field)
+			}
+
+			if nextCursor == 0 {
+				break
+			}
+			require.Greater(t, nextCursor, cursor)
+			cursor = nextCursor
+		}
+
+		slices.Sort(scanned)
+		expected := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			expected = append(expected, entry.Field)
+		}
+		slices.Sort(expected)
+		assert.Equal(t, expected, scanned, "unexpected fields scanned with a count of %d", count)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"github.com/codecrafters-io/redis-starter-go/app/command"
 	"github.com/codecrafters-io/redis-starter-go/app/connection"
@@ -23,6 +24,14 @@ const (
 	DEFAULT_PORT = 6379
 )
 
+const (
+	// The version of redis that the server reports to clients
+	redisVersion = "7.4.0"
+
+	// The name of the only user. Without ACLs, the default user can run every command and has no password
+	defaultUsername = "default"
+)
+
 type Server interface {
 	// Run the server
 	Run(ctx context.Context) error
@@ -30,16 +39,220 @@ type Server interface {
 	// ExecuteCommand runs a command on this server
 	ExecuteCommand(conn connection.Connection, command command.Command) error
 
-	// Set sets a key in the server's store
-	Set(key string, value any, expiryTimeMs int64)
+	// Set stores a string value at key in the server's store and returns whether it was stored, along with the
+	// string that was at key before if options.Get is set
+	Set(key string, value []byte, options SetOptions) (bool, []byte, error)
 
 	// Get fetches a value from the server's store and returns a bool
-	// indicating whether or not the key was found
-	Get(key string) (any, bool)
+	// indicating whether or not the key was found. It fails if the key holds something other than a string
+	Get(key string) ([]byte, bool, error)
+
+	// MGet returns the strings stored at each of keys, with nil for keys that don't hold a string
+	MGet(keys ...string) [][]byte
+
+	// MSet stores each of the pairs and returns whether they were stored. If onlyIfNoneExist is set, nothing is
+	// stored when any of the keys exist
+	MSet(pairs []command.KeyValue, onlyIfNoneExist bool) bool
+
+	// IncrBy adds increment to the integer stored at key and returns the result
+	IncrBy(key string, increment int64) (int64, error)
+
+	// IncrByFloat adds increment to the number stored at key and returns the result as it's stored
+	IncrByFloat(key string, increment float64) ([]byte, error)
+
+	// Append adds value to the end of the string stored at key and returns the length of the result
+	Append(key string, value []byte) (int, error)
+
+	// StrLen returns the length of the string stored at key
+	StrLen(key string) (int, error)
+
+	// GetRange returns the part of the string stored at key between the start and end offsets, inclusive
+	GetRange(key string, start int64, end int64) ([]byte, error)
+
+	// SetRange overwrites the string stored at key with value, starting at offset, and returns the length of
+	// the result
+	SetRange(key string, offset int64, value []byte) (int, error)
+
+	// GetDel deletes key and returns the string that was stored at it, along with whether the key existed
+	GetDel(key string) ([]byte, bool, error)
+
+	// GetEx returns the string stored at key, along with whether the key exists. It changes the key's expiry
+	// to expiresAt if it's set, or removes the key's expiry if persist is set
+	GetEx(key string, expiresAt *time.Time, persist bool) ([]byte, bool, error)
+
+	// ListPush adds elements to an end of the list stored at key and returns the length of the list. If
+	// onlyIfExists is set, a missing list isn't created
+	ListPush(key string, end command.ListEnd, elements [][]byte, onlyIfExists bool) (int, error)
+
+	// ListPop removes up to count elements from an end of the list stored at key and returns them, along with
+	// whether the list exists
+	ListPop(key string, end command.ListEnd, count int64) ([][]byte, bool, error)
+
+	// LRange returns the elements of the list stored at key between the start and end offsets, inclusive
+	LRange(key string, start int64, end int64) ([][]byte, error)
+
+	// LLen returns the length of the list stored at key
+	LLen(key string) (int, error)
+
+	// LIndex returns the element at index in the list stored at key, along with whether there is one
+	LIndex(key string, index int64) ([]byte, bool, error)
+
+	// LSet replaces the element at index in the list stored at key
+	LSet(key string, index int64, element []byte) error
+
+	// LInsert adds element before or after pivot in the list stored at key and returns the length of the list,
+	// or -1 if pivot wasn't found
+	LInsert(key string, before bool, pivot []byte, element []byte) (int, error)
+
+	// LRem removes up to count elements that are equal to element from the list stored at key and returns how
+	// many were removed
+	LRem(key string, count int64, element []byte) (int, error)
+
+	// LTrim removes the elements of the list stored at key that aren't between the start and end offsets
+	LTrim(key string, start int64, end int64) error
+
+	// LPos returns the indexes of elements that are equal to element in the list stored at key
+	LPos(key string, element []byte, rank int64, count int64, maxLen int64) ([]int, error)
+
+	// LMove moves an element from an end of the list at source to an end of the list at destination and returns
+	// it, along with whether source exists
+	LMove(source string, destination string, from command.ListEnd, to command.ListEnd) ([]byte, bool, error)
+
+	// HSet stores each of the values at its field of the hash stored at key and returns how many of the fields are
+	// new
+	HSet(key string, pairs []command.FieldValue) (int, error)
+
+	// HSetNX stores value at field of the hash stored at key if the field doesn't exist and returns whether it
+	// was stored
+	HSetNX(key string, field string, value []byte) (bool, error)
+
+	// HGet returns the value at field of the hash stored at key, along with whether there is one
+	HGet(key string, field string) ([]byte, bool, error)
+
+	// HMGet returns the values at each of fields of the hash stored at key, with nil for missing fields
+	HMGet(key string, fields ...string) ([][]byte, error)
+
+	// HDel removes fields from the hash stored at key and returns how many of them existed
+	HDel(key string, fields ...string) (int, error)
+
+	// HGetAll returns every field of the hash stored at key along with its value
+	HGetAll(key string) ([]command.FieldValue, error)
+
+	// HLen returns the number of fields in the hash stored at key
+	HLen(key string) (int, error)
+
+	// HIncrBy adds increment to the integer at field of the hash stored at key and returns the result
+	HIncrBy(key string, field string, increment int64) (int64, error)
+
+	// HIncrByFloat adds increment to the number at field of the hash stored at key and returns the result as it's
+	// stored
+	HIncrByFloat(key string, field string, increment float64) ([]byte, error)
+
+	// HRandField returns random fields of the hash stored at key along with their values. A negative count allows
+	// the same field to be returned more than once
+	HRandField(key string, count int64) ([]command.FieldValue, error)
+
+	// HScan returns a page of the fields of the hash stored at key that match a glob pattern, along with the cursor
+	// to continue from
+	HScan(key string, cursor uint64, match string, count int64) (uint64, []command.FieldValue, error)
+
+	// HExpire sets when each of fields of the hash stored at key expires if its current expiry meets the conditions
+	// in options. It returns -2 for each missing field, 0 if the conditions weren't met, 1 if the expiry was set and
+	// 2 if the field was deleted because the expiry has passed
+	HExpire(key string, expiresAt time.Time, options command.ExpireOptions, fields ...string) ([]int, error)
+
+	// HExpiry returns when each of fields of the hash stored at key expires
+	HExpiry(key string, fields ...string) ([]FieldExpiry, error)
+
+	// HPersist removes the expiry from each of fields of the hash stored at key. It returns -2 for each missing
+	// field, -1 if the field has no expiry and 1 if its expiry was removed
+	HPersist(key string, fields ...string) ([]int, error)
+
+	// SAdd adds members to the set stored at key and returns how many of them are new
+	SAdd(key string, members ...string) (int, error)
+
+	// SRem removes members from the set stored at key and returns how many of them were there
+	SRem(key string, members ...string) (int, error)
+
+	// SMembers returns every member of the set stored at key
+	SMembers(key string) ([]string, error)
+
+	// SIsMember returns whether each of members is in the set stored at key
+	SIsMember(key string, members ...string) ([]bool, error)
+
+	// SCard returns the number of members in the set stored at key
+	SCard(key string) (int, error)
+
+	// SPop removes up to count random members from the set stored at key and returns them
+	SPop(key string, count int64) ([]string, error)
+
+	// SRandMember returns random members of the set stored at key. A negative count allows the same member to be
+	// returned more than once
+	SRandMember(key string, count int64) ([]string, error)
+
+	// SMove moves member from the set stored at source to the set stored at destination and returns whether it
+	// was moved
+	SMove(source string, destination string, member string) (bool, error)
+
+	// SetCombine returns the members of the sets stored at keys combined by op
+	SetCombine(op command.SetOperation, keys ...string) ([]string, error)
+
+	// SetCombineStore stores the sets stored at keys combined by op at destination and returns the number of
+	// members in the result
+	SetCombineStore(op command.SetOperation, destination string, keys ...string) (int, error)
+
+	// SInterCard returns the number of members in the intersection of the sets stored at keys, counting no higher
+	// than limit unless it's 0
+	SInterCard(limit int64, keys ...string) (int, error)
+
+	// SScan returns a page of the members of the set stored at key that match a glob pattern, along with the
+	// cursor to continue from
+	SScan(key string, cursor uint64, match string, count int64) (uint64, []string, error)
+
+	// Delete removes keys from the server's store and returns the number of keys that existed
+	Delete(keys ...string) int
+
+	// Unlink removes keys from the server's store like Delete, but frees large values in the background
+	Unlink(keys ...string) int
+
+	// Exists returns the number of the provided keys that are in the server's store
+	Exists(keys ...string) int
+
+	// Type returns the name of the type of the value at key, or "none" if there is no such key
+	Type(key string) string
+
+	// Rename moves the value at key to newKey. If onlyIfNew is set, the value is only moved if newKey
+	// doesn't exist. It returns whether the value was moved
+	Rename(key string, newKey string, onlyIfNew bool) (bool, error)
+
+	// Copy copies the value at source to destination and returns whether it was copied
+	Copy(source string, destination string, replace bool) (bool, error)
+
+	// Expire sets when key expires if its current expiry meets the conditions in options. It returns whether the
+	// expiry was set
+	Expire(key string, expiresAt time.Time, options command.ExpireOptions) bool
+
+	// Persist removes the expiry from key and returns whether it had one
+	Persist(key string) bool
+
+	// Expiry returns when key expires, or nil if it never expires, and whether the key exists
+	Expiry(key string) (*time.Time, bool)
 
 	// Size Returns the number of items in the store
 	Size() int
 
+	// Save synchronously writes the store to the server's RDB file
+	Save() error
+
+	// BackgroundSave writes the store to the server's RDB file without blocking the caller
+	BackgroundSave() error
+
+	// LastSave returns the time of the last successful save
+	LastSave() time.Time
+
+	// PersistenceInfo returns the fields for the persistence section of the INFO command
+	PersistenceInfo() map[string]string
+
 	// Logger returns this server's logger
 	Logger() log.Logger
 
@@ -58,15 +271,64 @@ type BaseServer struct {
 	listener     net.Listener
 	listenerPort int
 
+	// The clients that are waiting on a blocking command
+	blockedClients *blockedClients
+
+	// The clients that are blocked until a list is pushed to, by the keys that they are waiting on
+	listWaiters *listWaiters
+
 	// storeData is a map containing the keys and values held by this store
 	storeData   serverStore
 	storeDataMu *sync.Mutex
 
+	// The sizes past which a hash stops using the compact encoding
+	hashLimits hashLimits
+
+	// The size past which a set of integers stops using the intset encoding
+	setLimits setLimits
+
+	// Whether expired keys are kept in the store until they are explicitly deleted. Replicas keep them so that
+	// only the master decides when a key is deleted
+	keepsExpiredKeys bool
+
+	// Whether the command being executed came from the master's replication stream. A replica treats the expired
+	// keys and hash fields that it has kept as live for these commands, since the master hadn't expired them when it
+	// sent the command
+	applyingMasterStream bool
+
+	// The commands that the store added to the replication stream while executing the current command
+	propagation *pendingPropagation
+
+	// The directory and file name of the RDB file used to persist the store
+	rdbDir      string
+	rdbFilename string
+	persistence *persistenceState
+
+	// The size of the replication backlog to create once this server has replicas
+	replBacklogSize int
+
 	logger log.Logger
 }
 
 type ServerOptions struct {
 	Port *int
+
+	// The directory containing the RDB file
+	Dir *string
+
+	// The name of the RDB file
+	DBFilename *string
+
+	// The size in bytes of the replication backlog kept by a master for partial resynchronization
+	ReplBacklogSize *int
+
+	// The most fields that a hash can hold, and the longest field or value, before it stops using the compact
+	// encoding
+	HashMaxListpackEntries *int
+	HashMaxListpackValue   *int
+
+	// The most members that a set of integers can hold before it stops using the intset encoding
+	SetMaxIntsetEntries *int
 }
 
 func NewBaseServer(logger log.Logger, opts ServerOptions) (BaseServer, error) {
@@ -76,19 +338,64 @@ func NewBaseServer(logger log.Logger, opts ServerOptions) (BaseServer, error) {
 		port = *opts.Port
 	}
 
+	rdbDir := DEFAULT_RDB_DIR
+	if opts.Dir != nil {
+		rdbDir = *opts.Dir
+	}
+
+	rdbFilename := DEFAULT_RDB_FILENAME
+	if opts.DBFilename != nil {
+		rdbFilename = *opts.DBFilename
+	}
+
 	listener, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", port))
 	if err != nil {
 		return BaseServer{}, fmt.Errorf("failed to bind to port %d: %w", port, err)
 	}
 
-	return BaseServer{
-		eventQueue:   make(chan Event, eventQueueSize),
-		listener:     listener,
-		listenerPort: port,
-		logger:       logger,
-		storeData:    make(map[string]storeValue),
-		storeDataMu:  &sync.Mutex{},
-	}, nil
+	replBacklogSize := DEFAULT_REPL_BACKLOG_SIZE
+	if opts.ReplBacklogSize != nil {
+		replBacklogSize = *opts.ReplBacklogSize
+	}
+
+	hashLimits := defaultHashLimits
+	if opts.HashMaxListpackEntries != nil {
+		hashLimits.maxListpackEntries = *opts.HashMaxListpackEntries
+	}
+	if opts.HashMaxListpackValue != nil {
+		hashLimits.maxListpackValue = *opts.HashMaxListpackValue
+	}
+
+	setLimits := defaultSetLimits
+	if opts.SetMaxIntsetEntries != nil {
+		setLimits.maxIntsetEntries = *opts.SetMaxIntsetEntries
+	}
+
+	server := BaseServer{
+		eventQueue:      make(chan Event, eventQueueSize),
+		blockedClients:  newBlockedClients(),
+		listWaiters:     newListWaiters(),
+		listener:        listener,
+		listenerPort:    port,
+		logger:          logger,
+		storeData:       make(map[string]storeValue),
+		storeDataMu:     &sync.Mutex{},
+		hashLimits:      hashLimits,
+		setLimits:       setLimits,
+		propagation:     newPendingPropagation(),
+		rdbDir:          rdbDir,
+		rdbFilename:     rdbFilename,
+		persistence:     newPersistenceState(),
+		replBacklogSize: replBacklogSize,
+	}
+
+	// The store needs to be populated before we start accepting connections
+	if err := server.loadRDBFile(); err != nil {
+		listener.Close()
+		return BaseServer{}, err
+	}
+
+	return server, nil
 }
 
 func (s *BaseServer) Logger() log.Logger {
@@ -0,0 +1,542 @@
+package server
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"strconv"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+)
+
+// The most members that a set of integers can hold before it stops using the intset encoding
+const DEFAULT_SET_MAX_INTSET_ENTRIES = 512
+
+// setLimits are the sizes past which a set is converted from the intset encoding to a map
+type setLimits struct {
+	maxIntsetEntries int
+}
+
+var defaultSetLimits = setLimits{maxIntsetEntries: DEFAULT_SET_MAX_INTSET_ENTRIES}
+
+// setValue is an unordered collection of distinct binary safe strings. Like in redis, a set that only holds
+// integers is kept as a sorted slice of them, an intset, which takes far less memory than a map and can still be
+// searched quickly. Once the set gets a member that isn't an integer, or has too many members, it's converted to a
+// map for good
+type setValue struct {
+	// The members of the set in ascending order while it uses the intset encoding
+	intset []int64
+
+	// The members of the set once it has been converted to a map, or nil while it uses the intset encoding
+	table map[string]struct{}
+}
+
+func newSetValue() *setValue {
+	return &setValue{}
+}
+
+func (*setValue) typeName() string {
+	return "set"
+}
+
+func (s *setValue) clone() value {
+	return &setValue{intset: slices.Clone(s.intset), table: maps.Clone(s.table)}
+}
+
+func (s *setValue) freeEffort() int {
+	return s.len()
+}
+
+func (s *setValue) free() {
+	clear(s.table)
+	*s = setValue{}
+}
+
+func (s *setValue) len() int {
+	if s.table == nil {
+		return len(s.intset)
+	}
+	return len(s.table)
+}
+
+func (s *setValue) isIntset() bool {
+	return s.table == nil
+}
+
+func (s *setValue) contains(member string) bool {
+	if s.table != nil {
+		_, ok := s.table[member]
+		return ok
+	}
+
+	value, ok := parseIntsetMember(member)
+	if !ok {
+		return false
+	}
+	_, found := slices.BinarySearch(s.intset, value)
+	return found
+}
+
+// add adds member to the set and returns whether it's new. An intset is converted to a map if member isn't an
+// integer, or if it takes the set past the most members that an intset can hold
+func (s *setValue) add(member string, limits setLimits) bool {
+	if s.table == nil {
+		value, ok := parseIntsetMember(member)
+		if ok {
+			idx, found := slices.BinarySearch(s.intset, value)
+			if found {
+				return false
+			}
+			s.intset = slices.Insert(s.intset, idx, value)
+			if len(s.intset) > limits.maxIntsetEntries {
+				s.convertToTable()
+			}
+			return true
+		}
+		s.convertToTable()
+	}
+
+	if _, exists := s.table[member]; exists {
+		return false
+	}
+	s.table[member] = struct{}{}
+	return true
+}
+
+// remove deletes member from the set and returns whether it was there. A set that has been converted to a map
+// stays a map
+func (s *setValue) remove(member string) bool {
+	if s.table != nil {
+		_, exists := s.table[member]
+		delete(s.table, member)
+		return exists
+	}
+
+	value, ok := parseIntsetMember(member)
+	if !ok {
+		return false
+	}
+	idx, found := slices.BinarySearch(s.intset, value)
+	if !found {
+		return false
+	}
+	s.intset = slices.Delete(s.intset, idx, idx+1)
+	return true
+}
+
+// members returns every member of the set. An intset returns them in ascending order
+func (s *setValue) members() []string {
+	members := make([]string, 0, s.len())
+	if s.table == nil {
+		for _, value := range s.intset {
+			members = append(members, strconv.FormatInt(value, 10))
+		}
+		return members
+	}
+
+	for member := range s.table {
+		members = append(members, member)
+	}
+	return members
+}
+
+func (s *setValue) convertToTable() {
+	s.table = make(map[string]struct{}, len(s.intset))
+	for _, value := range s.intset {
+		s.table[strconv.FormatInt(value, 10)] = struct{}{}
+	}
+	s.intset = nil
+}
+
+// parseIntsetMember returns the integer that member holds, if it's one that an intset can store. Like in redis,
+// only the canonical form of an integer counts, so members such as "+1" or "01" have to be stored as strings
+func parseIntsetMember(member string) (int64, bool) {
+	value, err := strconv.ParseInt(member, 10, 64)
+	if err != nil || strconv.FormatInt(value, 10) != member {
+		return 0, false
+	}
+	return value, true
+}
+
+// setData returns the set that the value holds, or nil for the empty storeValue that lookup returns for a missing
+// key. It fails with a WRONGTYPE error if the value holds another type
+func (v storeValue) setData() (*setValue, error) {
+	if v.data == nil {
+		return nil, nil
+	}
+
+	set, ok := v.data.(*setValue)
+	if !ok {
+		return nil, command.ErrWrongType
+	}
+	return set, nil
+}
+
+// lookupSet returns the set stored at key, or nil if the key doesn't exist. The caller must hold storeDataMu
+func (s *BaseServer) lookupSet(key string) (*setValue, error) {
+	existing, _ := s.lookup(key)
+	return existing.setData()
+}
+
+// ensureSet returns set, or stores a new set at key and returns that if set is nil because the key doesn't exist.
+// The caller must hold storeDataMu
+func (s *BaseServer) ensureSet(key string, set *setValue) *setValue {
+	if set == nil {
+		set = newSetValue()
+		s.storeData[key] = storeValue{data: set}
+	}
+	return set
+}
+
+// SAdd adds members to the set stored at key, creating the set if it doesn't exist, and returns how many of them
+// are new
+func (s *BaseServer) SAdd(key string, members ...string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return 0, err
+	}
+
+	set = s.ensureSet(key, set)
+	added := 0
+	for _, member := range members {
+		if set.add(member, s.setLimits) {
+			added++
+		}
+	}
+
+	if added > 0 {
+		s.persistence.recordChange()
+	}
+	return added, nil
+}
+
+// SRem removes members from the set stored at key and returns how many of them were there. The key is deleted once
+// the set has no members left, since redis never stores an empty set
+func (s *BaseServer) SRem(key string, members ...string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil || set == nil {
+		return 0, err
+	}
+
+	removed := 0
+	for _, member := range members {
+		if set.remove(member) {
+			removed++
+		}
+	}
+
+	if removed > 0 {
+		if set.len() == 0 {
+			delete(s.storeData, key)
+		}
+		s.persistence.recordChange()
+	}
+	return removed, nil
+}
+
+// SMembers returns every member of the set stored at key
+func (s *BaseServer) SMembers(key string) ([]string, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil || set == nil {
+		return []string{}, err
+	}
+	return set.members(), nil
+}
+
+// SIsMember returns whether each of members is in the set stored at key
+func (s *BaseServer) SIsMember(key string, members ...string) ([]bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]bool, len(members))
+	if set == nil {
+		return found, nil
+	}
+	for i, member := range members {
+		found[i] = set.contains(member)
+	}
+	return found, nil
+}
+
+// SCard returns the number of members in the set stored at key, or 0 if the key doesn't exist
+func (s *BaseServer) SCard(key string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil || set == nil {
+		return 0, err
+	}
+	return set.len(), nil
+}
+
+// SPop removes up to count random members from the set stored at key and returns them, deleting the key if that
+// leaves the set empty. Since replicas would pick different members, it's propagated as an SREM of the members
+// that were popped
+func (s *BaseServer) SPop(key string, count int64) ([]string, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil || set == nil || count == 0 {
+		return []string{}, err
+	}
+
+	popped := pickDistinct(set.members(), count)
+	for _, member := range popped {
+		set.remove(member)
+	}
+	if set.len() == 0 {
+		delete(s.storeData, key)
+	}
+	s.persistence.recordChange()
+	s.propagateAs(command.SRem{Key: key, Members: popped})
+
+	return popped, nil
+}
+
+// SRandMember returns random members of the set stored at key. A positive count returns that many distinct
+// members, or every member if the set is smaller, while a negative count returns exactly that many members, which
+// can repeat
+func (s *BaseServer) SRandMember(key string, count int64) ([]string, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil || set == nil {
+		return []string{}, err
+	}
+
+	members := set.members()
+	if count < 0 {
+		var picked []string
+		for range -count {
+			picked = append(picked, members[rand.Intn(len(members))])
+		}
+		return picked, nil
+	}
+	return pickDistinct(members, count), nil
+}
+
+// pickDistinct returns count random members, or every member if there are fewer than that
+func pickDistinct(members []string, count int64) []string {
+	if count >= int64(len(members)) {
+		return members
+	}
+
+	picked := make([]string, 0, count)
+	for _, idx := range rand.Perm(len(members))[:count] {
+		picked = append(picked, members[idx])
+	}
+	return picked
+}
+
+// SMove moves member from the set stored at source to the set stored at destination, creating the destination set
+// if it doesn't exist, and returns whether member was moved. Both keys have to hold sets if they exist, even if
+// member isn't in source
+func (s *BaseServer) SMove(source string, destination string, member string) (bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	sourceSet, err := s.lookupSet(source)
+	if err != nil {
+		return false, err
+	}
+	destinationSet, err := s.lookupSet(destination)
+	if err != nil {
+		return false, err
+	}
+
+	if sourceSet == nil || !sourceSet.contains(member) {
+		return false, nil
+	}
+	if source == destination {
+		return true, nil
+	}
+
+	sourceSet.remove(member)
+	if sourceSet.len() == 0 {
+		delete(s.storeData, source)
+	}
+	s.ensureSet(destination, destinationSet).add(member, s.setLimits)
+	s.persistence.recordChange()
+
+	return true, nil
+}
+
+// SetCombine returns the members of the sets stored at keys combined by op. Keys that don't exist count as empty
+// sets
+func (s *BaseServer) SetCombine(op command.SetOperation, keys ...string) ([]string, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	result, err := s.combineSets(op, keys)
+	if err != nil {
+		return nil, err
+	}
+	return result.members(), nil
+}
+
+// SetCombineStore stores the sets stored at keys combined by op at destination, replacing anything that was there
+// along with its expiry, and returns the number of members in the result. An empty result deletes destination
+func (s *BaseServer) SetCombineStore(op command.SetOperation, destination string, keys ...string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	result, err := s.combineSets(op, keys)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.len() == 0 {
+		if _, ok := s.lookup(destination); ok {
+			delete(s.storeData, destination)
+			s.persistence.recordChange()
+		}
+		return 0, nil
+	}
+
+	s.storeData[destination] = storeValue{data: result}
+	s.persistence.recordChange()
+
+	return result.len(), nil
+}
+
+// SInterCard returns the number of members in the intersection of the sets stored at keys. A limit other than 0
+// stops counting once the count reaches it
+func (s *BaseServer) SInterCard(limit int64, keys ...string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	sets, err := s.lookupSets(keys)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	forEachIntersecting(sets, func(string) bool {
+		count++
+		return limit == 0 || int64(count) < limit
+	})
+	return count, nil
+}
+
+// SScan returns a page of the members of the set stored at key that match the glob pattern in match, along with
+// the cursor to continue from, which is 0 once every member has been returned. An intset is returned in a single
+// page. Otherwise, members are paged through the same way that HScan pages through fields
+func (s *BaseServer) SScan(key string, cursor uint64, match string, count int64) (uint64, []string, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	set, err := s.lookupSet(key)
+	if err != nil || set == nil {
+		return 0, []string{}, err
+	}
+
+	var page []string
+	nextCursor := uint64(0)
+	if set.isIntset() {
+		page = set.members()
+	} else {
+		page, nextCursor = scanPage(set.members(), func(member string) string { return member }, cursor, count)
+	}
+
+	matching := make([]string, 0, len(page))
+	for _, member := range page {
+		if match == "*" || globMatch(match, member) {
+			matching = append(matching, member)
+		}
+	}
+	return nextCursor, matching, nil
+}
+
+// lookupSets returns the sets stored at each of keys, with nil for the keys that don't exist. It fails with a
+// WRONGTYPE error if any of the keys hold another type. The caller must hold storeDataMu
+func (s *BaseServer) lookupSets(keys []string) ([]*setValue, error) {
+	sets := make([]*setValue, 0, len(keys))
+	for _, key := range keys {
+		set, err := s.lookupSet(key)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, set)
+	}
+	return sets, nil
+}
+
+// combineSets returns a new set that holds the sets stored at keys combined by op. The caller must hold
+// storeDataMu
+func (s *BaseServer) combineSets(op command.SetOperation, keys []string) (*setValue, error) {
+	sets, err := s.lookupSets(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	result := newSetValue()
+	switch op {
+	case command.SetIntersection:
+		forEachIntersecting(sets, func(member string) bool {
+			result.add(member, s.setLimits)
+			return true
+		})
+	case command.SetUnion:
+		for _, set := range sets {
+			for _, member := range setMembers(set) {
+				result.add(member, s.setLimits)
+			}
+		}
+	case command.SetDifference:
+		for _, member := range setMembers(sets[0]) {
+			inOthers := slices.ContainsFunc(sets[1:], func(other *setValue) bool {
+				return other != nil && other.contains(member)
+			})
+			if !inOthers {
+				result.add(member, s.setLimits)
+			}
+		}
+	}
+	return result, nil
+}
+
+// forEachIntersecting calls fn with each member that is in every one of sets until fn returns false. A nil set is
+// empty, so nothing intersects with it. The smallest set is the one that is iterated, which keeps the number of
+// lookups down
+func forEachIntersecting(sets []*setValue, fn func(member string) bool) {
+	if slices.Contains(sets, nil) {
+		return
+	}
+
+	smallest := slices.MinFunc(sets, func(a *setValue, b *setValue) int {
+		return a.len() - b.len()
+	})
+	for _, member := range smallest.members() {
+		inAll := !slices.ContainsFunc(sets, func(set *setValue) bool {
+			return !set.contains(member)
+		})
+		if inAll && !fn(member) {
+			return
+		}
+	}
+}
+
+// setMembers returns the members of set, which is empty if the key doesn't exist
+func setMembers(set *setValue) []string {
+	if set == nil {
+		return nil
+	}
+	return set.members()
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// setOf returns a set that holds the provided members
+func setOf(members ...string) *setValue {
+	set := newSetValue()
+	for _, member := range members {
+		set.add(member, defaultSetLimits)
+	}
+	return set
+}
+
+func TestSetValue(t *testing.T) {
+	limits := setLimits{maxIntsetEntries: 3}
+
+	t.Run("a set of integers should stay an intset and keep its members sorted", func(t *testing.T) {
+		set := newSetValue()
+		assert.True(t, set.add("10", limits))
+		assert.True(t, set.add("-5", limits))
+		assert.False(t, set.add("10", limits))
+		assert.True(t, set.add("3", limits))
+
+		assert.True(t, set.isIntset())
+		assert.Equal(t, 3, set.len())
+		assert.Equal(t, []string{"-5", "3", "10"}, set.members())
+		assert.True(t, set.contains("3"))
+		assert.False(t, set.contains("4"))
+		assert.False(t, set.contains("a"))
+	})
+
+	t.Run("a set should be converted to a map once it has a member that isn't a canonical integer", func(t *testing.T) {
+		for _, member := range []string{"a", "01", "+1", "1.0", "99999999999999999999"} {
+			set := setOf("1", "2")
+			assert.True(t, set.add(member, limits))
+			assert.False(t, set.isIntset(), "expected %q to convert the set", member)
+			assert.ElementsMatch(t, []string{"1", "2", member}, set.members())
+			assert.True(t, set.contains("1"))
+		}
+	})
+
+	t.Run("a set should be converted to a map once it has too many members", func(t *testing.T) {
+		set := newSetValue()
+		for _, member := range []string{"1", "2", "3", "4"} {
+			set.add(member, limits)
+		}
+		assert.False(t, set.isIntset())
+		assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, set.members())
+
+		// Removing members shouldn't convert it back
+		assert.True(t, set.remove("1"))
+		assert.False(t, set.remove("1"))
+		assert.False(t, set.isIntset())
+		assert.Equal(t, 3, set.len())
+	})
+
+	t.Run("removing members from an intset should keep the rest sorted", func(t *testing.T) {
+		set := setOf("3", "1", "2")
+		assert.True(t, set.remove("2"))
+		assert.False(t, set.remove("2"))
+		assert.False(t, set.remove("a"))
+		assert.Equal(t, []string{"1", "3"}, set.members())
+	})
+
+	t.Run("a clone should keep the encoding and not share members", func(t *testing.T) {
+		for _, set := range []*setValue{setOf("1", "2"), setOf("a", "b")} {
+			cloned := set.clone().(*setValue)
+			assert.Equal(t, set.isIntset(), cloned.isIntset())
+			assert.ElementsMatch(t, set.members(), cloned.members())
+
+			cloned.add("new", defaultSetLimits)
+			assert.False(t, set.contains("new"))
+		}
+	})
+}
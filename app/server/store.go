@@ -2,55 +2,692 @@ package server
 
 import (
 	"fmt"
+	"math"
+	"slices"
+	"strconv"
 	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
 )
 
 type serverStore map[string]storeValue
 
 type storeValue struct {
-	data      any
+	data      value
 	expiresAt *time.Time
 }
 
+// value is the data that is stored under a key. Each type of value that redis supports has its own implementation
+type value interface {
+	// typeName is the name of the value's type, as reported by the TYPE command
+	typeName() string
+
+	// clone returns a deep copy of the value
+	clone() value
+
+	// freeEffort estimates how much work it takes to free the value, such as the number of elements that it holds
+	freeEffort() int
+
+	// free releases everything that the value holds. The value can't be used afterwards
+	free()
+}
+
+// stringValue is a binary safe string. Like in redis, numbers are stored as strings too
+type stringValue []byte
+
+func (stringValue) typeName() string {
+	return "string"
+}
+
+func (v stringValue) clone() value {
+	return stringValue(slices.Clone(v))
+}
+
+func (stringValue) freeEffort() int {
+	return 1
+}
+
+func (stringValue) free() {}
+
 func (v storeValue) isExpired() bool {
 	return v.expiresAt != nil && v.expiresAt.Before(time.Now())
 }
 
-func (s *BaseServer) Set(key string, value any, expiryTimeMs int64) {
+// stringData returns the string that the value holds, or nil for the empty storeValue that lookup returns for a
+// missing key. It fails with a WRONGTYPE error if the value holds another type
+func (v storeValue) stringData() (stringValue, error) {
+	if v.data == nil {
+		return nil, nil
+	}
+
+	str, ok := v.data.(stringValue)
+	if !ok {
+		return nil, command.ErrWrongType
+	}
+	return str, nil
+}
+
+// SetOptions control when and how Set stores a value
+type SetOptions struct {
+	// When the value expires, or nil if it never expires
+	ExpiresAt *time.Time
+
+	// Keep the expiry of the value that is replaced instead of using ExpiresAt
+	KeepTTL bool
+
+	Condition command.SetCondition
+
+	// Return the string that was stored at the key before. Set fails if the key holds something other than a string
+	Get bool
+}
+
+// Set stores a string value at key and returns whether it was stored. If options.Get is set, the string that was at
+// the key before is returned too, or nil if the key didn't exist. An expiry that has already passed deletes the key
+// straight away, except on a replica. Since replicas apply the change later, it's propagated with an absolute
+// expiry, or as a DEL if the key was deleted
+func (s *BaseServer) Set(key string, value []byte, options SetOptions) (bool, []byte, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, exists := s.lookup(key)
+
+	var previous []byte
+	if options.Get && exists {
+		str, err := existing.stringData()
+		if err != nil {
+			return false, nil, err
+		}
+		previous = append([]byte{}, str...)
+	}
+
+	if (options.Condition == command.SetIfMissing && exists) || (options.Condition == command.SetIfExists && !exists) {
+		return false, previous, nil
+	}
+
+	newValue := storeValue{data: stringValue(value), expiresAt: options.ExpiresAt}
+	if options.KeepTTL {
+		newValue.expiresAt = existing.expiresAt
+	}
+	s.storeData[key] = newValue
+	s.persistence.recordChange()
+
+	propagated := command.Set{KeyPayload: key, ValuePayload: value}
+	switch {
+	case options.KeepTTL:
+		propagated.ExpiryOption = command.KeepTTL
+	case options.ExpiresAt != nil:
+		if s.deletesOnArrival(*options.ExpiresAt) {
+			delete(s.storeData, key)
+			s.propagateAs(command.Del{Keys: []string{key}})
+			return true, previous, nil
+		}
+		propagated.ExpiryOption, propagated.ExpiryTime = command.ExpiryPXAT, options.ExpiresAt.UnixMilli()
+	}
+	s.propagateAs(propagated)
+
+	return true, previous, nil
+}
+
+func (s *BaseServer) Get(key string) ([]byte, bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	value, ok := s.lookup(key)
+	str, err := value.stringData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+	return str, true, nil
+}
+
+// MGet returns the strings stored at each of keys, with nil for the keys that don't exist or hold another type. All
+// of the keys are read under a single lock, so the result is a consistent view of the store
+func (s *BaseServer) MGet(keys ...string) [][]byte {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	values := make([][]byte, len(keys))
+	for i, key := range keys {
+		value, ok := s.lookup(key)
+		if !ok {
+			continue
+		}
+		if str, ok := value.data.(stringValue); ok {
+			values[i] = append([]byte{}, str...)
+		}
+	}
+	return values
+}
+
+// MSet stores each of the pairs without an expiry and returns whether they were stored. If onlyIfNoneExist is set,
+// nothing is stored when any of the keys exist. All of the pairs are stored under a single lock, so no other
+// command sees only some of them
+func (s *BaseServer) MSet(pairs []command.KeyValue, onlyIfNoneExist bool) bool {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	if onlyIfNoneExist {
+		for _, pair := range pairs {
+			if _, ok := s.lookup(pair.Key); ok {
+				return false
+			}
+		}
+	}
+
+	for _, pair := range pairs {
+		s.storeData[pair.Key] = storeValue{data: stringValue(pair.Value)}
+		s.persistence.recordChange()
+	}
+	return true
+}
+
+// IncrBy adds increment to the integer stored at key and returns the result. A missing key counts as 0, and an
+// existing key keeps its expiry
+func (s *BaseServer) IncrBy(key string, increment int64) (int64, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, exists := s.lookup(key)
+
+	str, err := existing.stringData()
+	if err != nil {
+		return 0, err
+	}
+
+	current := int64(0)
+	if exists {
+		// Like redis, only the canonical form of an integer counts, so values such as "+1" or "01" are rejected
+		current, err = strconv.ParseInt(string(str), 10, 64)
+		if err != nil || strconv.FormatInt(current, 10) != string(str) {
+			return 0, command.ErrNotInteger
+		}
+	}
+
+	if (increment > 0 && current > math.MaxInt64-increment) || (increment < 0 && current < math.MinInt64-increment) {
+		return 0, command.ErrOverflow
+	}
+	result := current + increment
+
+	s.storeData[key] = storeValue{data: stringValue(strconv.FormatInt(result, 10)), expiresAt: existing.expiresAt}
+	s.persistence.recordChange()
+
+	return result, nil
+}
+
+// IncrByFloat adds increment to the number stored at key and returns the result as it's stored. A missing key
+// counts as 0, and an existing key keeps its expiry. So that replicas store exactly the same result, it's
+// propagated as a SET
+func (s *BaseServer) IncrByFloat(key string, increment float64) ([]byte, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, exists := s.lookup(key)
+
+	str, err := existing.stringData()
+	if err != nil {
+		return nil, err
+	}
+
+	current := 0.0
+	if exists {
+		var ok bool
+		current, ok = command.ParseFloat(string(str))
+		if !ok {
+			return nil, command.ErrNotFloat
+		}
+	}
+
+	result := current + increment
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return nil, command.ErrorReply("ERR increment would produce NaN or Infinity")
+	}
+
+	// Numbers are stored without an exponent, like redis does for INCRBYFLOAT
+	resultStr := []byte(strconv.FormatFloat(result, 'f', -1, 64))
+	s.storeData[key] = storeValue{data: stringValue(resultStr), expiresAt: existing.expiresAt}
+	s.persistence.recordChange()
+	s.propagateAs(command.Set{KeyPayload: key, ValuePayload: resultStr, ExpiryOption: command.KeepTTL})
+
+	return resultStr, nil
+}
+
+// Append adds value to the end of the string stored at key, creating the key if it doesn't exist, and returns the
+// length of the result
+func (s *BaseServer) Append(key string, value []byte) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, _ := s.lookup(key)
+	str, err := existing.stringData()
+	if err != nil {
+		return 0, err
+	}
+	if len(str)+len(value) > command.MaxBulkLength {
+		return 0, errStringTooLong
+	}
+
+	// The stored string may share its storage with other values, such as the ones loaded from the same RDB file,
+	// so the result is always a new slice rather than one that's grown in place
+	existing.data = slices.Concat(str, value)
+	s.storeData[key] = existing
+	s.persistence.recordChange()
+
+	return len(str) + len(value), nil
+}
+
+// StrLen returns the length of the string stored at key, or 0 if the key doesn't exist
+func (s *BaseServer) StrLen(key string) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, _ := s.lookup(key)
+	str, err := existing.stringData()
+	return len(str), err
+}
+
+// GetRange returns the part of the string stored at key between the start and end offsets, inclusive. Negative
+// offsets count back from the end of the string and offsets past either end are clamped to it, like in redis
+func (s *BaseServer) GetRange(key string, start int64, end int64) ([]byte, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, _ := s.lookup(key)
+	str, err := existing.stringData()
+	if err != nil {
+		return nil, err
+	}
+
+	length := int64(len(str))
+	if start < 0 && end < 0 && start > end {
+		return []byte{}, nil
+	}
+	if start < 0 {
+		start = max(length+start, 0)
+	}
+	if end < 0 {
+		end = max(length+end, 0)
+	}
+	end = min(end, length-1)
+	if start > end || length == 0 {
+		return []byte{}, nil
+	}
+
+	return slices.Clone(str[start : end+1]), nil
+}
+
+// SetRange overwrites the string stored at key with value, starting at offset, and returns the length of the
+// result. The string is padded with zero bytes if it's shorter than offset. An empty value changes nothing, so it
+// doesn't create a missing key either
+func (s *BaseServer) SetRange(key string, offset int64, value []byte) (int, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, _ := s.lookup(key)
+	str, err := existing.stringData()
+	if err != nil {
+		return 0, err
+	}
+	if len(value) == 0 {
+		return len(str), nil
+	}
+	if offset+int64(len(value)) > command.MaxBulkLength {
+		return 0, errStringTooLong
+	}
+
+	if end := int(offset) + len(value); end > len(str) {
+		str = slices.Concat(str, make([]byte, end-len(str)))
+	}
+	copy(str[offset:], value)
+
+	existing.data = str
+	s.storeData[key] = existing
+	s.persistence.recordChange()
+
+	return len(str), nil
+}
+
+var errStringTooLong = command.ErrorReply("ERR string exceeds maximum allowed size (proto-max-bulk-len)")
+
+// GetDel deletes key and returns the string that was stored at it, along with whether the key existed. Nothing is
+// deleted if the key holds another type
+func (s *BaseServer) GetDel(key string) ([]byte, bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	existing, ok := s.lookup(key)
+	str, err := existing.stringData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	delete(s.storeData, key)
+	s.persistence.recordChange()
+
+	// A replica should delete the key even if it has expired there in the meantime
+	s.propagateAs(command.Del{Keys: []string{key}})
+
+	return str, true, nil
+}
+
+// GetEx returns the string stored at key, along with whether the key exists. If expiresAt is set, the key's expiry
+// is changed the same way that Expire changes it, while persist removes the key's expiry
+func (s *BaseServer) GetEx(key string, expiresAt *time.Time, persist bool) ([]byte, bool, error) {
 	s.storeDataMu.Lock()
 	defer s.storeDataMu.Unlock()
 
-	if expiryTimeMs == 0 {
-		s.storeData[key] = storeValue{
-			data: value,
+	existing, ok := s.lookup(key)
+	str, err := existing.stringData()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	switch {
+	case expiresAt != nil:
+		s.setExpiry(key, existing, *expiresAt)
+	case persist && existing.expiresAt != nil:
+		existing.expiresAt = nil
+		s.storeData[key] = existing
+		s.persistence.recordChange()
+		s.propagateAs(command.Persist{Key: key})
+	}
+
+	return str, true, nil
+}
+
+// Delete removes keys from the store and returns how many of them existed
+func (s *BaseServer) Delete(keys ...string) int {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	numDeleted := 0
+	for _, key := range keys {
+		if _, ok := s.lookup(key); ok {
+			s.persistence.recordChange()
+			numDeleted++
 		}
+
+		// On a replica this also removes expired keys, which are only ever deleted by the master's DEL
+		delete(s.storeData, key)
+	}
+
+	return numDeleted
+}
+
+// Unlink removes keys from the store like Delete, but values that are expensive to free are freed in the background
+// so that removing them doesn't hold up the event loop
+func (s *BaseServer) Unlink(keys ...string) int {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	numDeleted := 0
+	var toFree []value
+	for _, key := range keys {
+		if value, ok := s.lookup(key); ok {
+			s.persistence.recordChange()
+			numDeleted++
+			if value.data.freeEffort() > lazyFreeThreshold {
+				toFree = append(toFree, value.data)
+			}
+		}
+		delete(s.storeData, key)
+	}
+
+	if len(toFree) > 0 {
+		go func() {
+			for _, value := range toFree {
+				value.free()
+			}
+		}()
+	}
+
+	return numDeleted
+}
+
+// Exists returns how many of the keys are in the store. A key is counted once for every time that it's provided
+func (s *BaseServer) Exists(keys ...string) int {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	numExisting := 0
+	for _, key := range keys {
+		if _, ok := s.lookup(key); ok {
+			numExisting++
+		}
+	}
+	return numExisting
+}
+
+// Type returns the name of the type of the value stored at key, or "none" if the key doesn't exist
+func (s *BaseServer) Type(key string) string {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	value, ok := s.lookup(key)
+	if !ok {
+		return "none"
+	}
+	return value.data.typeName()
+}
+
+// Rename moves the value at key to newKey, along with its expiry, replacing anything that was at newKey. If
+// onlyIfNew is set, nothing is moved when newKey already exists. It returns whether the value was moved
+func (s *BaseServer) Rename(key string, newKey string, onlyIfNew bool) (bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	value, ok := s.lookup(key)
+	if !ok {
+		return false, command.ErrNoSuchKey
+	}
+
+	if _, ok := s.lookup(newKey); ok && onlyIfNew {
+		return false, nil
+	}
+	if key == newKey {
+		return !onlyIfNew, nil
+	}
+
+	delete(s.storeData, key)
+	s.storeData[newKey] = value
+	s.persistence.recordChange()
+	s.listWaiters.signal(newKey)
+
+	return true, nil
+}
+
+// Copy stores a copy of the value at source, along with its expiry, at destination. Nothing is copied if source
+// doesn't exist, or if destination exists and replace isn't set. It returns whether the value was copied
+func (s *BaseServer) Copy(source string, destination string, replace bool) (bool, error) {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	if source == destination {
+		return false, command.ErrorReply("ERR source and destination objects are the same")
+	}
+
+	value, ok := s.lookup(source)
+	if !ok {
+		return false, nil
+	}
+	if _, ok := s.lookup(destination); ok && !replace {
+		return false, nil
+	}
+
+	copied := storeValue{data: value.data.clone()}
+	if value.expiresAt != nil {
+		expiresAt := *value.expiresAt
+		copied.expiresAt = &expiresAt
+	}
+	s.storeData[destination] = copied
+	s.persistence.recordChange()
+	s.listWaiters.signal(destination)
+
+	return true, nil
+}
+
+// Expire sets when key expires, as long as the key's current expiry meets the conditions in options. An expiry that
+// has already passed deletes the key, except on a replica which keeps it hidden until the master's DEL arrives.
+// Since replicas apply the change later, it's propagated as an absolute PEXPIREAT, or as a DEL if the key was
+// deleted. It returns whether the expiry was set
+func (s *BaseServer) Expire(key string, expiresAt time.Time, options command.ExpireOptions) bool {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	value, ok := s.lookup(key)
+	if !ok || !canReplaceExpiry(value.expiresAt, expiresAt, options) {
+		return false
+	}
+
+	s.setExpiry(key, value, expiresAt)
+	return true
+}
+
+// setExpiry sets when an existing key expires, or deletes it if expiresAt has passed, and rewrites the command
+// that is being executed to the PEXPIREAT or DEL that replicas should apply. The caller must hold storeDataMu
+func (s *BaseServer) setExpiry(key string, value storeValue, expiresAt time.Time) {
+	s.persistence.recordChange()
+	if s.deletesOnArrival(expiresAt) {
+		delete(s.storeData, key)
+		s.propagateAs(command.Del{Keys: []string{key}})
 		return
 	}
 
-	expiryTime := time.Now().Add(time.Duration(expiryTimeMs) * time.Millisecond)
-	s.storeData[key] = storeValue{
-		data:      value,
-		expiresAt: &expiryTime,
+	value.expiresAt = &expiresAt
+	s.storeData[key] = value
+	s.propagateAs(command.Expire{Cmd: command.PExpireAtCmd, Key: key, Time: expiresAt.UnixMilli()})
+}
+
+// canReplaceExpiry is true if expiresAt can replace a key's current expiry under the NX, XX, GT and LT options. The
+// current expiry is nil if the key never expires, which GT and LT treat as an infinitely long expiry
+func canReplaceExpiry(current *time.Time, expiresAt time.Time, options command.ExpireOptions) bool {
+	switch {
+	case options.Has(command.ExpireNX) && current != nil,
+		options.Has(command.ExpireXX) && current == nil,
+		options.Has(command.ExpireGT) && (current == nil || !expiresAt.After(*current)),
+		options.Has(command.ExpireLT) && current != nil && !expiresAt.Before(*current):
+		return false
+	}
+	return true
+}
+
+// Persist removes the expiry from key and returns whether it had one
+func (s *BaseServer) Persist(key string) bool {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	value, ok := s.lookup(key)
+	if !ok || value.expiresAt == nil {
+		return false
 	}
+
+	value.expiresAt = nil
+	s.storeData[key] = value
+	s.persistence.recordChange()
+
+	return true
 }
 
-func (s *BaseServer) Get(key string) (any, bool) {
+// Expiry returns when key expires, or nil if it never expires. The bool is false if the key doesn't exist
+func (s *BaseServer) Expiry(key string) (*time.Time, bool) {
 	s.storeDataMu.Lock()
 	defer s.storeDataMu.Unlock()
 
+	value, ok := s.lookup(key)
+	if !ok || value.expiresAt == nil {
+		return nil, ok
+	}
+
+	expiresAt := *value.expiresAt
+	return &expiresAt, true
+}
+
+// lookup fetches a value from the store, treating expired keys as missing. A master deletes an expired key once it
+// finds it and propagates that as a DEL, while a replica leaves it in place until the master's DEL arrives so that
+// the replica's dataset never drifts from the master's. For the same reason, a replica only hides the keys that it
+// has kept from its own clients, and applies the master's replication stream to them like redis does. Expired hash
+// fields are handled the same way, and a master that deletes the last field of a hash deletes the key too. The
+// caller must hold storeDataMu
+func (s *BaseServer) lookup(key string) (storeValue, bool) {
 	value, ok := s.storeData[key]
 	if !ok {
-		return nil, false
+		return storeValue{}, false
 	}
 
-	// If we find that the key is expired, delete it
-	if value.isExpired() {
-		s.logger.Debug(fmt.Sprintf("found expired key for value %q", key))
-		delete(s.storeData, key)
-		return nil, false
+	if value.isExpired() && !s.applyingMasterStream {
+		if !s.keepsExpiredKeys {
+			s.logger.Debug(fmt.Sprintf("found expired key for value %q", key))
+			s.deleteExpiredKey(key)
+		}
+		return storeValue{}, false
+	}
+
+	if hash, ok := value.data.(*hashValue); ok && hash.hasFieldExpiries() && !s.keepsExpiredKeys {
+		s.deleteExpiredFields(key, hash)
+		if hash.len(time.Now()) == 0 {
+			return storeValue{}, false
+		}
+	}
+
+	return value, true
+}
+
+// expiryTime returns the time that hash fields are checked for expiry against. While a replica applies its master's
+// replication stream, none of the fields that it has kept count as expired. The caller must hold storeDataMu
+func (s *BaseServer) expiryTime() time.Time {
+	if s.applyingMasterStream {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// deletesOnArrival is true if a key or field that is given an expiry that has already passed should be deleted
+// straight away rather than stored. Only a master does, since a replica waits for its master's DEL
+func (s *BaseServer) deletesOnArrival(expiresAt time.Time) bool {
+	return !s.keepsExpiredKeys && !expiresAt.After(time.Now())
+}
+
+// deleteExpiredKey deletes a key that has expired and propagates the deletion. The caller must hold storeDataMu
+func (s *BaseServer) deleteExpiredKey(key string) {
+	delete(s.storeData, key)
+	s.persistence.recordChange()
+	s.alsoPropagate(command.Del{Keys: []string{key}})
+}
+
+// deleteExpiredKeys checks a random sampling of at most `samplesPerExpiry` keys in the store to see if they are
+// expired. Any expired keys that are found are deleted and propagated, as are any expired fields of the hashes that
+// are sampled
+func (s *BaseServer) deleteExpiredKeys() {
+	s.storeDataMu.Lock()
+	defer s.storeDataMu.Unlock()
+
+	// NOTE: Map itterations in go are pseudo-random so
+	// there's no need to explictly randomize this itteration
+	inspectedKeys := int64(0)
+	expiredKeys := int64(0)
+	expiredFields := int64(0)
+	for key, value := range s.storeData {
+		inspectedKeys++
+		if value.isExpired() {
+			expiredKeys++
+			s.logger.Debug(fmt.Sprintf("expiry loop deleting expired key %q", key))
+			s.deleteExpiredKey(key)
+		} else if hash, ok := value.data.(*hashValue); ok && hash.hasFieldExpiries() {
+			expiredFields += int64(s.deleteExpiredFields(key, hash))
+		}
+
+		if inspectedKeys > samplesPerExpiry {
+			break
+		}
 	}
 
-	return value.data, true
+	s.logger.Info(
+		"expiry loop completed a run",
+		zap.Int64("inspectedKeys", inspectedKeys),
+		zap.Int64("expiredKeys", expiredKeys),
+		zap.Int64("expiredFields", expiredFields),
+	)
 }
 
 func (s *BaseServer) Size() int {
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/codecrafters-io/redis-starter-go/app/command"
+	"github.com/codecrafters-io/redis-starter-go/app/connection"
+)
+
+// The reply sent to blocked clients, such as the ones blocked on a WAIT command, when their master becomes a replica
+const unblockedByRoleChangeError = "-UNBLOCKED force unblock from blocking operation, instance state changed (master -> replica?)\r\n"
+
+// ackWaiter is a client that is blocked on a WAIT command until enough replicas acknowledge its writes
+type ackWaiter struct {
+	conn connection.Connection
+
+	// The number of replicas that need to acknowledge targetOffset before the client is unblocked
+	numReplicas int64
+
+	// The replication offset of the client's last write when it called WAIT
+	targetOffset int64
+
+	// The timer that unblocks the client once its timeout passes. This is nil if the client has no timeout
+	timer *time.Timer
+}
+
+// numReplicasAckedTo returns the number of replicas that have acknowledged processing the stream up to offset
+func (s *MasterServer) numReplicasAckedTo(offset int64) int64 {
+	numAcked := int64(0)
+	for _, replica := range s.registeredReplicas {
+		if replica.ackOffset >= offset {
+			numAcked++
+		}
+	}
+	return numAcked
+}
+
+// waitForReplicas replies to a WAIT command once numReplicas replicas have acknowledged the client's last write
+// or the timeout passes, whichever comes first. A timeout of 0 waits forever. Rather than blocking the event
+// loop, the client is blocked and the reply is sent later from the event loop
+func (s *MasterServer) waitForReplicas(conn connection.Connection, numReplicas int64, timeout time.Duration) error {
+	targetOffset := conn.ClientState().LastWriteOffset
+
+	numAcked := s.numReplicasAckedTo(targetOffset)
+	if numAcked >= numReplicas {
+		return writeWaitResponse(conn, numAcked)
+	}
+
+	// Ask the replicas how far along they are. This goes through the replication stream so that every
+	// replica keeps the same view of the replication offset
+	if err := s.propagate(command.ReplConf{Payload: []string{"GETACK", "*"}}); err != nil {
+		return fmt.Errorf("error requesting ACKs from replicas: %w", err)
+	}
+
+	waiter := &ackWaiter{
+		conn:         conn,
+		numReplicas:  numReplicas,
+		targetOffset: targetOffset,
+	}
+	s.ackWaiters = append(s.ackWaiters, waiter)
+	s.blockedClients.block(conn, func() { s.removeAckWaiter(waiter) })
+
+	if timeout > 0 {
+		waiter.timer = time.AfterFunc(timeout, func() {
+			s.runOnEventLoop(func() { s.finishAckWaiter(waiter) })
+		})
+	}
+
+	return nil
+}
+
+// handleReplicaAck records the offset that a replica acknowledged and unblocks any WAIT commands it satisfies
+func (s *MasterServer) handleReplicaAck(conn connection.Connection, offset int64) {
+	for _, replica := range s.registeredReplicas {
+		if replica.conn == conn {
+			replica.ackOffset = max(replica.ackOffset, offset)
+		}
+	}
+
+	for _, waiter := range slices.Clone(s.ackWaiters) {
+		if s.numReplicasAckedTo(waiter.targetOffset) >= waiter.numReplicas {
+			s.finishAckWaiter(waiter)
+		}
+	}
+}
+
+// finishAckWaiter sends a blocked WAIT client the number of replicas that have acknowledged its
+// writes and unblocks it. Nothing happens if the waiter has already finished
+func (s *MasterServer) finishAckWaiter(waiter *ackWaiter) {
+	if !s.removeAckWaiter(waiter) {
+		return
+	}
+
+	if err := writeWaitResponse(waiter.conn, s.numReplicasAckedTo(waiter.targetOffset)); err != nil {
+		s.logger.Error("failed to send response to blocked WAIT command", zap.Error(err))
+	}
+	s.blockedClients.unblock(waiter.conn)
+}
+
+// removeAckWaiter stops tracking a waiter and returns false if it was not being tracked
+func (s *MasterServer) removeAckWaiter(waiter *ackWaiter) bool {
+	idx := slices.Index(s.ackWaiters, waiter)
+	if idx == -1 {
+		return false
+	}
+
+	s.ackWaiters = slices.Delete(s.ackWaiters, idx, idx+1)
+	if waiter.timer != nil {
+		waiter.timer.Stop()
+	}
+	return true
+}
+
+func writeWaitResponse(conn connection.Connection, numAcked int64) error {
+	waitRes, err := command.Encoder{}.EncodePrimitive(int(numAcked))
+	if err != nil {
+		return fmt.Errorf("failed to encode response to wait command: %w", err)
+	}
+
+	if _, err := conn.WriteString(waitRes); err != nil {
+		return fmt.Errorf("error writing reponse to WAIT command to client: %w", err)
+	}
+	return nil
+}